@@ -0,0 +1,35 @@
+package actions
+
+import (
+	"io"
+
+	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/archive"
+)
+
+// ExportDatasetHistory writes every version of a dataset to w as a dataset
+// archive
+func ExportDatasetHistory(node *p2p.QriNode, ref *repo.DatasetRef, w io.Writer, format archive.Format) (*archive.Manifest, error) {
+	if err := repo.CanonicalizeDatasetRef(node.Repo, ref); err != nil {
+		return nil, err
+	}
+	return archive.ExportDataset(w, node.Repo, *ref, format)
+}
+
+// ImportDatasetHistory reads a dataset archive into a repo, recreating the
+// dataset's history. If name is set the dataset is added under that name
+func ImportDatasetHistory(node *p2p.QriNode, rd io.Reader, name string) (*archive.ImportResult, error) {
+	r := node.Repo
+	res, err := archive.ImportDataset(rd, r, name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ref := range res.Imported {
+		if err := logEvent(r, repo.ETDsAdded, ref, nil); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,72 @@
+package actions
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/qri-io/dataset/dstest"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/archive"
+)
+
+func TestExportImportDatasetHistory(t *testing.T) {
+	src := newTestNode(t)
+	v1 := addCitiesDataset(t, src)
+
+	tc, err := dstest.NewTestCaseFromDir(testdataPath("cities"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	ds := tc.Input
+	ds.PreviousPath = v1.Path
+	ds.Meta.Title = "version two"
+	v2, err := CreateDataset(src, tc.Name, ds, tc.BodyFile(), nil, true)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	for _, format := range []archive.Format{archive.FormatTarGz, archive.FormatZip} {
+		buf := &bytes.Buffer{}
+		man, err := ExportDatasetHistory(src, &repo.DatasetRef{Peername: "me", Name: v2.Name}, buf, format)
+		if err != nil {
+			t.Fatalf("%s: export error: %s", format, err.Error())
+		}
+		if len(man.History) != 2 || man.History[0].Path != v1.Path || man.History[1].Path != v2.Path {
+			t.Errorf("%s: expected manifest history to be [%s %s], got: %v", format, v1.Path, v2.Path, man.History)
+		}
+
+		dst := newTestNode(t)
+		res, err := ImportDatasetHistory(dst, bytes.NewReader(buf.Bytes()), "")
+		if err != nil {
+			t.Fatalf("%s: import error: %s", format, err.Error())
+		}
+		if res.Versions != 2 {
+			t.Errorf("%s: expected 2 versions to be imported, got: %d", format, res.Versions)
+		}
+		if len(res.Changed) != 0 {
+			t.Errorf("%s: expected imported versions to keep their paths, got changes: %v", format, res.Changed)
+		}
+
+		ref, err := dst.Repo.GetRef(repo.DatasetRef{Peername: v2.Peername, Name: v2.Name})
+		if err != nil {
+			t.Fatalf("%s: error getting imported ref: %s", format, err.Error())
+		}
+		if ref.Path != v2.Path {
+			t.Errorf("%s: expected imported head to be %s, got: %s", format, v2.Path, ref.Path)
+		}
+		events, err := dst.Repo.EventsForRef(ref, -1, 0)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if len(events) != 1 || events[0].Type != repo.ETDsAdded {
+			t.Errorf("%s: expected a single added event, got: %d events", format, len(events))
+		}
+
+		if _, err := ImportDatasetHistory(dst, bytes.NewReader(buf.Bytes()), ""); err != repo.ErrNameTaken {
+			t.Errorf("%s: expected re-importing to fail with name taken, got: %v", format, err)
+		}
+		if _, err := ImportDatasetHistory(dst, bytes.NewReader(buf.Bytes()), "cities_copy"); err != nil {
+			t.Errorf("%s: error importing under a new name: %s", format, err.Error())
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package actions
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+)
+
+// changeRequestStore asserts a repo supports change requests
+func changeRequestStore(r repo.Repo) (repo.ChangeRequestStore, error) {
+	if s, ok := r.(repo.ChangeRequestStore); ok {
+		return s, nil
+	}
+	return nil, repo.ErrChangeRequestsNotSupported
+}
+
+// SendChangeRequest proposes a version of a dataset in this repo as the next
+// version of target, a dataset owned by another peer. The request is delivered
+// to the target's owner & a copy is kept in the local repo
+func SendChangeRequest(node *p2p.QriNode, target, proposal repo.DatasetRef, title, message string) (*repo.ChangeRequest, error) {
+	r := node.Repo
+	store, err := changeRequestStore(r)
+	if err != nil {
+		return nil, err
+	}
+	pro, err := r.Profile()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := repo.CanonicalizeDatasetRef(r, &proposal); err != nil {
+		return nil, fmt.Errorf("error with proposed dataset: %s", err.Error())
+	}
+	ds, err := dsfs.LoadDataset(r.Store(), datastore.NewKey(proposal.Path))
+	if err != nil {
+		log.Debug(err.Error())
+		return nil, fmt.Errorf("error loading proposed version %s: %s", proposal.Path, err.Error())
+	}
+
+	if _, err := ResolveDatasetRef(node, &target); err != nil {
+		return nil, fmt.Errorf("error with target dataset: %s", err.Error())
+	}
+	if target.ProfileID == pro.ID {
+		return nil, fmt.Errorf("%s is your own dataset, save a new version instead of sending a change request", target.AliasString())
+	}
+
+	if title == "" && ds.Commit != nil {
+		title = ds.Commit.Title
+	}
+
+	cr := &repo.ChangeRequest{
+		ID:         proposal.Path,
+		Path:       proposal.Path,
+		Target:     repo.DatasetRef{Peername: target.Peername, ProfileID: target.ProfileID, Name: target.Name, Path: target.Path},
+		Proposer:   pro.Peername,
+		ProposerID: pro.ID,
+		Title:      title,
+		Message:    message,
+		Status:     repo.CRStatusOpen,
+		Created:    time.Now(),
+	}
+
+	if !node.Online {
+		return nil, p2p.ErrNotConnected
+	}
+	pids, err := r.Profiles().PeerIDs(target.ProfileID)
+	if err != nil || len(pids) == 0 {
+		return nil, fmt.Errorf("no network info for %s, try connecting to them first", target.Peername)
+	}
+
+	for _, pid := range pids {
+		got, e := node.SendChangeRequest(node.Context(), pid, cr)
+		if e != nil {
+			log.Debugf("sending change request to %s: %s", pid.Pretty(), e.Error())
+			err = e
+			continue
+		}
+		return got, store.PutChangeRequest(got)
+	}
+	return nil, fmt.Errorf("error sending change request: %s", err.Error())
+}
+
+// ListChangeRequests lists change requests in the local repo, most recent
+// first. If target isn't empty only requests for that dataset are returned.
+// remote lists the requests the owner of target has received instead
+func ListChangeRequests(node *p2p.QriNode, target repo.DatasetRef, remote bool, limit, offset int) ([]*repo.ChangeRequest, error) {
+	r := node.Repo
+	if remote {
+		if target.IsEmpty() {
+			return nil, fmt.Errorf("a target dataset is required to list remote change requests")
+		}
+		if _, err := ResolveDatasetRef(node, &target); err != nil {
+			return nil, fmt.Errorf("error with target dataset: %s", err.Error())
+		}
+		if !node.Online {
+			return nil, p2p.ErrNotConnected
+		}
+		pids, err := r.Profiles().PeerIDs(target.ProfileID)
+		if err != nil || len(pids) == 0 {
+			return nil, fmt.Errorf("no network info for %s, try connecting to them first", target.Peername)
+		}
+		for _, pid := range pids {
+			crs, e := node.RequestChangeRequests(node.Context(), pid, p2p.ChangeRequestsParams{Target: target, Limit: limit, Offset: offset})
+			if e == nil {
+				return crs, nil
+			}
+			err = e
+		}
+		return nil, err
+	}
+
+	store, err := changeRequestStore(r)
+	if err != nil {
+		return nil, err
+	}
+	if target.IsEmpty() {
+		return store.ListChangeRequests(limit, offset)
+	}
+	if err := repo.CanonicalizeDatasetRef(r, &target); err != nil && err != repo.ErrNotFound && err != profile.ErrNotFound {
+		return nil, err
+	}
+	return store.ChangeRequestsForTarget(target, limit, offset)
+}
+
+// GetChangeRequest fetches a change request from the local repo
+func GetChangeRequest(r repo.Repo, id string) (*repo.ChangeRequest, error) {
+	store, err := changeRequestStore(r)
+	if err != nil {
+		return nil, err
+	}
+	return store.GetChangeRequest(id)
+}
+
+// AcceptChangeRequest writes the proposed version of a change request as the
+// next version of the target dataset, recording the proposer in the commit.
+// The target must not have changed since the request was made. The proposer
+// is notified if they're connected
+func AcceptChangeRequest(node *p2p.QriNode, id string) (cr *repo.ChangeRequest, ref repo.DatasetRef, err error) {
+	r := node.Repo
+	store, head, cr, err := openChangeRequest(r, id)
+	if err != nil {
+		return
+	}
+
+	if normalizePath(head.Path) != normalizePath(cr.Target.Path) {
+		err = fmt.Errorf("%s has changed since the change request was made, the proposer needs to send a new request based on the latest version", head.AliasString())
+		return
+	}
+
+	proposed, err := dsfs.LoadDataset(r.Store(), datastore.NewKey(cr.Path))
+	if err != nil {
+		log.Debug(err.Error())
+		err = fmt.Errorf("error loading proposed version %s: %s", cr.Path, err.Error())
+		return
+	}
+	body, err := dsfs.LoadBody(r.Store(), proposed)
+	if err != nil {
+		log.Debug(err.Error())
+		err = fmt.Errorf("error loading proposed body: %s", err.Error())
+		return
+	}
+
+	ds := &dataset.Dataset{}
+	ds.Assign(proposed)
+	ds.PreviousPath = head.Path
+	// never execute transforms proposed by other peers
+	ds.Transform = nil
+
+	title := cr.Title
+	if title == "" {
+		title = fmt.Sprintf("accepted change request from %s", cr.Proposer)
+	}
+	msg := fmt.Sprintf("change request %s proposed by %s (%s)", cr.ID, cr.Proposer, cr.ProposerID)
+	if cr.Message != "" {
+		msg = fmt.Sprintf("%s\n\n%s", msg, cr.Message)
+	}
+	ds.Commit = &dataset.Commit{Title: title, Message: msg}
+
+	// dsfs will re-calculate these
+	if ds.Meta != nil {
+		ds.Meta.SetPath("")
+	}
+	if ds.Structure != nil {
+		ds.Structure.SetPath("")
+		ds.Structure.Checksum = ""
+	}
+
+	if ref, err = CreateDataset(node, head.Name, ds, body, nil, true); err != nil {
+		return
+	}
+
+	cr.Status = repo.CRStatusAccepted
+	cr.Result = ref.Path
+	cr.Updated = time.Now()
+	if err = store.PutChangeRequest(cr); err != nil {
+		return
+	}
+
+	notifyProposer(node, cr)
+	return
+}
+
+// RejectChangeRequest declines a change request. The proposer is notified if
+// they're connected
+func RejectChangeRequest(node *p2p.QriNode, id string) (*repo.ChangeRequest, error) {
+	store, _, cr, err := openChangeRequest(node.Repo, id)
+	if err != nil {
+		return nil, err
+	}
+
+	cr.Status = repo.CRStatusRejected
+	cr.Updated = time.Now()
+	if err := store.PutChangeRequest(cr); err != nil {
+		return nil, err
+	}
+
+	notifyProposer(node, cr)
+	return cr, nil
+}
+
+// openChangeRequest fetches an open change request for a dataset owned by
+// this repo, along with the current head of the target dataset
+func openChangeRequest(r repo.Repo, id string) (store repo.ChangeRequestStore, head repo.DatasetRef, cr *repo.ChangeRequest, err error) {
+	if store, err = changeRequestStore(r); err != nil {
+		return
+	}
+	if cr, err = store.GetChangeRequest(id); err != nil {
+		err = fmt.Errorf("change request %s: %s", id, err.Error())
+		return
+	}
+	if cr.Status != repo.CRStatusOpen {
+		err = fmt.Errorf("change request %s has already been %s", id, cr.Status)
+		return
+	}
+
+	pro, err := r.Profile()
+	if err != nil {
+		return
+	}
+	if cr.Target.ProfileID != pro.ID {
+		err = fmt.Errorf("change request %s was sent to %s, only they can accept or reject it", id, cr.Target.Peername)
+		return
+	}
+
+	head, err = r.GetRef(repo.DatasetRef{Peername: cr.Target.Peername, ProfileID: cr.Target.ProfileID, Name: cr.Target.Name})
+	if err != nil {
+		err = fmt.Errorf("error with target dataset %s: %s", cr.Target.AliasString(), err.Error())
+	}
+	return
+}
+
+// notifyProposer tells the peer that made a change request it's been accepted
+// or rejected. Proposers that can't be reached will see the new status the
+// next time they list requests for the target
+func notifyProposer(node *p2p.QriNode, cr *repo.ChangeRequest) {
+	if !node.Online {
+		return
+	}
+	pids, err := node.Repo.Profiles().PeerIDs(cr.ProposerID)
+	if err != nil {
+		return
+	}
+	for _, pid := range pids {
+		if err := node.SendChangeRequestStatus(node.Context(), pid, cr); err != nil {
+			log.Debugf("notifying %s of change request status: %s", pid.Pretty(), err.Error())
+			continue
+		}
+		return
+	}
+}
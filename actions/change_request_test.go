@@ -0,0 +1,97 @@
+package actions
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/dataset/dstest"
+	"github.com/qri-io/qri/repo"
+)
+
+func TestAcceptChangeRequest(t *testing.T) {
+	node := newTestNode(t)
+	cities := addCitiesDataset(t, node)
+	store := node.Repo.(repo.ChangeRequestStore)
+
+	// stand in for a version proposed by another peer
+	tc, err := dstest.NewTestCaseFromDir(testdataPath("cities"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	tc.Input.Meta = &dataset.Meta{Title: "proposed title"}
+	proposal, err := CreateDataset(node, "cities_proposal", tc.Input, tc.BodyFile(), nil, true)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	newRequest := func(id string) *repo.ChangeRequest {
+		return &repo.ChangeRequest{
+			ID:         id,
+			Path:       proposal.Path,
+			Target:     cities,
+			Proposer:   "other_peer",
+			ProposerID: "QmOtherPeer",
+			Title:      "better title",
+			Message:    "the old one was boring",
+			Status:     repo.CRStatusOpen,
+			Created:    time.Now(),
+		}
+	}
+	if err := store.PutChangeRequest(newRequest(proposal.Path)); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	cr, ref, err := AcceptChangeRequest(node, proposal.Path)
+	if err != nil {
+		t.Fatalf("error accepting change request: %s", err.Error())
+	}
+	if cr.Status != repo.CRStatusAccepted || cr.Result != ref.Path {
+		t.Errorf("expected accepted change request to record result, got: %v", cr)
+	}
+
+	ds, err := dsfs.LoadDataset(node.Repo.Store(), datastore.NewKey(ref.Path))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if ds.PreviousPath != cities.Path {
+		t.Errorf("expected new version to follow %s, got: %s", cities.Path, ds.PreviousPath)
+	}
+	if ds.Meta == nil || ds.Meta.Title != "proposed title" {
+		t.Errorf("expected new version to have proposed meta, got: %v", ds.Meta)
+	}
+	if ds.Commit.Title != "better title" || !strings.Contains(ds.Commit.Message, "other_peer") {
+		t.Errorf("expected commit to record the proposer, got: %s: %s", ds.Commit.Title, ds.Commit.Message)
+	}
+
+	if _, _, err := AcceptChangeRequest(node, proposal.Path); err == nil {
+		t.Error("expected accepting a closed change request to error")
+	}
+
+	// the target has moved on, so requests based on the old version are stale
+	if err := store.PutChangeRequest(newRequest("/map/stale")); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, _, err := AcceptChangeRequest(node, "/map/stale"); err == nil {
+		t.Error("expected accepting a change request for an outdated version to error")
+	}
+
+	cr, err = RejectChangeRequest(node, "/map/stale")
+	if err != nil {
+		t.Fatalf("error rejecting change request: %s", err.Error())
+	}
+	if cr.Status != repo.CRStatusRejected {
+		t.Errorf("expected change request to be rejected, got: %s", cr.Status)
+	}
+
+	crs, err := ListChangeRequests(node, repo.DatasetRef{Peername: "me", Name: "cities"}, false, 10, 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(crs) != 2 {
+		t.Errorf("expected 2 change requests for cities, got: %d", len(crs))
+	}
+}
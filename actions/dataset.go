@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	"github.com/ipfs/go-datastore"
 	"github.com/qri-io/cafs"
@@ -13,6 +14,7 @@ import (
 	"github.com/qri-io/dataset/dsfs"
 	"github.com/qri-io/dataset/validate"
 	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/progress"
 	"github.com/qri-io/qri/repo"
 	"github.com/qri-io/qri/repo/profile"
 	"github.com/qri-io/varName"
@@ -187,8 +189,9 @@ func UpdateDataset(node *p2p.QriNode, dsp *dataset.DatasetPod) (ds *dataset.Data
 	return
 }
 
-// CreateDataset initializes a dataset from a dataset pointer and data file
-func CreateDataset(node *p2p.QriNode, name string, ds *dataset.Dataset, data cafs.File, secrets map[string]string, pin bool) (ref repo.DatasetRef, err error) {
+// CreateDataset initializes a dataset from a dataset pointer and data file,
+// running the dataset's transform with tfOpts if it has one
+func CreateDataset(node *p2p.QriNode, name string, ds *dataset.Dataset, data cafs.File, tfOpts *TransformOpts, pin bool) (ref repo.DatasetRef, err error) {
 	var (
 		r   = node.Repo
 		pro *profile.Profile
@@ -216,12 +219,10 @@ func CreateDataset(node *p2p.QriNode, name string, ds *dataset.Dataset, data caf
 	}
 
 	if ds.Transform != nil {
-		log.Info("running transformation...")
-		data, err = ExecTransform(node, ds, data, secrets)
+		data, err = ExecTransform(node, ds, data, tfOpts)
 		if err != nil {
 			return
 		}
-		log.Info("done")
 		ds.Assign(userSet)
 	}
 
@@ -229,21 +230,45 @@ func CreateDataset(node *p2p.QriNode, name string, ds *dataset.Dataset, data caf
 		return
 	}
 
-	if ref, err = repo.CreateDataset(node.Repo, name, ds, data, pin); err != nil {
+	task := progress.NewTask(node.Progress, "saving "+name, progress.Bytes, 0)
+	if data != nil {
+		data = progressFile{File: data, r: task.Reader(data)}
+	}
+	ref, err = repo.CreateDataset(node.Repo, name, ds, data, pin)
+	task.Finish(err)
+	if err != nil {
 		return
 	}
 
-	if err = r.LogEvent(repo.ETDsCreated, ref); err != nil {
+	if err = logEvent(r, repo.ETDsCreated, ref, createdEventParams(ds)); err != nil {
 		return
 	}
 
 	_, storeIsPinner := r.Store().(cafs.Pinner)
 	if pin && storeIsPinner {
-		r.LogEvent(repo.ETDsPinned, ref)
+		logEvent(r, repo.ETDsPinned, ref, nil)
+	}
+
+	if node.Online {
+		// let peers that follow this dataset know about the new version
+		e := &repo.Event{Time: time.Now(), Type: repo.ETDsCreated, Ref: ref, PeerID: node.ID}
+		if err := node.AnnounceEvents(e); err != nil {
+			log.Debugf("error announcing new version: %s", err.Error())
+		}
 	}
 	return
 }
 
+// createdEventParams records the previous version & commit of a new dataset
+// version
+func createdEventParams(ds *dataset.Dataset) *repo.EventParams {
+	params := &repo.EventParams{PrevPath: ds.PreviousPath}
+	if ds.Commit != nil {
+		params.Details = map[string]interface{}{"title": ds.Commit.Title}
+	}
+	return params
+}
+
 // AddDataset fetches & pins a dataset to the store, adding it to the list of stored refs
 func AddDataset(node *p2p.QriNode, ref *repo.DatasetRef) (err error) {
 	err = repo.CanonicalizeDatasetRef(node.Repo, ref)
@@ -254,7 +279,7 @@ func AddDataset(node *p2p.QriNode, ref *repo.DatasetRef) (err error) {
 	}
 
 	if ref.Path == "" && node != nil {
-		if err := node.RequestDataset(ref); err != nil {
+		if err := node.RequestDataset(node.Context(), ref); err != nil {
 			return fmt.Errorf("error requesting dataset: %s", err.Error())
 		}
 	}
@@ -263,21 +288,37 @@ func AddDataset(node *p2p.QriNode, ref *repo.DatasetRef) (err error) {
 	key := datastore.NewKey(strings.TrimSuffix(ref.Path, "/"+dsfs.PackageFileDataset.String()))
 	path := datastore.NewKey(key.String() + "/" + dsfs.PackageFileDataset.String())
 
-	fetcher, ok := r.Store().(cafs.Fetcher)
-	if !ok {
-		err = fmt.Errorf("this store cannot fetch from remote sources")
-		return
-	}
-
-	// TODO: This is asserting that the target is Fetch-able, but inside dsfs.LoadDataset,
-	// only Get is called. Clean up the semantics of Fetch and Get to get this expection
-	// more correctly in line with what's actually required.
-	_, err = fetcher.Fetch(cafs.SourceAny, key)
-	if err != nil {
-		return fmt.Errorf("error fetching file: %s", err.Error())
+	if fetcher, ok := r.Store().(cafs.Fetcher); ok {
+		// TODO: This is asserting that the target is Fetch-able, but inside dsfs.LoadDataset,
+		// only Get is called. Clean up the semantics of Fetch and Get to get this expection
+		// more correctly in line with what's actually required.
+		task := progress.NewTask(node.Progress, "fetching "+ref.AliasString(), progress.Steps, 1)
+		if _, err = fetcher.Fetch(cafs.SourceAny, key); err == nil {
+			task.Add(1)
+		}
+		task.Finish(err)
+		if err != nil {
+			return fmt.Errorf("error fetching file: %s", err.Error())
+		}
+	} else if has, e := r.Store().Has(key); e != nil || !has {
+		// stores that can't fetch transfer datasets from qri peers, or add
+		// datasets they already hold
+		if !node.Online {
+			return fmt.Errorf("this store cannot fetch from remote sources, and %s isn't stored locally", key.String())
+		}
+		task := progress.NewTask(node.Progress, "transferring "+ref.AliasString(), progress.Bytes, 0)
+		// transfers are only accepted if they're stored at ref.Path
+		_, err := node.FetchDataset(node.Context(), ref, func(p p2p.TransferProgress) {
+			task.Set(p.Bytes, p.TotalBytes)
+		})
+		task.Finish(err)
+		if err != nil {
+			return fmt.Errorf("error transferring dataset: %s", err.Error())
+		}
 	}
 
-	if err = PinDataset(r, *ref); err != nil {
+	// stores that don't pin keep everything they hold
+	if err = PinDataset(r, *ref); err != nil && err != repo.ErrNotPinner {
 		log.Debug(err.Error())
 		return fmt.Errorf("error pinning root key: %s", err.Error())
 	}
@@ -294,7 +335,7 @@ func AddDataset(node *p2p.QriNode, ref *repo.DatasetRef) (err error) {
 	}
 
 	ref.Dataset = ds.Encode()
-	return
+	return logEvent(r, repo.ETDsAdded, *ref, nil)
 }
 
 // ReadDataset grabs a dataset from the store
@@ -337,14 +378,16 @@ func RenameDataset(node *p2p.QriNode, current, new *repo.DatasetRef) (err error)
 		return err
 	}
 
-	return r.LogEvent(repo.ETDsRenamed, *new)
+	return logEvent(r, repo.ETDsRenamed, *new, &repo.EventParams{PrevName: current.Name})
 }
 
 // PinDataset marks a dataset for retention in a store
 func PinDataset(r repo.Repo, ref repo.DatasetRef) error {
 	if pinner, ok := r.Store().(cafs.Pinner); ok {
-		pinner.Pin(datastore.NewKey(ref.Path), true)
-		return r.LogEvent(repo.ETDsPinned, ref)
+		if err := pinner.Pin(datastore.NewKey(ref.Path), true); err != nil {
+			return err
+		}
+		return logEvent(r, repo.ETDsPinned, ref, nil)
 	}
 	return repo.ErrNotPinner
 }
@@ -353,7 +396,7 @@ func PinDataset(r repo.Repo, ref repo.DatasetRef) error {
 func UnpinDataset(r repo.Repo, ref repo.DatasetRef) error {
 	if pinner, ok := r.Store().(cafs.Pinner); ok {
 		pinner.Unpin(datastore.NewKey(ref.Path), true)
-		return r.LogEvent(repo.ETDsUnpinned, ref)
+		return logEvent(r, repo.ETDsUnpinned, ref, nil)
 	}
 	return repo.ErrNotPinner
 }
@@ -389,5 +432,131 @@ func DeleteDataset(node *p2p.QriNode, ref *repo.DatasetRef) (err error) {
 		return err
 	}
 
-	return r.LogEvent(repo.ETDsDeleted, *ref)
+	return logEvent(r, repo.ETDsDeleted, *ref, nil)
+}
+
+// ResetDataset moves a dataset's head back to an earlier version in its history,
+// removing all newer versions. ref must resolve to the current head of a dataset,
+// path is the version to reset to. Versions that are no longer in the history
+// of any reference are unpinned. ref is updated to point to the new head, and
+// the paths of removed versions are returned
+func ResetDataset(node *p2p.QriNode, ref *repo.DatasetRef, path string) (removed []string, err error) {
+	r := node.Repo
+	head, err := datasetHeadRef(r, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := versionPaths(r.Store(), head.Path, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, p := range history {
+		if normalizePath(p) == normalizePath(path) {
+			if i == 0 {
+				return nil, fmt.Errorf("%s is already the latest version of %s", path, head.AliasString())
+			}
+			return history[:i], rewindDataset(r, ref, head, history[i], history[:i])
+		}
+	}
+	return nil, fmt.Errorf("version %s is not in the history of %s", path, head.AliasString())
+}
+
+// RemoveRevisions drops the n most recent versions from a dataset's history,
+// resetting the dataset to the version before them. Use DeleteDataset to
+// remove an entire history
+func RemoveRevisions(node *p2p.QriNode, ref *repo.DatasetRef, n int) (removed []string, err error) {
+	if n < 1 {
+		return nil, fmt.Errorf("revisions to remove must be greater than zero")
+	}
+
+	r := node.Repo
+	head, err := datasetHeadRef(r, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := versionPaths(r.Store(), head.Path, n+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(history) <= n {
+		return nil, fmt.Errorf("%s only has %d versions, cannot remove %d. remove the dataset to delete its entire history", head.AliasString(), len(history), n)
+	}
+
+	return history[:n], rewindDataset(r, ref, head, history[n], history[:n])
+}
+
+// datasetHeadRef resolves a reference to the current head of a dataset
+func datasetHeadRef(r repo.Repo, ref *repo.DatasetRef) (head repo.DatasetRef, err error) {
+	if err = repo.CanonicalizeDatasetRef(r, ref); err != nil {
+		log.Debug(err.Error())
+		return
+	}
+	if head, err = r.GetRef(repo.DatasetRef{Peername: ref.Peername, ProfileID: ref.ProfileID, Name: ref.Name}); err != nil {
+		log.Debug(err.Error())
+	}
+	return
+}
+
+// versionPaths lists the paths in a dataset's history, starting with head and
+// following previous paths. no more than max paths are returned, a max of -1
+// returns the entire history
+func versionPaths(store cafs.Filestore, head string, max int) (paths []string, err error) {
+	path := head
+	for path != "" && path != "/" && (max < 0 || len(paths) < max) {
+		paths = append(paths, path)
+		ds, e := dsfs.LoadDataset(store, datastore.NewKey(path))
+		if e != nil {
+			return nil, fmt.Errorf("error loading version %s: %s", path, e.Error())
+		}
+		path = ds.PreviousPath
+	}
+	return paths, nil
+}
+
+// rewindDataset points a dataset reference at an earlier version, unpinning
+// removed versions that aren't in the history of any other reference in the
+// repo
+func rewindDataset(r repo.Repo, ref *repo.DatasetRef, head repo.DatasetRef, to string, removed []string) error {
+	if err := r.DeleteRef(head); err != nil {
+		return err
+	}
+	ref.Path = to
+	ref.Dataset = nil
+	if err := r.PutRef(repo.DatasetRef{ProfileID: head.ProfileID, Peername: head.Peername, Name: head.Name, Path: to}); err != nil {
+		return err
+	}
+
+	// removed versions can still be the history of a fork or merge, previous
+	// paths aren't links, so nothing else keeps them pinned
+	reachable, err := ReachablePaths(r)
+	if err != nil {
+		return fmt.Errorf("error finding versions still in use: %s", err.Error())
+	}
+	for _, p := range removed {
+		if pathReachable(reachable, p) {
+			continue
+		}
+		if err := UnpinDataset(r, repo.DatasetRef{ProfileID: head.ProfileID, Peername: head.Peername, Name: head.Name, Path: p}); err != nil && err != repo.ErrNotPinner {
+			return err
+		}
+	}
+
+	return logEvent(r, repo.ETDsReset, *ref, &repo.EventParams{
+		PrevPath: head.Path,
+		Details:  map[string]interface{}{"removed": removed},
+	})
+}
+
+// progressFile counts bytes read from a file as work done by a progress task
+type progressFile struct {
+	cafs.File
+	r io.Reader
+}
+
+// Read implements the io.Reader interface
+func (f progressFile) Read(p []byte) (int, error) {
+	return f.r.Read(p)
 }
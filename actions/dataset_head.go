@@ -19,7 +19,7 @@ func DatasetHead(node *p2p.QriNode, ds *repo.DatasetRef) error {
 		if node == nil {
 			return fmt.Errorf("%s, and no p2p connection", err.Error())
 		}
-		return node.RequestDataset(ds)
+		return node.RequestDataset(node.Context(), ds)
 	}
 
 	return ReadDataset(node.Repo, ds)
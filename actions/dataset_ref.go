@@ -20,5 +20,5 @@ func ResolveDatasetRef(n *p2p.QriNode, ref *repo.DatasetRef) (local bool, err er
 		return false, err
 	}
 
-	return false, n.ResolveDatasetRef(ref)
+	return false, n.ResolveDatasetRef(n.Context(), ref)
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/qri-io/qri/config"
 	"github.com/qri-io/qri/p2p"
 	"github.com/qri-io/qri/p2p/test"
+	"github.com/qri-io/qri/progress"
 	"github.com/qri-io/qri/repo"
 	"github.com/qri-io/qri/repo/profile"
 	"github.com/qri-io/registry/regserver/mock"
@@ -87,6 +88,25 @@ func TestAddDataset(t *testing.T) {
 	}
 }
 
+func TestCreateDatasetProgress(t *testing.T) {
+	node := newTestNode(t)
+	ref := addCitiesDataset(t, node)
+
+	var saved *progress.Update
+	for _, u := range node.Progress.Since(context.Background(), 0) {
+		if u.Name == "saving "+ref.Name && u.Complete {
+			u := u
+			saved = &u
+		}
+	}
+	if saved == nil {
+		t.Fatal("expected saving a dataset to report progress")
+	}
+	if saved.Unit != progress.Bytes || saved.Done == 0 || saved.Error != "" {
+		t.Errorf("expected bytes of the body read to be reported, got: %#v", saved)
+	}
+}
+
 func TestDataset(t *testing.T) {
 	rc, _ := mock.NewMockServer()
 
@@ -290,9 +310,112 @@ func testEventsLog(t *testing.T, rmf RepoMakerFunc) {
 		return
 	}
 
+	pro, err := node.Repo.Profile()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
 	for i, et := range ets {
 		if events[i].Type != et {
 			t.Errorf("case %d eventType mismatch. expected: %s, got: %s", i, et, events[i].Type)
 		}
+		if events[i].Actor() != pro.Peername {
+			t.Errorf("case %d actor mismatch. expected: %s, got: %s", i, pro.Peername, events[i].Actor())
+		}
+		if et == repo.ETDsRenamed && (events[i].Params == nil || events[i].Params.PrevName != "cities") {
+			t.Errorf("case %d expected rename event to record previous name 'cities', got: %v", i, events[i].Params)
+		}
+	}
+}
+
+func TestResetDataset(t *testing.T) {
+	node := newTestNode(t)
+	v1 := addCitiesDataset(t, node)
+
+	saveVersion := func(prev repo.DatasetRef, title string) repo.DatasetRef {
+		tc, err := dstest.NewTestCaseFromDir(testdataPath("cities"))
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		ds := tc.Input
+		ds.PreviousPath = prev.Path
+		ds.Meta.Title = title
+		ref, err := CreateDataset(node, tc.Name, ds, tc.BodyFile(), nil, true)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		return ref
+	}
+	v2 := saveVersion(v1, "version two")
+	v3 := saveVersion(v2, "version three")
+	v4 := saveVersion(v3, "version four")
+
+	ref := &repo.DatasetRef{Peername: "me", Name: v4.Name}
+	removed, err := RemoveRevisions(node, ref, 1)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(removed) != 1 || removed[0] != v4.Path {
+		t.Errorf("expected removed versions to be [%s], got: %v", v4.Path, removed)
+	}
+	if ref.Path != v3.Path {
+		t.Errorf("expected ref path to be %s, got: %s", v3.Path, ref.Path)
+	}
+	head, err := node.Repo.GetRef(repo.DatasetRef{Peername: v4.Peername, Name: v4.Name})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if head.Path != v3.Path {
+		t.Errorf("expected repo head to be %s, got: %s", v3.Path, head.Path)
+	}
+
+	ref = &repo.DatasetRef{Peername: "me", Name: v4.Name}
+	if _, err := ResetDataset(node, ref, v4.Path); err == nil {
+		t.Error("expected resetting to a removed version to error")
+	}
+	removed, err = ResetDataset(node, ref, v1.Path)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(removed) != 2 {
+		t.Errorf("expected 2 removed versions, got: %d", len(removed))
+	}
+	if ref.Path != v1.Path {
+		t.Errorf("expected ref path to be %s, got: %s", v1.Path, ref.Path)
+	}
+
+	ref = &repo.DatasetRef{Peername: "me", Name: v4.Name}
+	if _, err := RemoveRevisions(node, ref, 1); err == nil {
+		t.Error("expected removing the only version to error")
+	}
+
+	events, err := node.Repo.Events(1, 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(events) != 1 || events[0].Type != repo.ETDsReset {
+		t.Errorf("expected last event to be a reset event")
+	}
+}
+
+func TestResetDatasetKeepsSharedHistory(t *testing.T) {
+	node := newTestNode(t)
+	v1 := addCitiesDataset(t, node)
+	v2 := saveCitiesVersion(t, node, "cities", v1, "version two", citiesBody)
+	saveCitiesVersion(t, node, "cities_fork", v2, "forked version", citiesBody)
+
+	ref := &repo.DatasetRef{Peername: "me", Name: "cities"}
+	if _, err := RemoveRevisions(node, ref, 1); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	events, err := node.Repo.Events(10, 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	for _, e := range events {
+		if e.Type == repo.ETDsUnpinned && e.Ref.Path == v2.Path {
+			t.Errorf("expected %s to stay pinned while it's in the history of cities_fork", v2.Path)
+		}
 	}
 }
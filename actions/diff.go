@@ -8,7 +8,8 @@ import (
 	"github.com/qri-io/qri/repo"
 )
 
-// DiffDatasets calculates the difference between two dataset references
+// DiffDatasets calculates the difference between two dataset references.
+// Row-level differences between dataset bodies are calculated by DiffBodies
 func DiffDatasets(node *p2p.QriNode, leftRef, rightRef repo.DatasetRef, all bool, components map[string]bool) (diffs map[string]*dsdiff.SubDiff, err error) {
 	if leftRef.IsEmpty() || rightRef.IsEmpty() {
 		// TODO - make new error
@@ -41,12 +42,6 @@ func DiffDatasets(node *p2p.QriNode, leftRef, rightRef repo.DatasetRef, all bool
 			err = fmt.Errorf("error diffing datasets: %s", err.Error())
 			return
 		}
-		// TODO: remove this temporary hack
-		if diffs["data"] == nil || len(diffs["data"].Deltas()) == 0 {
-			// dereference data paths
-			// marshal json to []byte
-			// call `dsdiff.DiffJSON(a, b)`
-		}
 	} else {
 		for k, v := range components {
 			if v {
@@ -101,51 +96,5 @@ func DiffDatasets(node *p2p.QriNode, leftRef, rightRef repo.DatasetRef, all bool
 			}
 		}
 	}
-	// Hack to examine data
-	// if all || components["data"] == true {
-	// 	if dsLeft.Structure.Checksum == dsRight.Structure.Checksum {
-	// 		return
-	// 	}
-	// 	params0 := &LookupParams{
-	// 		Format: dataset.JSONDataFormat,
-	// 		Path:   dsLeft.Path().String(),
-	// 	}
-	// 	params1 := &LookupParams{
-	// 		Format: dataset.JSONDataFormat,
-	// 		Path:   dsRight.Path().String(),
-	// 	}
-	// 	result0 := &LookupResult{}
-	// 	result1 := &LookupResult{}
-	// 	err := r.LookupBody(params0, result0)
-	// 	if err != nil {
-	// 		log.Debug(err.Error())
-	// 		return fmt.Errorf("error getting structured data: %s", err.Error())
-	// 	}
-	// 	err = r.LookupBody(params1, result1)
-	// 	if err != nil {
-	// 		log.Debug(err.Error())
-	// 		return fmt.Errorf("error getting structured data: %s", err.Error())
-	// 	}
-
-	// 	m0 := &map[string]json.RawMessage{"data": result0.Data}
-	// 	m1 := &map[string]json.RawMessage{"data": result1.Data}
-	// 	dataBytes0, err := json.Marshal(m0)
-	// 	if err != nil {
-	// 		log.Debug(err.Error())
-	// 		return fmt.Errorf("error marshaling json: %s", err.Error())
-	// 	}
-	// 	dataBytes1, err := json.Marshal(m1)
-	// 	if err != nil {
-	// 		log.Debug(err.Error())
-	// 		return fmt.Errorf("error marshaling json: %s", err.Error())
-	// 	}
-	// 	dataDiffs, err := dsdiff.DiffJSON(dataBytes0, dataBytes1, "data")
-	// 	if err != nil {
-	// 		log.Debug(err.Error())
-	// 		return fmt.Errorf("error comparing structured data: %s", err.Error())
-	// 	}
-	// 	diffs["data"] = dataDiffs
-	// }
-
 	return
 }
@@ -0,0 +1,432 @@
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/repo"
+)
+
+const (
+	// DefaultBodyDiffMaxEntries is the default number of entries DiffBodies will
+	// hold in memory when matching rows by key
+	DefaultBodyDiffMaxEntries = 100000
+	// DefaultBodyDiffMaxChanges is the default number of changed rows DiffBodies
+	// will report before truncating results
+	DefaultBodyDiffMaxChanges = 1000
+)
+
+// BodyDiff describes row-level differences between two dataset bodies
+type BodyDiff struct {
+	// PrimaryKey lists the columns used to match rows. rows are matched by
+	// position when PrimaryKey is empty
+	PrimaryKey []string `json:"primaryKey,omitempty"`
+	// Added lists rows that only exist in the right body
+	Added []*BodyRow `json:"added,omitempty"`
+	// Removed lists rows that only exist in the left body
+	Removed []*BodyRow `json:"removed,omitempty"`
+	// Modified lists rows present in both bodies with differing values
+	Modified []*BodyRowDiff `json:"modified,omitempty"`
+	// Truncated is true when diffing stopped at the requested max number of changes
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// BodyRow is a single body entry identified by a key
+type BodyRow struct {
+	Key   string      `json:"key"`
+	Value interface{} `json:"value"`
+}
+
+// BodyRowDiff lists the cell-level changes to a row present in both bodies
+type BodyRowDiff struct {
+	Key   string      `json:"key"`
+	Cells []*CellDiff `json:"cells"`
+}
+
+// CellDiff is a change to a single value within a row
+type CellDiff struct {
+	Column string      `json:"column"`
+	Left   interface{} `json:"left"`
+	Right  interface{} `json:"right"`
+}
+
+// Changes gives the total number of added, removed & modified rows
+func (d *BodyDiff) Changes() int {
+	return len(d.Added) + len(d.Removed) + len(d.Modified)
+}
+
+// DiffBodies calculates a row-level difference between the bodies of two
+// dataset references. Rows are matched using primaryKey if provided, falling back
+// to a "primaryKey" declared in the left dataset's schema, falling back to
+// matching rows by position. Matching by key requires holding the left body's
+// entries in memory, maxEntries caps the number of entries held. maxChanges
+// caps the number of changed rows reported
+func DiffBodies(node *p2p.QriNode, leftRef, rightRef repo.DatasetRef, primaryKey []string, maxEntries, maxChanges int) (diff *BodyDiff, err error) {
+	if leftRef.IsEmpty() || rightRef.IsEmpty() {
+		err = fmt.Errorf("please provide two dataset references to compare")
+		return
+	}
+	if maxEntries <= 0 {
+		maxEntries = DefaultBodyDiffMaxEntries
+	}
+	if maxChanges <= 0 {
+		maxChanges = DefaultBodyDiffMaxChanges
+	}
+
+	leftFile, left, err := bodyEntryReader(node, &leftRef)
+	if err != nil {
+		return
+	}
+	defer leftFile.Close()
+
+	rightFile, right, err := bodyEntryReader(node, &rightRef)
+	if err != nil {
+		return
+	}
+	defer rightFile.Close()
+
+	st := left.Structure()
+	if len(primaryKey) == 0 {
+		primaryKey = schemaPrimaryKey(st)
+	}
+
+	diff = &BodyDiff{PrimaryKey: primaryKey}
+	bd := &bodyDiffer{
+		diff:       diff,
+		primaryKey: primaryKey,
+		columns:    schemaColumns(st),
+		maxEntries: maxEntries,
+		maxChanges: maxChanges,
+	}
+
+	if len(primaryKey) == 0 && (st.Schema == nil || st.Schema.TopLevelType() != "object") {
+		err = bd.diffByPosition(left, right)
+	} else {
+		err = bd.diffByKey(left, right)
+	}
+	return
+}
+
+// bodyEntryReader resolves a reference & opens an entry reader on it's body
+func bodyEntryReader(node *p2p.QriNode, ref *repo.DatasetRef) (file cafs.File, rr dsio.EntryReader, err error) {
+	if err = DatasetHead(node, ref); err != nil {
+		return
+	}
+	ds, err := ref.DecodeDataset()
+	if err != nil {
+		return
+	}
+	if ds == nil || ds.Structure == nil {
+		err = fmt.Errorf("dataset %s has no structure, cannot read body", ref)
+		return
+	}
+
+	if file, err = dsfs.LoadBody(node.Repo.Store(), ds); err != nil {
+		log.Debug(err.Error())
+		err = fmt.Errorf("error loading body for %s: %s", ref, err.Error())
+		return
+	}
+
+	if rr, err = dsio.NewEntryReader(ds.Structure, file); err != nil {
+		file.Close()
+		err = fmt.Errorf("error allocating data reader: %s", err.Error())
+	}
+	return
+}
+
+type bodyDiffer struct {
+	diff       *BodyDiff
+	primaryKey []string
+	columns    []string
+	maxEntries int
+	maxChanges int
+}
+
+// fits reports whether another change fits in the diff. Diffs are only
+// truncated when a change is found after reaching the max number of changes,
+// so a diff with exactly maxChanges changes is complete
+func (bd *bodyDiffer) fits() bool {
+	if bd.diff.Changes() >= bd.maxChanges {
+		bd.diff.Truncated = true
+		return false
+	}
+	return true
+}
+
+func (bd *bodyDiffer) diffByPosition(left, right dsio.EntryReader) error {
+	var leftDone, rightDone bool
+	for {
+		var le, re dsio.Entry
+		var err error
+
+		if !leftDone {
+			if le, err = left.ReadEntry(); err != nil {
+				if err.Error() != "EOF" {
+					return fmt.Errorf("error reading left body: %s", err.Error())
+				}
+				leftDone = true
+			}
+		}
+		if !rightDone {
+			if re, err = right.ReadEntry(); err != nil {
+				if err.Error() != "EOF" {
+					return fmt.Errorf("error reading right body: %s", err.Error())
+				}
+				rightDone = true
+			}
+		}
+
+		if leftDone && rightDone {
+			return nil
+		}
+
+		var cells []*CellDiff
+		if !leftDone && !rightDone {
+			if cells = diffCells(le.Value, re.Value, bd.columns); len(cells) == 0 {
+				continue
+			}
+		}
+		if !bd.fits() {
+			return nil
+		}
+
+		switch {
+		case leftDone:
+			bd.diff.Added = append(bd.diff.Added, &BodyRow{Key: positionKey(re), Value: re.Value})
+		case rightDone:
+			bd.diff.Removed = append(bd.diff.Removed, &BodyRow{Key: positionKey(le), Value: le.Value})
+		default:
+			bd.diff.Modified = append(bd.diff.Modified, &BodyRowDiff{Key: positionKey(le), Cells: cells})
+		}
+	}
+}
+
+func (bd *bodyDiffer) diffByKey(left, right dsio.EntryReader) error {
+	var (
+		index = map[string]dsio.Entry{}
+		order []string
+	)
+
+	for i := 0; ; i++ {
+		ent, err := left.ReadEntry()
+		if err != nil {
+			if err.Error() == "EOF" {
+				break
+			}
+			return fmt.Errorf("error reading left body: %s", err.Error())
+		}
+		if i == bd.maxEntries {
+			return fmt.Errorf("left body has more than %d entries, too large to diff by key", bd.maxEntries)
+		}
+
+		key, err := rowKey(ent, bd.primaryKey, bd.columns)
+		if err != nil {
+			return err
+		}
+		if _, ok := index[key]; ok {
+			return fmt.Errorf("duplicate key '%s' in left body", key)
+		}
+		index[key] = ent
+		order = append(order, key)
+	}
+
+	for {
+		re, err := right.ReadEntry()
+		if err != nil {
+			if err.Error() == "EOF" {
+				break
+			}
+			return fmt.Errorf("error reading right body: %s", err.Error())
+		}
+
+		key, err := rowKey(re, bd.primaryKey, bd.columns)
+		if err != nil {
+			return err
+		}
+
+		le, ok := index[key]
+		if !ok {
+			if !bd.fits() {
+				return nil
+			}
+			bd.diff.Added = append(bd.diff.Added, &BodyRow{Key: key, Value: re.Value})
+			continue
+		}
+		delete(index, key)
+
+		if cells := diffCells(le.Value, re.Value, bd.columns); len(cells) > 0 {
+			if !bd.fits() {
+				return nil
+			}
+			bd.diff.Modified = append(bd.diff.Modified, &BodyRowDiff{Key: key, Cells: cells})
+		}
+	}
+
+	for _, key := range order {
+		if le, ok := index[key]; ok {
+			if !bd.fits() {
+				return nil
+			}
+			bd.diff.Removed = append(bd.diff.Removed, &BodyRow{Key: key, Value: le.Value})
+		}
+	}
+	return nil
+}
+
+// positionKey gives the key of an entry, using it's index if the entry
+// has no key
+func positionKey(ent dsio.Entry) string {
+	if ent.Key != "" {
+		return ent.Key
+	}
+	return strconv.Itoa(ent.Index)
+}
+
+// rowKey derives a string key for an entry from primary key columns
+func rowKey(ent dsio.Entry, primaryKey, columns []string) (string, error) {
+	if len(primaryKey) == 0 {
+		return positionKey(ent), nil
+	}
+
+	vals := make([]string, len(primaryKey))
+	for i, col := range primaryKey {
+		switch row := ent.Value.(type) {
+		case []interface{}:
+			idx := -1
+			for j, c := range columns {
+				if c == col {
+					idx = j
+					break
+				}
+			}
+			if idx < 0 || idx >= len(row) {
+				return "", fmt.Errorf("primary key column '%s' not found in entry %d", col, ent.Index)
+			}
+			vals[i] = fmt.Sprintf("%v", row[idx])
+		case map[string]interface{}:
+			v, ok := row[col]
+			if !ok {
+				return "", fmt.Errorf("primary key column '%s' not found in entry %d", col, ent.Index)
+			}
+			vals[i] = fmt.Sprintf("%v", v)
+		default:
+			return "", fmt.Errorf("cannot use primary key with entry %d: entry is not an array or object", ent.Index)
+		}
+	}
+	return strings.Join(vals, ","), nil
+}
+
+// diffCells compares two row values, returning any changed cells
+func diffCells(left, right interface{}, columns []string) (cells []*CellDiff) {
+	la, lok := left.([]interface{})
+	ra, rok := right.([]interface{})
+	if lok && rok {
+		l := len(la)
+		if len(ra) > l {
+			l = len(ra)
+		}
+		for i := 0; i < l; i++ {
+			var lv, rv interface{}
+			if i < len(la) {
+				lv = la[i]
+			}
+			if i < len(ra) {
+				rv = ra[i]
+			}
+			if !reflect.DeepEqual(lv, rv) {
+				col := strconv.Itoa(i)
+				if i < len(columns) && columns[i] != "" {
+					col = columns[i]
+				}
+				cells = append(cells, &CellDiff{Column: col, Left: lv, Right: rv})
+			}
+		}
+		return
+	}
+
+	lm, lok := left.(map[string]interface{})
+	rm, rok := right.(map[string]interface{})
+	if lok && rok {
+		keys := []string{}
+		for key := range lm {
+			keys = append(keys, key)
+		}
+		for key := range rm {
+			if _, ok := lm[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if !reflect.DeepEqual(lm[key], rm[key]) {
+				cells = append(cells, &CellDiff{Column: key, Left: lm[key], Right: rm[key]})
+			}
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(left, right) {
+		cells = append(cells, &CellDiff{Left: left, Right: right})
+	}
+	return
+}
+
+// schemaDefinition decodes a structure's schema into a generic map
+func schemaDefinition(st *dataset.Structure) map[string]interface{} {
+	sch := map[string]interface{}{}
+	if st == nil || st.Schema == nil {
+		return sch
+	}
+	data, err := st.Schema.MarshalJSON()
+	if err != nil {
+		log.Debug(err.Error())
+		return sch
+	}
+	if err := json.Unmarshal(data, &sch); err != nil {
+		log.Debug(err.Error())
+	}
+	return sch
+}
+
+// schemaColumns gives the column titles of a tabular schema, if any
+func schemaColumns(st *dataset.Structure) []string {
+	if items, ok := schemaDefinition(st)["items"].(map[string]interface{}); ok {
+		if cols, ok := items["items"].([]interface{}); ok {
+			titles := make([]string, len(cols))
+			for i, c := range cols {
+				if col, ok := c.(map[string]interface{}); ok {
+					titles[i], _ = col["title"].(string)
+				}
+			}
+			return titles
+		}
+	}
+	return nil
+}
+
+// schemaPrimaryKey reads a "primaryKey" declaration from the top level of a
+// schema, which can be either a column name or a list of column names
+func schemaPrimaryKey(st *dataset.Structure) []string {
+	switch pk := schemaDefinition(st)["primaryKey"].(type) {
+	case string:
+		return []string{pk}
+	case []interface{}:
+		keys := make([]string, 0, len(pk))
+		for _, k := range pk {
+			if s, ok := k.(string); ok {
+				keys = append(keys, s)
+			}
+		}
+		return keys
+	}
+	return nil
+}
@@ -1,6 +1,11 @@
 package actions
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/dataset/dstest"
+)
 
 func TestDiffDatasets(t *testing.T) {
 	node := newTestNode(t)
@@ -15,3 +20,80 @@ func TestDiffDatasets(t *testing.T) {
 		t.Error("expected some diffs")
 	}
 }
+
+func TestDiffBodies(t *testing.T) {
+	node := newTestNode(t)
+	cities := addCitiesDataset(t, node)
+
+	tc, err := dstest.NewTestCaseFromDir(testdataPath("cities"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	body := cafs.NewMemfileBytes("body.csv", []byte(`city,pop,avg_age,in_usa
+new york,8500000,44.4,true
+toronto,40000000,55.5,false
+chicago,300000,44.4,true
+chatham,35000,65.25,true
+oakland,420000,37.0,true
+`))
+	cities2, err := CreateDataset(node, "cities_2", tc.Input, body, nil, true)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	diff, err := DiffBodies(node, cities, cities2, []string{"city"}, 0, 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(diff.Added) != 1 || diff.Added[0].Key != "oakland" {
+		t.Errorf("expected oakland to be added, got: %v", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0].Key != "raleigh" {
+		t.Errorf("expected raleigh to be removed, got: %v", diff.Removed)
+	}
+	if len(diff.Modified) != 0 {
+		t.Errorf("expected no modified rows, got: %d", len(diff.Modified))
+	}
+
+	diff, err = DiffBodies(node, cities, cities2, nil, 0, 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(diff.Modified) != 3 {
+		t.Errorf("expected 3 modified rows matching by position, got: %d", len(diff.Modified))
+	}
+	if len(diff.Modified) > 0 {
+		cell := diff.Modified[0].Cells[0]
+		if cell.Column != "city" || cell.Left != "toronto" || cell.Right != "new york" {
+			t.Errorf("unexpected cell diff: %#v", cell)
+		}
+	}
+
+	diff, err = DiffBodies(node, cities, cities2, nil, 0, 2)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !diff.Truncated || diff.Changes() != 2 {
+		t.Errorf("expected diff to be truncated at 2 changes, got %d", diff.Changes())
+	}
+
+	diff, err = DiffBodies(node, cities, cities2, nil, 0, 3)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if diff.Truncated || diff.Changes() != 3 {
+		t.Errorf("expected a diff with exactly max changes not to be truncated, got %d changes, truncated: %t", diff.Changes(), diff.Truncated)
+	}
+
+	diff, err = DiffBodies(node, cities, cities2, []string{"city"}, 0, 2)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if diff.Truncated || diff.Changes() != 2 {
+		t.Errorf("expected a keyed diff with exactly max changes not to be truncated, got %d changes, truncated: %t", diff.Changes(), diff.Truncated)
+	}
+
+	if _, err := DiffBodies(node, cities, cities2, []string{"city"}, 2, 0); err == nil {
+		t.Error("expected exceeding max entries to error")
+	}
+}
@@ -0,0 +1,120 @@
+package actions
+
+import (
+	"time"
+
+	"github.com/qri-io/qri/repo"
+
+	peer "gx/ipfs/QmdVrMn1LhB4ybb8hMVaMLXnA8XRSewMnK6YqXKXoTcRvN/go-libp2p-peer"
+)
+
+// logEvent records an action in a repo's event log, adding details about the
+// user & peer that performed it
+func logEvent(r repo.Repo, t repo.EventType, ref repo.DatasetRef, params *repo.EventParams) error {
+	if params == nil {
+		params = &repo.EventParams{}
+	}
+
+	var pid peer.ID
+	if pro, err := r.Profile(); err == nil {
+		params.ProfileID = pro.ID
+		params.Peername = pro.Peername
+		if len(pro.PeerIDs) > 0 {
+			pid = pro.PeerIDs[0]
+		}
+	}
+
+	return r.LogEventDetails(t, time.Now(), pid, ref, params)
+}
+
+// EventFilter selects events from an event log. Zero-valued fields match all
+// events
+type EventFilter struct {
+	// Ref restricts events to a dataset
+	Ref repo.DatasetRef
+	// Type restricts events to a single event type
+	Type repo.EventType
+	// Actor restricts events to those performed by a peername, profileID or peerID
+	Actor string
+	// Since & Until restrict events to a window of time
+	Since time.Time
+	Until time.Time
+}
+
+// Match returns true if an event passes the filter
+func (f EventFilter) Match(e *repo.Event) bool {
+	if !f.Ref.IsEmpty() && !e.MatchesRef(f.Ref) {
+		return false
+	}
+	if f.Type != "" && e.Type != f.Type {
+		return false
+	}
+	if f.Actor != "" && !eventActor(e, f.Actor) {
+		return false
+	}
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && e.Time.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+// eventActor checks if an event was performed by a peername, profileID or peerID
+func eventActor(e *repo.Event, actor string) bool {
+	if e.PeerID != "" && e.PeerID.Pretty() == actor {
+		return true
+	}
+	if e.Params == nil {
+		return false
+	}
+	return e.Params.Peername == actor || (e.Params.ProfileID != "" && e.Params.ProfileID.String() == actor)
+}
+
+// FilterEvents lists events that match a filter, most recent first. The
+// event log's ref & type indexes are used when the filter sets them, other
+// fields are matched by reading through the log a page at a time
+func FilterEvents(r repo.EventLog, f EventFilter, limit, offset int) ([]*repo.Event, error) {
+	var query func(limit, offset int) ([]*repo.Event, error)
+	switch {
+	case !f.Ref.IsEmpty():
+		query = func(limit, offset int) ([]*repo.Event, error) { return r.EventsForRef(f.Ref, limit, offset) }
+	case f.Type != "":
+		query = func(limit, offset int) ([]*repo.Event, error) { return r.EventsByType(f.Type, limit, offset) }
+	default:
+		query = r.Events
+	}
+
+	indexed := EventFilter{Ref: f.Ref}
+	if f.Ref.IsEmpty() {
+		indexed.Type = f.Type
+	}
+	if f == indexed {
+		return query(limit, offset)
+	}
+
+	var (
+		matched  []*repo.Event
+		pageSize = 100
+	)
+	for page := 0; ; page++ {
+		events, err := query(pageSize, page*pageSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range events {
+			if !f.Since.IsZero() && e.Time.Before(f.Since) {
+				// events are newest first, no remaining events can match
+				return repo.PageEvents(matched, limit, offset), nil
+			}
+			if f.Match(e) {
+				matched = append(matched, e)
+			}
+		}
+		if len(events) < pageSize || (limit >= 0 && len(matched) >= offset+limit) {
+			break
+		}
+	}
+	return repo.PageEvents(matched, limit, offset), nil
+}
@@ -0,0 +1,54 @@
+package actions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/qri-io/qri/repo"
+)
+
+func TestFilterEvents(t *testing.T) {
+	r := &repo.MemEventLog{}
+	a := repo.DatasetRef{Peername: "peer", Name: "a"}
+	b := repo.DatasetRef{Peername: "peer", Name: "b"}
+	byPeer := &repo.EventParams{Peername: "peer"}
+	byOther := &repo.EventParams{Peername: "other"}
+
+	r.LogEventDetails(repo.ETDsCreated, time.Unix(10, 0), "", a, byPeer)
+	r.LogEventDetails(repo.ETDsCreated, time.Unix(20, 0), "", b, byOther)
+	r.LogEventDetails(repo.ETDsRenamed, time.Unix(30, 0), "", a, byPeer)
+	r.LogEventDetails(repo.ETDsPinned, time.Unix(40, 0), "", b, byPeer)
+
+	cases := []struct {
+		f      EventFilter
+		limit  int
+		offset int
+		expect []int64
+	}{
+		{EventFilter{}, 10, 0, []int64{40, 30, 20, 10}},
+		{EventFilter{Ref: a}, 10, 0, []int64{30, 10}},
+		{EventFilter{Type: repo.ETDsCreated}, 10, 0, []int64{20, 10}},
+		{EventFilter{Actor: "peer"}, 10, 0, []int64{40, 30, 10}},
+		{EventFilter{Actor: "peer"}, 1, 1, []int64{30}},
+		{EventFilter{Ref: b, Actor: "other"}, 10, 0, []int64{20}},
+		{EventFilter{Since: time.Unix(20, 0), Until: time.Unix(30, 0)}, 10, 0, []int64{30, 20}},
+		{EventFilter{Type: repo.ETDsPinned, Actor: "other"}, 10, 0, []int64{}},
+	}
+
+	for i, c := range cases {
+		got, err := FilterEvents(r, c.f, c.limit, c.offset)
+		if err != nil {
+			t.Errorf("case %d unexpected error: %s", i, err.Error())
+			continue
+		}
+		if len(got) != len(c.expect) {
+			t.Errorf("case %d length mismatch. expected: %d, got: %d", i, len(c.expect), len(got))
+			continue
+		}
+		for j, e := range got {
+			if e.Time.Unix() != c.expect[j] {
+				t.Errorf("case %d event %d time mismatch. expected: %d, got: %d", i, j, c.expect[j], e.Time.Unix())
+			}
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+
+	peer "gx/ipfs/QmdVrMn1LhB4ybb8hMVaMLXnA8XRSewMnK6YqXKXoTcRvN/go-libp2p-peer"
+)
+
+const (
+	// ReplicateMetadata keeps only the dataset document of new versions of
+	// followed datasets
+	ReplicateMetadata = "metadata"
+	// ReplicateFull fetches & pins the body of new versions of followed
+	// datasets
+	ReplicateFull = "full"
+)
+
+// ReplicationPolicy reads the follow replication policy from p2p
+// configuration, defaulting to ReplicateMetadata
+func ReplicationPolicy(cfg *config.P2P) string {
+	if cfg != nil && cfg.FollowReplication == ReplicateFull {
+		return ReplicateFull
+	}
+	return ReplicateMetadata
+}
+
+// Follow adds a peer or dataset to a repo's follow list. The profileID of
+// peers this repo knows is recorded so follows survive a peer changing
+// peername
+func Follow(r repo.Repo, f *repo.Follow) error {
+	fs, ok := r.(repo.FollowStore)
+	if !ok {
+		return repo.ErrFollowsNotSupported
+	}
+	if f.Peername == "" {
+		return repo.ErrPeernameRequired
+	}
+	if pro, err := r.Profile(); err == nil && pro.Peername == f.Peername {
+		return fmt.Errorf("can't follow yourself")
+	}
+	if f.ProfileID == "" {
+		if id, err := r.Profiles().PeernameID(f.Peername); err == nil {
+			f.ProfileID = id
+		}
+	}
+	if f.Created.IsZero() {
+		f.Created = time.Now()
+	}
+	return fs.PutFollow(f)
+}
+
+// Unfollow removes a peer or dataset from a repo's follow list
+func Unfollow(r repo.Repo, peername, name string) error {
+	fs, ok := r.(repo.FollowStore)
+	if !ok {
+		return repo.ErrFollowsNotSupported
+	}
+	return fs.DeleteFollow(peername, name)
+}
+
+// ListFollows lists the peers & datasets a repo follows
+func ListFollows(r repo.Repo) ([]*repo.Follow, error) {
+	fs, ok := r.(repo.FollowStore)
+	if !ok {
+		return nil, repo.ErrFollowsNotSupported
+	}
+	return fs.ListFollows()
+}
+
+// followedRefs selects new versions of followed datasets from a list of
+// events, newest first. Only the newest version of each dataset is kept, &
+// only datasets that belong to pro
+func followedRefs(follows []*repo.Follow, pro *profile.Profile, events []*repo.Event) []repo.DatasetRef {
+	refs := []repo.DatasetRef{}
+	seen := map[string]bool{}
+	for _, e := range events {
+		if e.Type != repo.ETDsCreated || e.Ref.Path == "" || seen[e.Ref.Name] {
+			continue
+		}
+		// peers can only announce versions of their own datasets
+		if (e.Ref.ProfileID != "" && e.Ref.ProfileID != pro.ID) || (e.Ref.ProfileID == "" && e.Ref.Peername != pro.Peername) {
+			continue
+		}
+		for _, f := range follows {
+			if f.Matches(e.Ref) {
+				seen[e.Ref.Name] = true
+				refs = append(refs, e.Ref)
+				break
+			}
+		}
+	}
+	return refs
+}
+
+// peerProfile gives the profile of a peer, requesting it if it isn't known
+func peerProfile(node *p2p.QriNode, pid peer.ID) (*profile.Profile, error) {
+	if pro, err := node.Repo.Profiles().PeerProfile(pid); err == nil {
+		return pro, nil
+	}
+	return node.RequestProfile(pid)
+}
+
+// ReplicateDataset adds a new version of a followed dataset, replacing the
+// reference to any previous version. The ReplicateFull policy also fetches &
+// pins the dataset body
+func ReplicateDataset(node *p2p.QriNode, ref repo.DatasetRef, policy string) error {
+	r := node.Repo
+	prev, err := r.GetRef(repo.DatasetRef{Peername: ref.Peername, Name: ref.Name})
+	hasPrev := err == nil
+	if hasPrev {
+		if prev.Path == ref.Path {
+			return nil
+		}
+		// AddDataset refuses names that are already in the repo
+		if err = r.DeleteRef(prev); err != nil {
+			return err
+		}
+	}
+
+	if err := AddDataset(node, &ref); err != nil {
+		if hasPrev {
+			restoreRef(r, prev)
+		}
+		return err
+	}
+	if policy != ReplicateFull || ref.Dataset == nil || ref.Dataset.BodyPath == "" {
+		return nil
+	}
+
+	key := datastore.NewKey(ref.Dataset.BodyPath)
+	if fetcher, ok := r.Store().(cafs.Fetcher); ok {
+		if _, err := fetcher.Fetch(cafs.SourceAny, key); err != nil {
+			return fmt.Errorf("error fetching dataset body: %s", err.Error())
+		}
+	}
+	if pinner, ok := r.Store().(cafs.Pinner); ok {
+		if err := pinner.Pin(key, true); err != nil {
+			return fmt.Errorf("error pinning dataset body: %s", err.Error())
+		}
+	}
+	return nil
+}
+
+// restoreRef points a dataset name back at a previous version after adding a
+// new version failed, replacing any reference to the new version
+func restoreRef(r repo.Repo, prev repo.DatasetRef) {
+	if cur, err := r.GetRef(repo.DatasetRef{Peername: prev.Peername, Name: prev.Name}); err == nil {
+		if cur.Path == prev.Path {
+			return
+		}
+		if err := r.DeleteRef(cur); err != nil {
+			log.Debugf("error removing reference to %s: %s", cur.String(), err.Error())
+		}
+	}
+	if err := r.PutRef(prev); err != nil {
+		log.Debugf("error restoring reference to %s: %s", prev.String(), err.Error())
+	}
+}
+
+// replicateEvents replicates followed datasets from a list of events
+// announced by a peer, returning versions that weren't already in the repo.
+// pid must be the peer that sent the events, only versions of datasets that
+// belong to it's profile are replicated
+func replicateEvents(node *p2p.QriNode, pid peer.ID, events []*repo.Event, policy string) ([]repo.DatasetRef, error) {
+	follows, err := ListFollows(node.Repo)
+	if err != nil || len(follows) == 0 {
+		return nil, err
+	}
+	pro, err := peerProfile(node, pid)
+	if err != nil {
+		return nil, err
+	}
+
+	replicated := []repo.DatasetRef{}
+	for _, ref := range followedRefs(follows, pro, events) {
+		if prev, err := node.Repo.GetRef(repo.DatasetRef{Peername: ref.Peername, Name: ref.Name}); err == nil && prev.Path == ref.Path {
+			// already have this version
+			continue
+		}
+		if err := ReplicateDataset(node, ref, policy); err != nil {
+			log.Debugf("error replicating %s: %s", ref.String(), err.Error())
+			continue
+		}
+		replicated = append(replicated, ref)
+	}
+	return replicated, nil
+}
+
+// SyncFollowedPeer checks a peer's recent events for new versions of followed
+// datasets, replicating any it finds
+func SyncFollowedPeer(node *p2p.QriNode, pid peer.ID, policy string) ([]repo.DatasetRef, error) {
+	follows, err := ListFollows(node.Repo)
+	if err != nil || len(follows) == 0 {
+		return nil, err
+	}
+	pro, err := peerProfile(node, pid)
+	if err != nil {
+		return nil, err
+	}
+
+	followed := false
+	for _, f := range follows {
+		if f.Peername == pro.Peername || (f.ProfileID != "" && f.ProfileID == pro.ID) {
+			followed = true
+			break
+		}
+	}
+	if !followed {
+		return nil, nil
+	}
+
+	events, err := node.RequestEventsList(node.Context(), pid, p2p.EventsParams{Limit: eventsPageSize})
+	if err != nil {
+		return nil, err
+	}
+	return replicateEvents(node, pid, events, policy)
+}
+
+// ReplicateFollows listens for followed peers connecting & announcing events,
+// replicating new versions of followed datasets with the given policy. It
+// never returns, call it in a goroutine once the node is online
+func ReplicateFollows(node *p2p.QriNode, policy string) {
+	msgs := node.ReceiveMessages()
+	for msg := range msgs {
+		switch {
+		case msg.Type == p2p.MtEvents && msg.Header("phase") == "announce":
+			// announcements come straight from the announcing peer, trust the
+			// stream's remote peer over the initiator the message claims
+			pid := msg.Provider()
+			if pid == "" || pid == node.ID {
+				continue
+			}
+			if msg.Initiator != pid {
+				log.Debugf("ignoring events announced by %s on behalf of %s", pid.Pretty(), msg.Initiator.Pretty())
+				continue
+			}
+			events := []*repo.Event{}
+			if err := json.Unmarshal(msg.Body, &events); err != nil {
+				log.Debug(err.Error())
+				continue
+			}
+			go func() {
+				if _, err := replicateEvents(node, pid, events, policy); err != nil {
+					log.Debugf("error replicating events from %s: %s", pid.Pretty(), err.Error())
+				}
+			}()
+		case msg.Type == p2p.MtConnected:
+			pinfo := struct{ ID string }{}
+			if err := json.Unmarshal(msg.Body, &pinfo); err != nil {
+				log.Debug(err.Error())
+				continue
+			}
+			pid, err := peer.IDB58Decode(pinfo.ID)
+			if err != nil || pid == node.ID {
+				continue
+			}
+			go func() {
+				if _, err := SyncFollowedPeer(node, pid, policy); err != nil {
+					log.Debugf("error syncing followed peer %s: %s", pid.Pretty(), err.Error())
+				}
+			}()
+		}
+	}
+}
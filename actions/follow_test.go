@@ -0,0 +1,108 @@
+package actions
+
+import (
+	"context"
+	"testing"
+
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/p2p/test"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+)
+
+func TestFollowedRefs(t *testing.T) {
+	pro := &profile.Profile{ID: profile.ID("a"), Peername: "alice"}
+	follows := []*repo.Follow{{Peername: "alice", Name: "movies"}}
+	events := []*repo.Event{
+		{Type: repo.ETDsCreated, Ref: repo.DatasetRef{Peername: "alice", ProfileID: pro.ID, Name: "movies", Path: "/map/movies2"}},
+		{Type: repo.ETDsPinned, Ref: repo.DatasetRef{Peername: "alice", ProfileID: pro.ID, Name: "movies", Path: "/map/movies2"}},
+		{Type: repo.ETDsCreated, Ref: repo.DatasetRef{Peername: "alice", ProfileID: pro.ID, Name: "movies", Path: "/map/movies1"}},
+		{Type: repo.ETDsCreated, Ref: repo.DatasetRef{Peername: "alice", ProfileID: pro.ID, Name: "cities", Path: "/map/cities"}},
+		// versions of followed datasets can only come from their owner
+		{Type: repo.ETDsCreated, Ref: repo.DatasetRef{Peername: "alice", ProfileID: profile.ID("b"), Name: "movies", Path: "/map/fake"}},
+	}
+
+	refs := followedRefs(follows, pro, events)
+	if len(refs) != 1 {
+		t.Fatalf("expected 1 followed ref, got: %d", len(refs))
+	}
+	if refs[0].Path != "/map/movies2" {
+		t.Errorf("expected newest version to be followed, got: %s", refs[0].Path)
+	}
+}
+
+func TestSyncFollowedPeer(t *testing.T) {
+	ctx := context.Background()
+	factory := p2ptest.NewTestNodeFactory(p2p.NewTestableQriNode)
+	testPeers, err := p2ptest.NewTestNetwork(ctx, factory, 2)
+	if err != nil {
+		t.Fatalf("error creating network: %s", err.Error())
+	}
+	if err := p2ptest.ConnectQriPeers(ctx, testPeers); err != nil {
+		t.Fatalf("error connecting peers: %s", err.Error())
+	}
+	peers := make([]*p2p.QriNode, len(testPeers))
+	for i, node := range testPeers {
+		peers[i] = node.(*p2p.QriNode)
+	}
+	m0 := peers[0].Repo.Store().(*cafs.MapStore)
+	m1 := peers[1].Repo.Store().(*cafs.MapStore)
+	m0.AddConnection(m1)
+
+	ref := addFlourinatedCompoundsDataset(t, peers[1])
+
+	// nothing is followed yet
+	refs, err := SyncFollowedPeer(peers[0], peers[1].ID, ReplicateFull)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(refs) != 0 {
+		t.Errorf("expected no replicated datasets without follows, got: %d", len(refs))
+	}
+
+	if err := Follow(peers[0].Repo, &repo.Follow{Peername: ref.Peername}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if refs, err = SyncFollowedPeer(peers[0], peers[1].ID, ReplicateFull); err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(refs) != 1 {
+		t.Fatalf("expected 1 replicated dataset, got: %d", len(refs))
+	}
+
+	got, err := peers[0].Repo.GetRef(repo.DatasetRef{Peername: ref.Peername, Name: ref.Name})
+	if err != nil {
+		t.Fatalf("expected replicated dataset to be in repo: %s", err.Error())
+	}
+	if got.Path != ref.Path {
+		t.Errorf("replicated path mismatch. expected: %s, got: %s", ref.Path, got.Path)
+	}
+
+	// versions that can't be added leave the existing version in place
+	missing := repo.DatasetRef{Peername: ref.Peername, ProfileID: ref.ProfileID, Name: ref.Name, Path: "/map/QmMissingVersion"}
+	if err := ReplicateDataset(peers[0], missing, ReplicateFull); err == nil {
+		t.Error("expected replicating a missing version to error")
+	}
+	if got, err = peers[0].Repo.GetRef(repo.DatasetRef{Peername: ref.Peername, Name: ref.Name}); err != nil {
+		t.Fatalf("expected failed replication to keep the previous version: %s", err.Error())
+	}
+	if got.Path != ref.Path {
+		t.Errorf("expected failed replication to keep path %s, got: %s", ref.Path, got.Path)
+	}
+
+	// syncing again is a no-op
+	if refs, err = SyncFollowedPeer(peers[0], peers[1].ID, ReplicateFull); err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(refs) != 0 {
+		t.Errorf("expected resync to replicate nothing, got: %d", len(refs))
+	}
+
+	if err := Unfollow(peers[0].Repo, ref.Peername, ""); err != nil {
+		t.Error(err.Error())
+	}
+	if err := Follow(peers[0].Repo, &repo.Follow{}); err != repo.ErrPeernameRequired {
+		t.Errorf("expected follow without a peername to error, got: %v", err)
+	}
+}
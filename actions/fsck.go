@@ -0,0 +1,293 @@
+package actions
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/multiformats/go-multihash"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/progress"
+	"github.com/qri-io/qri/repo"
+)
+
+// IssueType classifies problems found when checking a repo
+type IssueType string
+
+const (
+	// IssueDanglingRef is a reference to a dataset that isn't in the store
+	IssueDanglingRef = IssueType("dangling_ref")
+	// IssueBrokenHistory is a previous version of a dataset that isn't in the
+	// store, history can't be followed past it
+	IssueBrokenHistory = IssueType("broken_history")
+	// IssueMissingComponent is a dataset version with a component, like
+	// structure or meta, that fails to load
+	IssueMissingComponent = IssueType("missing_component")
+	// IssueMissingBody is a dataset version with a body that isn't in the store
+	IssueMissingBody = IssueType("missing_body")
+	// IssueBodyChecksum is a dataset body that doesn't match the checksum
+	// recorded in it's structure
+	IssueBodyChecksum = IssueType("body_checksum")
+	// IssueNotIndexed is a reference that's missing from the search index
+	IssueNotIndexed = IssueType("not_indexed")
+	// IssueStaleIndex is a search index entry with no matching reference
+	IssueStaleIndex = IssueType("stale_index")
+	// IssuePin is a dataset that couldn't be re-pinned
+	IssuePin = IssueType("pin")
+)
+
+// RepoIssue is a single problem found checking a repo
+type RepoIssue struct {
+	Type IssueType `json:"type"`
+	// Ref is the dataset reference the issue was found under
+	Ref repo.DatasetRef `json:"ref"`
+	// Path is the store path with the problem
+	Path    string `json:"path"`
+	Message string `json:"message"`
+	// Repaired is true if the issue was fixed
+	Repaired bool `json:"repaired"`
+}
+
+// CheckRepoResult describes the outcome of checking a repo
+type CheckRepoResult struct {
+	// Repair is true if repairs were attempted
+	Repair bool `json:"repair"`
+	// Refs is the number of references checked
+	Refs int `json:"refs"`
+	// Versions is the number of dataset versions checked, including history
+	Versions int `json:"versions"`
+	// Repinned is the number of datasets re-pinned while repairing
+	Repinned int `json:"repinned"`
+	// Issues lists all problems found, ordered by reference
+	Issues []*RepoIssue `json:"issues"`
+}
+
+// Unrepaired counts issues that weren't fixed
+func (res *CheckRepoResult) Unrepaired() (count int) {
+	for _, is := range res.Issues {
+		if !is.Repaired {
+			count++
+		}
+	}
+	return
+}
+
+// CheckRepo verifies the integrity of every dataset in a repo. Each version in
+// the history of every reference is checked to load, including components &
+// body, and bodies are checked against their recorded checksum. Repos with a
+// search index are checked for index entries that don't match the refstore.
+// When repair is true dangling references are removed, referenced datasets
+// are re-pinned & the search index is rebuilt if it doesn't match. Missing
+// history & components can't be repaired
+func CheckRepo(node *p2p.QriNode, repair bool) (*CheckRepoResult, error) {
+	r := node.Repo
+	store := r.Store()
+	res := &CheckRepoResult{Repair: repair, Issues: []*RepoIssue{}}
+
+	var (
+		mu    sync.Mutex
+		heads []repo.DatasetRef
+		// dangling references can't be removed while walking the refstore
+		dangling []*RepoIssue
+	)
+
+	task := progress.NewTask(node.Progress, "checking dataset versions", progress.Steps, 0)
+	err := repo.WalkRepoDatasets(r, func(depth int, ref *repo.DatasetRef, e error) (bool, error) {
+		defer task.Add(1)
+
+		issues := []*RepoIssue{}
+		if e != nil {
+			is := &RepoIssue{Type: IssueBrokenHistory, Ref: *ref, Path: ref.Path, Message: e.Error()}
+			if depth == 0 {
+				is.Type = IssueDanglingRef
+			}
+			issues = append(issues, is)
+		} else {
+			issues = checkVersion(store, *ref)
+		}
+		// clear the dataset so issues only record identifying details
+		for _, is := range issues {
+			is.Ref.Dataset = nil
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		res.Versions++
+		if depth == 0 {
+			res.Refs++
+			if e == nil {
+				heads = append(heads, repo.DatasetRef{Peername: ref.Peername, ProfileID: ref.ProfileID, Name: ref.Name, Path: ref.Path})
+			}
+		}
+		for _, is := range issues {
+			if is.Type == IssueDanglingRef {
+				dangling = append(dangling, is)
+			}
+		}
+		res.Issues = append(res.Issues, issues...)
+		return true, nil
+	})
+	if err == repo.ErrRepoEmpty {
+		err = nil
+	}
+	task.Finish(err)
+	if err != nil {
+		return nil, err
+	}
+
+	indexIssues, err := checkSearchIndex(r)
+	if err != nil {
+		return nil, err
+	}
+	res.Issues = append(res.Issues, indexIssues...)
+
+	if repair {
+		for _, is := range dangling {
+			if err := r.DeleteRef(is.Ref); err != nil {
+				return nil, fmt.Errorf("error removing dangling reference %s: %s", is.Ref, err.Error())
+			}
+			is.Repaired = true
+		}
+
+		if pinner, ok := store.(cafs.Pinner); ok {
+			for _, ref := range heads {
+				if err := pinner.Pin(datastore.NewKey(ref.Path), true); err != nil {
+					res.Issues = append(res.Issues, &RepoIssue{Type: IssuePin, Ref: ref, Path: ref.Path, Message: err.Error()})
+					continue
+				}
+				res.Repinned++
+			}
+		}
+
+		// dropping dangling references leaves stale index entries, so the index
+		// is rebuilt after references are repaired
+		if len(indexIssues) > 0 || len(dangling) > 0 {
+			if indexer, ok := r.(repo.SearchIndexer); ok {
+				task := progress.NewTask(node.Progress, "rebuilding search index", progress.Steps, 1)
+				err := indexer.RebuildSearchIndex()
+				if err == nil {
+					task.Add(1)
+				}
+				task.Finish(err)
+				if err != nil {
+					return nil, fmt.Errorf("error rebuilding search index: %s", err.Error())
+				}
+				for _, is := range indexIssues {
+					is.Repaired = true
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(res.Issues, func(i, j int) bool {
+		return res.Issues[i].Ref.AliasString() < res.Issues[j].Ref.AliasString()
+	})
+	return res, nil
+}
+
+// checkVersion verifies a single dataset version loads completely & has an
+// intact body
+func checkVersion(store cafs.Filestore, ref repo.DatasetRef) []*RepoIssue {
+	ds, err := dsfs.LoadDataset(store, datastore.NewKey(ref.Path))
+	if err != nil {
+		return []*RepoIssue{{Type: IssueMissingComponent, Ref: ref, Path: ref.Path, Message: err.Error()}}
+	}
+	if ds.BodyPath == "" {
+		return nil
+	}
+
+	if has, err := store.Has(datastore.NewKey(ds.BodyPath)); err != nil || !has {
+		msg := "body isn't in the store"
+		if err != nil {
+			msg = err.Error()
+		}
+		return []*RepoIssue{{Type: IssueMissingBody, Ref: ref, Path: ds.BodyPath, Message: msg}}
+	}
+
+	if ds.Structure == nil || ds.Structure.Checksum == "" {
+		return nil
+	}
+	sum, err := bodyChecksum(store, ds)
+	if err != nil {
+		return []*RepoIssue{{Type: IssueMissingBody, Ref: ref, Path: ds.BodyPath, Message: err.Error()}}
+	}
+	if sum != ds.Structure.Checksum {
+		return []*RepoIssue{{
+			Type:    IssueBodyChecksum,
+			Ref:     ref,
+			Path:    ds.BodyPath,
+			Message: fmt.Sprintf("body checksum %s doesn't match structure checksum %s", sum, ds.Structure.Checksum),
+		}}
+	}
+	return nil
+}
+
+// bodyChecksum calculates the checksum of a dataset body the way dsfs does
+// when a dataset is saved: a base58-encoded sha256 multihash of body bytes
+func bodyChecksum(store cafs.Filestore, ds *dataset.Dataset) (string, error) {
+	f, err := store.Get(datastore.NewKey(ds.BodyPath))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	buf, err := multihash.Encode(h.Sum(nil), multihash.SHA2_256)
+	if err != nil {
+		return "", err
+	}
+	return multihash.Multihash(buf).B58String(), nil
+}
+
+// checkSearchIndex compares the paths in a repo's search index with the
+// paths in it's refstore. Repos without a search index have no index issues
+func checkSearchIndex(r repo.Repo) ([]*RepoIssue, error) {
+	indexer, ok := r.(repo.SearchIndexer)
+	if !ok {
+		return nil, nil
+	}
+	indexed, err := indexer.IndexedPaths()
+	if err == repo.ErrSearchNotSupported {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("error reading search index: %s", err.Error())
+	}
+
+	count, err := r.RefCount()
+	if err != nil {
+		return nil, err
+	}
+	refs, err := r.References(count, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	inIndex := map[string]bool{}
+	for _, p := range indexed {
+		inIndex[p] = true
+	}
+
+	issues := []*RepoIssue{}
+	inRefs := map[string]bool{}
+	for _, ref := range refs {
+		inRefs[ref.Path] = true
+		if !inIndex[ref.Path] {
+			issues = append(issues, &RepoIssue{Type: IssueNotIndexed, Ref: ref, Path: ref.Path, Message: "reference is missing from the search index"})
+		}
+	}
+	for _, p := range indexed {
+		if !inRefs[p] {
+			issues = append(issues, &RepoIssue{Type: IssueStaleIndex, Path: p, Message: "search index entry has no matching reference"})
+		}
+	}
+	return issues, nil
+}
@@ -0,0 +1,70 @@
+package actions
+
+import (
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/qri/repo"
+)
+
+func TestCheckRepo(t *testing.T) {
+	node := newTestNode(t)
+	ref := addCitiesDataset(t, node)
+
+	res, err := CheckRepo(node, false)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(res.Issues) != 0 {
+		t.Errorf("expected a clean repo to have no issues, got: %d. first: %s", len(res.Issues), res.Issues[0].Message)
+	}
+	if res.Refs != 1 || res.Versions != 1 {
+		t.Errorf("expected 1 ref & 1 version to be checked, got: %d refs, %d versions", res.Refs, res.Versions)
+	}
+
+	dangling := repo.DatasetRef{Peername: "peer", ProfileID: ref.ProfileID, Name: "gone", Path: "/map/QmdWJ7RnFj3SdWW85mR4AYP17C8dRPD9eUPyTqUxVyGMgD"}
+	if err := node.Repo.PutRef(dangling); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	ds, err := dsfs.LoadDataset(node.Repo.Store(), datastore.NewKey(ref.Path))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := node.Repo.Store().Delete(datastore.NewKey(ds.BodyPath)); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if res, err = CheckRepo(node, false); err != nil {
+		t.Fatal(err.Error())
+	}
+	found := map[IssueType]bool{}
+	for _, is := range res.Issues {
+		found[is.Type] = true
+		if is.Repaired {
+			t.Errorf("expected check without repair to leave issue %s unrepaired", is.Type)
+		}
+	}
+	if len(res.Issues) != 2 || !found[IssueDanglingRef] || !found[IssueMissingBody] {
+		t.Errorf("expected a dangling ref & a missing body, got: %v", found)
+	}
+
+	if res, err = CheckRepo(node, true); err != nil {
+		t.Fatal(err.Error())
+	}
+	for _, is := range res.Issues {
+		if is.Type == IssueDanglingRef && !is.Repaired {
+			t.Error("expected dangling ref to be repaired")
+		}
+		if is.Type == IssueMissingBody && is.Repaired {
+			t.Error("expected missing body to be left unrepaired")
+		}
+	}
+	if res.Unrepaired() != 1 {
+		t.Errorf("expected 1 unrepaired issue, got: %d", res.Unrepaired())
+	}
+	if _, err := node.Repo.GetRef(repo.DatasetRef{Peername: "peer", Name: "gone"}); err != repo.ErrNotFound {
+		t.Errorf("expected dangling ref to be removed, got: %v", err)
+	}
+}
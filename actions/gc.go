@@ -0,0 +1,377 @@
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/repo"
+
+	"gx/ipfs/QmebqVUQQqQFhg74FtQFszUJo22Vpr3e8qBAkvvV4ho9HH/go-ipfs/core/corerepo"
+)
+
+// ErrCannotListStore is returned when garbage collecting a store that can't
+// enumerate it's contents
+var ErrCannotListStore = fmt.Errorf("this store doesn't support listing contents, cannot collect garbage")
+
+// KeyLister is a store that can list every key it contains. Stores must be
+// able to list keys to be garbage collected
+type KeyLister interface {
+	Keys() ([]datastore.Key, error)
+}
+
+// GCResult describes the outcome of a garbage collection pass
+type GCResult struct {
+	// DryRun is true if nothing was actually removed
+	DryRun bool `json:"dryRun"`
+	// Reachable is the number of paths referenced by the repo
+	Reachable int `json:"reachable"`
+	// Removed lists store paths that were removed, or would be removed on a dry run
+	Removed []string `json:"removed"`
+	// BytesReclaimed is the total size of removed content
+	BytesReclaimed int64 `json:"bytesReclaimed"`
+}
+
+// GarbageCollect removes all content from a repo's store that isn't reachable
+// from a dataset reference or profile. Reachable content includes every version
+// in the history of a referenced dataset, along with it's body, transform & viz.
+// When dryRun is true GarbageCollect reports what would be removed without
+// changing the store
+func GarbageCollect(node *p2p.QriNode, dryRun bool) (*GCResult, error) {
+	r := node.Repo
+	store := r.Store()
+
+	reachable, err := ReachablePaths(r)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := storeKeys(store)
+	if err == ErrCannotListStore {
+		// ipfs stores can't list their contents, but can list what's pinned
+		if _, e := node.IPFSNode(); e == nil {
+			return ipfsGarbageCollect(node, reachable, dryRun)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := &GCResult{DryRun: dryRun, Reachable: len(reachable)}
+	err = collectKeys(store, keys, reachable, res, func(key datastore.Key) error {
+		if pinner, ok := store.(cafs.Pinner); ok {
+			// unreachable content may not be pinned, ignore unpin errors
+			pinner.Unpin(key, true)
+		}
+		return store.Delete(key)
+	})
+	return res, err
+}
+
+// ipfsGarbageCollect collects garbage from a store backed by an ipfs node.
+// Every dataset qri saves to ipfs is pinned, so unreachable datasets are found
+// by checking pinned datasets against reachable paths. Unreachable datasets are
+// unpinned, then ipfs garbage collection removes the blocks no pin references.
+// Pins that aren't qri datasets are left alone, so pinning with ipfs directly
+// keeps working in a shared ipfs repo. BytesReclaimed is an estimate, content
+// shared with other pins isn't removed
+func ipfsGarbageCollect(node *p2p.QriNode, reachable map[string]bool, dryRun bool) (*GCResult, error) {
+	store := node.Repo.Store()
+	pinner, ok := store.(cafs.Pinner)
+	if !ok {
+		return nil, ErrCannotListStore
+	}
+	ipfsnode, err := node.IPFSNode()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []datastore.Key{}
+	for _, c := range ipfsnode.Pinning.RecursiveKeys() {
+		key := datastore.NewKey("/ipfs/" + c.String())
+		// only qri datasets are candidates for removal
+		if _, err := dsfs.LoadDataset(store, key.ChildString(dsfs.PackageFileDataset.String())); err != nil {
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	res := &GCResult{DryRun: dryRun, Reachable: len(reachable)}
+	err = collectKeys(store, keys, reachable, res, func(key datastore.Key) error {
+		return pinner.Unpin(key, true)
+	})
+	if err != nil || dryRun || len(res.Removed) == 0 {
+		return res, err
+	}
+
+	if err = corerepo.GarbageCollect(ipfsnode, node.Context()); err != nil {
+		return res, fmt.Errorf("error running ipfs garbage collection: %s", err.Error())
+	}
+	return res, nil
+}
+
+// collectKeys calls remove on each key that isn't reachable, recording what's
+// removed in res. On a dry run nothing is removed
+func collectKeys(store cafs.Filestore, keys []datastore.Key, reachable map[string]bool, res *GCResult, remove func(key datastore.Key) error) error {
+	defer sort.Strings(res.Removed)
+
+	for _, key := range keys {
+		if pathReachable(reachable, key.String()) {
+			continue
+		}
+
+		size, err := storeFileSize(store, key)
+		if err != nil {
+			return fmt.Errorf("error reading %s: %s", key.String(), err.Error())
+		}
+
+		if !res.DryRun {
+			if err = remove(key); err != nil {
+				return fmt.Errorf("error removing %s: %s", key.String(), err.Error())
+			}
+		}
+
+		res.Removed = append(res.Removed, key.String())
+		res.BytesReclaimed += size
+	}
+	return nil
+}
+
+// ReachablePaths lists all store paths referenced by a repo, walking the full
+// history of every dataset reference, and including profile photos, named
+// render templates & the proposals of open change requests
+func ReachablePaths(r repo.Repo) (map[string]bool, error) {
+	paths := map[string]bool{}
+	versions := []string{}
+	mu := sync.Mutex{}
+
+	err := repo.WalkRepoDatasets(r, func(depth int, ref *repo.DatasetRef, e error) (bool, error) {
+		if e != nil {
+			return false, e
+		}
+		data, err := json.Marshal(ref.Dataset)
+		if err != nil {
+			return false, err
+		}
+		var v interface{}
+		if err = json.Unmarshal(data, &v); err != nil {
+			return false, err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		paths[normalizePath(ref.Path)] = true
+		versions = append(versions, ref.Path)
+		addPathStrings(paths, v)
+		return true, nil
+	})
+	if err != nil && err != repo.ErrRepoEmpty {
+		return nil, err
+	}
+
+	for _, path := range versions {
+		if err := addMergedPaths(r.Store(), paths, path); err != nil {
+			return nil, err
+		}
+	}
+
+	pro, err := r.Profile()
+	if err != nil {
+		return nil, err
+	}
+	addProfilePaths(paths, pro.Photo, pro.Thumb, pro.Poster)
+
+	if ps := r.Profiles(); ps != nil {
+		profiles, err := ps.List()
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range profiles {
+			addProfilePaths(paths, p.Photo, p.Thumb, p.Poster)
+		}
+	}
+
+	if ts, ok := r.(repo.TemplateStore); ok {
+		templates, err := ts.ListTemplates()
+		if err != nil && err != repo.ErrTemplatesNotSupported {
+			return nil, err
+		}
+		for _, t := range templates {
+			paths[normalizePath(t.Path)] = true
+		}
+	}
+
+	if cs, ok := r.(repo.ChangeRequestStore); ok {
+		crs, err := cs.ListChangeRequests(-1, 0)
+		if err != nil && err != repo.ErrChangeRequestsNotSupported {
+			return nil, err
+		}
+		for _, cr := range crs {
+			// open proposals must survive until they're accepted or rejected
+			if cr.Status != repo.CRStatusOpen || paths[normalizePath(cr.Path)] {
+				continue
+			}
+			if err := addHistoryPaths(r.Store(), paths, cr.Path); err != nil {
+				log.Debugf("error loading change request %s: %s", cr.ID, err.Error())
+			}
+		}
+	}
+
+	return paths, nil
+}
+
+// addMergedPaths adds the versions a merge commit merged in to a set of paths,
+// along with their history. Merged versions are parents of the merge commit,
+// but may not be in the history of any reference
+func addMergedPaths(store cafs.Filestore, paths map[string]bool, path string) error {
+	ds, err := dsfs.LoadDataset(store, datastore.NewKey(path))
+	if err != nil {
+		return err
+	}
+	parents := datasetParents(ds)
+	if ds.PreviousPath != "" && ds.PreviousPath != "/" {
+		parents = parents[1:]
+	}
+
+	for _, merged := range parents {
+		if paths[normalizePath(merged)] {
+			continue
+		}
+		if err := addHistoryPaths(store, paths, merged); err != nil {
+			// history can't be kept past a version that's already gone
+			log.Debugf("error loading merged version %s: %s", merged, err.Error())
+		}
+	}
+	return nil
+}
+
+// addHistoryPaths adds a version, it's ancestors & the components of each to a
+// set of paths
+func addHistoryPaths(store cafs.Filestore, paths map[string]bool, path string) error {
+	var visitErr error
+	err := walkAncestors(store, path, func(p string) bool {
+		refs, err := dsfs.LoadDatasetRefs(store, datastore.NewKey(p))
+		if err != nil {
+			visitErr = err
+			return false
+		}
+		data, err := json.Marshal(refs.Encode())
+		if err != nil {
+			visitErr = err
+			return false
+		}
+		var v interface{}
+		if err = json.Unmarshal(data, &v); err != nil {
+			visitErr = err
+			return false
+		}
+		paths[normalizePath(p)] = true
+		addPathStrings(paths, v)
+		return true
+	})
+	if err == nil {
+		err = visitErr
+	}
+	return err
+}
+
+// addPathStrings adds any string value within a decoded JSON value that looks
+// like a path to a set of paths. Component references in encoded datasets are
+// path strings, so this collects body, commit, meta, structure, transform & viz
+// paths without needing to know the shape of each component
+func addPathStrings(paths map[string]bool, v interface{}) {
+	switch x := v.(type) {
+	case string:
+		if strings.HasPrefix(x, "/") {
+			paths[normalizePath(x)] = true
+		}
+	case map[string]interface{}:
+		for _, val := range x {
+			addPathStrings(paths, val)
+		}
+	case []interface{}:
+		for _, val := range x {
+			addPathStrings(paths, val)
+		}
+	}
+}
+
+func addProfilePaths(paths map[string]bool, keys ...datastore.Key) {
+	for _, key := range keys {
+		if p := key.String(); p != "" && p != "/" {
+			paths[normalizePath(p)] = true
+		}
+	}
+}
+
+// pathReachable checks if a path or any of it's parent paths is in the set of
+// reachable paths. Reachable paths can also point within a path, keeping a
+// wrapping directory
+func pathReachable(reachable map[string]bool, path string) bool {
+	path = normalizePath(path)
+	if reachable[path] {
+		return true
+	}
+	for p := range reachable {
+		if strings.HasPrefix(p, path+"/") || strings.HasPrefix(path, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// storeKeys lists all keys in a store
+func storeKeys(store cafs.Filestore) ([]datastore.Key, error) {
+	// stores wrapped by middleware list the keys of the store they wrap
+	switch s := repo.BaseStore(store).(type) {
+	case KeyLister:
+		return s.Keys()
+	case *cafs.MapStore:
+		keys := make([]datastore.Key, 0, len(s.Files))
+		for key := range s.Files {
+			keys = append(keys, key)
+		}
+		return keys, nil
+	}
+	return nil, ErrCannotListStore
+}
+
+// storeFileSize calculates the size of a file in the store
+func storeFileSize(store cafs.Filestore, key datastore.Key) (int64, error) {
+	f, err := store.Get(key)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return fileSize(f)
+}
+
+func fileSize(f cafs.File) (int64, error) {
+	if !f.IsDirectory() {
+		return io.Copy(ioutil.Discard, f)
+	}
+
+	var size int64
+	for {
+		child, err := f.NextFile()
+		if err == io.EOF {
+			return size, nil
+		} else if err != nil {
+			return size, err
+		}
+		s, err := fileSize(child)
+		child.Close()
+		if err != nil {
+			return size, err
+		}
+		size += s
+	}
+}
@@ -0,0 +1,138 @@
+package actions
+
+import (
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/qri/repo"
+)
+
+func TestGarbageCollect(t *testing.T) {
+	node := newTestNode(t)
+	ref := addCitiesDataset(t, node)
+	store := node.Repo.Store()
+
+	junk, err := store.Put(cafs.NewMemfileBytes("junk.txt", []byte("not referenced by anything")), false)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	res, err := GarbageCollect(node, true)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(res.Removed) != 1 || res.Removed[0] != junk.String() {
+		t.Errorf("expected dry run to remove only %s, got: %v", junk.String(), res.Removed)
+	}
+	if res.BytesReclaimed != int64(len("not referenced by anything")) {
+		t.Errorf("bytes reclaimed mismatch. expected: %d, got: %d", len("not referenced by anything"), res.BytesReclaimed)
+	}
+	if has, _ := store.Has(junk); !has {
+		t.Error("expected dry run to leave store unchanged")
+	}
+
+	if res, err = GarbageCollect(node, false); err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(res.Removed) != 1 {
+		t.Errorf("expected 1 removed path, got: %d", len(res.Removed))
+	}
+	if has, _ := store.Has(junk); has {
+		t.Error("expected unreferenced content to be removed from the store")
+	}
+	ds, err := dsfs.LoadDataset(store, datastore.NewKey(ref.Path))
+	if err != nil {
+		t.Fatalf("expected referenced dataset to remain in store: %s", err.Error())
+	}
+	if _, err := dsfs.LoadBody(store, ds); err != nil {
+		t.Errorf("expected referenced body to remain in store: %s", err.Error())
+	}
+}
+
+func TestGarbageCollectMergedVersions(t *testing.T) {
+	node := newTestNode(t)
+	base := addCitiesDataset(t, node)
+
+	theirs := saveCitiesVersion(t, node, "cities_fork", base, "city data", citiesBody)
+	saveCitiesVersion(t, node, "cities", base, "city data", `city,pop,avg_age,in_usa
+toronto,40000000,56.5,false
+new york,8500000,44.4,true
+chicago,300000,44.4,true
+chatham,35000,65.25,true
+raleigh,250000,50.65,true
+`)
+
+	res, err := MergeDatasets(node, repo.DatasetRef{}, repo.DatasetRef{Peername: "me", Name: "cities"}, theirs)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(res.Conflicts) != 0 {
+		t.Fatalf("expected no conflicts, got: %v", res.Conflicts)
+	}
+
+	// the merged version is now only reachable through the merge commit
+	if err := node.Repo.DeleteRef(theirs); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	reachable, err := ReachablePaths(node.Repo)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !reachable[normalizePath(theirs.Path)] {
+		t.Errorf("expected merged version %s to be reachable", theirs.Path)
+	}
+
+	gc, err := GarbageCollect(node, false)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	for _, path := range gc.Removed {
+		if pathReachable(map[string]bool{normalizePath(theirs.Path): true}, path) {
+			t.Errorf("expected merged version to be kept, removed: %s", path)
+		}
+	}
+	if _, err := MergeBase(node.Repo, res.Ref, theirs); err != nil {
+		t.Errorf("expected history of merge commit to load after gc: %s", err.Error())
+	}
+}
+
+func TestGarbageCollectChangeRequests(t *testing.T) {
+	node := newTestNode(t)
+	base := addCitiesDataset(t, node)
+
+	proposal := saveCitiesVersion(t, node, "cities_proposal", base, "proposed city data", citiesBody)
+	if err := node.Repo.DeleteRef(proposal); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	crs := node.Repo.(repo.ChangeRequestStore)
+	cr := &repo.ChangeRequest{ID: proposal.Path, Target: base, Path: proposal.Path, Status: repo.CRStatusOpen}
+	if err := crs.PutChangeRequest(cr); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	res, err := GarbageCollect(node, true)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	for _, path := range res.Removed {
+		if pathReachable(map[string]bool{normalizePath(proposal.Path): true}, path) {
+			t.Errorf("expected open change request proposal to be kept, removed: %s", path)
+		}
+	}
+
+	cr.Status = repo.CRStatusRejected
+	if err := crs.PutChangeRequest(cr); err != nil {
+		t.Fatal(err.Error())
+	}
+	reachable, err := ReachablePaths(node.Repo)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if reachable[normalizePath(proposal.Path)] {
+		t.Error("expected proposal of a rejected change request to be unreachable")
+	}
+}
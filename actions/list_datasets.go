@@ -54,7 +54,7 @@ func ListDatasets(node *p2p.QriNode, ds *repo.DatasetRef, limit, offset int, RPC
 			return nil, fmt.Errorf("couldn't find a peer address for profile: %s", pro.ID)
 		}
 
-		res, err = node.RequestDatasetsList(pro.PeerIDs[0], p2p.DatasetsListParams{
+		res, err = node.RequestDatasetsList(node.Context(), pro.PeerIDs[0], p2p.DatasetsListParams{
 			Limit:  limit,
 			Offset: offset,
 		})
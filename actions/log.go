@@ -15,7 +15,7 @@ func DatasetLog(node *p2p.QriNode, ref repo.DatasetRef, limit, offset int) (rlog
 	}
 
 	if !local {
-		return node.RequestDatasetLog(ref, limit, offset)
+		return node.RequestDatasetLog(node.Context(), ref, limit, offset)
 	}
 
 	for {
@@ -41,3 +41,20 @@ func DatasetLog(node *p2p.QriNode, ref repo.DatasetRef, limit, offset int) (rlog
 
 	return rlog, nil
 }
+
+// DatasetEvents lists repo events that match a filter, most recent first.
+// The filter's dataset reference is canonicalized before matching
+func DatasetEvents(r repo.Repo, f EventFilter, limit, offset int) ([]*repo.Event, error) {
+	if !f.Ref.IsEmpty() {
+		// events outlive the refs they describe, so a missing ref isn't an error.
+		// only match on path if one was explicitly given, otherwise events for all
+		// versions of the dataset are returned
+		path := f.Ref.Path
+		if err := repo.CanonicalizeDatasetRef(r, &f.Ref); err != nil && err != repo.ErrNotFound {
+			return nil, err
+		}
+		f.Ref.Path = path
+	}
+
+	return FilterEvents(r, f, limit, offset)
+}
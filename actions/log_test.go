@@ -37,3 +37,44 @@ func TestDatasetLog(t *testing.T) {
 	}
 
 }
+
+func TestDatasetEvents(t *testing.T) {
+	mr, err := testrepo.NewTestRepo(nil)
+	if err != nil {
+		t.Fatalf("error allocating test repo: %s", err.Error())
+	}
+
+	ref := repo.DatasetRef{Peername: "peer", Name: "movies"}
+	for _, et := range []repo.EventType{repo.ETDsCreated, repo.ETDsPinned, repo.ETDsPinned} {
+		if err := mr.LogEvent(et, ref); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+	if err := mr.LogEvent(repo.ETDsPinned, repo.DatasetRef{Peername: "peer", Name: "cities"}); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	cases := []struct {
+		ref    repo.DatasetRef
+		t      repo.EventType
+		limit  int
+		expect int
+	}{
+		{repo.DatasetRef{Peername: "me", Name: "movies"}, "", 10, 3},
+		{ref, repo.ETDsPinned, 10, 2},
+		{ref, repo.ETDsPinned, 1, 1},
+		{repo.DatasetRef{}, repo.ETDsPinned, 10, 3},
+		{repo.DatasetRef{Peername: "peer", Name: "deleted"}, "", 10, 0},
+	}
+
+	for i, c := range cases {
+		got, err := DatasetEvents(mr, EventFilter{Ref: c.ref, Type: c.t}, c.limit, 0)
+		if err != nil {
+			t.Errorf("case %d unexpected error: %s", i, err.Error())
+			continue
+		}
+		if len(got) != c.expect {
+			t.Errorf("case %d event count mismatch. expected: %d, got: %d", i, c.expect, len(got))
+		}
+	}
+}
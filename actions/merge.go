@@ -0,0 +1,578 @@
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/repo"
+)
+
+// ErrNoCommonAncestor is returned when two datasets share no history
+var ErrNoCommonAncestor = fmt.Errorf("datasets have no common ancestor")
+
+// ErrMergeNotHead is returned when merging into a version of a dataset that
+// isn't it's latest. The merge commit would drop any versions made since
+var ErrMergeNotHead = fmt.Errorf("can only merge into the latest version of a dataset")
+
+// mergedTrailer prefixes the line of a merge commit message that records the
+// path of the version merged in. Merge commits have two parents, ours is the
+// PreviousPath & theirs is recorded with this trailer
+const mergedTrailer = "merged: "
+
+// MergeConflict describes a change that cannot be merged automatically
+type MergeConflict struct {
+	// Component is the dataset component the conflict occurred in, one of
+	// "meta", "structure" or "body"
+	Component string `json:"component"`
+	// Key identifies the body row of a conflict. empty for non-body conflicts
+	Key string `json:"key,omitempty"`
+	// Field is the name of the conflicting field or column
+	Field string `json:"field,omitempty"`
+	// Base, Ours & Theirs are the conflicting values. nil values indicate
+	// the value was absent
+	Base   interface{} `json:"base"`
+	Ours   interface{} `json:"ours"`
+	Theirs interface{} `json:"theirs"`
+}
+
+// String implements the stringer interface for MergeConflict
+func (c *MergeConflict) String() string {
+	loc := c.Component
+	if c.Key != "" {
+		loc += " row " + c.Key
+	}
+	if c.Field != "" {
+		loc += " " + c.Field
+	}
+	return fmt.Sprintf("%s: base: %v, ours: %v, theirs: %v", loc, c.Base, c.Ours, c.Theirs)
+}
+
+// DatasetMergeResult is the outcome of a call to MergeDatasets
+type DatasetMergeResult struct {
+	// Base is the common ancestor the merge was calculated from
+	Base repo.DatasetRef `json:"base"`
+	// Ref is the merge commit. if the merge has conflicts, or theirs is already
+	// part of our history, Ref is the unchanged "ours" reference
+	Ref repo.DatasetRef `json:"ref"`
+	// Conflicts lists all changes that couldn't be merged automatically
+	Conflicts []*MergeConflict `json:"conflicts,omitempty"`
+	// UpToDate is true when theirs is already part of our history
+	UpToDate bool `json:"upToDate,omitempty"`
+}
+
+// MergeDatasets performs a three-way merge of two dataset versions, writing the
+// result as a new version of ours. ours must be the latest version of it's
+// dataset. If base is empty the common ancestor is found by walking the
+// PreviousPath of both versions. Merging is all-or-nothing, if any conflicts are
+// found no commit is written and the conflicts are returned
+func MergeDatasets(node *p2p.QriNode, base, ours, theirs repo.DatasetRef) (res *DatasetMergeResult, err error) {
+	r := node.Repo
+	if ours.IsEmpty() || theirs.IsEmpty() {
+		err = fmt.Errorf("please provide two dataset references to merge")
+		return
+	}
+
+	if err = repo.CanonicalizeDatasetRef(r, &ours); err != nil {
+		log.Debug(err.Error())
+		err = fmt.Errorf("error with our reference: %s", err.Error())
+		return
+	}
+	head, err := r.GetRef(repo.DatasetRef{Peername: ours.Peername, ProfileID: ours.ProfileID, Name: ours.Name})
+	if err != nil {
+		err = fmt.Errorf("error with our reference: %s", err.Error())
+		return
+	}
+	if ours.Path == "" {
+		ours = head
+	} else if normalizePath(ours.Path) != normalizePath(head.Path) {
+		err = ErrMergeNotHead
+		return
+	}
+	if err = DatasetHead(node, &theirs); err != nil {
+		err = fmt.Errorf("error with their reference: %s", err.Error())
+		return
+	}
+
+	if base.IsEmpty() {
+		if base, err = MergeBase(r, ours, theirs); err != nil {
+			return
+		}
+	}
+	if err = DatasetHead(node, &base); err != nil {
+		err = fmt.Errorf("error with base reference: %s", err.Error())
+		return
+	}
+
+	res = &DatasetMergeResult{Base: base, Ref: ours}
+	if normalizePath(base.Path) == normalizePath(theirs.Path) {
+		// theirs is already part of our history, nothing to do
+		res.UpToDate = true
+		return
+	}
+
+	store := r.Store()
+	baseDs, err := dsfs.LoadDataset(store, datastore.NewKey(base.Path))
+	if err != nil {
+		return
+	}
+	oursDs, err := dsfs.LoadDataset(store, datastore.NewKey(ours.Path))
+	if err != nil {
+		return
+	}
+	theirsDs, err := dsfs.LoadDataset(store, datastore.NewKey(theirs.Path))
+	if err != nil {
+		return
+	}
+
+	pro, err := r.Profile()
+	if err != nil {
+		return
+	}
+
+	ds := &dataset.Dataset{}
+	ds.Assign(oursDs)
+	ds.PreviousPath = ours.Path
+	ds.Commit = &dataset.Commit{
+		Title:   fmt.Sprintf("merged %s", theirs.AliasString()),
+		Message: fmt.Sprintf("merge %s into %s\n%s%s\nbase: %s", theirs.Path, ours.Path, mergedTrailer, theirs.Path, base.Path),
+		Author:  &dataset.User{ID: pro.ID.String()},
+	}
+
+	ds.Meta = &dataset.Meta{}
+	if err = mergeComponent("meta", baseDs.Meta, oursDs.Meta, theirsDs.Meta, ds.Meta, res); err != nil {
+		return
+	}
+	ds.Structure = &dataset.Structure{}
+	if err = mergeComponent("structure", baseDs.Structure, oursDs.Structure, theirsDs.Structure, ds.Structure, res); err != nil {
+		return
+	}
+
+	body, err := mergeBodies(store, ds.Structure, baseDs, oursDs, theirsDs, res)
+	if err != nil {
+		return
+	}
+
+	if len(res.Conflicts) > 0 {
+		return
+	}
+
+	// dsfs will re-calculate these
+	ds.Structure.Checksum = ""
+	ds.Structure.Length = 0
+	ds.Structure.Entries = 0
+	ds.Structure.ErrCount = 0
+
+	ref, err := repo.CreateDataset(r, ours.Name, ds, body, true)
+	if err != nil {
+		log.Debug(err.Error())
+		err = fmt.Errorf("error writing merge commit: %s", err.Error())
+		return
+	}
+	params := &repo.EventParams{
+		PrevPath: ours.Path,
+		Details:  map[string]interface{}{"merged": theirs.Path, "base": base.Path},
+	}
+	if err = logEvent(r, repo.ETDsCreated, ref, params); err != nil {
+		return
+	}
+
+	ref.Dataset = ds.Encode()
+	res.Ref = ref
+	return
+}
+
+// MergeBase finds the most recent common ancestor of two dataset versions by
+// walking the history of each, following both parents of merge commits
+func MergeBase(r repo.Repo, a, b repo.DatasetRef) (repo.DatasetRef, error) {
+	seen := map[string]bool{}
+	err := walkAncestors(r.Store(), a.Path, func(path string) bool {
+		seen[normalizePath(path)] = true
+		return true
+	})
+	if err != nil {
+		return repo.DatasetRef{}, fmt.Errorf("error loading history of %s: %s", a, err.Error())
+	}
+
+	base := ""
+	err = walkAncestors(r.Store(), b.Path, func(path string) bool {
+		if seen[normalizePath(path)] {
+			base = path
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return repo.DatasetRef{}, fmt.Errorf("error loading history of %s: %s", b, err.Error())
+	}
+	if base == "" {
+		return repo.DatasetRef{}, ErrNoCommonAncestor
+	}
+	return repo.DatasetRef{Path: base}, nil
+}
+
+// walkAncestors calls visit with path & every version in it's history, nearest
+// versions first, until visit returns false
+func walkAncestors(store cafs.Filestore, path string, visit func(path string) bool) error {
+	queue := []string{path}
+	visited := map[string]bool{}
+	for len(queue) > 0 {
+		path, queue = queue[0], queue[1:]
+		if path == "" || path == "/" || visited[normalizePath(path)] {
+			continue
+		}
+		visited[normalizePath(path)] = true
+
+		if !visit(path) {
+			return nil
+		}
+		ds, err := dsfs.LoadDataset(store, datastore.NewKey(path))
+		if err != nil {
+			return err
+		}
+		queue = append(queue, datasetParents(ds)...)
+	}
+	return nil
+}
+
+// datasetParents lists the versions a dataset version was made from: it's
+// PreviousPath, and for merge commits the version that was merged in
+func datasetParents(ds *dataset.Dataset) []string {
+	parents := []string{}
+	if ds.PreviousPath != "" && ds.PreviousPath != "/" {
+		parents = append(parents, ds.PreviousPath)
+	}
+	if ds.Commit != nil {
+		for _, line := range strings.Split(ds.Commit.Message, "\n") {
+			if strings.HasPrefix(line, mergedTrailer) {
+				parents = append(parents, strings.TrimSpace(strings.TrimPrefix(line, mergedTrailer)))
+			}
+		}
+	}
+	return parents
+}
+
+// normalizePath removes any trailing package file from a dataset path
+func normalizePath(path string) string {
+	return strings.TrimSuffix(path, "/"+dsfs.PackageFileDataset.String())
+}
+
+// mergeComponentSkipKeys are fields that will be re-calculated on save, and
+// shouldn't be considered when merging
+var mergeComponentSkipKeys = map[string]bool{
+	"path":     true,
+	"qri":      true,
+	"checksum": true,
+	"length":   true,
+	"entries":  true,
+	"errCount": true,
+}
+
+// mergeComponent performs a field-level three-way merge of a dataset component,
+// decoding the result into dst
+func mergeComponent(name string, base, ours, theirs, dst interface{}, res *DatasetMergeResult) error {
+	b, err := componentFields(base)
+	if err != nil {
+		return err
+	}
+	o, err := componentFields(ours)
+	if err != nil {
+		return err
+	}
+	t, err := componentFields(theirs)
+	if err != nil {
+		return err
+	}
+
+	keys := []string{}
+	for _, m := range []map[string]interface{}{o, t} {
+		for key := range m {
+			if _, ok := b[key]; !ok {
+				b[key] = nil
+			}
+		}
+	}
+	for key := range b {
+		if !mergeComponentSkipKeys[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	merged := map[string]interface{}{}
+	for _, key := range keys {
+		val, ok := mergeValue(b[key], o[key], t[key])
+		if !ok {
+			res.Conflicts = append(res.Conflicts, &MergeConflict{
+				Component: name,
+				Field:     key,
+				Base:      b[key],
+				Ours:      o[key],
+				Theirs:    t[key],
+			})
+			continue
+		}
+		if val != nil {
+			merged[key] = val
+		}
+	}
+
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, dst); err != nil {
+		return fmt.Errorf("error decoding merged %s: %s", name, err.Error())
+	}
+	return nil
+}
+
+// componentFields converts a dataset component to a generic map
+func componentFields(c interface{}) (map[string]interface{}, error) {
+	fields := map[string]interface{}{}
+	if c == nil || reflect.ValueOf(c).IsNil() {
+		return fields, nil
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	// components with only a path set marshal to a string
+	if len(data) > 0 && data[0] == '"' {
+		return fields, nil
+	}
+	err = json.Unmarshal(data, &fields)
+	return fields, err
+}
+
+// mergeValue performs a three-way merge of a single value, returning false if
+// both sides changed the value differently
+func mergeValue(base, ours, theirs interface{}) (interface{}, bool) {
+	switch {
+	case reflect.DeepEqual(ours, theirs):
+		return ours, true
+	case reflect.DeepEqual(base, ours):
+		return theirs, true
+	case reflect.DeepEqual(base, theirs):
+		return ours, true
+	}
+	return nil, false
+}
+
+// bodyRows is an ordered, keyed set of body entries
+type bodyRows struct {
+	keys []string
+	rows map[string]interface{}
+}
+
+func (br *bodyRows) get(key string) (interface{}, bool) {
+	v, ok := br.rows[key]
+	return v, ok
+}
+
+func readBodyRows(store cafs.Filestore, ds *dataset.Dataset, primaryKey, columns []string) (*bodyRows, error) {
+	file, err := dsfs.LoadBody(store, ds)
+	if err != nil {
+		return nil, fmt.Errorf("error loading body: %s", err.Error())
+	}
+	defer file.Close()
+
+	rr, err := dsio.NewEntryReader(ds.Structure, file)
+	if err != nil {
+		return nil, fmt.Errorf("error allocating data reader: %s", err.Error())
+	}
+
+	br := &bodyRows{rows: map[string]interface{}{}}
+	for i := 0; ; i++ {
+		ent, err := rr.ReadEntry()
+		if err != nil {
+			if err.Error() == "EOF" {
+				break
+			}
+			return nil, fmt.Errorf("error reading body: %s", err.Error())
+		}
+		if i == DefaultBodyDiffMaxEntries {
+			return nil, fmt.Errorf("body has more than %d entries, too large to merge", DefaultBodyDiffMaxEntries)
+		}
+
+		key, err := rowKey(ent, primaryKey, columns)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := br.rows[key]; ok {
+			return nil, fmt.Errorf("duplicate key '%s' in body", key)
+		}
+		br.keys = append(br.keys, key)
+		br.rows[key] = ent.Value
+	}
+	return br, nil
+}
+
+// mergeBodies performs a row-level three-way merge of dataset bodies, writing
+// rows according to the merged structure
+func mergeBodies(store cafs.Filestore, st *dataset.Structure, base, ours, theirs *dataset.Dataset, res *DatasetMergeResult) (cafs.File, error) {
+	if (theirs.BodyPath == base.BodyPath || theirs.BodyPath == ours.BodyPath) && ours.Structure.Format == st.Format {
+		return dsfs.LoadBody(store, ours)
+	}
+	if ours.BodyPath == base.BodyPath && theirs.Structure.Format == st.Format {
+		return dsfs.LoadBody(store, theirs)
+	}
+
+	primaryKey := schemaPrimaryKey(st)
+	columns := schemaColumns(st)
+
+	b, err := readBodyRows(store, base, primaryKey, columns)
+	if err != nil {
+		return nil, err
+	}
+	o, err := readBodyRows(store, ours, primaryKey, columns)
+	if err != nil {
+		return nil, err
+	}
+	t, err := readBodyRows(store, theirs, primaryKey, columns)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := append([]string{}, o.keys...)
+	for _, key := range t.keys {
+		if _, ok := o.get(key); !ok {
+			keys = append(keys, key)
+		}
+	}
+
+	buf, err := dsio.NewEntryBuffer(st)
+	if err != nil {
+		return nil, fmt.Errorf("error allocating result buffer: %s", err.Error())
+	}
+
+	isObject := st.Schema != nil && st.Schema.TopLevelType() == "object"
+	for i, key := range keys {
+		bv, inBase := b.get(key)
+		ov, inOurs := o.get(key)
+		tv, inTheirs := t.get(key)
+
+		val, keep := mergeRow(key, bv, ov, tv, inBase, inOurs, inTheirs, columns, res)
+		if !keep {
+			continue
+		}
+
+		ent := dsio.Entry{Index: i, Value: val}
+		if isObject {
+			ent.Key = key
+		}
+		if err := buf.WriteEntry(ent); err != nil {
+			return nil, fmt.Errorf("error writing merged body: %s", err.Error())
+		}
+	}
+
+	if err := buf.Close(); err != nil {
+		return nil, fmt.Errorf("error closing row buffer: %s", err.Error())
+	}
+	return cafs.NewMemfileBytes(fmt.Sprintf("body.%s", st.Format.String()), buf.Bytes()), nil
+}
+
+// mergeRow performs a three-way merge of a single body row, returning the
+// merged value & whether the row should be kept
+func mergeRow(key string, base, ours, theirs interface{}, inBase, inOurs, inTheirs bool, columns []string, res *DatasetMergeResult) (interface{}, bool) {
+	switch {
+	case inOurs && inTheirs:
+		if reflect.DeepEqual(ours, theirs) {
+			return ours, true
+		}
+		if inBase && reflect.DeepEqual(base, ours) {
+			return theirs, true
+		}
+		if inBase && reflect.DeepEqual(base, theirs) {
+			return ours, true
+		}
+		return mergeCells(key, base, ours, theirs, columns, res), true
+	case inOurs:
+		// removed by them, or added by us
+		if !inBase {
+			return ours, true
+		}
+		if !reflect.DeepEqual(base, ours) {
+			res.Conflicts = append(res.Conflicts, &MergeConflict{Component: "body", Key: key, Base: base, Ours: ours})
+		}
+		return nil, false
+	case inTheirs:
+		// removed by us, or added by them
+		if !inBase {
+			return theirs, true
+		}
+		if !reflect.DeepEqual(base, theirs) {
+			res.Conflicts = append(res.Conflicts, &MergeConflict{Component: "body", Key: key, Base: base, Theirs: theirs})
+		}
+		return nil, false
+	}
+	return nil, false
+}
+
+// mergeCells performs a cell-level three-way merge of a row changed by both sides
+func mergeCells(key string, base, ours, theirs interface{}, columns []string, res *DatasetMergeResult) interface{} {
+	conflict := func(field string, b, o, t interface{}) {
+		res.Conflicts = append(res.Conflicts, &MergeConflict{Component: "body", Key: key, Field: field, Base: b, Ours: o, Theirs: t})
+	}
+
+	oa, ook := ours.([]interface{})
+	ta, tok := theirs.([]interface{})
+	if ook && tok && len(oa) == len(ta) {
+		ba, _ := base.([]interface{})
+		merged := make([]interface{}, len(oa))
+		for i := range oa {
+			var bv interface{}
+			if i < len(ba) {
+				bv = ba[i]
+			}
+			val, ok := mergeValue(bv, oa[i], ta[i])
+			if !ok {
+				col := strconv.Itoa(i)
+				if i < len(columns) && columns[i] != "" {
+					col = columns[i]
+				}
+				conflict(col, bv, oa[i], ta[i])
+				val = oa[i]
+			}
+			merged[i] = val
+		}
+		return merged
+	}
+
+	om, ook := ours.(map[string]interface{})
+	tm, tok := theirs.(map[string]interface{})
+	if ook && tok {
+		bm, _ := base.(map[string]interface{})
+		merged := map[string]interface{}{}
+		keys := map[string]bool{}
+		for k := range om {
+			keys[k] = true
+		}
+		for k := range tm {
+			keys[k] = true
+		}
+		for k := range keys {
+			val, ok := mergeValue(bm[k], om[k], tm[k])
+			if !ok {
+				conflict(k, bm[k], om[k], tm[k])
+				val = om[k]
+			}
+			if val != nil {
+				merged[k] = val
+			}
+		}
+		return merged
+	}
+
+	conflict("", base, ours, theirs)
+	return ours
+}
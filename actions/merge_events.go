@@ -1,9 +1,18 @@
 package actions
 
 import (
+	"fmt"
+
+	"github.com/qri-io/qri/p2p"
 	"github.com/qri-io/qri/repo"
+
+	peer "gx/ipfs/QmdVrMn1LhB4ybb8hMVaMLXnA8XRSewMnK6YqXKXoTcRvN/go-libp2p-peer"
 )
 
+// eventsPageSize is the number of events to request at a time when reading
+// a full event log
+const eventsPageSize = 30
+
 // MergeResultSet contains information about how to merge a collection of EventLogs.
 type MergeResultSet struct {
 	peers []MergeResultEntry
@@ -30,81 +39,303 @@ func (e MergeResultEntry) NumUpdates() int {
 	return e.updates
 }
 
+// EventConflictType classifies conflicts between events
+type EventConflictType string
+
+const (
+	// ECConcurrentChange is two peers changing the same dataset after logs diverged
+	ECConcurrentChange = EventConflictType("concurrent_change")
+	// ECApplyFailed is an event that couldn't be applied to the local Refstore
+	ECApplyFailed = EventConflictType("apply_failed")
+)
+
+// EventConflict describes events that cannot be reconciled automatically
+type EventConflict struct {
+	Type EventConflictType `json:"type"`
+	// Local is the local event involved in the conflict, if any
+	Local *repo.Event `json:"local,omitempty"`
+	// Remote is the remote event involved in the conflict
+	Remote *repo.Event `json:"remote"`
+	// Reason is a human-readable description of the conflict
+	Reason string `json:"reason,omitempty"`
+}
+
+// EventsMerge is the result of comparing two event logs
+type EventsMerge struct {
+	// Divergence is the number of events both logs have in common
+	Divergence int
+	// LocalOnly are events only in the local log, oldest first
+	LocalOnly []*repo.Event
+	// RemoteOnly are events only in the remote log, oldest first
+	RemoteOnly []*repo.Event
+	// Apply lists remote events that can be applied to the local log
+	Apply []*repo.Event
+	// Conflicts lists pairs of events that cannot be reconciled
+	Conflicts []*EventConflict
+	// RemoteUpdates counts local events the remote could apply
+	RemoteUpdates int
+}
+
+// EventsReconciliation is the outcome of reconciling an event log with a peer
+type EventsReconciliation struct {
+	// Applied lists remote events that were applied to the local repo
+	Applied []*repo.Event `json:"applied,omitempty"`
+	// Conflicts lists events that couldn't be reconciled automatically
+	Conflicts []*EventConflict `json:"conflicts,omitempty"`
+}
+
 // MergeRepoEvents tries to merge multiple EventLogs.
 func MergeRepoEvents(one repo.Repo, two repo.Repo) (MergeResultSet, error) {
 	resultSet := MergeResultSet{}
 	resultSet.peers = make([]MergeResultEntry, 2)
-	oneLog := one.(repo.EventLog)
-	twoLog := two.(repo.EventLog)
-	// TODO: Handle any length of EventLogs.
-	oneEvents, _ := oneLog.Events(100, 0)
-	twoEvents, _ := twoLog.Events(100, 0)
-	var possibleConflictEvent *repo.Event
-	// Events are stored in reverse timestamp order.
-	i := len(oneEvents) - 1
-	j := len(twoEvents) - 1
-	for i >= 0 && j >= 0 {
-		// TODO: It may be correct to find the first point of divergence, and then
-		// check each pair-wise elements using CanResolveEvents. This loop, which works
-		// like a zip, incorrectly assumes that a conflict can occur, followed by more
-		// matching elements, but there's really no use case where that can happen.
-		oneEv := oneEvents[i]
-		twoEv := twoEvents[j]
-		if oneEv.Time == twoEv.Time {
-			i--
-			j--
-			continue
-		} else if oneEv.Time.Before(twoEv.Time) {
-			// TODO: Handle more than one conflict at a time.
-			possibleConflictEvent = oneEv
-			i--
-			continue
-		} else if twoEv.Time.Before(oneEv.Time) {
-			possibleConflictEvent = twoEv
-			j--
+
+	oneEvents, err := AllEvents(one)
+	if err != nil {
+		return resultSet, err
+	}
+	twoEvents, err := AllEvents(two)
+	if err != nil {
+		return resultSet, err
+	}
+
+	m := MergeEventLogs(oneEvents, twoEvents)
+	resultSet.peers[0].updates = len(m.Apply)
+	resultSet.peers[0].conflicts = len(m.Conflicts)
+	resultSet.peers[1].updates = m.RemoteUpdates
+	resultSet.peers[1].conflicts = len(m.Conflicts)
+	return resultSet, nil
+}
+
+// AllEvents reads the entire event log of a repo, newest first
+func AllEvents(r repo.EventLog) ([]*repo.Event, error) {
+	events := []*repo.Event{}
+	for offset := 0; ; offset += eventsPageSize {
+		page, err := r.Events(eventsPageSize, offset)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, page...)
+		if len(page) < eventsPageSize {
+			break
+		}
+	}
+	return events, nil
+}
+
+// RequestAllEvents fetches the entire event log of a peer, newest first
+func RequestAllEvents(node *p2p.QriNode, pid peer.ID) ([]*repo.Event, error) {
+	events := []*repo.Event{}
+	for offset := 0; ; offset += eventsPageSize {
+		page, err := node.RequestEventsList(node.Context(), pid, p2p.EventsParams{Limit: eventsPageSize, Offset: offset})
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, page...)
+		if len(page) < eventsPageSize {
+			break
+		}
+	}
+	return events, nil
+}
+
+// MergeEventLogs compares a local & remote event log, both sorted newest first
+// (the order EventLog.Events returns). Logs are compared from the oldest event
+// forward to find the point of divergence, events after that point are paired
+// off to find conflicts
+func MergeEventLogs(local, remote []*repo.Event) *EventsMerge {
+	local = chronological(local)
+	remote = chronological(remote)
+
+	m := &EventsMerge{}
+	for m.Divergence < len(local) && m.Divergence < len(remote) && eventsEqual(local[m.Divergence], remote[m.Divergence]) {
+		m.Divergence++
+	}
+	m.LocalOnly = local[m.Divergence:]
+	m.RemoteOnly = remote[m.Divergence:]
+
+	localConflicts := map[*repo.Event]bool{}
+	for _, rev := range m.RemoteOnly {
+		conflicted := false
+		for _, lev := range m.LocalOnly {
+			if eventsOverlap(*lev, *rev) && !CanResolveEvents(*lev, *rev) {
+				m.Conflicts = append(m.Conflicts, &EventConflict{
+					Type:   ECConcurrentChange,
+					Local:  lev,
+					Remote: rev,
+					Reason: fmt.Sprintf("local %s and remote %s both changed %s", lev.Type, rev.Type, rev.Ref.AliasString()),
+				})
+				localConflicts[lev] = true
+				conflicted = true
+			}
+		}
+		if !conflicted {
+			m.Apply = append(m.Apply, rev)
+		}
+	}
+
+	for _, lev := range m.LocalOnly {
+		if !localConflicts[lev] {
+			m.RemoteUpdates++
+		}
+	}
+	return m
+}
+
+// ReconcileEvents fetches the full event log of a peer, compares it to the
+// local event log, and applies any non-conflicting dataset creations, renames,
+// resets & deletions to the local Refstore
+func ReconcileEvents(node *p2p.QriNode, pid peer.ID) (*EventsReconciliation, error) {
+	r := node.Repo
+	local, err := AllEvents(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading local events: %s", err.Error())
+	}
+	remote, err := RequestAllEvents(node, pid)
+	if err != nil {
+		return nil, fmt.Errorf("error requesting events from %s: %s", pid.Pretty(), err.Error())
+	}
+
+	m := MergeEventLogs(local, remote)
+	res := &EventsReconciliation{Conflicts: m.Conflicts}
+	for _, e := range m.Apply {
+		applied, err := ApplyEvent(r, e)
+		if err != nil {
+			res.Conflicts = append(res.Conflicts, &EventConflict{
+				Type:   ECApplyFailed,
+				Remote: e,
+				Reason: err.Error(),
+			})
 			continue
 		}
+		if applied {
+			res.Applied = append(res.Applied, e)
+		}
 	}
-	// Handle any leftovers.
-	for i >= 0 {
-		oneEv := oneEvents[i]
-		if possibleConflictEvent == nil {
-			// TODO: Add data from oneEv into the update.
-			resultSet.peers[1].updates++
-		} else if CanResolveEvents(*possibleConflictEvent, *oneEv) {
-			resultSet.peers[0].updates++
-			resultSet.peers[1].updates++
-		} else {
-			resultSet.peers[0].conflicts++
-			resultSet.peers[1].conflicts++
-		}
-		i--
-	}
-	for j >= 0 {
-		twoEv := twoEvents[j]
-		if possibleConflictEvent == nil {
-			// TODO: Add data from twoEv into the update.
-			resultSet.peers[0].updates++
-		} else if CanResolveEvents(*possibleConflictEvent, *twoEv) {
-			resultSet.peers[0].updates++
-			resultSet.peers[1].updates++
-		} else {
-			resultSet.peers[0].conflicts++
-			resultSet.peers[1].conflicts++
-		}
-		j--
+	return res, nil
+}
+
+// ApplyEvent updates a repo's Refstore to reflect a dataset event that occurred
+// on another peer, returning true if the event was applied. Only ds_created,
+// ds_renamed, ds_reset and ds_deleted events affect the Refstore, all others
+// are skipped.
+// applied events are recorded in the repo's event log with their original
+// details if the log supports it
+func ApplyEvent(r repo.Repo, e *repo.Event) (applied bool, err error) {
+	ref := e.Ref
+	switch e.Type {
+	case repo.ETDsCreated, repo.ETDsReset:
+		if prev, err := r.GetRef(repo.DatasetRef{Peername: ref.Peername, Name: ref.Name}); err == nil {
+			if prev.Path == ref.Path {
+				return false, nil
+			}
+			if err = r.DeleteRef(prev); err != nil {
+				return false, err
+			}
+		}
+		if err = r.PutRef(ref); err != nil {
+			return false, err
+		}
+	case repo.ETDsRenamed:
+		prev := repo.DatasetRef{Path: ref.Path}
+		if from, _, ok := renameEventNames(*e); ok {
+			prev = repo.DatasetRef{Peername: ref.Peername, Name: from}
+		}
+		if prev, err = r.GetRef(prev); err != nil {
+			return false, fmt.Errorf("cannot rename %s: %s", ref.AliasString(), err.Error())
+		}
+		if err = r.DeleteRef(prev); err != nil {
+			return false, err
+		}
+		if ref.ProfileID == "" {
+			ref.ProfileID = prev.ProfileID
+		}
+		if err = r.PutRef(ref); err != nil {
+			return false, err
+		}
+	case repo.ETDsDeleted:
+		if err = r.DeleteRef(repo.DatasetRef{Peername: ref.Peername, ProfileID: ref.ProfileID, Name: ref.Name}); err != nil {
+			if err == repo.ErrNotFound {
+				return false, nil
+			}
+			return false, err
+		}
+	default:
+		return false, nil
 	}
-	return resultSet, nil
+
+	return true, r.LogEventDetails(e.Type, e.Time, e.PeerID, ref, e.Params)
 }
 
 // CanResolveEvents determines whether two Events can be resolved, or if they conflict.
 func CanResolveEvents(left repo.Event, right repo.Event) bool {
-	// TODO: Handle more cases.
-	if left.Type == repo.ETDsRenamed && right.Type == repo.ETDsRenamed {
-		return false
-	} else if left.Type == repo.ETDsRenamed || right.Type == repo.ETDsRenamed {
+	switch {
+	case left.Type == right.Type && left.Ref.Path == right.Ref.Path && left.Ref.Name == right.Ref.Name:
+		// both peers made the same change
+		return true
+	case !refstoreEvent(left.Type) || !refstoreEvent(right.Type):
+		// pins, adds & transforms only affect local state
+		return true
+	case left.Type == repo.ETDsRenamed && right.Type == repo.ETDsRenamed:
+		return left.Ref.Name == right.Ref.Name
+	case left.Type == repo.ETDsRenamed || right.Type == repo.ETDsRenamed:
 		return true
-	} else {
-		return false
 	}
+	return false
+}
+
+// refstoreEvent returns true for event types that modify the refstore
+func refstoreEvent(t repo.EventType) bool {
+	return t == repo.ETDsCreated || t == repo.ETDsRenamed || t == repo.ETDsDeleted || t == repo.ETDsReset
+}
+
+// chronological returns a copy of a newest-first event slice, oldest first
+func chronological(events []*repo.Event) []*repo.Event {
+	res := make([]*repo.Event, len(events))
+	for i, e := range events {
+		res[len(events)-1-i] = e
+	}
+	return res
+}
+
+// eventsEqual checks if two events describe the same occurrence. Times are
+// compared at second precision, which is the precision events are transferred
+// between logs at
+func eventsEqual(a, b *repo.Event) bool {
+	return a.Type == b.Type &&
+		a.Time.Unix() == b.Time.Unix() &&
+		a.Ref.Path == b.Ref.Path &&
+		a.Ref.Name == b.Ref.Name
+}
+
+// eventsOverlap returns true if two events affect the same dataset, accounting
+// for renames
+func eventsOverlap(a, b repo.Event) bool {
+	if a.Ref.Path != "" && a.Ref.Path == b.Ref.Path {
+		return true
+	}
+	for _, an := range eventDatasetNames(a) {
+		for _, bn := range eventDatasetNames(b) {
+			if an == bn {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// eventDatasetNames lists all dataset names an event refers to
+func eventDatasetNames(e repo.Event) []string {
+	names := []string{e.Ref.Name}
+	if from, to, ok := renameEventNames(e); ok {
+		names = append(names, from, to)
+	}
+	return names
+}
+
+// renameEventNames reads the previous & new name of a rename event
+func renameEventNames(e repo.Event) (from, to string, ok bool) {
+	if e.Type != repo.ETDsRenamed || e.Params == nil || e.Params.PrevName == "" {
+		return
+	}
+	return e.Params.PrevName, e.Ref.Name, true
 }
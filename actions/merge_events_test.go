@@ -1,10 +1,14 @@
 package actions
 
 import (
+	"context"
 	peer "gx/ipfs/QmdVrMn1LhB4ybb8hMVaMLXnA8XRSewMnK6YqXKXoTcRvN/go-libp2p-peer"
 	"testing"
+	"time"
 
 	"github.com/qri-io/cafs"
+	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/p2p/test"
 	"github.com/qri-io/qri/repo"
 	"github.com/qri-io/qri/repo/profile"
 )
@@ -47,16 +51,16 @@ func TestNewChangesThatCanMerge(t *testing.T) {
 	peerBID, _ := peer.IDB58Decode(peerBID)
 
 	// Events for A.
-	aLog.LogEventDetails(repo.ETDsCreated, 1000, peerAID, ref, nil)
-	aLog.LogEventDetails(repo.ETDsPinned, 1001, peerAID, ref, nil)
-	aLog.LogEventDetails(repo.ETDsCreated, 1002, peerAID, ref1, nil)
+	aLog.LogEventDetails(repo.ETDsCreated, time.Unix(1000, 0), peerAID, ref, nil)
+	aLog.LogEventDetails(repo.ETDsPinned, time.Unix(1001, 0), peerAID, ref, nil)
+	aLog.LogEventDetails(repo.ETDsCreated, time.Unix(1002, 0), peerAID, ref1, nil)
 	// Events for B (same exact events).
-	bLog.LogEventDetails(repo.ETDsCreated, 1000, peerAID, ref, nil)
-	bLog.LogEventDetails(repo.ETDsPinned, 1001, peerAID, ref, nil)
-	bLog.LogEventDetails(repo.ETDsCreated, 1002, peerAID, ref1, nil)
+	bLog.LogEventDetails(repo.ETDsCreated, time.Unix(1000, 0), peerAID, ref, nil)
+	bLog.LogEventDetails(repo.ETDsPinned, time.Unix(1001, 0), peerAID, ref, nil)
+	bLog.LogEventDetails(repo.ETDsCreated, time.Unix(1002, 0), peerAID, ref1, nil)
 	// New stuff on B, can be merged.
-	bLog.LogEventDetails(repo.ETDsCreated, 1010, peerBID, ref2, nil)
-	bLog.LogEventDetails(repo.ETDsCreated, 1011, peerBID, ref3, nil)
+	bLog.LogEventDetails(repo.ETDsCreated, time.Unix(1010, 0), peerBID, ref2, nil)
+	bLog.LogEventDetails(repo.ETDsCreated, time.Unix(1011, 0), peerBID, ref3, nil)
 
 	resultSet, err := MergeRepoEvents(aRepo, bRepo)
 	if err != nil {
@@ -89,17 +93,17 @@ func TestBothMadeChanges(t *testing.T) {
 	peerBID, _ := peer.IDB58Decode(peerBID)
 
 	// Events for A.
-	aLog.LogEventDetails(repo.ETDsCreated, 1000, peerAID, ref, nil)
-	aLog.LogEventDetails(repo.ETDsPinned, 1001, peerAID, ref, nil)
-	aLog.LogEventDetails(repo.ETDsCreated, 1002, peerAID, ref1, nil)
+	aLog.LogEventDetails(repo.ETDsCreated, time.Unix(1000, 0), peerAID, ref, nil)
+	aLog.LogEventDetails(repo.ETDsPinned, time.Unix(1001, 0), peerAID, ref, nil)
+	aLog.LogEventDetails(repo.ETDsCreated, time.Unix(1002, 0), peerAID, ref1, nil)
 	// Events for B (same exact events).
-	bLog.LogEventDetails(repo.ETDsCreated, 1000, peerAID, ref, nil)
-	bLog.LogEventDetails(repo.ETDsPinned, 1001, peerAID, ref, nil)
-	bLog.LogEventDetails(repo.ETDsCreated, 1002, peerAID, ref1, nil)
+	bLog.LogEventDetails(repo.ETDsCreated, time.Unix(1000, 0), peerAID, ref, nil)
+	bLog.LogEventDetails(repo.ETDsPinned, time.Unix(1001, 0), peerAID, ref, nil)
+	bLog.LogEventDetails(repo.ETDsCreated, time.Unix(1002, 0), peerAID, ref1, nil)
 	// New stuff on A.
-	aLog.LogEventDetails(repo.ETDsCreated, 1010, peerAID, ref2, nil)
+	aLog.LogEventDetails(repo.ETDsCreated, time.Unix(1010, 0), peerAID, ref2, nil)
 	// Also new stuff on B, this is a conflict.
-	bLog.LogEventDetails(repo.ETDsCreated, 1020, peerBID, ref3, nil)
+	bLog.LogEventDetails(repo.ETDsCreated, time.Unix(1020, 0), peerBID, ref3, nil)
 
 	resultSet, err := MergeRepoEvents(aRepo, bRepo)
 	if err != nil {
@@ -130,17 +134,17 @@ func TestDeleteAfterRename(t *testing.T) {
 	ref1 := repo.DatasetRef{Peername: "test-peer-0", Name: "test-dataset-1", Path: refPath0}
 
 	// Events for A
-	aLog.LogEventDetails(repo.ETDsCreated, 1000, peerAID, ref, nil)
-	aLog.LogEventDetails(repo.ETDsPinned, 1001, peerAID, ref, nil)
+	aLog.LogEventDetails(repo.ETDsCreated, time.Unix(1000, 0), peerAID, ref, nil)
+	aLog.LogEventDetails(repo.ETDsPinned, time.Unix(1001, 0), peerAID, ref, nil)
 	// Events for B (same exact events).
-	bLog.LogEventDetails(repo.ETDsCreated, 1000, peerAID, ref, nil)
-	bLog.LogEventDetails(repo.ETDsPinned, 1001, peerAID, ref, nil)
+	bLog.LogEventDetails(repo.ETDsCreated, time.Unix(1000, 0), peerAID, ref, nil)
+	bLog.LogEventDetails(repo.ETDsPinned, time.Unix(1001, 0), peerAID, ref, nil)
 
 	// B renames.
-	bLog.LogEventDetails(repo.ETDsRenamed, 1010, peerBID, ref1,
-		[2]string{"test-dataset-0", "test-dataset-1"})
+	bLog.LogEventDetails(repo.ETDsRenamed, time.Unix(1010, 0), peerBID, ref1,
+		&repo.EventParams{PrevName: "test-dataset-0"})
 	// A deletes (should apply to new name).
-	aLog.LogEventDetails(repo.ETDsDeleted, 1020, peerAID, ref, nil)
+	aLog.LogEventDetails(repo.ETDsDeleted, time.Unix(1020, 0), peerAID, ref, nil)
 
 	resultSet, err := MergeRepoEvents(aRepo, bRepo)
 	if err != nil {
@@ -174,17 +178,17 @@ func TestRenameAndAddContent(t *testing.T) {
 	ref2 := repo.DatasetRef{Peername: "test-peer-0", Name: "test-dataset-0", Path: refPath2}
 
 	// Events for A
-	aLog.LogEventDetails(repo.ETDsCreated, 1000, peerAID, ref, nil)
-	aLog.LogEventDetails(repo.ETDsPinned, 1001, peerAID, ref, nil)
+	aLog.LogEventDetails(repo.ETDsCreated, time.Unix(1000, 0), peerAID, ref, nil)
+	aLog.LogEventDetails(repo.ETDsPinned, time.Unix(1001, 0), peerAID, ref, nil)
 	// Events for B (same exact events).
-	bLog.LogEventDetails(repo.ETDsCreated, 1000, peerAID, ref, nil)
-	bLog.LogEventDetails(repo.ETDsPinned, 1001, peerAID, ref, nil)
+	bLog.LogEventDetails(repo.ETDsCreated, time.Unix(1000, 0), peerAID, ref, nil)
+	bLog.LogEventDetails(repo.ETDsPinned, time.Unix(1001, 0), peerAID, ref, nil)
 
 	// B renames.
-	bLog.LogEventDetails(repo.ETDsRenamed, 1010, peerBID, ref1,
-		[2]string{"test-dataset-0", "test-dataset-1"})
+	bLog.LogEventDetails(repo.ETDsRenamed, time.Unix(1010, 0), peerBID, ref1,
+		&repo.EventParams{PrevName: "test-dataset-0"})
 	// A adds content.
-	aLog.LogEventDetails(repo.ETDsCreated, 1020, peerAID, ref2, nil)
+	aLog.LogEventDetails(repo.ETDsCreated, time.Unix(1020, 0), peerAID, ref2, nil)
 
 	resultSet, err := MergeRepoEvents(aRepo, bRepo)
 	if err != nil {
@@ -203,3 +207,139 @@ func TestRenameAndAddContent(t *testing.T) {
 		t.Errorf("Expected 1 updates for Peer B")
 	}
 }
+
+func TestApplyEvent(t *testing.T) {
+	aRepo, bRepo, aLog, bLog := createReposAndLogs()
+
+	peerAID, _ := peer.IDB58Decode(peerAID)
+	peerBID, _ := peer.IDB58Decode(peerBID)
+
+	ref := repo.DatasetRef{Peername: "test-peer-0", Name: "test-dataset-0", Path: refPath0}
+	ref1 := repo.DatasetRef{Peername: "test-peer-0", Name: "test-dataset-0", Path: refPath1}
+	renamed := repo.DatasetRef{Peername: "test-peer-0", Name: "test-dataset-1", Path: refPath1}
+
+	aRepo.PutRef(ref)
+	aLog.LogEventDetails(repo.ETDsCreated, time.Unix(1000, 0), peerAID, ref, nil)
+	bLog.LogEventDetails(repo.ETDsCreated, time.Unix(1000, 0), peerAID, ref, nil)
+	bLog.LogEventDetails(repo.ETDsCreated, time.Unix(1010, 0), peerBID, ref1, nil)
+	bLog.LogEventDetails(repo.ETDsPinned, time.Unix(1011, 0), peerBID, ref1, nil)
+	bLog.LogEventDetails(repo.ETDsRenamed, time.Unix(1020, 0), peerBID, renamed, &repo.EventParams{PrevName: "test-dataset-0"})
+
+	local, err := AllEvents(aRepo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	remote, err := AllEvents(bRepo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := MergeEventLogs(local, remote)
+	if m.Divergence != 1 {
+		t.Errorf("expected divergence at 1, got: %d", m.Divergence)
+	}
+	if len(m.Conflicts) != 0 {
+		t.Fatalf("expected no conflicts, got: %d", len(m.Conflicts))
+	}
+	if len(m.Apply) != 3 {
+		t.Fatalf("expected 3 events to apply, got: %d", len(m.Apply))
+	}
+
+	applied := 0
+	for _, e := range m.Apply {
+		ok, err := ApplyEvent(aRepo, e)
+		if err != nil {
+			t.Fatalf("error applying %s event: %s", e.Type, err.Error())
+		}
+		if ok {
+			applied++
+		}
+	}
+	if applied != 2 {
+		t.Errorf("expected 2 applied events, got: %d", applied)
+	}
+
+	if _, err := aRepo.GetRef(repo.DatasetRef{Peername: "test-peer-0", Name: "test-dataset-0"}); err != repo.ErrNotFound {
+		t.Errorf("expected old name to be removed, got error: %v", err)
+	}
+	got, err := aRepo.GetRef(repo.DatasetRef{Peername: "test-peer-0", Name: "test-dataset-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Path != refPath1 {
+		t.Errorf("expected renamed ref path to be %s, got: %s", refPath1, got.Path)
+	}
+
+	events, err := AllEvents(aRepo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 3 {
+		t.Errorf("expected applied events to be logged. expected 3 events, got: %d", len(events))
+	}
+}
+
+func TestMergeEventLogsConflicts(t *testing.T) {
+	peerAID, _ := peer.IDB58Decode(peerAID)
+	peerBID, _ := peer.IDB58Decode(peerBID)
+
+	ref := repo.DatasetRef{Peername: "test-peer-0", Name: "test-dataset-0", Path: refPath0}
+	a := &repo.MemEventLog{}
+	b := &repo.MemEventLog{}
+	a.LogEventDetails(repo.ETDsCreated, time.Unix(1000, 0), peerAID, ref, nil)
+	b.LogEventDetails(repo.ETDsCreated, time.Unix(1000, 0), peerAID, ref, nil)
+	a.LogEventDetails(repo.ETDsRenamed, time.Unix(1010, 0), peerAID, repo.DatasetRef{Peername: "test-peer-0", Name: "a-name", Path: refPath0}, &repo.EventParams{PrevName: "test-dataset-0"})
+	b.LogEventDetails(repo.ETDsRenamed, time.Unix(1020, 0), peerBID, repo.DatasetRef{Peername: "test-peer-0", Name: "b-name", Path: refPath0}, &repo.EventParams{PrevName: "test-dataset-0"})
+
+	m := MergeEventLogs(*a, *b)
+	if len(m.Conflicts) != 1 {
+		t.Fatalf("expected 1 conflict, got: %d", len(m.Conflicts))
+	}
+	c := m.Conflicts[0]
+	if c.Type != ECConcurrentChange {
+		t.Errorf("conflict type mismatch. expected: %s, got: %s", ECConcurrentChange, c.Type)
+	}
+	if c.Local.Ref.Name != "a-name" || c.Remote.Ref.Name != "b-name" {
+		t.Errorf("expected conflict between a-name & b-name, got: %s & %s", c.Local.Ref.Name, c.Remote.Ref.Name)
+	}
+	if len(m.Apply) != 0 {
+		t.Errorf("expected no events to apply, got: %d", len(m.Apply))
+	}
+}
+
+func TestReconcileEvents(t *testing.T) {
+	ctx := context.Background()
+	factory := p2ptest.NewTestNodeFactory(p2p.NewTestableQriNode)
+	testPeers, err := p2ptest.NewTestNetwork(ctx, factory, 2)
+	if err != nil {
+		t.Fatalf("error creating network: %s", err.Error())
+	}
+	if err := p2ptest.ConnectQriPeers(ctx, testPeers); err != nil {
+		t.Fatalf("error connecting peers: %s", err.Error())
+	}
+	peers := make([]*p2p.QriNode, len(testPeers))
+	for i, node := range testPeers {
+		peers[i] = node.(*p2p.QriNode)
+	}
+
+	ref := addFlourinatedCompoundsDataset(t, peers[1])
+
+	res, err := ReconcileEvents(peers[0], peers[1].ID)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(res.Conflicts) != 0 {
+		t.Errorf("expected no conflicts, got: %d", len(res.Conflicts))
+	}
+	if len(res.Applied) == 0 {
+		t.Fatal("expected remote events to be applied")
+	}
+
+	got, err := peers[0].Repo.GetRef(repo.DatasetRef{Peername: ref.Peername, Name: ref.Name})
+	if err != nil {
+		t.Fatalf("expected reconciled dataset to be in repo: %s", err.Error())
+	}
+	if got.Path != ref.Path {
+		t.Errorf("reconciled path mismatch. expected: %s, got: %s", ref.Path, got.Path)
+	}
+}
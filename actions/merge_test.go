@@ -0,0 +1,153 @@
+package actions
+
+import (
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/dataset/dstest"
+	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/repo"
+)
+
+const citiesBody = `city,pop,avg_age,in_usa
+toronto,40000000,55.5,false
+new york,8500000,44.4,true
+chicago,300000,44.4,true
+chatham,35000,65.25,true
+raleigh,250000,50.65,true
+`
+
+func saveCitiesVersion(t *testing.T, node *p2p.QriNode, name string, prev repo.DatasetRef, title, body string) repo.DatasetRef {
+	tc, err := dstest.NewTestCaseFromDir(testdataPath("cities"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	ds := tc.Input
+	ds.PreviousPath = prev.Path
+	ds.Meta.Title = title
+
+	ref, err := CreateDataset(node, name, ds, cafs.NewMemfileBytes("body.csv", []byte(body)), nil, true)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	// creating a version with a PreviousPath removes any reference to the previous
+	// path, restore it so both versions are referenced
+	if err := node.Repo.PutRef(prev); err != nil {
+		t.Fatal(err.Error())
+	}
+	return ref
+}
+
+func TestMergeDatasets(t *testing.T) {
+	node := newTestNode(t)
+	base := addCitiesDataset(t, node)
+
+	theirs := saveCitiesVersion(t, node, "cities_fork", base, "example city data", `city,pop,avg_age,in_usa
+toronto,40000000,55.5,false
+new york,8500000,44.4,true
+chicago,300000,44.4,true
+chatham,35000,65.25,true
+raleigh,260000,50.65,true
+`)
+	ours := saveCitiesVersion(t, node, "cities", base, "city data", `city,pop,avg_age,in_usa
+toronto,40000000,56.5,false
+new york,8500000,44.4,true
+chicago,300000,44.4,true
+chatham,35000,65.25,true
+raleigh,250000,50.65,true
+`)
+
+	mb, err := MergeBase(node.Repo, ours, theirs)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if normalizePath(mb.Path) != normalizePath(base.Path) {
+		t.Errorf("merge base mismatch. expected: %s, got: %s", base.Path, mb.Path)
+	}
+
+	res, err := MergeDatasets(node, repo.DatasetRef{}, repo.DatasetRef{Peername: "me", Name: "cities"}, theirs)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(res.Conflicts) != 0 {
+		t.Fatalf("expected no conflicts, got: %v", res.Conflicts)
+	}
+	if res.Ref.Path == ours.Path {
+		t.Fatal("expected merge to create a new version")
+	}
+
+	ds, err := dsfs.LoadDataset(node.Repo.Store(), datastore.NewKey(res.Ref.Path))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if ds.PreviousPath != ours.Path {
+		t.Errorf("expected merge commit previous path to be ours. got: %s", ds.PreviousPath)
+	}
+	if ds.Meta.Title != "city data" {
+		t.Errorf("expected our meta title to be kept, got: %s", ds.Meta.Title)
+	}
+
+	rows, err := readBodyRows(node.Repo.Store(), ds, nil, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if toronto := rows.rows["0"].([]interface{}); toronto[2] != 56.5 {
+		t.Errorf("expected our change to toronto's avg_age, got: %v", toronto[2])
+	}
+	if raleigh := rows.rows["4"].([]interface{}); raleigh[1] != int64(260000) {
+		t.Errorf("expected their change to raleigh's pop, got: %v", raleigh[1])
+	}
+
+	parents := datasetParents(ds)
+	if len(parents) != 2 || parents[1] != theirs.Path {
+		t.Errorf("expected merge commit to record theirs as a parent, got: %v", parents)
+	}
+
+	mb, err = MergeBase(node.Repo, res.Ref, theirs)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if normalizePath(mb.Path) != normalizePath(theirs.Path) {
+		t.Errorf("expected merged version to be the merge base. expected: %s, got: %s", theirs.Path, mb.Path)
+	}
+
+	again, err := MergeDatasets(node, repo.DatasetRef{}, repo.DatasetRef{Peername: "me", Name: "cities"}, theirs)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !again.UpToDate {
+		t.Error("expected merging the same version twice to be up to date")
+	}
+	if again.Ref.Path != res.Ref.Path {
+		t.Errorf("expected an up to date merge not to create a version. expected: %s, got: %s", res.Ref.Path, again.Ref.Path)
+	}
+
+	if _, err := MergeDatasets(node, repo.DatasetRef{}, ours, theirs); err != ErrMergeNotHead {
+		t.Errorf("expected merging into a version that isn't the latest to return ErrMergeNotHead, got: %v", err)
+	}
+}
+
+func TestMergeDatasetsConflicts(t *testing.T) {
+	node := newTestNode(t)
+	base := addCitiesDataset(t, node)
+
+	theirs := saveCitiesVersion(t, node, "cities_fork", base, "their city data", citiesBody)
+	ours := saveCitiesVersion(t, node, "cities", base, "our city data", citiesBody)
+
+	res, err := MergeDatasets(node, base, ours, theirs)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(res.Conflicts) != 1 {
+		t.Fatalf("expected 1 conflict, got: %d", len(res.Conflicts))
+	}
+	c := res.Conflicts[0]
+	if c.Component != "meta" || c.Field != "title" || c.Ours != "our city data" || c.Theirs != "their city data" {
+		t.Errorf("unexpected conflict: %s", c)
+	}
+	if res.Ref.Path != ours.Path {
+		t.Error("expected conflicting merge to leave our reference unchanged")
+	}
+}
@@ -24,7 +24,10 @@ func Publish(node *p2p.QriNode, ref repo.DatasetRef) (err error) {
 		return
 	}
 
-	return cli.PutDataset(ref.Peername, ref.Name, ds.Encode(), pub)
+	if err = cli.PutDataset(ref.Peername, ref.Name, ds.Encode(), pub); err != nil {
+		return err
+	}
+	return logEvent(r, repo.ETDsPublished, ref, nil)
 }
 
 // Unpublish a dataset from a repo's specified registry
@@ -37,7 +40,10 @@ func Unpublish(node *p2p.QriNode, ref repo.DatasetRef) (err error) {
 	if err = permission(r, ref); err != nil {
 		return
 	}
-	return cli.DeleteDataset(ref.Peername, ref.Name, ds.Encode(), pub)
+	if err = cli.DeleteDataset(ref.Peername, ref.Name, ds.Encode(), pub); err != nil {
+		return err
+	}
+	return logEvent(r, repo.ETDsUnpublished, ref, nil)
 }
 
 // Status checks to see if a dataset is published to a repo's specific registry
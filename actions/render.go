@@ -3,27 +3,90 @@ package actions
 import (
 	"bytes"
 	"fmt"
-	"html/template"
+	htmltemplate "html/template"
 	"io"
 	"io/ioutil"
+	"regexp"
 	"strings"
+	"sync"
+	texttemplate "text/template"
 
 	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs"
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/dataset/dsfs"
 	"github.com/qri-io/dataset/dsio"
 	"github.com/qri-io/qri/repo"
 )
 
+// RenderFormat is the kind of output a template produces
+type RenderFormat string
+
+const (
+	// RenderHTML renders with html/template, escaping values for HTML
+	RenderHTML = RenderFormat("html")
+	// RenderMarkdown renders markdown text
+	RenderMarkdown = RenderFormat("markdown")
+	// RenderText renders plain text
+	RenderText = RenderFormat("text")
+)
+
+// RootTemplateName is the name a render's template is parsed under. Named
+// templates can't use it, they'd replace the template being rendered
+const RootTemplateName = "template"
+
+// ParseRenderFormat reads a render format from a string. An empty string is
+// RenderHTML
+func ParseRenderFormat(s string) (RenderFormat, error) {
+	switch strings.ToLower(s) {
+	case "", "html":
+		return RenderHTML, nil
+	case "md", "markdown":
+		return RenderMarkdown, nil
+	case "txt", "text":
+		return RenderText, nil
+	default:
+		return "", fmt.Errorf("invalid render format: '%s', options are html, markdown & text", s)
+	}
+}
+
+// RenderOpts configures rendering a dataset. Templates are chosen in order
+// of: Template, TemplateName, the dataset's viz, then the default template
+// for the output format
+type RenderOpts struct {
+	// Template is template text
+	Template []byte
+	// TemplateName is the name of a template stored in the repo
+	TemplateName string
+	// Format of the output, defaults to the format of a named template or
+	// dataset viz, falling back to html
+	Format RenderFormat
+	// Limit & Offset select body entries. All reads every entry after Offset
+	Limit, Offset int
+	All           bool
+	// Stream leaves .Body empty, reading entries from the body only as the
+	// template ranges over bodyEntries. Use Stream to render large bodies
+	Stream bool
+}
+
 // Render executes a template for a dataset, returning a slice of HTML
 func Render(r repo.Repo, ref repo.DatasetRef, tmplData []byte, limit, offest int, all bool) ([]byte, error) {
-	const tmplName = "template"
-	var rdr io.Reader
+	buf := &bytes.Buffer{}
+	opts := RenderOpts{Template: tmplData, Limit: limit, Offset: offest, All: all}
+	if err := RenderTo(buf, r, ref, opts); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
 
+// RenderTo executes a template for a dataset, writing output to w as the
+// template executes. Named templates stored in the repo are available to
+// every template as partials, called with {{ template "name" . }}
+func RenderTo(w io.Writer, r repo.Repo, ref repo.DatasetRef, opts RenderOpts) error {
 	err := repo.CanonicalizeDatasetRef(r, &ref)
 	if err != nil {
 		log.Debug(err.Error())
-		return nil, err
+		return err
 	}
 
 	store := r.Store()
@@ -31,102 +94,337 @@ func Render(r repo.Repo, ref repo.DatasetRef, tmplData []byte, limit, offest int
 	ds, err := dsfs.LoadDataset(store, datastore.NewKey(ref.Path))
 	if err != nil {
 		log.Debug(err.Error())
-		return nil, err
+		return err
+	}
+
+	script, format, err := renderScript(r, ds, opts)
+	if err != nil {
+		return err
+	}
+
+	partials, err := templatePartials(r, script)
+	if err != nil {
+		return err
 	}
 
-	if tmplData != nil {
-		rdr = bytes.NewBuffer(tmplData)
+	s := &entryStreamer{
+		store:  store,
+		ds:     ds,
+		limit:  opts.Limit,
+		offset: opts.Offset,
+		all:    opts.All,
+		done:   make(chan struct{}),
+	}
+	funcs := renderFuncs(format, ds.Structure)
+	funcs["bodyEntries"] = s.entries
+
+	tmpl, err := parseTemplate(RootTemplateName, format, script, partials, funcs)
+	if err != nil {
+		return err
+	}
+
+	enc := ds.Encode()
+	// TODO - repo.DatasetRef should be refactored into this newly expanded DatasetPod,
+	// once that's done these values should be populated by ds.Encode(), removing the need
+	// for these assignments
+	enc.Peername = ref.Peername
+	enc.ProfileID = ref.ProfileID.String()
+	enc.Name = ref.Name
+	if enc.Meta == nil {
+		enc.Meta = &dataset.Meta{}
+	}
+
+	if !opts.Stream {
+		if enc.Body, err = s.readBody(); err != nil {
+			return err
+		}
+	}
+
+	err = tmpl.Execute(w, enc)
+	if serr := s.close(); err == nil {
+		err = serr
+	}
+	return err
+}
+
+// renderScript picks the template for a render, returning template text &
+// the output format
+func renderScript(r repo.Repo, ds *dataset.Dataset, opts RenderOpts) (string, RenderFormat, error) {
+	format := opts.Format
+	if opts.Template != nil {
+		return string(opts.Template), defaultFormat(format, ""), nil
+	}
+
+	name := opts.TemplateName
+	if name == "" && ds.Viz != nil {
+		name, _ = repo.TemplateName(ds.Viz.ScriptPath)
+	}
+	if name != "" {
+		t, err := namedTemplate(r, name)
+		if err != nil {
+			return "", "", err
+		}
+		script, err := loadScript(r.Store(), t.Path)
+		if err != nil {
+			return "", "", fmt.Errorf("loading template %s: %s", name, err.Error())
+		}
+		return script, defaultFormat(format, t.Format), nil
 	}
 
 	// TODO - hack for now. a subpackage of dataset should handle all of the below,
 	// and use a method to set the default template if one can be loaded from the web
-	if rdr == nil && ds.Viz != nil && ds.Viz.ScriptPath != "" {
-		f, err := store.Get(datastore.NewKey(ds.Viz.ScriptPath))
+	if ds.Viz != nil && ds.Viz.ScriptPath != "" {
+		script, err := loadScript(r.Store(), ds.Viz.ScriptPath)
 		if err != nil {
-			return nil, fmt.Errorf("loading template from store: %s", err.Error())
+			return "", "", fmt.Errorf("loading template from store: %s", err.Error())
 		}
-		rdr = f
+		return script, defaultFormat(format, ds.Viz.Format), nil
 	}
 
-	if rdr == nil {
-		rdr = strings.NewReader(DefaultTemplate)
+	format = defaultFormat(format, "")
+	switch format {
+	case RenderMarkdown:
+		return DefaultMarkdownTemplate, format, nil
+	case RenderText:
+		return DefaultTextTemplate, format, nil
+	default:
+		return DefaultTemplate, format, nil
 	}
+}
 
-	tmplBytes, err := ioutil.ReadAll(rdr)
-	if err != nil {
-		return nil, fmt.Errorf("reading template data: %s", err.Error())
+// defaultFormat returns format if set, falling back to a format string, then
+// html. Unrecognized fallbacks are html
+func defaultFormat(format RenderFormat, fallback string) RenderFormat {
+	if format != "" {
+		return format
+	}
+	if f, err := ParseRenderFormat(fallback); err == nil {
+		return f
 	}
+	return RenderHTML
+}
 
-	tmpl, err := template.New(tmplName).Parse(string(tmplBytes))
-	if err != nil {
-		return nil, fmt.Errorf("parsing template: %s", err.Error())
+// namedTemplate fetches a template from a repo's template store
+func namedTemplate(r repo.Repo, name string) (*repo.Template, error) {
+	ts, ok := r.(repo.TemplateStore)
+	if !ok {
+		return nil, repo.ErrTemplatesNotSupported
+	}
+	t, err := ts.GetTemplate(name)
+	if err == repo.ErrNotFound {
+		return nil, fmt.Errorf("template '%s' not found", name)
 	}
+	return t, err
+}
 
-	file, err := dsfs.LoadBody(store, ds)
-	if err != nil {
-		log.Debug(err.Error())
-		return nil, err
+// templateCallRegex matches templates a script calls, like
+// {{ template "name" . }} or {{ block "name" . }}
+var templateCallRegex = regexp.MustCompile(`{{-?\s*(?:template|block)\s+"([^"]+)"`)
+
+// templatePartials loads the scripts of the named templates a script calls,
+// along with the templates those call in turn. Calls to templates the script
+// defines itself aren't in the store & are skipped
+func templatePartials(r repo.Repo, script string) (map[string]string, error) {
+	partials := map[string]string{}
+	ts, ok := r.(repo.TemplateStore)
+	if !ok {
+		return partials, nil
 	}
 
-	var (
-		array []interface{}
-		obj   = map[string]interface{}{}
-		read  = 0
-	)
+	calls := templateCalls(script)
+	for len(calls) > 0 {
+		name := calls[0]
+		calls = calls[1:]
+		if _, ok := partials[name]; ok || name == RootTemplateName {
+			continue
+		}
+		t, err := ts.GetTemplate(name)
+		if err == repo.ErrNotFound || err == repo.ErrTemplatesNotSupported {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if partials[name], err = loadScript(r.Store(), t.Path); err != nil {
+			return nil, fmt.Errorf("loading template %s: %s", name, err.Error())
+		}
+		calls = append(calls, templateCalls(partials[name])...)
+	}
+	return partials, nil
+}
 
-	tlt := ds.Structure.Schema.TopLevelType()
+// templateCalls lists the names of templates a script calls
+func templateCalls(script string) (names []string) {
+	for _, match := range templateCallRegex.FindAllStringSubmatch(script, -1) {
+		names = append(names, match[1])
+	}
+	return names
+}
 
-	rr, err := dsio.NewEntryReader(ds.Structure, file)
+// ValidateTemplate checks a template can be stored under a name: the name
+// can't be RootTemplateName & the script must parse for the given format
+func ValidateTemplate(name string, format RenderFormat, script string) error {
+	if name == RootTemplateName {
+		return fmt.Errorf("template name '%s' is reserved", name)
+	}
+	funcs := renderFuncs(format, nil)
+	funcs["bodyEntries"] = (&entryStreamer{}).entries
+	_, err := parseTemplate(name, format, script, nil, funcs)
+	return err
+}
+
+func loadScript(store cafs.Filestore, path string) (string, error) {
+	f, err := store.Get(datastore.NewKey(path))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	data, err := ioutil.ReadAll(f)
 	if err != nil {
-		return nil, fmt.Errorf("error allocating data reader: %s", err)
+		return "", fmt.Errorf("reading template data: %s", err.Error())
 	}
+	return string(data), nil
+}
 
-	for i := 0; i >= 0; i++ {
-		val, err := rr.ReadEntry()
+// executor is the common interface of html & text templates
+type executor interface {
+	Execute(w io.Writer, data interface{}) error
+}
+
+// parseTemplate parses a script & partials. html output uses html/template
+// to escape values, markdown & text use text/template
+func parseTemplate(name string, format RenderFormat, script string, partials map[string]string, funcs map[string]interface{}) (executor, error) {
+	if format == RenderHTML {
+		tmpl, err := htmltemplate.New(name).Funcs(htmltemplate.FuncMap(funcs)).Parse(script)
 		if err != nil {
-			if err.Error() == "EOF" {
-				break
+			return nil, fmt.Errorf("parsing template: %s", err.Error())
+		}
+		for pname, p := range partials {
+			if _, err := tmpl.New(pname).Parse(p); err != nil {
+				return nil, fmt.Errorf("parsing template %s: %s", pname, err.Error())
 			}
-			return nil, fmt.Errorf("row iteration error: %s", err.Error())
 		}
-		if !all && i < offest {
-			continue
+		return tmpl, nil
+	}
+
+	tmpl, err := texttemplate.New(name).Funcs(texttemplate.FuncMap(funcs)).Parse(script)
+	if err != nil {
+		return nil, fmt.Errorf("parsing template: %s", err.Error())
+	}
+	for pname, p := range partials {
+		if _, err := tmpl.New(pname).Parse(p); err != nil {
+			return nil, fmt.Errorf("parsing template %s: %s", pname, err.Error())
 		}
+	}
+	return tmpl, nil
+}
 
+// entryStreamer reads body entries for a render, either all at once for
+// .Body or lazily over channels for bodyEntries
+type entryStreamer struct {
+	store         cafs.Filestore
+	ds            *dataset.Dataset
+	limit, offset int
+	all           bool
+
+	done chan struct{}
+	wg   sync.WaitGroup
+	mu   sync.Mutex
+	err  error
+}
+
+// readBody reads selected entries into an array, or a map for object bodies
+func (s *entryStreamer) readBody() (interface{}, error) {
+	var (
+		array []interface{}
+		obj   = map[string]interface{}{}
+		tlt   = s.ds.Structure.Schema.TopLevelType()
+	)
+	err := s.eachEntry(func(ent dsio.Entry) bool {
 		if tlt == "object" {
-			obj[val.Key] = val.Value
+			obj[ent.Key] = ent.Value
 		} else {
-			array = append(array, val.Value)
+			array = append(array, ent.Value)
 		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if tlt == "object" {
+		return obj, nil
+	}
+	return array, nil
+}
 
-		read++
-		if read == limit {
-			break
+// entries starts reading selected entries, sending them on the returned
+// channel. Templates range over the channel, reading stops when the render
+// finishes
+func (s *entryStreamer) entries() <-chan dsio.Entry {
+	ch := make(chan dsio.Entry)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer close(ch)
+		err := s.eachEntry(func(ent dsio.Entry) bool {
+			select {
+			case ch <- ent:
+				return true
+			case <-s.done:
+				return false
+			}
+		})
+		if err != nil {
+			s.mu.Lock()
+			if s.err == nil {
+				s.err = err
+			}
+			s.mu.Unlock()
 		}
-	}
+	}()
+	return ch
+}
 
-	enc := ds.Encode()
-	// TODO - repo.DatasetRef should be refactored into this newly expanded DatasetPod,
-	// once that's done these values should be populated by ds.Encode(), removing the need
-	// for these assignments
-	enc.Peername = ref.Peername
-	enc.ProfileID = ref.ProfileID.String()
-	enc.Name = ref.Name
-	if enc.Meta == nil {
-		enc.Meta = &dataset.Meta{}
+// close stops all entry streams, returning the first error a stream hit
+func (s *entryStreamer) close() error {
+	close(s.done)
+	s.wg.Wait()
+	return s.err
+}
+
+// eachEntry calls fn for each selected entry until fn returns false
+func (s *entryStreamer) eachEntry(fn func(ent dsio.Entry) bool) error {
+	file, err := dsfs.LoadBody(s.store, s.ds)
+	if err != nil {
+		log.Debug(err.Error())
+		return err
 	}
+	defer file.Close()
 
-	if tlt == "object" {
-		enc.Body = obj
-	} else {
-		enc.Body = array
+	rr, err := dsio.NewEntryReader(s.ds.Structure, file)
+	if err != nil {
+		return fmt.Errorf("error allocating data reader: %s", err)
 	}
 
-	tmplBuf := &bytes.Buffer{}
-	if err := tmpl.Execute(tmplBuf, enc); err != nil {
-		return nil, err
+	read := 0
+	for i := 0; ; i++ {
+		ent, err := rr.ReadEntry()
+		if err != nil {
+			if err.Error() == "EOF" {
+				return nil
+			}
+			return fmt.Errorf("row iteration error: %s", err.Error())
+		}
+		if i < s.offset {
+			continue
+		}
+		if !fn(ent) {
+			return nil
+		}
+		read++
+		if !s.all && read == s.limit {
+			return nil
+		}
 	}
-	return tmplBuf.Bytes(), nil
 }
 
 // DefaultTemplate is the template that render will fall back to should no
@@ -178,3 +476,37 @@ var DefaultTemplate = `<!DOCTYPE html>
   </footer>
 </body>
 </html>`
+
+// DefaultMarkdownTemplate is the template markdown renders fall back to
+var DefaultMarkdownTemplate = `# {{ .Meta.Title }}
+
+**{{ .Peername }}/{{ .Name }}** ` + "`{{ .Path }}`" + `
+
+{{ .Meta.Description }}
+
+| | |
+| --- | --- |
+| updated | {{ .Commit.Timestamp.Format "Mon, 02 Jan 2006" }} |
+| data format | {{ .Structure.Format }} |
+| entry count | {{ formatNumber .Structure.Entries }} |
+| errors | {{ formatNumber .Structure.ErrCount }} |
+| commit title | {{ .Commit.Title }} |
+{{ if .Meta.License }}
+License: [{{ .Meta.License.Type }}]({{ .Meta.License.URL }})
+{{ end }}
+Created with [qri](https://qri.io)
+`
+
+// DefaultTextTemplate is the template text renders fall back to
+var DefaultTextTemplate = `{{ .Peername }}/{{ .Name }}
+{{ .Meta.Title }}
+{{ .Path }}
+{{ if .Meta.Description }}
+{{ .Meta.Description }}
+{{ end }}
+updated:      {{ .Commit.Timestamp.Format "Mon, 02 Jan 2006" }}
+data format:  {{ .Structure.Format }}
+entry count:  {{ formatNumber .Structure.Entries }}
+errors:       {{ formatNumber .Structure.ErrCount }}
+commit title: {{ .Commit.Title }}
+`
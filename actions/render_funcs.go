@@ -0,0 +1,434 @@
+package actions
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+)
+
+// renderFuncs are the helper functions available to render templates:
+//
+//	formatNumber n [decimals]   n with thousands separators
+//	column body name            values of a column, by schema title, key or index
+//	table body                  body entries as a table, headed with schema titles
+//	barChart values [w h]       bar chart of numbers
+//	lineChart values [w h]      line chart of numbers
+//
+// tables & charts are HTML/SVG for html & markdown renders. Text renders get
+// aligned text tables & sparkline charts
+func renderFuncs(format RenderFormat, st *dataset.Structure) map[string]interface{} {
+	columns := schemaColumns(st)
+
+	html := func(s string) interface{} {
+		if format == RenderHTML {
+			return htmltemplate.HTML(s)
+		}
+		return s
+	}
+
+	return map[string]interface{}{
+		"formatNumber": formatNumber,
+		"column": func(body interface{}, col interface{}) ([]interface{}, error) {
+			return bodyColumn(body, columns, col)
+		},
+		"table": func(body interface{}) interface{} {
+			header, rows := tableRows(body, columns)
+			switch format {
+			case RenderMarkdown:
+				return markdownTable(header, rows)
+			case RenderText:
+				return textTable(header, rows)
+			default:
+				return html(htmlTable(header, rows))
+			}
+		},
+		"barChart": func(values interface{}, size ...int) interface{} {
+			if format == RenderText {
+				return sparkline(toFloats(values))
+			}
+			w, h := chartSize(size)
+			return html(svgBarChart(toFloats(values), w, h))
+		},
+		"lineChart": func(values interface{}, size ...int) interface{} {
+			if format == RenderText {
+				return sparkline(toFloats(values))
+			}
+			w, h := chartSize(size)
+			return html(svgLineChart(toFloats(values), w, h))
+		},
+	}
+}
+
+// formatNumber writes a number with thousands separators. Whole numbers have
+// no decimal places unless decimals is given, other numbers default to two
+func formatNumber(v interface{}, decimals ...int) string {
+	f, ok := toFloat(v)
+	if !ok {
+		return fmt.Sprint(v)
+	}
+	d := 2
+	if f == math.Trunc(f) {
+		d = 0
+	}
+	if len(decimals) > 0 {
+		d = decimals[0]
+	}
+
+	s := strconv.FormatFloat(math.Abs(f), 'f', d, 64)
+	whole, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		whole, frac = s[:i], s[i:]
+	}
+
+	buf := &bytes.Buffer{}
+	if f < 0 {
+		buf.WriteByte('-')
+	}
+	for i, c := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteRune(c)
+	}
+	buf.WriteString(frac)
+	return buf.String()
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// toFloats converts a list of values to numbers, skipping values that
+// aren't numeric
+func toFloats(v interface{}) []float64 {
+	var values []interface{}
+	switch vs := v.(type) {
+	case []interface{}:
+		values = vs
+	case []float64:
+		return vs
+	case []int:
+		fs := make([]float64, len(vs))
+		for i, n := range vs {
+			fs[i] = float64(n)
+		}
+		return fs
+	}
+
+	fs := make([]float64, 0, len(values))
+	for _, val := range values {
+		if f, ok := toFloat(val); ok {
+			fs = append(fs, f)
+		}
+	}
+	return fs
+}
+
+// bodyColumn lists the values of a column from a body or a list of entries.
+// Columns are selected by schema title or index for array rows, and by key
+// for object rows
+func bodyColumn(body interface{}, columns []string, col interface{}) ([]interface{}, error) {
+	index := -1
+	key := fmt.Sprint(col)
+	if i, ok := col.(int); ok {
+		index = i
+	} else {
+		for i, c := range columns {
+			if c == key {
+				index = i
+				break
+			}
+		}
+	}
+
+	values := []interface{}{}
+	for _, row := range renderRows(body) {
+		switch r := row.(type) {
+		case []interface{}:
+			if index < 0 {
+				return nil, fmt.Errorf("column '%s' isn't in the schema", key)
+			}
+			if index < len(r) {
+				values = append(values, r[index])
+			} else {
+				values = append(values, nil)
+			}
+		case map[string]interface{}:
+			values = append(values, r[key])
+		default:
+			values = append(values, r)
+		}
+	}
+	return values, nil
+}
+
+// renderRows lists the values of a body or a stream of body entries. Object
+// bodies are ordered by key
+func renderRows(body interface{}) []interface{} {
+	switch b := body.(type) {
+	case []interface{}:
+		rows := make([]interface{}, len(b))
+		for i, v := range b {
+			if ent, ok := v.(dsio.Entry); ok {
+				v = ent.Value
+			}
+			rows[i] = v
+		}
+		return rows
+	case map[string]interface{}:
+		keys := sortedKeys(b)
+		rows := make([]interface{}, len(keys))
+		for i, k := range keys {
+			rows[i] = b[k]
+		}
+		return rows
+	case <-chan dsio.Entry:
+		// streamed entries are read in full
+		rows := []interface{}{}
+		for ent := range b {
+			rows = append(rows, ent.Value)
+		}
+		return rows
+	}
+	return nil
+}
+
+// tableRows lays out a body as a table. Array rows are headed with schema
+// column titles, object rows with the set of keys in all rows
+func tableRows(body interface{}, columns []string) (header []string, rows [][]string) {
+	values := renderRows(body)
+
+	objectRows := false
+	keys := map[string]bool{}
+	width := len(columns)
+	for _, v := range values {
+		switch r := v.(type) {
+		case map[string]interface{}:
+			objectRows = true
+			for k := range r {
+				keys[k] = true
+			}
+		case []interface{}:
+			if len(r) > width {
+				width = len(r)
+			}
+		}
+	}
+
+	if objectRows {
+		for k := range keys {
+			header = append(header, k)
+		}
+		sort.Strings(header)
+	} else {
+		header = make([]string, width)
+		for i := range header {
+			if i < len(columns) && columns[i] != "" {
+				header[i] = columns[i]
+			} else {
+				header[i] = strconv.Itoa(i + 1)
+			}
+		}
+		if width == 0 {
+			header = []string{"value"}
+		}
+	}
+
+	for _, v := range values {
+		row := make([]string, len(header))
+		switch r := v.(type) {
+		case map[string]interface{}:
+			for i, k := range header {
+				row[i] = cellString(r[k])
+			}
+		case []interface{}:
+			for i := range row {
+				if i < len(r) {
+					row[i] = cellString(r[i])
+				}
+			}
+		default:
+			if len(row) > 0 {
+				row[0] = cellString(r)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return header, rows
+}
+
+func cellString(v interface{}) string {
+	switch c := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return c
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(c)
+		if err != nil {
+			return fmt.Sprint(c)
+		}
+		return string(data)
+	}
+	return fmt.Sprint(v)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func htmlTable(header []string, rows [][]string) string {
+	buf := &bytes.Buffer{}
+	buf.WriteString("<table>\n<thead><tr>")
+	for _, h := range header {
+		fmt.Fprintf(buf, "<th>%s</th>", htmltemplate.HTMLEscapeString(h))
+	}
+	buf.WriteString("</tr></thead>\n<tbody>\n")
+	for _, row := range rows {
+		buf.WriteString("<tr>")
+		for _, cell := range row {
+			fmt.Fprintf(buf, "<td>%s</td>", htmltemplate.HTMLEscapeString(cell))
+		}
+		buf.WriteString("</tr>\n")
+	}
+	buf.WriteString("</tbody>\n</table>")
+	return buf.String()
+}
+
+func markdownTable(header []string, rows [][]string) string {
+	escape := strings.NewReplacer("|", "\\|", "\n", " ")
+	buf := &bytes.Buffer{}
+	writeRow := func(cells []string) {
+		buf.WriteString("|")
+		for _, c := range cells {
+			fmt.Fprintf(buf, " %s |", escape.Replace(c))
+		}
+		buf.WriteString("\n")
+	}
+	writeRow(header)
+	buf.WriteString("|")
+	for range header {
+		buf.WriteString(" --- |")
+	}
+	buf.WriteString("\n")
+	for _, row := range rows {
+		writeRow(row)
+	}
+	return buf.String()
+}
+
+func textTable(header []string, rows [][]string) string {
+	buf := &bytes.Buffer{}
+	tw := tabwriter.NewWriter(buf, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	tw.Flush()
+	return buf.String()
+}
+
+func chartSize(size []int) (w, h int) {
+	w, h = 400, 150
+	if len(size) > 0 && size[0] > 0 {
+		w = size[0]
+	}
+	if len(size) > 1 && size[1] > 0 {
+		h = size[1]
+	}
+	return
+}
+
+// chartRange gives the bounds of chart values, always including zero
+func chartRange(values []float64) (min, max float64) {
+	for _, v := range values {
+		min = math.Min(min, v)
+		max = math.Max(max, v)
+	}
+	if max == min {
+		max = min + 1
+	}
+	return
+}
+
+func svgBarChart(values []float64, w, h int) string {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, `<svg xmlns="http://www.w3.org/2000/svg" class="chart bar-chart" width="%d" height="%d" viewBox="0 0 %d %d">`, w, h, w, h)
+	if len(values) > 0 {
+		min, max := chartRange(values)
+		scale := float64(h) / (max - min)
+		zero := float64(h) - (0-min)*scale
+		bw := float64(w) / float64(len(values))
+		for i, v := range values {
+			y, bh := zero-v*scale, v*scale
+			if v < 0 {
+				y, bh = zero, -v*scale
+			}
+			fmt.Fprintf(buf, `<rect x="%.2f" y="%.2f" width="%.2f" height="%.2f"><title>%s</title></rect>`, float64(i)*bw+bw*0.1, y, bw*0.8, bh, formatNumber(v))
+		}
+	}
+	buf.WriteString("</svg>")
+	return buf.String()
+}
+
+func svgLineChart(values []float64, w, h int) string {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, `<svg xmlns="http://www.w3.org/2000/svg" class="chart line-chart" width="%d" height="%d" viewBox="0 0 %d %d">`, w, h, w, h)
+	if len(values) > 0 {
+		min, max := chartRange(values)
+		scale := float64(h) / (max - min)
+		step := 0.0
+		if len(values) > 1 {
+			step = float64(w) / float64(len(values)-1)
+		}
+		points := make([]string, len(values))
+		for i, v := range values {
+			points[i] = fmt.Sprintf("%.2f,%.2f", float64(i)*step, float64(h)-(v-min)*scale)
+		}
+		fmt.Fprintf(buf, `<polyline fill="none" stroke="currentColor" points="%s"/>`, strings.Join(points, " "))
+	}
+	buf.WriteString("</svg>")
+	return buf.String()
+}
+
+// sparkline draws values with unicode block characters
+func sparkline(values []float64) string {
+	const ticks = "▁▂▃▄▅▆▇█"
+	blocks := []rune(ticks)
+	min, max := chartRange(values)
+	out := make([]rune, len(values))
+	for i, v := range values {
+		out[i] = blocks[int((v-min)/(max-min)*float64(len(blocks)-1))]
+	}
+	return string(out)
+}
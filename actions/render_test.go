@@ -1,7 +1,13 @@
 package actions
 
 import (
+	"bytes"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/qri/repo"
 )
 
 func TestRender(t *testing.T) {
@@ -14,3 +20,134 @@ func TestRender(t *testing.T) {
 	}
 
 }
+
+func TestRenderTo(t *testing.T) {
+	node := newTestNode(t)
+	ref := addCitiesDataset(t, node)
+	cities := `{{ range .Body }}{{ index . 0 }};{{ end }}`
+	streamed := `{{ range bodyEntries }}{{ index .Value 0 }};{{ end }}`
+
+	cases := []struct {
+		opts   RenderOpts
+		expect string
+	}{
+		{RenderOpts{Template: []byte(cities), Limit: 2, Offset: 1}, "new york;chicago;"},
+		{RenderOpts{Template: []byte(cities), All: true, Offset: 3}, "chatham;raleigh;"},
+		{RenderOpts{Template: []byte(streamed), Limit: 2, Offset: 1, Stream: true}, "new york;chicago;"},
+		{RenderOpts{Template: []byte(`{{ formatNumber 1234567.891 }}`), Format: RenderText}, "1,234,567.89"},
+		{RenderOpts{Template: []byte(`{{ column .Body "pop" }}`), Limit: 2, Format: RenderText}, "[40000000 8500000]"},
+		{RenderOpts{Template: []byte(`{{ barChart (column .Body "pop") }}`), Limit: 2, Format: RenderText}, "█▂"},
+		{RenderOpts{Template: []byte(`{{ table .Body }}`), Limit: 1, Format: RenderMarkdown}, "| city | pop | avg_age | in_usa |\n| --- | --- | --- | --- |\n| toronto | 40000000 | 55.5 | false |\n"},
+		{RenderOpts{Template: []byte(`{{ .Meta.Title }}`), Format: RenderHTML}, "example city data"},
+	}
+
+	for i, c := range cases {
+		buf := &bytes.Buffer{}
+		if err := RenderTo(buf, node.Repo, ref, c.opts); err != nil {
+			t.Errorf("case %d error: %s", i, err.Error())
+			continue
+		}
+		if buf.String() != c.expect {
+			t.Errorf("case %d result mismatch. expected: %q, got: %q", i, c.expect, buf.String())
+		}
+	}
+
+	for _, format := range []RenderFormat{RenderMarkdown, RenderText} {
+		buf := &bytes.Buffer{}
+		if err := RenderTo(buf, node.Repo, ref, RenderOpts{Format: format}); err != nil {
+			t.Errorf("%s default template error: %s", format, err.Error())
+			continue
+		}
+		if !strings.Contains(buf.String(), "example city data") {
+			t.Errorf("expected %s default template to include the dataset title, got: %s", format, buf.String())
+		}
+	}
+}
+
+func TestRenderNamedTemplate(t *testing.T) {
+	node := newTestNode(t)
+	ref := addCitiesDataset(t, node)
+
+	ts, ok := node.Repo.(repo.TemplateStore)
+	if !ok {
+		t.Fatal("expected test repo to be a template store")
+	}
+	putTemplate := func(name, format, script string) {
+		key, err := node.Repo.Store().Put(cafs.NewMemfileBytes(name, []byte(script)), true)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if err := ts.PutTemplate(&repo.Template{Name: name, Format: format, Path: key.String(), Updated: time.Now()}); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+	putTemplate("title", "text", `# {{ .Meta.Title }}`)
+	putTemplate("summary", "markdown", `{{ template "title" . }} <{{ .Name }}>`)
+
+	buf := &bytes.Buffer{}
+	if err := RenderTo(buf, node.Repo, ref, RenderOpts{TemplateName: "summary"}); err != nil {
+		t.Fatal(err.Error())
+	}
+	// markdown output isn't html-escaped
+	if expect := "# example city data <cities>"; buf.String() != expect {
+		t.Errorf("result mismatch. expected: %q, got: %q", expect, buf.String())
+	}
+
+	if err := RenderTo(&bytes.Buffer{}, node.Repo, ref, RenderOpts{TemplateName: "missing"}); err == nil {
+		t.Error("expected rendering a missing template to error")
+	}
+
+	// templates that aren't called are never parsed, a broken one only fails
+	// renders that use it
+	putTemplate("broken", "text", `{{ .Meta.Title `)
+	buf.Reset()
+	if err := RenderTo(buf, node.Repo, ref, RenderOpts{TemplateName: "summary"}); err != nil {
+		t.Errorf("expected rendering a template that doesn't call a broken template to succeed, got: %s", err.Error())
+	}
+	if err := RenderTo(&bytes.Buffer{}, node.Repo, ref, RenderOpts{Template: []byte(`{{ template "broken" . }}`), Format: RenderText}); err == nil {
+		t.Error("expected rendering a template that calls a broken template to error")
+	}
+}
+
+func TestValidateTemplate(t *testing.T) {
+	cases := []struct {
+		name, script string
+		format       RenderFormat
+		err          bool
+	}{
+		{"title", `# {{ .Meta.Title }}`, RenderMarkdown, false},
+		{"rows", `{{ range bodyEntries }}{{ .Value }}{{ end }}`, RenderHTML, false},
+		{"calls", `{{ template "other" . }}`, RenderText, false},
+		{"unclosed", `{{ .Meta.Title `, RenderText, true},
+		{"unknown_func", `{{ nope .Body }}`, RenderHTML, true},
+		{RootTemplateName, `# {{ .Meta.Title }}`, RenderMarkdown, true},
+	}
+	for i, c := range cases {
+		err := ValidateTemplate(c.name, c.format, c.script)
+		if c.err != (err != nil) {
+			t.Errorf("case %d %s error mismatch. expected error: %t, got: %v", i, c.name, c.err, err)
+		}
+	}
+}
+
+func TestFormatNumber(t *testing.T) {
+	cases := []struct {
+		in       interface{}
+		decimals []int
+		expect   string
+	}{
+		{0, nil, "0"},
+		{999, nil, "999"},
+		{1000, nil, "1,000"},
+		{-1234567, nil, "-1,234,567"},
+		{1234.5, nil, "1,234.50"},
+		{1234.5, []int{0}, "1,235"},
+		{"4500", nil, "4,500"},
+		{"not a number", nil, "not a number"},
+	}
+	for i, c := range cases {
+		if got := formatNumber(c.in, c.decimals...); got != c.expect {
+			t.Errorf("case %d mismatch. expected: %s, got: %s", i, c.expect, got)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/dataset/validate"
+	"github.com/qri-io/jsonschema"
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/sql"
+)
+
+// QueryLimits caps how much of a dataset body a query reads into memory. Zero
+// values are unlimited
+type QueryLimits struct {
+	// MaxEntries is the max number of entries read from a single body
+	MaxEntries int
+	// MaxBytes is the max size of a body that can be read
+	MaxBytes int64
+}
+
+// QueryLimitsFromConfig reads limits from sql configuration. A nil
+// configuration has no limits
+func QueryLimitsFromConfig(cfg *config.SQL) QueryLimits {
+	if cfg == nil {
+		return QueryLimits{}
+	}
+	return QueryLimits{
+		MaxEntries: cfg.MaxEntries,
+		MaxBytes:   cfg.MaxBytes,
+	}
+}
+
+// Query executes a SQL query against dataset bodies. Table names in the query
+// are dataset references, like me/dataset_name or peer/dataset_name. Bodies are
+// read into memory in full, limits reject bodies that are too large to query
+func Query(node *p2p.QriNode, query string, limits QueryLimits) (*sql.Table, error) {
+	return sql.Query(query, DatasetSource(node, limits))
+}
+
+// DatasetSource creates a sql.Source that reads tables from dataset bodies,
+// resolving table names as dataset references
+func DatasetSource(node *p2p.QriNode, limits QueryLimits) sql.Source {
+	return sql.SourceFunc(func(name string) (*sql.Table, error) {
+		ref, err := repo.ParseDatasetRef(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid dataset reference '%s': %s", name, err.Error())
+		}
+		return datasetTable(node, &ref, limits)
+	})
+}
+
+// datasetTable reads the body of a dataset into a table, using the dataset's
+// schema for column names & types
+func datasetTable(node *p2p.QriNode, ref *repo.DatasetRef, limits QueryLimits) (*sql.Table, error) {
+	file, rr, err := bodyEntryReader(node, ref)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	st := rr.Structure()
+	if limits.MaxBytes > 0 && int64(st.Length) > limits.MaxBytes {
+		return nil, fmt.Errorf("body of %s is %d bytes, more than the query limit of %d bytes", ref, st.Length, limits.MaxBytes)
+	}
+	cols := schemaTableColumns(st)
+	isObject := st.Schema != nil && st.Schema.TopLevelType() == "object"
+	if isObject {
+		// entries of object-rooted bodies are identified by key
+		cols = append([]sql.Column{{Name: "key", Type: "string"}}, cols...)
+	}
+
+	t := &sql.Table{Name: ref.AliasString()}
+	var entries []dsio.Entry
+	for {
+		ent, err := rr.ReadEntry()
+		if err != nil {
+			if err.Error() == "EOF" {
+				break
+			}
+			return nil, fmt.Errorf("error reading body of %s: %s", ref, err.Error())
+		}
+		if limits.MaxEntries > 0 && len(entries) == limits.MaxEntries {
+			return nil, fmt.Errorf("body of %s has more than the query limit of %d entries", ref, limits.MaxEntries)
+		}
+		entries = append(entries, ent)
+	}
+
+	// bodies of objects without declared properties get columns from the set
+	// of keys in all entries
+	if len(cols) == 0 || (isObject && len(cols) == 1) {
+		cols = append(cols, entryKeyColumns(entries)...)
+	}
+	t.Columns = cols
+
+	t.Rows = make([][]interface{}, len(entries))
+	for i, ent := range entries {
+		row := make([]interface{}, len(cols))
+		offset := 0
+		if isObject {
+			row[0] = ent.Key
+			offset = 1
+		}
+
+		switch v := ent.Value.(type) {
+		case []interface{}:
+			copy(row[offset:], v)
+		case map[string]interface{}:
+			for j := offset; j < len(cols); j++ {
+				row[j] = v[cols[j].Name]
+			}
+		default:
+			if len(row) > offset {
+				row[offset] = v
+			}
+		}
+		t.Rows[i] = row
+	}
+
+	return t, nil
+}
+
+// schemaTableColumns gives the column definitions of a schema, either the
+// titled items of a tabular schema, or the properties of an array of objects
+func schemaTableColumns(st *dataset.Structure) []sql.Column {
+	items, ok := schemaDefinition(st)["items"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	if list, ok := items["items"].([]interface{}); ok {
+		cols := make([]sql.Column, len(list))
+		for i, c := range list {
+			cols[i] = sql.Column{Name: fmt.Sprintf("col_%d", i)}
+			if def, ok := c.(map[string]interface{}); ok {
+				if title, ok := def["title"].(string); ok && title != "" {
+					cols[i].Name = title
+				}
+				cols[i].Type, _ = def["type"].(string)
+			}
+		}
+		return cols
+	}
+
+	if props, ok := items["properties"].(map[string]interface{}); ok {
+		names := make([]string, 0, len(props))
+		for name := range props {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		cols := make([]sql.Column, len(names))
+		for i, name := range names {
+			cols[i] = sql.Column{Name: name}
+			if def, ok := props[name].(map[string]interface{}); ok {
+				cols[i].Type, _ = def["type"].(string)
+			}
+		}
+		return cols
+	}
+
+	return nil
+}
+
+// entryKeyColumns lists the keys of object entries in order of appearance.
+// entries that aren't objects are read as a single "value" column
+func entryKeyColumns(entries []dsio.Entry) []sql.Column {
+	var (
+		cols []sql.Column
+		seen = map[string]bool{}
+	)
+	for _, ent := range entries {
+		obj, ok := ent.Value.(map[string]interface{})
+		if !ok {
+			return []sql.Column{{Name: "value"}}
+		}
+		keys := make([]string, 0, len(obj))
+		for key := range obj {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if !seen[key] {
+				seen[key] = true
+				cols = append(cols, sql.Column{Name: key})
+			}
+		}
+	}
+	return cols
+}
+
+// QueryResultStructure creates a tabular structure describing the result of a
+// query, written in the given format
+func QueryResultStructure(t *sql.Table, format dataset.DataFormat, fcfg dataset.FormatConfig) (*dataset.Structure, error) {
+	items := make([]map[string]interface{}, len(t.Columns))
+	for i, c := range t.Columns {
+		items[i] = map[string]interface{}{"title": c.Name}
+		if c.Type != "" {
+			items[i]["type"] = c.Type
+		}
+	}
+
+	data, err := json.Marshal(map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type":  "array",
+			"items": items,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sch := &jsonschema.RootSchema{}
+	if err := sch.UnmarshalJSON(data); err != nil {
+		return nil, fmt.Errorf("error creating result schema: %s", err.Error())
+	}
+
+	if format == dataset.CSVDataFormat && fcfg == nil {
+		fcfg = &dataset.CSVOptions{HeaderRow: true}
+	}
+
+	return &dataset.Structure{
+		Format:       format,
+		FormatConfig: fcfg,
+		Schema:       sch,
+	}, nil
+}
+
+// QueryResultBody writes the rows of a query result according to a structure
+func QueryResultBody(t *sql.Table, st *dataset.Structure) ([]byte, error) {
+	buf, err := dsio.NewEntryBuffer(st)
+	if err != nil {
+		return nil, fmt.Errorf("error allocating result buffer: %s", err.Error())
+	}
+	for i, row := range t.Rows {
+		if err := buf.WriteEntry(dsio.Entry{Index: i, Value: row}); err != nil {
+			return nil, fmt.Errorf("error writing result row %d: %s", i, err.Error())
+		}
+	}
+	if err := buf.Close(); err != nil {
+		return nil, fmt.Errorf("error closing row buffer: %s", err.Error())
+	}
+	return buf.Bytes(), nil
+}
+
+// SaveQueryResult writes the result of a query as a new dataset, recording the
+// query in the dataset's commit message
+func SaveQueryResult(node *p2p.QriNode, name, query string, t *sql.Table) (ref repo.DatasetRef, err error) {
+	if err = validate.ValidName(name); err != nil {
+		err = fmt.Errorf("invalid name: %s", err.Error())
+		return
+	}
+
+	st, err := QueryResultStructure(t, dataset.JSONDataFormat, nil)
+	if err != nil {
+		return
+	}
+	data, err := QueryResultBody(t, st)
+	if err != nil {
+		return
+	}
+
+	ds := &dataset.Dataset{
+		Commit: &dataset.Commit{
+			Title:   "created from sql query",
+			Message: query,
+		},
+		Structure: st,
+	}
+	return CreateDataset(node, name, ds, cafs.NewMemfileBytes("body.json", data), nil, true)
+}
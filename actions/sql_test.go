@@ -0,0 +1,85 @@
+package actions
+
+import (
+	"testing"
+
+	"github.com/qri-io/dataset"
+)
+
+func TestQuery(t *testing.T) {
+	node := newTestNode(t)
+	cities := addCitiesDataset(t, node)
+
+	q := "SELECT city, pop FROM " + cities.AliasString() + " WHERE in_usa AND pop > 100000 ORDER BY pop DESC"
+	res, err := Query(node, q, QueryLimits{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(res.Columns) != 2 || res.Columns[0].Name != "city" || res.Columns[1].Type != "integer" {
+		t.Errorf("unexpected columns: %v", res.Columns)
+	}
+	if len(res.Rows) != 3 {
+		t.Fatalf("expected 3 rows, got: %d", len(res.Rows))
+	}
+	if res.Rows[0][0] != "new york" {
+		t.Errorf("expected first row to be new york, got: %v", res.Rows[0])
+	}
+
+	st, err := QueryResultStructure(res, dataset.CSVDataFormat, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	data, err := QueryResultBody(res, st)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	expect := "city,pop\nnew york,8500000\nchicago,300000\nraleigh,250000\n"
+	if string(data) != expect {
+		t.Errorf("csv result mismatch. expected:\n%s\ngot:\n%s", expect, string(data))
+	}
+
+	if _, err := Query(node, "SELECT * FROM peer/not_a_dataset", QueryLimits{}); err == nil {
+		t.Error("expected querying a missing dataset to error")
+	}
+
+	if _, err := SaveQueryResult(node, "invalid name", q, res); err == nil {
+		t.Error("expected saving with an invalid name to error")
+	}
+
+	ref, err := SaveQueryResult(node, "big_us_cities", q, res)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if ref.Dataset.Commit.Message != q {
+		t.Errorf("expected commit message to be the query, got: %s", ref.Dataset.Commit.Message)
+	}
+
+	saved, err := Query(node, "SELECT count(*) FROM "+ref.AliasString(), QueryLimits{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if saved.Rows[0][0] != int64(3) {
+		t.Errorf("expected saved dataset to have 3 rows, got: %v", saved.Rows[0][0])
+	}
+}
+
+func TestQueryLimits(t *testing.T) {
+	node := newTestNode(t)
+	cities := addCitiesDataset(t, node)
+	q := "SELECT city FROM " + cities.AliasString() + " LIMIT 1"
+
+	cases := []struct {
+		limits QueryLimits
+		err    bool
+	}{
+		{QueryLimits{}, false},
+		{QueryLimits{MaxEntries: 5, MaxBytes: 1024}, false},
+		{QueryLimits{MaxEntries: 4}, true},
+		{QueryLimits{MaxBytes: 10}, true},
+	}
+	for i, c := range cases {
+		if _, err := Query(node, q, c.limits); (err != nil) != c.err {
+			t.Errorf("case %d error mismatch. expected error: %t, got: %v", i, c.err, err)
+		}
+	}
+}
@@ -1,77 +1,399 @@
 package actions
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/qri-io/cafs"
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/qri/config"
 	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/progress"
 	"github.com/qri-io/qri/repo"
 	"github.com/qri-io/skytf"
 )
 
-// ExecTransform executes a designated transformation
-func ExecTransform(node *p2p.QriNode, ds *dataset.Dataset, infile cafs.File, secrets map[string]string) (file cafs.File, err error) {
-	filepath := ds.Transform.ScriptPath
-	rr, err := skytf.ExecFile(ds, filepath, infile, func(o *skytf.ExecOpts) {
-		if secrets != nil {
-			// convert to map[string]interface{}, which the lower-level skytf supports
-			// until we're sure map[string]string is going to work in the majority of use cases
-			s := map[string]interface{}{}
-			for key, val := range secrets {
-				s[key] = val
-			}
-			o.Secrets = s
+// TransformLimits caps the resources a transform can use. Zero values are
+// unlimited
+type TransformLimits struct {
+	// Timeout is the max wall-clock time a transform can run for
+	Timeout time.Duration
+	// MaxEntries is the max number of body entries a transform can output
+	MaxEntries int
+	// MaxBytes is the max size of a transform's body output, encoded as json
+	MaxBytes int64
+}
+
+// TransformLimitsFromConfig reads limits from transform configuration. A nil
+// configuration has no limits
+func TransformLimitsFromConfig(cfg *config.Transform) (TransformLimits, error) {
+	if cfg == nil {
+		return TransformLimits{}, nil
+	}
+	timeout, err := cfg.TimeoutDuration()
+	if err != nil {
+		return TransformLimits{}, err
+	}
+	return TransformLimits{
+		Timeout:    timeout,
+		MaxEntries: cfg.MaxEntries,
+		MaxBytes:   cfg.MaxBytes,
+	}, nil
+}
+
+// ErrTransformRunning is returned when running a transform while a transform
+// that exceeded it's time limit is still running. Skylark can't interrupt a
+// running script, so the node refuses transforms until it finishes rather than
+// piling up scripts that may never end. QriNode.ClearOverdueTransform lifts
+// the refusal early
+var ErrTransformRunning = fmt.Errorf("a transform that exceeded it's time limit is still running, try again once it finishes")
+
+// TransformOpts configures executing a transform
+type TransformOpts struct {
+	// Secrets are passed to the transform script
+	Secrets map[string]string
+	Limits  TransformLimits
+	// DryRun executes the transform without writing the script to the store
+	// or logging an event
+	DryRun bool
+}
+
+// TransformError is an error executing a transform script, positioned at the
+// script line that caused it where the position is known
+type TransformError struct {
+	Script    string
+	Line, Col int
+	Message   string
+}
+
+// Error implements the error interface
+func (e *TransformError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("transform error: %s line %d, column %d: %s", filepath.Base(e.Script), e.Line, e.Col, e.Message)
+	}
+	return fmt.Sprintf("transform error: %s", e.Message)
+}
+
+// scriptPosRegex matches "file:line:col" positions in skylark errors &
+// backtraces
+var scriptPosRegex = regexp.MustCompile(`(\S+?):(\d+):(\d+)`)
+
+// newTransformError positions an error raised by a transform script. Syntax
+// errors carry their position in the message, runtime errors in a backtrace,
+// where the last position in the script is where the error was raised
+func newTransformError(script string, err error) *TransformError {
+	if te, ok := err.(*TransformError); ok {
+		return te
+	}
+	te := &TransformError{Script: script, Message: err.Error()}
+
+	text := err.Error()
+	if bt, ok := err.(interface {
+		Backtrace() string
+	}); ok {
+		text = bt.Backtrace()
+	}
+
+	matches := scriptPosRegex.FindAllStringSubmatch(text, -1)
+	for i := len(matches) - 1; i >= 0; i-- {
+		m := matches[i]
+		if filepath.Base(m[1]) != filepath.Base(script) {
+			continue
 		}
-	})
+		te.Line, _ = strconv.Atoi(m[2])
+		te.Col, _ = strconv.Atoi(m[3])
+		// drop the position prefix from messages, it's part of Error()
+		if idx := strings.Index(te.Message, m[0]+": "); idx >= 0 {
+			te.Message = te.Message[:idx] + te.Message[idx+len(m[0])+2:]
+		}
+		break
+	}
+	return te
+}
+
+// ExecTransform executes a designated transformation. The script is read
+// once, the bytes that run are the bytes stored. Skylark can't be interrupted, a transform that
+// exceeds opts.Limits.Timeout keeps running in the background until it
+// finishes & it's output is discarded. Until then ExecTransform refuses to run
+// transforms on the same node, returning ErrTransformRunning
+func ExecTransform(node *p2p.QriNode, ds *dataset.Dataset, infile cafs.File, opts *TransformOpts) (file cafs.File, err error) {
+	if opts == nil {
+		opts = &TransformOpts{}
+	}
+	if script, ok := node.OverdueTransform(); ok {
+		log.Debugf("refusing transform, %s is still running", script)
+		return nil, ErrTransformRunning
+	}
+
+	task := progress.NewTask(node.Progress, "running transform", progress.Entries, 0)
+	defer func() { task.Finish(err) }()
+
+	scriptPath := ds.Transform.ScriptPath
+	script, err := ioutil.ReadFile(scriptPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading transform script: %s", err.Error())
+	}
+
+	// skytf executes scripts from files, run a copy of the script as read so
+	// changes to scriptPath can't make the stored script differ from what ran.
+	// the copy keeps it's base name, positioning errors in the original
+	dir, err := ioutil.TempDir("", "transform")
 	if err != nil {
 		return nil, err
 	}
+	execPath := filepath.Join(dir, filepath.Base(scriptPath))
+	if err := ioutil.WriteFile(execPath, script, 0644); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	var tfPath string
+	if !opts.DryRun {
+		key, err := node.Repo.Store().Put(cafs.NewMemfileBytes("transform.sky", script), false)
+		if err != nil {
+			os.RemoveAll(dir)
+			return nil, err
+		}
+		tfPath = key.String()
+	}
 
 	st := &dataset.Structure{
 		Format: dataset.JSONDataFormat,
 		Schema: ds.Structure.Schema,
 	}
 
-	buf, err := dsio.NewEntryBuffer(st)
+	type result struct {
+		data []byte
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		data, err := runTransform(ds, scriptPath, execPath, infile, st, opts, task)
+		os.RemoveAll(dir)
+		done <- result{data, err}
+	}()
+
+	var timeout <-chan time.Time
+	if opts.Limits.Timeout > 0 {
+		timer := time.NewTimer(opts.Limits.Timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	var data []byte
+	select {
+	case res := <-done:
+		if res.err != nil {
+			return nil, res.err
+		}
+		data = res.data
+	case <-timeout:
+		node.SetOverdueTransform(scriptPath)
+		go func() {
+			<-done
+			node.ClearOverdueTransform()
+		}()
+		return nil, &TransformError{Script: scriptPath, Message: fmt.Sprintf("transform didn't finish within the time limit of %s", opts.Limits.Timeout)}
+	}
+
+	if !opts.DryRun {
+		ref := repo.DatasetRef{
+			Dataset: &dataset.DatasetPod{
+				Transform: &dataset.TransformPod{
+					Syntax:     "skylark",
+					ScriptPath: tfPath,
+				},
+			},
+		}
+
+		// record secret names, never values
+		secretKeys := make([]string, 0, len(opts.Secrets))
+		for key := range opts.Secrets {
+			secretKeys = append(secretKeys, key)
+		}
+		sort.Strings(secretKeys)
+		params := &repo.EventParams{
+			PrevPath: ds.PreviousPath,
+			Details: map[string]interface{}{
+				"syntax":     "skylark",
+				"scriptPath": tfPath,
+				"secrets":    secretKeys,
+			},
+		}
+
+		if err = logEvent(node.Repo, repo.ETTransformExecuted, ref, params); err != nil {
+			return
+		}
+	}
+
+	ds.Structure = st
+	return cafs.NewMemfileBytes(fmt.Sprintf("data.%s", st.Format.String()), data), nil
+}
+
+// runTransform executes the transform script at execPath, writing it's output
+// as st within opts.Limits. Errors are reported against scriptPath. Output
+// entries are counted as work done by task
+func runTransform(ds *dataset.Dataset, scriptPath, execPath string, infile cafs.File, st *dataset.Structure, opts *TransformOpts, task *progress.Task) ([]byte, error) {
+	rr, err := skytf.ExecFile(ds, execPath, infile, func(o *skytf.ExecOpts) {
+		if opts.Secrets != nil {
+			// convert to map[string]interface{}, which the lower-level skytf supports
+			// until we're sure map[string]string is going to work in the majority of use cases
+			s := map[string]interface{}{}
+			for key, val := range opts.Secrets {
+				s[key] = val
+			}
+			o.Secrets = s
+		}
+	})
 	if err != nil {
-		return nil, fmt.Errorf("error allocating result buffer: %s", err)
+		return nil, newTransformError(scriptPath, err)
 	}
 
-	err = dsio.Copy(rr, buf)
+	buf := &bytes.Buffer{}
+	lw := &limitedWriter{w: buf, max: opts.Limits.MaxBytes, script: scriptPath}
+	w, err := dsio.NewEntryWriter(st, lw)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("error allocating result writer: %s", err)
+	}
+
+	// dsio may wrap errors, check for exceeded limits first
+	lr := &limitedEntryReader{EntryReader: rr, max: opts.Limits.MaxEntries, script: scriptPath, task: task}
+	if err = dsio.Copy(lr, w); err != nil {
+		if lr.err != nil {
+			return nil, lr.err
+		} else if lw.err != nil {
+			return nil, lw.err
+		}
+		return nil, newTransformError(scriptPath, err)
 	}
 
-	if err := buf.Close(); err != nil {
+	if err := w.Close(); err != nil {
+		if lw.err != nil {
+			return nil, lw.err
+		}
 		return nil, fmt.Errorf("error closing row buffer: %s", err.Error())
 	}
+	return buf.Bytes(), nil
+}
+
+// limitedEntryReader errors once more than max entries are read. A max of 0
+// is unlimited. Entries read are counted as work done by task
+type limitedEntryReader struct {
+	dsio.EntryReader
+	max, read int
+	script    string
+	err       *TransformError
+	task      *progress.Task
+}
 
-	// TODO - adding here just to get the content-addressed script path for the event.
-	// clean up events to handle this situation
-	f, err := os.Open(filepath)
+// ReadEntry implements the dsio.EntryReader interface
+func (r *limitedEntryReader) ReadEntry() (dsio.Entry, error) {
+	ent, err := r.EntryReader.ReadEntry()
 	if err != nil {
-		return nil, err
+		return ent, err
+	}
+	r.read++
+	r.task.Add(1)
+	if r.max > 0 && r.read > r.max {
+		r.err = &TransformError{Script: r.script, Message: fmt.Sprintf("transform output more than the limit of %d entries", r.max)}
+		return ent, r.err
+	}
+	return ent, nil
+}
+
+// limitedWriter errors once more than max bytes are written. A max of 0 is
+// unlimited
+type limitedWriter struct {
+	w      io.Writer
+	max, n int64
+	script string
+	err    *TransformError
+}
+
+// Write implements the io.Writer interface
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if w.max > 0 && w.n+int64(len(p)) > w.max {
+		w.err = &TransformError{Script: w.script, Message: fmt.Sprintf("transform output more than the limit of %d bytes", w.max)}
+		return 0, w.err
+	}
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+// DryRunTransform executes a dataset's transform without writing anything to
+// the repo, returning the resulting dataset with the first limit entries of
+// it's body. A limit of -1 returns the full body
+func DryRunTransform(node *p2p.QriNode, ds *dataset.Dataset, body cafs.File, opts TransformOpts, limit int) (*dataset.DatasetPod, error) {
+	if ds.Transform == nil || ds.Transform.ScriptPath == "" {
+		return nil, fmt.Errorf("a transform is required for a dry run")
 	}
-	tfPath, err := node.Repo.Store().Put(cafs.NewMemfileReader("transform.sky", f), false)
+
+	// NOTE - struct fields need to be instantiated to make assign set to
+	// new pointer values, user set fields override transform output, same as
+	// CreateDataset
+	userSet := &dataset.Dataset{
+		Commit:    &dataset.Commit{},
+		Meta:      &dataset.Meta{},
+		Structure: &dataset.Structure{},
+		Transform: &dataset.Transform{},
+		Viz:       &dataset.Viz{},
+	}
+	userSet.Assign(ds)
+
+	opts.DryRun = true
+	file, err := ExecTransform(node, ds, body, &opts)
 	if err != nil {
 		return nil, err
 	}
-	ref := repo.DatasetRef{
-		Dataset: &dataset.DatasetPod{
-			Transform: &dataset.TransformPod{
-				Syntax:     "skylark",
-				ScriptPath: tfPath.String(),
-			},
-		},
+	// read with the structure of the transform output, before user set
+	// structure is assigned
+	st := ds.Structure
+	ds.Assign(userSet)
+
+	rr, err := dsio.NewEntryReader(st, file)
+	if err != nil {
+		return nil, fmt.Errorf("error allocating data reader: %s", err)
 	}
 
-	if err = node.Repo.LogEvent(repo.ETTransformExecuted, ref); err != nil {
-		return
+	var (
+		array []interface{}
+		obj   = map[string]interface{}{}
+		tlt   = "array"
+	)
+	if st.Schema != nil {
+		tlt = st.Schema.TopLevelType()
+	}
+	for i := 0; limit < 0 || i < limit; i++ {
+		ent, err := rr.ReadEntry()
+		if err != nil {
+			if err.Error() == "EOF" {
+				break
+			}
+			return nil, fmt.Errorf("row iteration error: %s", err.Error())
+		}
+		if tlt == "object" {
+			obj[ent.Key] = ent.Value
+		} else {
+			array = append(array, ent.Value)
+		}
 	}
 
-	ds.Structure = st
-	return cafs.NewMemfileBytes(fmt.Sprintf("data.%s", st.Format.String()), buf.Bytes()), nil
+	pod := ds.Encode()
+	if tlt == "object" {
+		pod.Body = obj
+	} else {
+		pod.Body = array
+	}
+	return pod, nil
 }
@@ -1,10 +1,13 @@
 package actions
 
 import (
+	"context"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/qri-io/cafs"
 	"github.com/qri-io/dataset"
@@ -48,4 +51,149 @@ def transform(qri):
 	if _, err := ExecTransform(node, ds, nil, nil); err != nil {
 		t.Error(err.Error())
 	}
+
+	updates := node.Progress.Since(context.Background(), 0)
+	if len(updates) == 0 {
+		t.Fatal("expected transform to report progress")
+	}
+	last := updates[len(updates)-1]
+	if last.Name != "running transform" || !last.Complete || last.Done != 3 {
+		t.Errorf("expected a complete transform update with 3 entries done, got: %#v", last)
+	}
+}
+
+func TestExecTransformLimits(t *testing.T) {
+	node := newTestNode(t)
+
+	dir, err := ioutil.TempDir("", "qri_test_transform_limits")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	tfPath := filepath.Join(dir, "transform.sky")
+	data := `
+def transform(qri):
+	return [1,2,3]
+`
+	if err := ioutil.WriteFile(tfPath, []byte(data), 0777); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	cases := []struct {
+		limits TransformLimits
+		err    string
+	}{
+		{TransformLimits{}, ""},
+		{TransformLimits{MaxEntries: 3, MaxBytes: 100, Timeout: time.Minute}, ""},
+		{TransformLimits{MaxEntries: 2}, "transform error: transform output more than the limit of 2 entries"},
+		{TransformLimits{MaxBytes: 4}, "transform error: transform output more than the limit of 4 bytes"},
+	}
+
+	for i, c := range cases {
+		ds := &dataset.Dataset{
+			Structure: &dataset.Structure{},
+			Transform: &dataset.Transform{
+				Syntax:     "skylark",
+				ScriptPath: tfPath,
+			},
+		}
+		_, err := ExecTransform(node, ds, nil, &TransformOpts{Limits: c.limits})
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%v'", i, c.err, err)
+		}
+	}
+
+	// transforms are refused while one that exceeded it's time limit runs
+	node.SetOverdueTransform(tfPath)
+
+	ds := &dataset.Dataset{
+		Structure: &dataset.Structure{},
+		Transform: &dataset.Transform{Syntax: "skylark", ScriptPath: tfPath},
+	}
+	if _, err := ExecTransform(node, ds, nil, nil); err != ErrTransformRunning {
+		t.Errorf("expected ErrTransformRunning while a transform is overdue, got: %v", err)
+	}
+	if _, err := ExecTransform(newTestNode(t), ds, nil, nil); err != nil {
+		t.Errorf("expected other nodes to keep running transforms, got: %s", err.Error())
+	}
+
+	node.ClearOverdueTransform()
+	if _, err := ExecTransform(node, ds, nil, nil); err != nil {
+		t.Errorf("expected transforms to run once the overdue transform finishes, got: %s", err.Error())
+	}
+}
+
+func TestNewTransformError(t *testing.T) {
+	cases := []struct {
+		err    error
+		expect string
+	}{
+		{fmt.Errorf("/tmp/a/transform.sky:3:5: got illegal token, want primary expression"), "transform error: transform.sky line 3, column 5: got illegal token, want primary expression"},
+		{fmt.Errorf("error calling transform: /tmp/a/transform.sky:7:12: undefined: foo"), "transform error: transform.sky line 7, column 12: error calling transform: undefined: foo"},
+		{fmt.Errorf("other.sky:1:1: unrelated"), "transform error: other.sky:1:1: unrelated"},
+		{fmt.Errorf("no position"), "transform error: no position"},
+	}
+
+	for i, c := range cases {
+		got := newTransformError("/tmp/a/transform.sky", c.err).Error()
+		if got != c.expect {
+			t.Errorf("case %d mismatch.\nexpected: %s\ngot:      %s", i, c.expect, got)
+		}
+	}
+}
+
+func TestDryRunTransform(t *testing.T) {
+	node := newTestNode(t)
+
+	dir, err := ioutil.TempDir("", "qri_test_dry_run")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	tfPath := filepath.Join(dir, "transform.sky")
+	data := `
+def transform(qri):
+	return [1,2,3,4,5]
+`
+	if err := ioutil.WriteFile(tfPath, []byte(data), 0777); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	events, err := node.Repo.Events(100, 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	ds := &dataset.Dataset{
+		Structure: &dataset.Structure{},
+		Transform: &dataset.Transform{
+			Syntax:     "skylark",
+			ScriptPath: tfPath,
+		},
+	}
+	pod, err := DryRunTransform(node, ds, nil, TransformOpts{}, 2)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	body, ok := pod.Body.([]interface{})
+	if !ok || len(body) != 2 {
+		t.Errorf("expected body to have 2 entries, got: %v", pod.Body)
+	}
+	if pod.Structure == nil || pod.Structure.Format != "json" {
+		t.Errorf("expected dry run to have a json structure, got: %v", pod.Structure)
+	}
+
+	after, err := node.Repo.Events(100, 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(after) != len(events) {
+		t.Errorf("expected dry run not to log events")
+	}
+
+	if _, err := DryRunTransform(node, &dataset.Dataset{}, nil, TransformOpts{}, 2); err == nil {
+		t.Error("expected dry run without a transform to error")
+	}
 }
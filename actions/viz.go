@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 
 	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/repo"
 )
 
 // PrepareViz loads vizualization bytes from a local filepath
@@ -15,7 +16,8 @@ func PrepareViz(ds *dataset.Dataset) (err error) {
 		return nil
 	}
 
-	if ds.Viz != nil && ds.Viz.ScriptPath != "" {
+	// named templates are stored in the repo, there's no script to load
+	if ds.Viz != nil && ds.Viz.ScriptPath != "" && !isTemplateRef(ds.Viz.ScriptPath) {
 		// create a reader of script bytes
 		scriptdata, err := ioutil.ReadFile(ds.Viz.ScriptPath)
 		if err != nil {
@@ -25,3 +27,9 @@ func PrepareViz(ds *dataset.Dataset) (err error) {
 	}
 	return nil
 }
+
+// isTemplateRef returns true if a viz script path refers to a named template
+func isTemplateRef(scriptPath string) bool {
+	_, ok := repo.TemplateName(scriptPath)
+	return ok
+}
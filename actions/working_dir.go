@@ -0,0 +1,477 @@
+package actions
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/dataset/dsutil"
+	"github.com/qri-io/dsdiff"
+	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/repo"
+)
+
+const (
+	// WorkingDirLinkFile is a hidden file that records which dataset version a
+	// working directory was checked out from
+	WorkingDirLinkFile = ".qri-ref"
+	// WorkingDirDatasetFile holds dataset meta, structure, transform & viz config
+	WorkingDirDatasetFile = "dataset.yaml"
+	// WorkingDirBodyFile is the base name of the dataset body file. Body files
+	// have the extension of the body's data format, eg: body.csv
+	WorkingDirBodyFile = "body"
+	// WorkingDirTransformFile holds the transform script
+	WorkingDirTransformFile = "transform.sky"
+	// WorkingDirVizFile holds the viz template
+	WorkingDirVizFile = "viz.html"
+)
+
+// ErrNoWorkingDir is returned when a directory isn't linked to a dataset
+var ErrNoWorkingDir = fmt.Errorf("not a dataset working directory, use `qri checkout` to create one")
+
+// WorkingDirStatus describes the state of a working directory relative to
+// the dataset version it was checked out from
+type WorkingDirStatus struct {
+	// Ref is the dataset & version the working directory is linked to
+	Ref repo.DatasetRef `json:"ref"`
+	// Behind is true if the dataset has new versions since the working
+	// directory was checked out
+	Behind bool `json:"behind"`
+	// Modified lists components that differ from the linked version
+	Modified []string `json:"modified"`
+	// Components holds differences for modified meta, structure, transform
+	// & viz configuration
+	Components map[string]*dsdiff.SubDiff `json:"components,omitempty"`
+}
+
+// CheckoutDataset writes a dataset version to a directory for editing.
+// The directory must be empty or not exist. ref is resolved to the version
+// that was checked out
+func CheckoutDataset(node *p2p.QriNode, ref *repo.DatasetRef, dir string) error {
+	if infos, err := ioutil.ReadDir(dir); err == nil && len(infos) > 0 {
+		return fmt.Errorf("directory %s is not empty", dir)
+	}
+
+	if err := DatasetHead(node, ref); err != nil {
+		return err
+	}
+	ds, err := ref.DecodeDataset()
+	if err != nil {
+		return err
+	}
+	store := node.Repo.Store()
+
+	if err = os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+
+	dsMap, err := workingDirMap(ref.Dataset)
+	if err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(dsMap)
+	if err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, WorkingDirDatasetFile), data, os.ModePerm); err != nil {
+		return err
+	}
+
+	body, err := dsfs.LoadBody(store, ds)
+	if err != nil {
+		return fmt.Errorf("error loading body: %s", err.Error())
+	}
+	defer body.Close()
+	if err = writeWorkingDirFile(filepath.Join(dir, workingDirBodyFilename(ds.Structure)), body); err != nil {
+		return err
+	}
+
+	if ds.Transform != nil && ds.Transform.ScriptPath != "" {
+		f, err := store.Get(datastore.NewKey(ds.Transform.ScriptPath))
+		if err != nil {
+			return fmt.Errorf("error loading transform script: %s", err.Error())
+		}
+		defer f.Close()
+		if err = writeWorkingDirFile(filepath.Join(dir, WorkingDirTransformFile), f); err != nil {
+			return err
+		}
+	}
+
+	// viz that refer to a named template have no script to check out
+	if ds.Viz != nil && ds.Viz.ScriptPath != "" && !isTemplateRef(ds.Viz.ScriptPath) {
+		f, err := store.Get(datastore.NewKey(ds.Viz.ScriptPath))
+		if err != nil {
+			return fmt.Errorf("error loading viz template: %s", err.Error())
+		}
+		defer f.Close()
+		if err = writeWorkingDirFile(filepath.Join(dir, WorkingDirVizFile), f); err != nil {
+			return err
+		}
+	}
+
+	return writeWorkingDirLink(dir, *ref)
+}
+
+// WorkingDirRef reads the dataset reference a working directory is linked to
+func WorkingDirRef(dir string) (repo.DatasetRef, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, WorkingDirLinkFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return repo.DatasetRef{}, ErrNoWorkingDir
+		}
+		return repo.DatasetRef{}, err
+	}
+	return repo.ParseDatasetRef(strings.TrimSpace(string(data)))
+}
+
+// GetWorkingDirStatus compares a working directory to the dataset version it
+// was checked out from
+func GetWorkingDirStatus(node *p2p.QriNode, dir string) (*WorkingDirStatus, error) {
+	wd, err := loadWorkingDir(node, dir)
+	if err != nil {
+		return nil, err
+	}
+	return wd.status, nil
+}
+
+// SaveWorkingDir creates a new version of a dataset from the changes in a
+// working directory, updating the directory's link to the new version.
+// Transforms run within limits
+func SaveWorkingDir(node *p2p.QriNode, dir string, commit *dataset.CommitPod, limits TransformLimits) (ref repo.DatasetRef, err error) {
+	wd, err := loadWorkingDir(node, dir)
+	if err != nil {
+		return
+	}
+	if wd.status.Behind {
+		err = fmt.Errorf("%s has new versions since this directory was checked out, check out the latest version to make changes", wd.status.Ref.AliasString())
+		return
+	}
+	if len(wd.status.Modified) == 0 {
+		err = fmt.Errorf("no changes to save")
+		return
+	}
+
+	dsp := wd.pod
+	dsp.Peername = wd.status.Ref.Peername
+	dsp.Name = wd.status.Ref.Name
+	dsp.Commit = commit
+	for _, component := range wd.status.Modified {
+		switch component {
+		case "body":
+			dsp.BodyPath = wd.bodyPath
+		case "transform":
+			if wd.hasTransform {
+				if dsp.Transform == nil {
+					dsp.Transform = &dataset.TransformPod{}
+				}
+				dsp.Transform.ScriptPath = filepath.Join(dir, WorkingDirTransformFile)
+			}
+		case "viz":
+			if wd.hasViz {
+				if dsp.Viz == nil {
+					err = fmt.Errorf("%s has no viz configuration, add a viz section to save %s", WorkingDirDatasetFile, WorkingDirVizFile)
+					return
+				}
+				dsp.Viz.ScriptPath = filepath.Join(dir, WorkingDirVizFile)
+			}
+		}
+	}
+
+	ds, body, secrets, err := UpdateDataset(node, dsp)
+	if err != nil {
+		return
+	}
+
+	tfOpts := &TransformOpts{Secrets: secrets, Limits: limits}
+	if ref, err = CreateDataset(node, dsp.Name, ds, body, tfOpts, true); err != nil {
+		return
+	}
+	if err = writeWorkingDirLink(dir, ref); err != nil {
+		return
+	}
+	ref.Dataset = ds.Encode()
+	return
+}
+
+// workingDir is a working directory loaded for comparison with it's linked
+// dataset version
+type workingDir struct {
+	status       *WorkingDirStatus
+	pod          *dataset.DatasetPod
+	bodyPath     string
+	hasTransform bool
+	hasViz       bool
+}
+
+func loadWorkingDir(node *p2p.QriNode, dir string) (*workingDir, error) {
+	linked, err := WorkingDirRef(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// check the linked version is still the head of the dataset
+	head := repo.DatasetRef{Peername: linked.Peername, Name: linked.Name}
+	if err = repo.CanonicalizeDatasetRef(node.Repo, &head); err != nil {
+		return nil, err
+	}
+
+	prev := linked
+	if err = DatasetHead(node, &prev); err != nil {
+		return nil, err
+	}
+	prevDs, err := prev.DecodeDataset()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, WorkingDirDatasetFile))
+	if err != nil {
+		return nil, err
+	}
+	pod := &dataset.DatasetPod{}
+	if err = dsutil.UnmarshalYAMLDatasetPod(data, pod); err != nil {
+		return nil, fmt.Errorf("error reading %s: %s", WorkingDirDatasetFile, err.Error())
+	}
+
+	wd := &workingDir{
+		pod: pod,
+		status: &WorkingDirStatus{
+			Ref:        linked,
+			Behind:     normalizePath(head.Path) != normalizePath(linked.Path),
+			Components: map[string]*dsdiff.SubDiff{},
+		},
+	}
+
+	prevMap, err := workingDirMap(prev.Dataset)
+	if err != nil {
+		return nil, err
+	}
+	podMap, err := workingDirMap(pod)
+	if err != nil {
+		return nil, err
+	}
+	workDs := &dataset.Dataset{}
+	if err = workDs.Decode(pod); err != nil {
+		return nil, fmt.Errorf("error reading %s: %s", WorkingDirDatasetFile, err.Error())
+	}
+
+	for _, component := range []string{"meta", "structure", "transform", "viz"} {
+		if changed, err := componentChanged(prevMap[component], podMap[component]); err != nil {
+			return nil, err
+		} else if changed {
+			wd.status.Modified = append(wd.status.Modified, component)
+			if d, err := diffComponent(component, prevDs, workDs); err == nil && d != nil {
+				wd.status.Components[component] = d
+			}
+		}
+	}
+
+	store := node.Repo.Store()
+	if wd.bodyPath, err = workingDirBodyPath(dir); err != nil {
+		return nil, err
+	}
+	if filepath.Base(wd.bodyPath) != workingDirBodyFilename(prevDs.Structure) {
+		wd.status.Modified = append(wd.status.Modified, "body")
+	} else {
+		prevBody, err := dsfs.LoadBody(store, prevDs)
+		if err != nil {
+			return nil, fmt.Errorf("error loading body: %s", err.Error())
+		}
+		defer prevBody.Close()
+		if changed, err := fileChanged(prevBody, wd.bodyPath); err != nil {
+			return nil, err
+		} else if changed {
+			wd.status.Modified = append(wd.status.Modified, "body")
+		}
+	}
+
+	tfPath := filepath.Join(dir, WorkingDirTransformFile)
+	if _, err := os.Stat(tfPath); err == nil {
+		wd.hasTransform = true
+		changed, err := scriptChanged(store, prevDs.Transform != nil, func() string { return prevDs.Transform.ScriptPath }, tfPath)
+		if err != nil {
+			return nil, err
+		}
+		if changed && !wd.modified("transform") {
+			wd.status.Modified = append(wd.status.Modified, "transform")
+		}
+	}
+
+	vizPath := filepath.Join(dir, WorkingDirVizFile)
+	if _, err := os.Stat(vizPath); err == nil {
+		wd.hasViz = true
+		changed, err := scriptChanged(store, prevDs.Viz != nil, func() string { return prevDs.Viz.ScriptPath }, vizPath)
+		if err != nil {
+			return nil, err
+		}
+		if changed && !wd.modified("viz") {
+			wd.status.Modified = append(wd.status.Modified, "viz")
+		}
+	}
+
+	return wd, nil
+}
+
+func (wd *workingDir) modified(component string) bool {
+	for _, c := range wd.status.Modified {
+		if c == component {
+			return true
+		}
+	}
+	return false
+}
+
+// workingDirMap creates a generic map of dataset fields that are edited in a
+// working directory's dataset file. Paths, commit details & identifiers are
+// removed, as are script paths & the body path, which have their own files.
+// structure fields calculated from the body are also removed
+func workingDirMap(dsp *dataset.DatasetPod) (map[string]interface{}, error) {
+	data, err := json.Marshal(dsp)
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]interface{}{}
+	if err = json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	for _, key := range []string{"path", "previousPath", "commit", "bodyPath", "peername", "name", "profileID", "bodyBytes"} {
+		delete(m, key)
+	}
+	for _, component := range []string{"meta", "structure", "transform", "viz"} {
+		if cm, ok := m[component].(map[string]interface{}); ok {
+			delete(cm, "path")
+			delete(cm, "scriptPath")
+			delete(cm, "renderedPath")
+		}
+	}
+	if st, ok := m["structure"].(map[string]interface{}); ok {
+		for _, key := range []string{"checksum", "depth", "entries", "errCount", "length"} {
+			delete(st, key)
+		}
+	}
+	return m, nil
+}
+
+func componentChanged(a, b interface{}) (bool, error) {
+	adata, err := json.Marshal(a)
+	if err != nil {
+		return false, err
+	}
+	bdata, err := json.Marshal(b)
+	if err != nil {
+		return false, err
+	}
+	return !bytes.Equal(adata, bdata), nil
+}
+
+func diffComponent(component string, a, b *dataset.Dataset) (*dsdiff.SubDiff, error) {
+	switch component {
+	case "meta":
+		if a.Meta != nil && b.Meta != nil {
+			return dsdiff.DiffMeta(a.Meta, b.Meta)
+		}
+	case "structure":
+		if a.Structure != nil && b.Structure != nil {
+			return dsdiff.DiffStructure(a.Structure, b.Structure)
+		}
+	case "transform":
+		if a.Transform != nil && b.Transform != nil {
+			return dsdiff.DiffTransform(a.Transform, b.Transform)
+		}
+	case "viz":
+		if a.Viz != nil && b.Viz != nil {
+			return dsdiff.DiffViz(a.Viz, b.Viz)
+		}
+	}
+	return nil, nil
+}
+
+// scriptChanged compares a script file in a working directory to a script in
+// the store. scriptPath is only called if hasScript is true
+func scriptChanged(store cafs.Filestore, hasScript bool, scriptPath func() string, path string) (bool, error) {
+	if !hasScript || scriptPath() == "" {
+		return true, nil
+	}
+	f, err := store.Get(datastore.NewKey(scriptPath()))
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	return fileChanged(f, path)
+}
+
+// fileChanged compares the contents of a reader to a file
+func fileChanged(r io.Reader, path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	abuf := make([]byte, 32*1024)
+	bbuf := make([]byte, 32*1024)
+	for {
+		an, aerr := io.ReadFull(r, abuf)
+		bn, berr := io.ReadFull(f, bbuf)
+		if an != bn || !bytes.Equal(abuf[:an], bbuf[:bn]) {
+			return true, nil
+		}
+		aeof := aerr == io.EOF || aerr == io.ErrUnexpectedEOF
+		beof := berr == io.EOF || berr == io.ErrUnexpectedEOF
+		if aerr != nil && !aeof {
+			return false, aerr
+		}
+		if berr != nil && !beof {
+			return false, berr
+		}
+		if aeof || beof {
+			return aeof != beof, nil
+		}
+	}
+}
+
+func workingDirBodyFilename(st *dataset.Structure) string {
+	if st == nil {
+		return WorkingDirBodyFile
+	}
+	return fmt.Sprintf("%s.%s", WorkingDirBodyFile, st.Format.String())
+}
+
+// workingDirBodyPath finds the body file in a working directory
+func workingDirBodyPath(dir string) (string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, WorkingDirBodyFile+".*"))
+	if err != nil {
+		return "", err
+	}
+	if len(matches) != 1 {
+		return "", fmt.Errorf("working directory must contain exactly one body file, found %d", len(matches))
+	}
+	return matches[0], nil
+}
+
+func writeWorkingDirFile(path string, r io.Reader) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func writeWorkingDirLink(dir string, ref repo.DatasetRef) error {
+	link := repo.DatasetRef{Peername: ref.Peername, ProfileID: ref.ProfileID, Name: ref.Name, Path: ref.Path}
+	return ioutil.WriteFile(filepath.Join(dir, WorkingDirLinkFile), []byte(link.String()+"\n"), os.ModePerm)
+}
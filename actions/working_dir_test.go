@@ -0,0 +1,106 @@
+package actions
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/repo"
+)
+
+func TestWorkingDir(t *testing.T) {
+	node := newTestNode(t)
+	ref := addCitiesDataset(t, node)
+
+	tmp, err := ioutil.TempDir("", "qri_working_dir")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(tmp)
+	dir := filepath.Join(tmp, "cities")
+
+	co := &repo.DatasetRef{Peername: ref.Peername, Name: ref.Name}
+	if err := CheckoutDataset(node, co, dir); err != nil {
+		t.Fatal(err.Error())
+	}
+	for _, name := range []string{WorkingDirLinkFile, WorkingDirDatasetFile, "body.csv"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("expected checkout to write %s: %s", name, err.Error())
+		}
+	}
+	if err := CheckoutDataset(node, co, dir); err == nil {
+		t.Error("expected checkout into a non-empty directory to error")
+	}
+
+	linked, err := WorkingDirRef(dir)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if linked.Path != ref.Path {
+		t.Errorf("expected link path to be %s, got: %s", ref.Path, linked.Path)
+	}
+
+	status, err := GetWorkingDirStatus(node, dir)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if status.Behind || len(status.Modified) != 0 {
+		t.Fatalf("expected a fresh checkout to be unmodified, got: %v", status.Modified)
+	}
+
+	if _, err := SaveWorkingDir(node, dir, nil, TransformLimits{}); err == nil || err.Error() != "no changes to save" {
+		t.Errorf("expected saving an unmodified directory to error, got: %v", err)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, WorkingDirDatasetFile))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	dsMap := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &dsMap); err != nil {
+		t.Fatal(err.Error())
+	}
+	dsMap["meta"].(map[string]interface{})["title"] = "edited title"
+	if data, err = yaml.Marshal(dsMap); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, WorkingDirDatasetFile), data, os.ModePerm); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "body.csv"), []byte(citiesBody+"sarnia,550000,55.65,false\n"), os.ModePerm); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	status, err = GetWorkingDirStatus(node, dir)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(status.Modified) != 2 || status.Modified[0] != "meta" || status.Modified[1] != "body" {
+		t.Errorf("expected meta & body to be modified, got: %v", status.Modified)
+	}
+
+	saved, err := SaveWorkingDir(node, dir, &dataset.CommitPod{Title: "edited in working dir"}, TransformLimits{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if saved.Path == ref.Path {
+		t.Error("expected save to create a new version")
+	}
+	if saved.Dataset.Meta.Title != "edited title" {
+		t.Errorf("expected saved meta title to be 'edited title', got: '%s'", saved.Dataset.Meta.Title)
+	}
+
+	status, err = GetWorkingDirStatus(node, dir)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if status.Ref.Path != saved.Path {
+		t.Errorf("expected link to be updated to %s, got: %s", saved.Path, status.Ref.Path)
+	}
+	if status.Behind || len(status.Modified) != 0 {
+		t.Errorf("expected directory to be unmodified after save, got: %v", status.Modified)
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/ipfs/go-datastore"
 	golog "github.com/ipfs/go-log"
 	"github.com/qri-io/cafs"
+	"github.com/qri-io/qri/actions"
 	"github.com/qri-io/qri/config"
 	"github.com/qri-io/qri/lib"
 	"github.com/qri-io/qri/p2p"
@@ -66,6 +67,9 @@ func (s *Server) Serve() (err error) {
 		return fmt.Errorf("error starting P2P service: %s", err.Error())
 	}
 
+	// add new versions of followed datasets as followed peers announce them
+	go actions.ReplicateFollows(s.qriNode, actions.ReplicationPolicy(s.cfg.P2P))
+
 	if node, err := s.qriNode.IPFSNode(); err == nil {
 		if pinner, ok := s.qriNode.Repo.Store().(cafs.Pinner); ok {
 
@@ -253,6 +257,20 @@ func NewServerRoutes(s *Server) *http.ServeMux {
 	sh := NewSearchHandlers(s.qriNode)
 	m.Handle("/search", s.middleware(sh.SearchHandler))
 
+	qh := NewQueryHandlers(s.qriNode, s.cfg.API.ReadOnly)
+	m.Handle("/sql", s.middleware(qh.SQLHandler))
+
+	crh := NewChangeRequestHandlers(s.qriNode, s.cfg.API.ReadOnly)
+	m.Handle("/requests", s.middleware(crh.ChangeRequestsHandler))
+	m.Handle("/requests/", s.middleware(crh.ChangeRequestHandler))
+
+	fh := NewFollowHandlers(s.qriNode, s.cfg.API.ReadOnly)
+	m.Handle("/follow", s.middleware(fh.FollowsHandler))
+	m.Handle("/follow/", s.middleware(fh.FollowHandler))
+
+	prh := NewProgressHandlers(s.qriNode)
+	m.Handle("/progress", s.middleware(prh.ProgressHandler))
+
 	rh := NewRootHandler(dsh, ph)
 	m.Handle("/", s.datasetRefMiddleware(s.middleware(rh.Handler)))
 
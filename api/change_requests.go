@@ -0,0 +1,156 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	util "github.com/datatogether/api/apiutil"
+	"github.com/qri-io/qri/lib"
+	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/repo"
+)
+
+// ChangeRequestHandlers wraps a requests struct to interface with http.HandlerFunc
+type ChangeRequestHandlers struct {
+	lib.ChangeRequests
+	ReadOnly bool
+}
+
+// NewChangeRequestHandlers allocates a ChangeRequestHandlers pointer
+func NewChangeRequestHandlers(node *p2p.QriNode, readOnly bool) *ChangeRequestHandlers {
+	req := lib.NewChangeRequests(node, nil)
+	return &ChangeRequestHandlers{*req, readOnly}
+}
+
+// ChangeRequestsHandler is the endpoint for listing & sending change requests
+func (h *ChangeRequestHandlers) ChangeRequestsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "GET":
+		h.listChangeRequestsHandler(w, r)
+	case "POST":
+		if h.ReadOnly {
+			readOnlyResponse(w, "/requests")
+			return
+		}
+		h.sendChangeRequestHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+// ChangeRequestHandler is the endpoint for a single change request. GET
+// /requests/[id] fetches a request, POST /requests/accept/[id] and
+// /requests/reject/[id] act on it
+func (h *ChangeRequestHandlers) ChangeRequestHandler(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path[len("/requests"):]
+
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "GET":
+		h.getChangeRequestHandler(w, path)
+	case "POST":
+		if h.ReadOnly {
+			readOnlyResponse(w, "/requests/")
+			return
+		}
+		switch {
+		case strings.HasPrefix(path, "/accept/"):
+			h.acceptChangeRequestHandler(w, path[len("/accept"):])
+		case strings.HasPrefix(path, "/reject/"):
+			h.rejectChangeRequestHandler(w, path[len("/reject"):])
+		default:
+			util.NotFoundHandler(w, r)
+		}
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+func (h *ChangeRequestHandlers) listChangeRequestsHandler(w http.ResponseWriter, r *http.Request) {
+	p := &lib.ListChangeRequestsParams{
+		ListParams: lib.ListParamsFromRequest(r),
+		Remote:     r.FormValue("remote") == "true",
+	}
+	if s := r.FormValue("ref"); s != "" {
+		ref, err := repo.ParseDatasetRef(s)
+		if err != nil {
+			util.WriteErrResponse(w, http.StatusBadRequest, err)
+			return
+		}
+		p.Target = ref
+	}
+
+	res := []*repo.ChangeRequest{}
+	if err := h.List(p, &res); err != nil {
+		log.Infof("error listing change requests: %s", err.Error())
+		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	if err := util.WritePageResponse(w, res, r, p.Page()); err != nil {
+		log.Infof("error list change requests response: %s", err.Error())
+	}
+}
+
+func (h *ChangeRequestHandlers) sendChangeRequestHandler(w http.ResponseWriter, r *http.Request) {
+	p := &lib.SendChangeRequestParams{}
+	if r.Header.Get("Content-Type") == "application/json" {
+		if err := json.NewDecoder(r.Body).Decode(p); err != nil {
+			util.WriteErrResponse(w, http.StatusBadRequest, err)
+			return
+		}
+	} else {
+		var err error
+		if p.Target, err = repo.ParseDatasetRef(r.FormValue("target")); err != nil {
+			util.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("invalid target: %s", err.Error()))
+			return
+		}
+		if p.Proposal, err = repo.ParseDatasetRef(r.FormValue("proposal")); err != nil {
+			util.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("invalid proposal: %s", err.Error()))
+			return
+		}
+		p.Title = r.FormValue("title")
+		p.Message = r.FormValue("message")
+	}
+
+	res := &repo.ChangeRequest{}
+	if err := h.Send(p, res); err != nil {
+		log.Infof("error sending change request: %s", err.Error())
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	util.WriteResponse(w, res)
+}
+
+func (h *ChangeRequestHandlers) getChangeRequestHandler(w http.ResponseWriter, id string) {
+	res := &repo.ChangeRequest{}
+	if err := h.Get(&id, res); err != nil {
+		util.WriteErrResponse(w, http.StatusNotFound, err)
+		return
+	}
+	util.WriteResponse(w, res)
+}
+
+func (h *ChangeRequestHandlers) acceptChangeRequestHandler(w http.ResponseWriter, id string) {
+	res := &repo.ChangeRequest{}
+	if err := h.Accept(&id, res); err != nil {
+		log.Infof("error accepting change request: %s", err.Error())
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	util.WriteResponse(w, res)
+}
+
+func (h *ChangeRequestHandlers) rejectChangeRequestHandler(w http.ResponseWriter, id string) {
+	res := &repo.ChangeRequest{}
+	if err := h.Reject(&id, res); err != nil {
+		log.Infof("error rejecting change request: %s", err.Error())
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	util.WriteResponse(w, res)
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/qri-io/qri/repo"
+)
+
+func TestChangeRequestHandlers(t *testing.T) {
+	node, teardown := newTestNode(t)
+	defer teardown()
+
+	cities, err := node.Repo.GetRef(repo.DatasetRef{Peername: "peer", Name: "cities"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	cr := &repo.ChangeRequest{
+		ID:         "/map/proposal",
+		Path:       "/map/proposal",
+		Target:     cities,
+		Proposer:   "other_peer",
+		ProposerID: "QmOtherPeer",
+		Status:     repo.CRStatusOpen,
+		Created:    time.Now(),
+	}
+	if err := node.Repo.(repo.ChangeRequestStore).PutChangeRequest(cr); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	cases := []struct {
+		method, endpoint string
+		body             string
+		readOnly         bool
+		status           int
+	}{
+		{"OPTIONS", "/requests", "", false, http.StatusOK},
+		{"DELETE", "/requests", "", false, http.StatusNotFound},
+		{"GET", "/requests", "", false, http.StatusOK},
+		{"GET", "/requests?ref=peer/cities", "", false, http.StatusOK},
+		{"POST", "/requests", `{"target":{"peername":"peer","name":"cities"}}`, true, http.StatusForbidden},
+		{"POST", "/requests", `{"target":{"peername":"peer","name":"cities"}}`, false, http.StatusBadRequest},
+		{"GET", "/requests/map/proposal", "", false, http.StatusOK},
+		{"GET", "/requests/map/nope", "", false, http.StatusNotFound},
+		{"POST", "/requests/reject/map/proposal", "", true, http.StatusForbidden},
+		{"POST", "/requests/frobnicate/map/proposal", "", false, http.StatusNotFound},
+		{"POST", "/requests/reject/map/proposal", "", false, http.StatusOK},
+		{"POST", "/requests/accept/map/proposal", "", false, http.StatusBadRequest},
+	}
+
+	for i, c := range cases {
+		h := NewChangeRequestHandlers(node, c.readOnly)
+		req := httptest.NewRequest(c.method, c.endpoint, bytes.NewBufferString(c.body))
+		if c.body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		w := httptest.NewRecorder()
+		if req.URL.Path == "/requests" {
+			h.ChangeRequestsHandler(w, req)
+		} else {
+			h.ChangeRequestHandler(w, req)
+		}
+
+		if w.Code != c.status {
+			t.Errorf("case %d: %s %s status mismatch. expected: %d, got: %d", i, c.method, c.endpoint, c.status, w.Code)
+		}
+	}
+}
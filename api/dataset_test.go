@@ -135,3 +135,37 @@ func newMockDataServer(t *testing.T) *httptest.Server {
 	mockDataServer.Start()
 	return mockDataServer
 }
+
+func TestDatasetHistoryExport(t *testing.T) {
+	node, teardown := newTestNode(t)
+	defer teardown()
+
+	h := NewDatasetHandlers(node, false)
+	cases := []struct {
+		endpoint    string
+		status      int
+		contentType string
+	}{
+		{"/export/me/cities?history=true", http.StatusOK, "application/zip"},
+		{"/export/me/cities?history=true&format=tar.gz", http.StatusOK, "application/gzip"},
+		{"/export/me/cities?history=true&format=rar", http.StatusBadRequest, ""},
+		{"/export/me/not_a_dataset?history=true", http.StatusNotFound, ""},
+	}
+
+	for i, c := range cases {
+		req := httptest.NewRequest("GET", c.endpoint, nil)
+		w := httptest.NewRecorder()
+		h.ZipDatasetHandler(w, req)
+
+		if w.Code != c.status {
+			t.Errorf("case %d: expected status %d, got: %d", i, c.status, w.Code)
+			continue
+		}
+		if c.contentType != "" && w.Header().Get("Content-Type") != c.contentType {
+			t.Errorf("case %d: expected content type %s, got: %s", i, c.contentType, w.Header().Get("Content-Type"))
+		}
+		if c.status == http.StatusOK && w.Body.Len() == 0 {
+			t.Errorf("case %d: expected archive body", i)
+		}
+	}
+}
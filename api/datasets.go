@@ -15,9 +15,11 @@ import (
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/dataset/dsutil"
 	"github.com/qri-io/dsdiff"
+	"github.com/qri-io/qri/actions"
 	"github.com/qri-io/qri/lib"
 	"github.com/qri-io/qri/p2p"
 	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/archive"
 	"github.com/qri-io/qri/repo/profile"
 )
 
@@ -193,6 +195,11 @@ func (h *DatasetHandlers) zipDatasetHandler(w http.ResponseWriter, r *http.Reque
 		util.WriteErrResponse(w, http.StatusBadRequest, err)
 		return
 	}
+	if r.FormValue("history") == "true" {
+		h.historyArchiveHandler(w, r, args)
+		return
+	}
+
 	res := &repo.DatasetRef{}
 	err = h.Get(&args, res)
 	if err != nil {
@@ -213,6 +220,36 @@ func (h *DatasetHandlers) zipDatasetHandler(w http.ResponseWriter, r *http.Reque
 	dsutil.WriteZipArchive(h.repo.Store(), ds, w)
 }
 
+// historyArchiveHandler writes every version of a dataset as an archive. The
+// format param selects zip or tar.gz, defaulting to zip like single-version
+// exports
+func (h *DatasetHandlers) historyArchiveHandler(w http.ResponseWriter, r *http.Request, ref repo.DatasetRef) {
+	format := archive.FormatZip
+	if f := r.FormValue("format"); f != "" {
+		var err error
+		if format, err = archive.ParseFormat(f); err != nil {
+			util.WriteErrResponse(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	if err := repo.CanonicalizeDatasetRef(h.repo, &ref); err != nil {
+		util.WriteErrResponse(w, http.StatusNotFound, err)
+		return
+	}
+
+	contentType := "application/gzip"
+	if format == archive.FormatZip {
+		contentType = "application/zip"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("filename=\"%s%s\"", ref.Name, format.Ext()))
+	if _, err := archive.ExportDataset(w, h.repo, ref, format); err != nil {
+		// headers have already been sent, the best we can do is log
+		log.Infof("error exporting dataset history: %s", err.Error())
+	}
+}
+
 func (h *DatasetHandlers) listHandler(w http.ResponseWriter, r *http.Request) {
 	args := lib.ListParamsFromRequest(r)
 	args.OrderBy = "created"
@@ -252,6 +289,11 @@ func (h *DatasetHandlers) getHandler(w http.ResponseWriter, r *http.Request) {
 type diffAPIParams struct {
 	Left, Right string
 	Format      string
+	// Body requests a row-level diff of dataset bodies
+	Body bool
+	// Key is an optional list of columns to match body rows by
+	Key   []string
+	Limit int
 }
 
 func (h *DatasetHandlers) diffHandler(w http.ResponseWriter, r *http.Request) {
@@ -266,6 +308,13 @@ func (h *DatasetHandlers) diffHandler(w http.ResponseWriter, r *http.Request) {
 		d.Left = r.FormValue("left")
 		d.Right = r.FormValue("right")
 		d.Format = r.FormValue("format")
+		d.Body = r.FormValue("body") == "true"
+		if key := r.FormValue("key"); key != "" {
+			d.Key = strings.Split(key, ",")
+		}
+		if limit, err := util.ReqParamInt("limit", r); err == nil {
+			d.Limit = limit
+		}
 	}
 
 	left, err := DatasetRefFromPath(d.Left)
@@ -280,27 +329,37 @@ func (h *DatasetHandlers) diffHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	diffs := make(map[string]*dsdiff.SubDiff)
+	res := &lib.DiffResponse{}
 	p := &lib.DiffParams{
-		Left:    left,
-		Right:   right,
-		DiffAll: true,
+		Left:       left,
+		Right:      right,
+		DiffAll:    !d.Body,
+		DiffBody:   d.Body,
+		PrimaryKey: d.Key,
+		MaxChanges: d.Limit,
 	}
 
-	if err = h.Diff(p, &diffs); err != nil {
+	if err = h.Diff(p, res); err != nil {
 		util.WriteErrResponse(w, http.StatusInternalServerError, fmt.Errorf("error diffing datasets: %s", err))
+		return
+	}
+
+	if d.Body {
+		util.WriteResponse(w, res.Body)
+		return
 	}
 
 	if d.Format != "" {
-		formattedDiffs, err := dsdiff.MapDiffsToString(diffs, d.Format)
+		formattedDiffs, err := dsdiff.MapDiffsToString(res.Components, d.Format)
 		if err != nil {
 			util.WriteErrResponse(w, http.StatusInternalServerError, fmt.Errorf("error formating diffs: %s", err))
+			return
 		}
 		util.WriteResponse(w, formattedDiffs)
 		return
 	}
 
-	util.WriteResponse(w, diffs)
+	util.WriteResponse(w, res.Components)
 }
 
 func (h *DatasetHandlers) peerListHandler(w http.ResponseWriter, r *http.Request) {
@@ -463,8 +522,21 @@ func (h *DatasetHandlers) saveHandler(w http.ResponseWriter, r *http.Request) {
 	p := &lib.SaveParams{
 		Dataset: dsp,
 		Private: r.FormValue("private") == "true",
+		DryRun:  r.FormValue("dryRun") == "true",
+	}
+	if entries, err := util.ReqParamInt("dryRunEntries", r); err == nil {
+		p.DryRunEntries = entries
 	}
 	if err := h.Save(p, res); err != nil {
+		// transform errors are problems with the script, not the server
+		if _, ok := err.(*actions.TransformError); ok {
+			util.WriteErrResponse(w, http.StatusBadRequest, err)
+			return
+		}
+		if err == actions.ErrTransformRunning {
+			util.WriteErrResponse(w, http.StatusServiceUnavailable, err)
+			return
+		}
 		util.WriteErrResponse(w, http.StatusInternalServerError, err)
 		return
 	}
@@ -477,6 +549,21 @@ func (h *DatasetHandlers) removeHandler(w http.ResponseWriter, r *http.Request)
 		util.WriteErrResponse(w, http.StatusBadRequest, err)
 		return
 	}
+
+	// removing specific revisions or resetting to a version rewinds the
+	// dataset's history instead of removing it entirely
+	revisions, _ := util.ReqParamInt("revisions", r)
+	if revisions > 0 || r.FormValue("reset") == "true" {
+		res := &lib.ResetResponse{}
+		if err := h.Reset(&lib.ResetParams{Ref: p, Revisions: revisions}, res); err != nil {
+			log.Infof("error resetting dataset: %s", err.Error())
+			util.WriteErrResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+		util.WriteResponse(w, res)
+		return
+	}
+
 	ref := &repo.DatasetRef{}
 	if err := h.Get(&p, ref); err != nil {
 		util.WriteErrResponse(w, http.StatusBadRequest, err)
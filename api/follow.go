@@ -0,0 +1,97 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	util "github.com/datatogether/api/apiutil"
+	"github.com/qri-io/qri/lib"
+	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/repo"
+)
+
+// FollowHandlers wraps a requests struct to interface with http.HandlerFunc
+type FollowHandlers struct {
+	lib.FollowRequests
+	ReadOnly bool
+}
+
+// NewFollowHandlers allocates a FollowHandlers pointer
+func NewFollowHandlers(node *p2p.QriNode, readOnly bool) *FollowHandlers {
+	req := lib.NewFollowRequests(node, nil)
+	return &FollowHandlers{*req, readOnly}
+}
+
+// FollowsHandler is the endpoint for listing follows
+func (h *FollowHandlers) FollowsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "GET":
+		h.listFollowsHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+// FollowHandler is the endpoint for a single follow. POST
+// /follow/[peername] or /follow/[peername]/[name] follows a peer or dataset,
+// DELETE unfollows
+func (h *FollowHandlers) FollowHandler(w http.ResponseWriter, r *http.Request) {
+	ref := strings.Trim(r.URL.Path[len("/follow"):], "/")
+
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "POST", "PUT":
+		if h.ReadOnly {
+			readOnlyResponse(w, "/follow/")
+			return
+		}
+		h.followHandler(w, ref)
+	case "DELETE":
+		if h.ReadOnly {
+			readOnlyResponse(w, "/follow/")
+			return
+		}
+		h.unfollowHandler(w, ref)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+func (h *FollowHandlers) listFollowsHandler(w http.ResponseWriter, r *http.Request) {
+	var done bool
+	res := []*repo.Follow{}
+	if err := h.List(&done, &res); err != nil {
+		log.Infof("error listing follows: %s", err.Error())
+		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	util.WriteResponse(w, res)
+}
+
+func (h *FollowHandlers) followHandler(w http.ResponseWriter, ref string) {
+	res := &repo.Follow{}
+	if err := h.Follow(&ref, res); err != nil {
+		log.Infof("error following %s: %s", ref, err.Error())
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	util.WriteResponse(w, res)
+}
+
+func (h *FollowHandlers) unfollowHandler(w http.ResponseWriter, ref string) {
+	var ok bool
+	if err := h.Unfollow(&ref, &ok); err != nil {
+		// lib errors report the error they wrap
+		if err.Error() == repo.ErrNotFound.Error() {
+			util.WriteErrResponse(w, http.StatusNotFound, err)
+			return
+		}
+		log.Infof("error unfollowing %s: %s", ref, err.Error())
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	util.WriteResponse(w, ref)
+}
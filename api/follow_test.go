@@ -0,0 +1,46 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFollowHandlers(t *testing.T) {
+	node, teardown := newTestNode(t)
+	defer teardown()
+
+	cases := []struct {
+		method, endpoint string
+		readOnly         bool
+		status           int
+	}{
+		{"OPTIONS", "/follow", false, http.StatusOK},
+		{"POST", "/follow", false, http.StatusNotFound},
+		{"GET", "/follow", false, http.StatusOK},
+		{"POST", "/follow/b5", true, http.StatusForbidden},
+		{"POST", "/follow/b5", false, http.StatusOK},
+		{"PUT", "/follow/b5/world_bank_population", false, http.StatusOK},
+		{"POST", "/follow/", false, http.StatusBadRequest},
+		{"GET", "/follow", false, http.StatusOK},
+		{"DELETE", "/follow/b5", true, http.StatusForbidden},
+		{"DELETE", "/follow/b5", false, http.StatusOK},
+		{"DELETE", "/follow/b5", false, http.StatusNotFound},
+		{"GET", "/follow/b5", false, http.StatusNotFound},
+	}
+
+	for i, c := range cases {
+		h := NewFollowHandlers(node, c.readOnly)
+		req := httptest.NewRequest(c.method, c.endpoint, nil)
+		w := httptest.NewRecorder()
+		if req.URL.Path == "/follow" {
+			h.FollowsHandler(w, req)
+		} else {
+			h.FollowHandler(w, req)
+		}
+
+		if w.Code != c.status {
+			t.Errorf("case %d: %s %s status mismatch. expected: %d, got: %d", i, c.method, c.endpoint, c.status, w.Code)
+		}
+	}
+}
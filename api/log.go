@@ -49,6 +49,11 @@ func (h *LogHandlers) logHandler(w http.ResponseWriter, r *http.Request) {
 	lp := lib.ListParamsFromRequest(r)
 	lp.Peername = args.Peername
 
+	if r.FormValue("events") == "true" {
+		h.eventsHandler(w, r, args, lp)
+		return
+	}
+
 	params := &lib.LogParams{
 		ListParams: lp,
 		Ref:        args,
@@ -62,3 +67,20 @@ func (h *LogHandlers) logHandler(w http.ResponseWriter, r *http.Request) {
 
 	util.WritePageResponse(w, res, r, params.Page())
 }
+
+// eventsHandler responds with repo events for a dataset instead of versions
+func (h *LogHandlers) eventsHandler(w http.ResponseWriter, r *http.Request, ref repo.DatasetRef, lp lib.ListParams) {
+	params := &lib.EventsParams{
+		ListParams: lp,
+		Ref:        ref,
+		Type:       repo.EventType(r.FormValue("type")),
+	}
+
+	res := []*repo.Event{}
+	if err := h.Events(params, &res); err != nil {
+		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	util.WritePageResponse(w, res, r, params.Page())
+}
@@ -1,6 +1,9 @@
 package api
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/qri-io/dataset"
@@ -38,3 +41,31 @@ func TestHistoryHandlers(t *testing.T) {
 	}
 	runHandlerTestCases(t, "log", h.LogHandler, logCases)
 }
+
+func TestHistoryEvents(t *testing.T) {
+	node, teardown := newTestNode(t)
+	defer teardown()
+
+	if err := node.Repo.LogEvent(repo.ETDsPinned, repo.DatasetRef{Peername: "peer", Name: "cities"}); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	h := NewLogHandlers(node)
+	req := httptest.NewRequest("GET", "/history/me/cities?events=true&type=ds_pinned", nil)
+	w := httptest.NewRecorder()
+	h.LogHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status mismatch. expected: %d, got: %d", http.StatusOK, w.Code)
+	}
+
+	res := struct {
+		Data []*repo.Event `json:"data"`
+	}{}
+	if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(res.Data) != 1 || res.Data[0].Type != repo.ETDsPinned {
+		t.Errorf("expected one pin event, got: %v", res.Data)
+	}
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	util "github.com/datatogether/api/apiutil"
+	"github.com/qri-io/qri/lib"
+	"github.com/qri-io/qri/p2p"
+)
+
+// progressKeepAlive is how often an idle progress stream sends a comment to
+// keep the connection open
+var progressKeepAlive = time.Second * 15
+
+// ProgressHandlers wraps a requests struct to interface with http.HandlerFunc
+type ProgressHandlers struct {
+	lib.ProgressRequests
+}
+
+// NewProgressHandlers allocates a ProgressHandlers pointer
+func NewProgressHandlers(node *p2p.QriNode) *ProgressHandlers {
+	req := lib.NewProgressRequests(node, nil)
+	return &ProgressHandlers{*req}
+}
+
+// ProgressHandler is the endpoint for following the progress of long-running
+// operations as Server-Sent Events
+func (h *ProgressHandlers) ProgressHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "GET":
+		h.progressHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+// progressHandler streams progress updates until the client disconnects.
+// Each update is an event with the update's sequence number as it's id, so
+// reconnecting clients pick up where they left off with the Last-Event-ID
+// header. Clients can also pick a starting point with the "since" param.
+// Without either the stream starts with the next update
+func (h *ProgressHandlers) progressHandler(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		util.WriteErrResponse(w, http.StatusInternalServerError, fmt.Errorf("streaming isn't supported"))
+		return
+	}
+
+	since := int64(-1)
+	for _, s := range []string{r.Header.Get("Last-Event-ID"), r.FormValue("since")} {
+		if s == "" {
+			continue
+		}
+		seq, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			util.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("invalid sequence number: '%s'", s))
+			return
+		}
+		since = seq
+		break
+	}
+
+	if since < 0 {
+		res := &lib.ProgressUpdates{}
+		if err := h.ProgressRequests.Poll(&lib.ProgressParams{Since: -1}, res); err != nil {
+			util.WriteErrResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+		since = res.Seq
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ctx := r.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		res := &lib.ProgressUpdates{}
+		if err := h.ProgressRequests.Poll(&lib.ProgressParams{Since: since, Wait: progressKeepAlive}, res); err != nil {
+			log.Infof("progress error: %s", err.Error())
+			return
+		}
+		if len(res.Updates) == 0 {
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+		for _, u := range res.Updates {
+			data, err := json.Marshal(u)
+			if err != nil {
+				log.Infof("progress error: %s", err.Error())
+				return
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: progress\ndata: %s\n\n", u.Seq, data); err != nil {
+				return
+			}
+		}
+		since = res.Seq
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qri-io/qri/progress"
+)
+
+func TestProgressHandlers(t *testing.T) {
+	node, teardown := newTestNode(t)
+	defer teardown()
+
+	prevKeepAlive := progressKeepAlive
+	progressKeepAlive = time.Millisecond * 10
+	defer func() { progressKeepAlive = prevKeepAlive }()
+
+	h := NewProgressHandlers(node)
+
+	start := node.Progress.Seq()
+	task := progress.NewTask(node.Progress, "counting", progress.Steps, 2)
+	task.Add(2)
+	task.Finish(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest("GET", "/progress", nil).WithContext(ctx)
+	req.Header.Set("Last-Event-ID", strconv.FormatInt(start, 10))
+	w := httptest.NewRecorder()
+
+	done := make(chan struct{})
+	go func() {
+		h.ProgressHandler(w, req)
+		close(done)
+	}()
+	time.Sleep(time.Millisecond * 50)
+	cancel()
+	<-done
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status ok, got: %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("expected event stream content type, got: %s", ct)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "event: progress\n") {
+		t.Errorf("expected progress events, got: %s", body)
+	}
+	if !strings.Contains(body, fmt.Sprintf("id: %d\n", start+1)) {
+		t.Errorf("expected stream to start after Last-Event-ID, got: %s", body)
+	}
+	if !strings.Contains(body, `"name":"counting"`) || !strings.Contains(body, `"complete":true`) {
+		t.Errorf("expected task updates in stream, got: %s", body)
+	}
+
+	req = httptest.NewRequest("GET", "/progress?since=nope", nil)
+	w = httptest.NewRecorder()
+	h.ProgressHandler(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected invalid since param to be a bad request, got: %d", w.Code)
+	}
+
+	req = httptest.NewRequest("DELETE", "/progress", nil)
+	w = httptest.NewRecorder()
+	h.ProgressHandler(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected DELETE to 404, got: %d", w.Code)
+	}
+}
@@ -1,9 +1,11 @@
 package api
 
 import (
+	"bytes"
 	"net/http"
 
 	"github.com/datatogether/api/apiutil"
+	"github.com/qri-io/qri/actions"
 	"github.com/qri-io/qri/lib"
 	"github.com/qri-io/qri/repo"
 )
@@ -34,20 +36,64 @@ func (h *RenderHandlers) RenderHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	format, err := actions.ParseRenderFormat(r.FormValue("format"))
+	if err != nil {
+		apiutil.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// render every entry unless a page of entries is requested
+	limit, err := apiutil.ReqParamInt("limit", r)
+	all := err != nil
+	if all {
+		limit = 0
+	}
+	offset, err := apiutil.ReqParamInt("offset", r)
+	if err != nil {
+		offset = 0
+	}
+	if r.FormValue("all") != "" {
+		all = r.FormValue("all") == "true"
+	}
+
 	p := &lib.RenderParams{
-		Ref:            args,
-		TemplateFormat: "html",
-		// TODO - parameterize
-		All:    true,
-		Limit:  0,
-		Offset: 0,
+		Ref:          args,
+		TemplateName: r.FormValue("template"),
+		All:          all,
+		Limit:        limit,
+		Offset:       offset,
+		Stream:       r.FormValue("stream") == "true",
+	}
+	if r.FormValue("format") != "" {
+		p.TemplateFormat = string(format)
 	}
 
-	data := []byte{}
-	if err := h.Render(p, &data); err != nil {
-		apiutil.WriteErrResponse(w, http.StatusInternalServerError, err)
+	if !p.Stream {
+		buf := &bytes.Buffer{}
+		if err := h.RenderTo(buf, p); err != nil {
+			apiutil.WriteErrResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+		w.Header().Set("Content-Type", renderContentType(format))
+		w.Write(buf.Bytes())
 		return
 	}
 
-	w.Write(data)
+	// streamed renders write output as it's rendered, errors after the first
+	// write can only be logged
+	w.Header().Set("Content-Type", renderContentType(format))
+	if err := h.RenderTo(w, p); err != nil {
+		log.Infof("error streaming render: %s", err.Error())
+	}
+}
+
+func renderContentType(f actions.RenderFormat) string {
+	switch f {
+	case actions.RenderMarkdown:
+		return "text/markdown; charset=utf-8"
+	case actions.RenderText:
+		return "text/plain; charset=utf-8"
+	default:
+		return "text/html; charset=utf-8"
+	}
 }
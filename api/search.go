@@ -38,6 +38,7 @@ func (h *SearchHandlers) searchHandler(w http.ResponseWriter, r *http.Request) {
 		QueryString: r.FormValue("q"),
 		Limit:       100,
 		Offset:      0,
+		Network:     r.FormValue("network") == "true",
 	}
 
 	if r.Header.Get("Content-Type") == "application/json" {
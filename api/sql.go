@@ -0,0 +1,80 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	util "github.com/datatogether/api/apiutil"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/lib"
+	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/sql"
+)
+
+// QueryHandlers wraps a requests struct to interface with http.HandlerFunc
+type QueryHandlers struct {
+	lib.QueryRequests
+	ReadOnly bool
+}
+
+// NewQueryHandlers allocates a QueryHandlers pointer
+func NewQueryHandlers(node *p2p.QriNode, readOnly bool) *QueryHandlers {
+	req := lib.NewQueryRequests(node, nil)
+	return &QueryHandlers{*req, readOnly}
+}
+
+// QueryResponse is the result of a sql query
+type QueryResponse struct {
+	Columns []sql.Column     `json:"columns"`
+	Data    json.RawMessage  `json:"data"`
+	Ref     *repo.DatasetRef `json:"ref,omitempty"`
+}
+
+// SQLHandler is the endpoint for running sql queries against datasets
+func (h *QueryHandlers) SQLHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "GET", "POST":
+		h.sqlHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+func (h *QueryHandlers) sqlHandler(w http.ResponseWriter, r *http.Request) {
+	p := &lib.QueryParams{
+		Query:  r.FormValue("q"),
+		SaveAs: r.FormValue("save"),
+	}
+
+	if r.Header.Get("Content-Type") == "application/json" {
+		if err := json.NewDecoder(r.Body).Decode(p); err != nil {
+			util.WriteErrResponse(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	if p.SaveAs != "" && h.ReadOnly {
+		readOnlyResponse(w, "/sql")
+		return
+	}
+
+	// results are always embedded in a json response
+	p.Format = dataset.JSONDataFormat
+	p.FormatConfig = nil
+
+	res := &lib.QueryResult{}
+	if err := h.Query(p, res); err != nil {
+		log.Infof("sql error: %s", err.Error())
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	util.WriteResponse(w, QueryResponse{
+		Columns: res.Columns,
+		Data:    json.RawMessage(res.Data),
+		Ref:     res.Ref,
+	})
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestQueryHandlers(t *testing.T) {
+	node, teardown := newTestNode(t)
+	defer teardown()
+
+	cases := []struct {
+		method, endpoint string
+		body             string
+		readOnly         bool
+		status           int
+	}{
+		{"OPTIONS", "/sql", "", false, http.StatusOK},
+		{"DELETE", "/sql", "", false, http.StatusNotFound},
+		{"GET", "/sql?q=SELECT+city+FROM+peer/cities+WHERE+pop+>+1000000", "", false, http.StatusOK},
+		{"GET", "/sql?q=SELECT+nope+FROM+peer/cities", "", false, http.StatusBadRequest},
+		{"POST", "/sql", `{"query":"SELECT count(*) FROM peer/cities","saveAs":"city_count"}`, true, http.StatusForbidden},
+		{"POST", "/sql", `{"query":"SELECT count(*) FROM peer/cities","saveAs":"city_count"}`, false, http.StatusOK},
+	}
+
+	for i, c := range cases {
+		h := NewQueryHandlers(node, c.readOnly)
+		req := httptest.NewRequest(c.method, c.endpoint, bytes.NewBufferString(c.body))
+		if c.body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		w := httptest.NewRecorder()
+		h.SQLHandler(w, req)
+
+		if w.Code != c.status {
+			t.Errorf("case %d: %s %s status mismatch. expected: %d, got: %d", i, c.method, c.endpoint, c.status, w.Code)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/sql?q=SELECT+city+FROM+peer/cities+WHERE+pop+>+1000000+ORDER+BY+city", nil)
+	w := httptest.NewRecorder()
+	NewQueryHandlers(node, false).SQLHandler(w, req)
+
+	res := struct {
+		Data QueryResponse `json:"data"`
+	}{}
+	if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+		t.Fatal(err.Error())
+	}
+	if string(res.Data.Data) != `[["new york"],["toronto"]]` {
+		t.Errorf("data mismatch. got: %s", string(res.Data.Data))
+	}
+}
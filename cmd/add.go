@@ -37,7 +37,8 @@ the name of the peer that originally added the dataset.`,
 // AddOptions encapsulates state for the add command
 type AddOptions struct {
 	IOStreams
-	DatasetRequests *lib.DatasetRequests
+	DatasetRequests  *lib.DatasetRequests
+	ProgressRequests *lib.ProgressRequests
 }
 
 // Complete adds any missing configuration that can only be added just before calling Run
@@ -45,6 +46,9 @@ func (o *AddOptions) Complete(f Factory) (err error) {
 	if o.DatasetRequests, err = f.DatasetRequests(); err != nil {
 		return
 	}
+	if o.ProgressRequests, err = f.ProgressRequests(); err != nil {
+		return
+	}
 	return nil
 }
 
@@ -57,7 +61,10 @@ func (o *AddOptions) Run(args []string) error {
 		}
 
 		res := repo.DatasetRef{}
-		if err = o.DatasetRequests.Add(&ref, &res); err != nil {
+		stop := watchProgress(o.ErrOut, o.ProgressRequests)
+		err = o.DatasetRequests.Add(&ref, &res)
+		stop()
+		if err != nil {
 			return err
 		}
 
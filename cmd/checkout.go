@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/qri-io/qri/lib"
+	"github.com/qri-io/qri/repo"
+	"github.com/spf13/cobra"
+)
+
+// NewCheckoutCommand creates a new `qri checkout` cobra command for writing a
+// dataset to a working directory
+func NewCheckoutCommand(f Factory, ioStreams IOStreams) *cobra.Command {
+	o := &CheckoutOptions{IOStreams: ioStreams}
+	cmd := &cobra.Command{
+		Use:   "checkout",
+		Short: "Write a dataset to a directory for editing",
+		Long: `
+Checkout writes a dataset to a directory so you can edit it with the tools you
+already use. The directory will contain:
+
+  dataset.yaml    meta, structure, transform & viz configuration
+  body.<format>   the dataset body, in it's original format
+  transform.sky   the transform script, if the dataset has one
+  viz.html        the viz template, if the dataset has one
+
+Checkout also writes a hidden file linking the directory to the version of the
+dataset that was checked out. Run ` + "`qri status`" + ` from the directory to see
+what's changed, and ` + "`qri save`" + ` to save changes as a new version.`,
+		Example: `  # check out annual_pop into a directory named annual_pop:
+  qri checkout me/annual_pop annual_pop
+
+  # see what's changed:
+  cd annual_pop
+  qri status
+
+  # save changes:
+  qri save --title "updated population counts"`,
+		Annotations: map[string]string{
+			"group": "dataset",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(f, args); err != nil {
+				return err
+			}
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			return o.Run()
+		},
+	}
+
+	return cmd
+}
+
+// CheckoutOptions encapsulates state for the checkout command
+type CheckoutOptions struct {
+	IOStreams
+
+	Ref string
+	Dir string
+
+	DatasetRequests *lib.DatasetRequests
+}
+
+// Complete adds any missing configuration that can only be added just before calling Run
+func (o *CheckoutOptions) Complete(f Factory, args []string) (err error) {
+	if len(args) > 0 {
+		o.Ref = args[0]
+	}
+	if len(args) > 1 {
+		o.Dir = args[1]
+	}
+	o.DatasetRequests, err = f.DatasetRequests()
+	return
+}
+
+// Validate checks that all user input is valid
+func (o *CheckoutOptions) Validate() error {
+	if o.Ref == "" || o.Dir == "" {
+		return lib.NewError(lib.ErrBadArgs, "please provide a dataset reference and a directory to check out to, for example:\n    $ qri checkout me/dataset dataset_dir\nsee `qri checkout --help` for more details")
+	}
+	return nil
+}
+
+// Run executes the checkout command
+func (o *CheckoutOptions) Run() error {
+	ref, err := parseCmdLineDatasetRef(o.Ref)
+	if err != nil {
+		return err
+	}
+	// the directory may be written by a qri connect process in another location
+	dir, err := filepath.Abs(o.Dir)
+	if err != nil {
+		return err
+	}
+
+	res := &repo.DatasetRef{}
+	if err = o.DatasetRequests.Checkout(&lib.CheckoutParams{Ref: ref, Dir: dir}, res); err != nil {
+		return err
+	}
+
+	printSuccess(o.Out, "checked out %s to %s", res.AliasString(), o.Dir)
+	printInfo(o.Out, "path: %s", res.Path)
+	return nil
+}
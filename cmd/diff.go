@@ -23,7 +23,10 @@ the same dataset.`,
   me/annual_pop@/ipfs/QmVvqsge5wqp4piJbLArwVB6iJSTrdM8ZRpHY7fikASrr8
 
   show diff between two different datasets:
-  $ qri diff me/population_2016 me/population_2017`,
+  $ qri diff me/population_2016 me/population_2017
+
+  show row-level changes between dataset bodies, matching rows by the "city" column:
+  $ qri diff --body --key city me/cities_2016 me/cities_2017`,
 		Annotations: map[string]string{
 			"group": "dataset",
 		},
@@ -36,6 +39,9 @@ the same dataset.`,
 	}
 
 	cmd.Flags().StringVarP(&o.Display, "display", "d", "", "set display format [reg|short|delta|detail]")
+	cmd.Flags().BoolVarP(&o.Body, "body", "b", false, "show row-level differences between dataset bodies")
+	cmd.Flags().StringSliceVar(&o.PrimaryKey, "key", nil, "columns to match body rows by, overrides any schema primaryKey")
+	cmd.Flags().IntVarP(&o.Limit, "limit", "l", 0, "max number of changed body rows to show")
 	// datasetDiffCmd.Flags().BoolP("color", "c", false, "set ")
 
 	return cmd
@@ -45,9 +51,12 @@ the same dataset.`,
 type DiffOptions struct {
 	IOStreams
 
-	Display string
-	Left    string
-	Right   string
+	Display    string
+	Left       string
+	Right      string
+	Body       bool
+	PrimaryKey []string
+	Limit      int
 
 	UsingRPC        bool
 	DatasetRequests *lib.DatasetRequests
@@ -82,17 +91,25 @@ func (o *DiffOptions) Run() error {
 		return err
 	}
 
-	diffs := make(map[string]*dsdiff.SubDiff)
+	res := &lib.DiffResponse{}
 	p := &lib.DiffParams{
-		Left:    left,
-		Right:   right,
-		DiffAll: true,
+		Left:       left,
+		Right:      right,
+		DiffAll:    !o.Body,
+		DiffBody:   o.Body,
+		PrimaryKey: o.PrimaryKey,
+		MaxChanges: o.Limit,
 	}
 
-	if err = o.DatasetRequests.Diff(p, &diffs); err != nil {
+	if err = o.DatasetRequests.Diff(p, res); err != nil {
 		return err
 	}
 
+	if o.Body {
+		printBodyDiff(o.Out, res.Body)
+		return nil
+	}
+
 	displayFormat := "listKeys"
 	switch o.Display {
 	case "reg", "regular":
@@ -105,7 +122,7 @@ func (o *DiffOptions) Run() error {
 		displayFormat = "plusMinus"
 	}
 
-	result, err := dsdiff.MapDiffsToString(diffs, displayFormat)
+	result, err := dsdiff.MapDiffsToString(res.Components, displayFormat)
 	if err != nil {
 		return err
 	}
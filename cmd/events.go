@@ -0,0 +1,215 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/qri-io/qri/actions"
+	"github.com/qri-io/qri/lib"
+	"github.com/qri-io/qri/repo"
+	"github.com/spf13/cobra"
+)
+
+// NewEventsCommand creates a new `qri events` cobra command for showing the
+// audit trail of actions recorded in a repo's event log
+func NewEventsCommand(f Factory, ioStreams IOStreams) *cobra.Command {
+	o := &EventsOptions{IOStreams: ioStreams}
+	cmd := &cobra.Command{
+		Use:   "events",
+		Short: "Show actions recorded in the repo event log",
+		Long: `
+Events prints the activity recorded in your repo's event log, most recent
+first. Every action that changes a dataset, like saving, renaming, pinning,
+running a transform or publishing, records who performed it, when, and details
+like the previous path or name of the dataset.
+
+Pass a dataset reference to only show events for that dataset. Use the flags
+to filter by event type, the user that performed an action, or a window of
+time. Times are either dates (2006-01-02) or RFC3339 timestamps.
+
+Use --reconcile with a peer ID to bring your repo in line with a connected
+peer's. Datasets the peer created, renamed, reset or deleted since your event
+logs diverged are applied to your repo. Changes both of you made to the same
+dataset are listed as conflicts & left for you to resolve.`,
+		Example: `  show recent events:
+  $ qri events
+
+  show renames of b5/precip:
+  $ qri events --type ds_renamed b5/precip
+
+  show everything b5 did in March:
+  $ qri events --actor b5 --since 2018-03-01 --until 2018-04-01
+
+  apply dataset changes from a mirrored repo:
+  $ qri events --reconcile QmZePf5LeXow3RW5U1AgEiNbW46YnRGhZ7HPvm1UmPFPwt`,
+		Annotations: map[string]string{
+			"group": "dataset",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(f, args); err != nil {
+				return err
+			}
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			return o.Run()
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.Type, "type", "t", "", "only show events of this type")
+	cmd.Flags().StringVarP(&o.Actor, "actor", "a", "", "only show events performed by this peername, profileID or peerID")
+	cmd.Flags().StringVar(&o.Since, "since", "", "only show events at or after this time")
+	cmd.Flags().StringVar(&o.Until, "until", "", "only show events at or before this time")
+	cmd.Flags().StringVarP(&o.Format, "format", "f", "", "set output format [json]")
+	cmd.Flags().StringVar(&o.Reconcile, "reconcile", "", "apply dataset changes from the event log of the peer with this peer ID")
+	cmd.Flags().IntVarP(&o.Limit, "limit", "l", 25, "limit results, default 25")
+	cmd.Flags().IntVarP(&o.Offset, "offset", "o", 0, "offset results, default 0")
+
+	return cmd
+}
+
+// EventsOptions encapsulates state for the events command
+type EventsOptions struct {
+	IOStreams
+
+	Ref    string
+	Type   string
+	Actor  string
+	Since  string
+	Until  string
+	Format string
+	Limit  int
+	Offset int
+
+	Reconcile string
+
+	LogRequests *lib.LogRequests
+}
+
+// Complete adds any missing configuration that can only be added just before calling Run
+func (o *EventsOptions) Complete(f Factory, args []string) (err error) {
+	if len(args) > 0 {
+		o.Ref = args[0]
+	}
+	o.LogRequests, err = f.LogRequests()
+	return
+}
+
+// Validate checks that all user input is valid
+func (o *EventsOptions) Validate() error {
+	if o.Format != "" && o.Format != "json" {
+		return lib.NewError(lib.ErrBadArgs, fmt.Sprintf("invalid format '%s', only json is supported", o.Format))
+	}
+	if _, err := parseEventTime(o.Since); err != nil {
+		return lib.NewError(lib.ErrBadArgs, fmt.Sprintf("invalid --since time '%s', use a date like 2006-01-02 or an RFC3339 timestamp", o.Since))
+	}
+	if _, err := parseEventTime(o.Until); err != nil {
+		return lib.NewError(lib.ErrBadArgs, fmt.Sprintf("invalid --until time '%s', use a date like 2006-01-02 or an RFC3339 timestamp", o.Until))
+	}
+	return nil
+}
+
+// Run executes the events command
+func (o *EventsOptions) Run() error {
+	if o.Reconcile != "" {
+		return o.reconcile()
+	}
+
+	ref, err := repo.ParseDatasetRef(o.Ref)
+	if err != nil && err != repo.ErrEmptyRef {
+		return err
+	}
+
+	p := &lib.EventsParams{
+		Ref:   ref,
+		Type:  repo.EventType(o.Type),
+		Actor: o.Actor,
+		ListParams: lib.ListParams{
+			Limit:  o.Limit,
+			Offset: o.Offset,
+		},
+	}
+	p.Since, _ = parseEventTime(o.Since)
+	p.Until, _ = parseEventTime(o.Until)
+
+	events := []*repo.Event{}
+	if err := o.LogRequests.Events(p, &events); err != nil {
+		return err
+	}
+
+	if o.Format == "json" {
+		data, err := json.MarshalIndent(events, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(o.Out, string(data))
+		return nil
+	}
+
+	for _, e := range events {
+		printSuccess(o.Out, "%s - %s %s", e.Time.Format("Jan _2 15:04:05"), e.Type, e.Ref.String())
+		if actor := e.Actor(); actor != "" {
+			printInfo(o.Out, "\tby: %s", actor)
+		}
+		if e.Params != nil {
+			if e.Params.PrevName != "" {
+				printInfo(o.Out, "\tprevious name: %s", e.Params.PrevName)
+			}
+			if e.Params.PrevPath != "" {
+				printInfo(o.Out, "\tprevious path: %s", e.Params.PrevPath)
+			}
+			keys := make([]string, 0, len(e.Params.Details))
+			for key := range e.Params.Details {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				printInfo(o.Out, "\t%s: %v", key, e.Params.Details[key])
+			}
+		}
+	}
+	return nil
+}
+
+// reconcile applies changes from a peer's event log, printing what was applied
+// & any conflicts
+func (o *EventsOptions) reconcile() error {
+	res := &actions.EventsReconciliation{}
+	if err := o.LogRequests.ReconcileEvents(&lib.ReconcileEventsParams{PeerID: o.Reconcile}, res); err != nil {
+		return err
+	}
+
+	if o.Format == "json" {
+		data, err := json.MarshalIndent(res, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(o.Out, string(data))
+		return nil
+	}
+
+	for _, e := range res.Applied {
+		printSuccess(o.Out, "applied %s %s", e.Type, e.Ref.String())
+	}
+	for _, c := range res.Conflicts {
+		printWarning(o.Out, "conflict: %s", c.Reason)
+	}
+	printInfo(o.Out, "applied %d events, %d conflicts", len(res.Applied), len(res.Conflicts))
+	return nil
+}
+
+// parseEventTime parses a date or RFC3339 timestamp. An empty string is the
+// zero time
+func parseEventTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", s)
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/qri-io/qri/lib"
+)
+
+func TestEventsValidate(t *testing.T) {
+	cases := []struct {
+		format, since, until string
+		err                  string
+		msg                  string
+	}{
+		{"", "", "", "", ""},
+		{"json", "2018-03-01", "2018-04-01T12:00:00Z", "", ""},
+		{"csv", "", "", lib.ErrBadArgs.Error(), "invalid format 'csv', only json is supported"},
+		{"", "last tuesday", "", lib.ErrBadArgs.Error(), "invalid --since time 'last tuesday', use a date like 2006-01-02 or an RFC3339 timestamp"},
+		{"", "", "2018-13-01", lib.ErrBadArgs.Error(), "invalid --until time '2018-13-01', use a date like 2006-01-02 or an RFC3339 timestamp"},
+	}
+	for i, c := range cases {
+		opt := &EventsOptions{
+			Format: c.format,
+			Since:  c.since,
+			Until:  c.until,
+		}
+
+		err := opt.Validate()
+		if (err == nil && c.err != "") || (err != nil && c.err != err.Error()) {
+			t.Errorf("case %d, mismatched error. Expected: %s, Got: %s", i, c.err, err)
+			continue
+		}
+		if libErr, ok := err.(lib.Error); ok {
+			if libErr.Message() != c.msg {
+				t.Errorf("case %d, mismatched user-friendly message. Expected: '%s', Got: '%s'", i, c.msg, libErr.Message())
+				continue
+			}
+		} else if c.msg != "" {
+			t.Errorf("case %d, mismatched user-friendly message. Expected: '%s', Got: ''", i, c.msg)
+			continue
+		}
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/qri-io/dataset/dsutil"
 	"github.com/qri-io/qri/lib"
 	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/archive"
 	"github.com/qri-io/qri/repo/profile"
 	"github.com/spf13/cobra"
 )
@@ -32,6 +33,10 @@ Export gets datasets out of qri. By default it exports only the dataset body.
 
 To export to a specific directory, use the --output flag.
 
+Use --history to export every version of a dataset to a single archive file,
+one directory per version. Archives are tar.gz files, or zip files with --zip.
+Use ` + "`qri import`" + ` to recreate the dataset & it's history in another repo.
+
 If you want an empty dataset that can be filled in with details to create a
 new dataset, use --blank.`,
 		Example: `  # export dataset
@@ -41,7 +46,10 @@ new dataset, use --blank.`,
   qri export --no-body me/annual_pop
 
   # export to a specific directory
-  qri export -o ~/new_directory me/annual_pop`,
+  qri export -o ~/new_directory me/annual_pop
+
+  # export the full history of a dataset as a zip archive
+  qri export --history --zip me/annual_pop`,
 		Annotations: map[string]string{
 			"group": "dataset",
 		},
@@ -59,7 +67,8 @@ new dataset, use --blank.`,
 	cmd.Flags().StringVarP(&o.BodyFormat, "body-format", "", "", "format for dataset body. default is the original data format. options: json, csv, cbor")
 	cmd.Flags().BoolVarP(&o.NoBody, "no-body", "b", false, "don't include dataset body in export")
 	cmd.Flags().BoolVarP(&o.PeerDir, "peer-dir", "d", false, "export to a peer name namespaced directory")
-	// cmd.Flags().BoolVarP(&o.Zipped, "zip", "z", false, "compress export as zip archive, export all parts of dataset, data in original format")
+	cmd.Flags().BoolVarP(&o.Zipped, "zip", "z", false, "compress export as zip archive, export all parts of dataset, data in original format")
+	cmd.Flags().BoolVarP(&o.History, "history", "", false, "export every version of the dataset to an archive")
 	// exportCmd.Flags().BoolVarP(&exportCmdVis, "vis-conf", "c", false, "export viz config file")
 
 	return cmd
@@ -72,6 +81,7 @@ type ExportOptions struct {
 	Ref        string
 	PeerDir    bool
 	Zipped     bool
+	History    bool
 	Blank      bool
 	Output     string
 	Format     string
@@ -154,6 +164,10 @@ func (o *ExportOptions) Run() error {
 	}
 	path = filepath.Join(path, dsr.Name)
 
+	if o.History {
+		return o.exportHistory(dsr, path)
+	}
+
 	if o.Zipped {
 		dst, err := os.Create(fmt.Sprintf("%s.zip", path))
 		if err != nil {
@@ -253,6 +267,29 @@ func (o *ExportOptions) Run() error {
 	return nil
 }
 
+// exportHistory writes every version of a dataset to an archive file
+func (o *ExportOptions) exportHistory(ref repo.DatasetRef, path string) error {
+	format := archive.FormatTarGz
+	if o.Zipped {
+		format = archive.FormatZip
+	}
+	p := &lib.ExportHistoryParams{
+		Ref:    ref,
+		Path:   path + format.Ext(),
+		Format: format,
+	}
+	if err := os.MkdirAll(filepath.Dir(p.Path), os.ModePerm); err != nil {
+		return err
+	}
+
+	man := &archive.Manifest{}
+	if err := o.DatasetRequests.ExportHistory(p, man); err != nil {
+		return err
+	}
+	printSuccess(o.Out, "exported %d versions to: %s", man.Versions, p.Path)
+	return nil
+}
+
 const blankYamlDataset = `# This file defines a qri dataset. Change this file, save it, then from a terminal run:
 # $ qri add --file=dataset.yaml
 # For more info check out https://qri.io/docs
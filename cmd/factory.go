@@ -32,6 +32,12 @@ type Factory interface {
 	SearchRequests() (*lib.SearchRequests, error)
 	RenderRequests() (*lib.RenderRequests, error)
 	SelectionRequests() (*lib.SelectionRequests, error)
+	RepoRequests() (*lib.RepoRequests, error)
+	QueryRequests() (*lib.QueryRequests, error)
+	ChangeRequests() (*lib.ChangeRequests, error)
+	SiteRequests() (*lib.SiteRequests, error)
+	FollowRequests() (*lib.FollowRequests, error)
+	ProgressRequests() (*lib.ProgressRequests, error)
 }
 
 // PathFactory is a function that returns paths to qri & ipfs repos
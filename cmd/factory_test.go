@@ -128,6 +128,36 @@ func (t TestFactory) RenderRequests() (*lib.RenderRequests, error) {
 	return lib.NewRenderRequests(t.repo, t.rpc), nil
 }
 
+// RepoRequests generates a lib.RepoRequests from internal state
+func (t TestFactory) RepoRequests() (*lib.RepoRequests, error) {
+	return lib.NewRepoRequests(t.node, t.rpc), nil
+}
+
+// QueryRequests generates a lib.QueryRequests from internal state
+func (t TestFactory) QueryRequests() (*lib.QueryRequests, error) {
+	return lib.NewQueryRequests(t.node, t.rpc), nil
+}
+
+// ChangeRequests generates a lib.ChangeRequests from internal state
+func (t TestFactory) ChangeRequests() (*lib.ChangeRequests, error) {
+	return lib.NewChangeRequests(t.node, t.rpc), nil
+}
+
+// SiteRequests generates a lib.SiteRequests from internal state
+func (t TestFactory) SiteRequests() (*lib.SiteRequests, error) {
+	return lib.NewSiteRequests(t.node, t.rpc), nil
+}
+
+// FollowRequests generates a lib.FollowRequests from internal state
+func (t TestFactory) FollowRequests() (*lib.FollowRequests, error) {
+	return lib.NewFollowRequests(t.node, t.rpc), nil
+}
+
+// ProgressRequests generates a lib.ProgressRequests from internal state
+func (t TestFactory) ProgressRequests() (*lib.ProgressRequests, error) {
+	return lib.NewProgressRequests(t.node, t.rpc), nil
+}
+
 func TestEnvPathFactory(t *testing.T) {
 	//Needed to clean up changes after the test has finished running
 	prevQRIPath := os.Getenv("QRI_PATH")
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/qri-io/qri/lib"
+	"github.com/qri-io/qri/repo"
+	"github.com/spf13/cobra"
+)
+
+// NewFollowCommand creates a `qri follow` command that replicates datasets of
+// followed peers
+func NewFollowCommand(f Factory, ioStreams IOStreams) *cobra.Command {
+	o := &FollowOptions{IOStreams: ioStreams}
+	cmd := &cobra.Command{
+		Use:   "follow [PEERNAME | PEERNAME/DATASET]...",
+		Short: "Follow peers & datasets, keeping a copy of their latest versions",
+		Long: `
+Follow adds peers or single datasets to your list of follows. While connected
+(qri connect), your node adds new versions of followed datasets as followed
+peers announce them, and checks for versions it missed when a followed peer
+connects.
+
+How much of each version is kept depends on the p2p.followreplication config
+setting: "metadata" keeps only the dataset document, "full" also fetches &
+pins the dataset body.
+
+Run follow without arguments to list your follows.`,
+		Example: `  # follow every dataset b5 publishes
+  $ qri follow b5
+
+  # follow a single dataset
+  $ qri follow b5/world_bank_population
+
+  # list follows
+  $ qri follow`,
+		Annotations: map[string]string{
+			"group": "network",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(f, args); err != nil {
+				return err
+			}
+			if len(args) == 0 {
+				return o.List()
+			}
+			return o.Follow()
+		},
+	}
+
+	return cmd
+}
+
+// NewUnfollowCommand creates a `qri unfollow` command
+func NewUnfollowCommand(f Factory, ioStreams IOStreams) *cobra.Command {
+	o := &FollowOptions{IOStreams: ioStreams}
+	cmd := &cobra.Command{
+		Use:   "unfollow PEERNAME | PEERNAME/DATASET...",
+		Short: "Stop following peers & datasets",
+		Long: `
+Unfollow removes peers or datasets from your list of follows. Versions that
+have already been added to your repo are kept, use qri remove to drop them.`,
+		Example: `  # stop following b5
+  $ qri unfollow b5`,
+		Annotations: map[string]string{
+			"group": "network",
+		},
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(f, args); err != nil {
+				return err
+			}
+			return o.Unfollow()
+		},
+	}
+
+	return cmd
+}
+
+// FollowOptions encapsulates state for the follow & unfollow commands
+type FollowOptions struct {
+	IOStreams
+
+	Refs []string
+
+	FollowRequests *lib.FollowRequests
+}
+
+// Complete adds any missing configuration that can only be added just before calling Run
+func (o *FollowOptions) Complete(f Factory, args []string) (err error) {
+	o.Refs = args
+	o.FollowRequests, err = f.FollowRequests()
+	return
+}
+
+// Follow adds follows
+func (o *FollowOptions) Follow() error {
+	for _, ref := range o.Refs {
+		res := &repo.Follow{}
+		if err := o.FollowRequests.Follow(&ref, res); err != nil {
+			return err
+		}
+		printSuccess(o.Out, "following %s", res.String())
+	}
+	return nil
+}
+
+// Unfollow removes follows
+func (o *FollowOptions) Unfollow() error {
+	for _, ref := range o.Refs {
+		var ok bool
+		if err := o.FollowRequests.Unfollow(&ref, &ok); err != nil {
+			return err
+		}
+		printSuccess(o.Out, "unfollowed %s", ref)
+	}
+	return nil
+}
+
+// List prints follows
+func (o *FollowOptions) List() error {
+	var done bool
+	res := []*repo.Follow{}
+	if err := o.FollowRequests.List(&done, &res); err != nil {
+		return err
+	}
+	if len(res) == 0 {
+		printInfo(o.Out, "you aren't following anyone, follow a peer with:\n    $ qri follow peername")
+		return nil
+	}
+	for _, fl := range res {
+		fmt.Fprintln(o.Out, fl.String())
+	}
+	return nil
+}
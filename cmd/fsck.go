@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/qri-io/qri/actions"
+	"github.com/qri-io/qri/lib"
+	"github.com/spf13/cobra"
+)
+
+// NewFSCKCommand creates a new `qri fsck` cobra command for checking the
+// integrity of a qri repo
+func NewFSCKCommand(f Factory, ioStreams IOStreams) *cobra.Command {
+	o := &FSCKOptions{IOStreams: ioStreams}
+	cmd := &cobra.Command{
+		Use:   "fsck",
+		Short: "Check the integrity of your repo",
+		Long: `
+Fsck (file system check) verifies every dataset in your repo. Each version in
+the history of every dataset is loaded to check nothing is missing from your
+store, and dataset bodies are checked against the checksum recorded when they
+were saved. If your repo has a search index, fsck checks it matches your
+datasets.
+
+Use --repair to fix what can be fixed: references to datasets that aren't in
+your store are removed, datasets are re-pinned, and the search index is
+rebuilt. Missing history can't be repaired.`,
+		Example: `  check your repo:
+  $ qri fsck
+
+  check your repo & repair problems:
+  $ qri fsck --repair`,
+		Annotations: map[string]string{
+			"group": "other",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(f, args); err != nil {
+				return err
+			}
+			return o.Run()
+		},
+	}
+
+	cmd.Flags().BoolVarP(&o.Repair, "repair", "r", false, "fix problems that can be fixed")
+
+	return cmd
+}
+
+// FSCKOptions encapsulates state for the fsck command
+type FSCKOptions struct {
+	IOStreams
+
+	Repair bool
+
+	RepoRequests     *lib.RepoRequests
+	ProgressRequests *lib.ProgressRequests
+}
+
+// Complete adds any missing configuration that can only be added just before calling Run
+func (o *FSCKOptions) Complete(f Factory, args []string) (err error) {
+	if f.RPC() != nil {
+		return usingRPCError("fsck")
+	}
+	if o.RepoRequests, err = f.RepoRequests(); err != nil {
+		return
+	}
+	o.ProgressRequests, err = f.ProgressRequests()
+	return
+}
+
+// Run executes the fsck command
+func (o *FSCKOptions) Run() error {
+	res := &actions.CheckRepoResult{}
+	stop := watchProgress(o.ErrOut, o.ProgressRequests)
+	err := o.RepoRequests.CheckRepo(&lib.CheckRepoParams{Repair: o.Repair}, res)
+	stop()
+	if err != nil {
+		return err
+	}
+
+	for _, is := range res.Issues {
+		ref := is.Ref.AliasString()
+		if ref == "" {
+			ref = is.Path
+		}
+		if is.Repaired {
+			printSuccess(o.Out, "repaired %s %s: %s", is.Type, ref, is.Message)
+		} else {
+			printWarning(o.Out, "%s %s: %s", is.Type, ref, is.Message)
+		}
+	}
+
+	printInfo(o.Out, "checked %d versions of %d datasets", res.Versions, res.Refs)
+	if res.Repair && res.Repinned > 0 {
+		printInfo(o.Out, "re-pinned %d datasets", res.Repinned)
+	}
+
+	if n := res.Unrepaired(); n > 0 {
+		if !res.Repair {
+			return fmt.Errorf("found %d problems, run `qri fsck --repair` to fix what can be fixed", n)
+		}
+		return fmt.Errorf("%d problems couldn't be repaired", n)
+	}
+	if len(res.Issues) > 0 {
+		printSuccess(o.Out, "repaired %d problems", len(res.Issues))
+		return nil
+	}
+	printSuccess(o.Out, "no problems found")
+	return nil
+}
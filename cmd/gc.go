@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"github.com/qri-io/qri/actions"
+	"github.com/qri-io/qri/lib"
+	"github.com/spf13/cobra"
+)
+
+// NewGCCommand creates a new `qri gc` cobra command for removing unreferenced
+// content from the repo store
+func NewGCCommand(f Factory, ioStreams IOStreams) *cobra.Command {
+	o := &GCOptions{IOStreams: ioStreams}
+	cmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Free up space by removing unreferenced data",
+		Long: `
+GC (garbage collection) frees up storage by removing anything from your store
+that isn't referenced by a dataset or profile. Removing a dataset, or dropping
+versions with ` + "`qri remove --revisions`" + ` or ` + "`qri reset`" + `, leaves
+that data in your store until it's garbage collected.
+
+Everything in the history of a dataset in your repo is kept, including bodies,
+transforms and viz. Use --dry-run to see what would be removed without removing
+anything.
+
+When your store is IPFS, unreferenced datasets are unpinned & IPFS garbage
+collection is run, which also removes any other unpinned IPFS data. Content you've
+pinned with IPFS directly is kept.`,
+		Example: `  see how much space garbage collection would free up:
+  $ qri gc --dry-run
+
+  remove unreferenced data:
+  $ qri gc`,
+		Annotations: map[string]string{
+			"group": "other",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(f, args); err != nil {
+				return err
+			}
+			return o.Run()
+		},
+	}
+
+	cmd.Flags().BoolVarP(&o.DryRun, "dry-run", "n", false, "list unreferenced data without removing it")
+
+	return cmd
+}
+
+// GCOptions encapsulates state for the gc command
+type GCOptions struct {
+	IOStreams
+
+	DryRun bool
+
+	RepoRequests *lib.RepoRequests
+}
+
+// Complete adds any missing configuration that can only be added just before calling Run
+func (o *GCOptions) Complete(f Factory, args []string) (err error) {
+	if f.RPC() != nil {
+		return usingRPCError("gc")
+	}
+	o.RepoRequests, err = f.RepoRequests()
+	return
+}
+
+// Run executes the gc command
+func (o *GCOptions) Run() error {
+	res := &actions.GCResult{}
+	if err := o.RepoRequests.GarbageCollect(&lib.GCParams{DryRun: o.DryRun}, res); err != nil {
+		return err
+	}
+
+	if o.DryRun {
+		for _, path := range res.Removed {
+			printInfo(o.Out, "%s", path)
+		}
+		printInfo(o.Out, "%d unreferenced paths, gc would free %s", len(res.Removed), printByteInfo(int(res.BytesReclaimed)))
+		return nil
+	}
+
+	printSuccess(o.Out, "removed %d unreferenced paths, freed %s", len(res.Removed), printByteInfo(int(res.BytesReclaimed)))
+	return nil
+}
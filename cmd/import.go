@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"github.com/qri-io/qri/lib"
+	"github.com/qri-io/qri/repo/archive"
+	"github.com/spf13/cobra"
+)
+
+// NewImportCommand creates a new `qri import` cobra command for reading
+// dataset archives into a repo
+func NewImportCommand(f Factory, ioStreams IOStreams) *cobra.Command {
+	o := &ImportOptions{IOStreams: ioStreams}
+	cmd := &cobra.Command{
+		Use:   "import FILE",
+		Short: "Add a dataset & it's history from an archive",
+		Long: `
+Import reads an archive written by ` + "`qri export --history`" + ` and adds the
+dataset to your repo, recreating every version in it's history. Versions keep
+the same hashes they had in the exporting repo, so imported datasets can be
+compared, merged & shared with the original.
+
+Both tar.gz & zip archives can be imported. Use --name to add the dataset
+under a different name.`,
+		Example: `  import a dataset archive:
+  $ qri import annual_pop.tar.gz
+
+  import a dataset under a new name:
+  $ qri import --name pop_copy annual_pop.zip`,
+		Annotations: map[string]string{
+			"group": "dataset",
+		},
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(f, args); err != nil {
+				return err
+			}
+			return o.Run()
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.Name, "name", "n", "", "name to add the dataset under")
+
+	return cmd
+}
+
+// ImportOptions encapsulates state for the import command
+type ImportOptions struct {
+	IOStreams
+
+	Path string
+	Name string
+
+	DatasetRequests *lib.DatasetRequests
+}
+
+// Complete adds any missing configuration that can only be added just before calling Run
+func (o *ImportOptions) Complete(f Factory, args []string) (err error) {
+	if f.RPC() != nil {
+		return usingRPCError("import")
+	}
+	o.Path = args[0]
+	o.DatasetRequests, err = f.DatasetRequests()
+	return
+}
+
+// Run executes the import command
+func (o *ImportOptions) Run() error {
+	res := &archive.ImportResult{}
+	if err := o.DatasetRequests.Import(&lib.ImportParams{Path: o.Path, Name: o.Name}, res); err != nil {
+		return err
+	}
+
+	if len(res.Changed) > 0 {
+		printWarning(o.Out, "%d of %d versions have new paths, this repo's store hashes content differently than the exporting repo", len(res.Changed), res.Versions)
+	}
+	for _, ref := range res.Imported {
+		printSuccess(o.Out, "imported %d versions of %s", res.Versions, ref.AliasString())
+	}
+	return nil
+}
@@ -21,9 +21,19 @@ about how that dataset looked at at that point in time.
 We call these snapshots versions. Each version has an author (the peer that 
 created the version) and a message explaining what changed. Log prints these 
 details in order of occurrence, starting with the most recent known version, 
-working backwards in time.`,
+working backwards in time.
+
+Use --events to show the activity recorded in your repo's event log for a 
+dataset instead, like when it was created, renamed or pinned. Without a dataset 
+reference --events lists events for all datasets.`,
 		Example: `  show log for the dataset b5/precip:
-  $ qri log b5/precip`,
+  $ qri log b5/precip
+
+  show repo events for the dataset b5/precip:
+  $ qri log --events b5/precip
+
+  show only pin events for b5/precip:
+  $ qri log --events --type ds_pinned b5/precip`,
 		Annotations: map[string]string{
 			"group": "dataset",
 		},
@@ -38,6 +48,8 @@ working backwards in time.`,
 	// cmd.Flags().StringVarP(&o.Format, "format", "f", "", "set output format [json]")
 	cmd.Flags().IntVarP(&o.Limit, "limit", "l", 25, "limit results, default 25")
 	cmd.Flags().IntVarP(&o.Offset, "offset", "o", 0, "offset results, default 0")
+	cmd.Flags().BoolVarP(&o.Events, "events", "e", false, "show repo events for the dataset instead of versions")
+	cmd.Flags().StringVarP(&o.Type, "type", "t", "", "only show events of this type, requires --events")
 
 	return cmd
 }
@@ -49,6 +61,8 @@ type LogOptions struct {
 	Limit  int
 	Offset int
 	Ref    string
+	Events bool
+	Type   string
 
 	LogRequests *lib.LogRequests
 }
@@ -78,6 +92,10 @@ func (o *LogOptions) Run() error {
 		return err
 	}
 
+	if o.Events {
+		return o.printEvents(ref)
+	}
+
 	p := &lib.LogParams{
 		Ref: ref,
 		ListParams: lib.ListParams{
@@ -114,3 +132,24 @@ func (o *LogOptions) Run() error {
 	// }
 	return nil
 }
+
+func (o *LogOptions) printEvents(ref repo.DatasetRef) error {
+	p := &lib.EventsParams{
+		Ref:  ref,
+		Type: repo.EventType(o.Type),
+		ListParams: lib.ListParams{
+			Limit:  o.Limit,
+			Offset: o.Offset,
+		},
+	}
+
+	events := []*repo.Event{}
+	if err := o.LogRequests.Events(p, &events); err != nil {
+		return err
+	}
+
+	for _, e := range events {
+		printSuccess(o.Out, "%s - %s\n\t%s\n", e.Time.Format("Jan _2 15:04:05"), e.Type, e.Ref)
+	}
+	return nil
+}
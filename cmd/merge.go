@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/qri-io/qri/actions"
+	"github.com/qri-io/qri/lib"
+	"github.com/qri-io/qri/repo"
+	"github.com/spf13/cobra"
+)
+
+// NewMergeCommand creates a new `qri merge` cobra command for merging divergent
+// versions of a dataset
+func NewMergeCommand(f Factory, ioStreams IOStreams) *cobra.Command {
+	o := &MergeOptions{IOStreams: ioStreams}
+	cmd := &cobra.Command{
+		Use:   "merge",
+		Short: "Merge changes from another version of a dataset",
+		Long: `
+Merge brings changes from one version of a dataset into one of your datasets.
+The two versions must share history, for example a dataset you've added from
+another peer with ` + "`qri add`" + ` and your own edited copy of it.
+
+Merge finds the most recent version both datasets have in common, and combines
+changes made to meta, structure and body since then. Body rows are matched
+using the "primaryKey" declared in the dataset schema, or by position if no
+primary key is declared. If both sides changed the same value differently, merge
+lists the conflicts and doesn't write a new version.`,
+		Example: `  merge changes from a peer's version of a dataset into your own:
+  $ qri merge me/annual_pop other_peer/annual_pop`,
+		Annotations: map[string]string{
+			"group": "dataset",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(f, args); err != nil {
+				return err
+			}
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			return o.Run()
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.Base, "base", "b", "", "version to use as the common ancestor, default is the most recent shared version")
+
+	return cmd
+}
+
+// MergeOptions encapsulates state for the merge command
+type MergeOptions struct {
+	IOStreams
+
+	Ours   string
+	Theirs string
+	Base   string
+
+	DatasetRequests *lib.DatasetRequests
+}
+
+// Complete adds any missing configuration that can only be added just before calling Run
+func (o *MergeOptions) Complete(f Factory, args []string) (err error) {
+	if len(args) == 2 {
+		o.Ours = args[0]
+		o.Theirs = args[1]
+	}
+	o.DatasetRequests, err = f.DatasetRequests()
+	return
+}
+
+// Validate checks that all user input is valid
+func (o *MergeOptions) Validate() error {
+	if o.Ours == "" || o.Theirs == "" {
+		return lib.NewError(lib.ErrBadArgs, "please provide two dataset references, the dataset to merge into and the dataset to merge from, for example:\n    $ qri merge me/dataset other_peer/dataset\nsee `qri merge --help` for more details")
+	}
+	return nil
+}
+
+// Run executes the merge command
+func (o *MergeOptions) Run() error {
+	ours, err := parseCmdLineDatasetRef(o.Ours)
+	if err != nil {
+		return err
+	}
+	theirs, err := parseCmdLineDatasetRef(o.Theirs)
+	if err != nil {
+		return err
+	}
+	base, err := repo.ParseDatasetRef(o.Base)
+	if err != nil && err != repo.ErrEmptyRef {
+		return err
+	}
+
+	p := &lib.MergeParams{
+		Base:   base,
+		Ours:   ours,
+		Theirs: theirs,
+	}
+	res := &actions.DatasetMergeResult{}
+	if err = o.DatasetRequests.Merge(p, res); err != nil {
+		return err
+	}
+
+	if len(res.Conflicts) > 0 {
+		printWarning(o.ErrOut, "merge has %d conflicts:", len(res.Conflicts))
+		for _, c := range res.Conflicts {
+			printWarning(o.ErrOut, "  %s", c)
+		}
+		return fmt.Errorf("couldn't merge %s into %s", o.Theirs, o.Ours)
+	}
+
+	if res.UpToDate {
+		printInfo(o.Out, "%s is already up to date", o.Ours)
+		return nil
+	}
+
+	printSuccess(o.Out, "merged %s into %s", o.Theirs, res.Ref.AliasString())
+	printInfo(o.Out, "path: %s", res.Ref.Path)
+	return nil
+}
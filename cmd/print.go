@@ -14,6 +14,7 @@ import (
 	"github.com/fatih/color"
 	"github.com/olekukonko/tablewriter"
 	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/actions"
 	"github.com/qri-io/qri/config"
 	"github.com/qri-io/qri/lib"
 	"github.com/qri-io/qri/repo"
@@ -280,6 +281,39 @@ func printDiffs(w io.Writer, diffText string) {
 	}
 }
 
+func printBodyDiff(w io.Writer, diff *actions.BodyDiff) {
+	if diff == nil || diff.Changes() == 0 {
+		printInfo(w, "no body changes")
+		return
+	}
+
+	buf := &bytes.Buffer{}
+	if len(diff.PrimaryKey) > 0 {
+		fmt.Fprintf(buf, "rows matched by key: %s\n", strings.Join(diff.PrimaryKey, ", "))
+	} else {
+		fmt.Fprintln(buf, "rows matched by position")
+	}
+	fmt.Fprintf(buf, "%d added, %d removed, %d modified\n", len(diff.Added), len(diff.Removed), len(diff.Modified))
+	for _, row := range diff.Removed {
+		fmt.Fprintf(buf, "- %s: %v\n", row.Key, row.Value)
+	}
+	for _, row := range diff.Added {
+		fmt.Fprintf(buf, "+ %s: %v\n", row.Key, row.Value)
+	}
+	for _, row := range diff.Modified {
+		fmt.Fprintf(buf, "~ %s\n", row.Key)
+		for _, cell := range row.Cells {
+			fmt.Fprintf(buf, "\t%s: %v -> %v\n", cell.Column, cell.Left, cell.Right)
+		}
+	}
+	if diff.Truncated {
+		fmt.Fprintln(buf, "...")
+		fmt.Fprintln(buf, "(diff truncated, use --limit to see more changes)")
+	}
+
+	printDiffs(w, buf.String())
+}
+
 func usingRPCError(cmdName string) error {
 	return fmt.Errorf(`sorry, we can't run the '%s' command while 'qri connect' is running
 we know this is super irritating, and it'll be fixed in the future. 
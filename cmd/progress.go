@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/qri-io/qri/lib"
+	"github.com/qri-io/qri/progress"
+)
+
+// progressBarWidth is the number of characters in a progress bar
+const progressBarWidth = 30
+
+// progressPollWait is how long each progress poll waits for updates, which
+// is also the longest stopping a progress watcher takes
+const progressPollWait = time.Millisecond * 250
+
+// watchProgress renders progress bars for operations qri runs to w until the
+// returned stop func is called. Progress is only rendered when w is a
+// terminal, so piped output & test buffers are left alone
+func watchProgress(w io.Writer, pr *lib.ProgressRequests) (stop func()) {
+	if pr == nil || !isTerminal(w) {
+		return func() {}
+	}
+
+	start := &lib.ProgressUpdates{}
+	if err := pr.Poll(&lib.ProgressParams{Since: -1}, start); err != nil {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		bars := &progressBars{w: w}
+		seq := start.Seq
+		for {
+			wait := progressPollWait
+			stopping := false
+			select {
+			case <-done:
+				// drain whatever updates are left without waiting
+				wait, stopping = 0, true
+			default:
+			}
+
+			res := &lib.ProgressUpdates{}
+			if err := pr.Poll(&lib.ProgressParams{Since: seq, Wait: wait}, res); err != nil {
+				bars.close()
+				return
+			}
+			seq = res.Seq
+			for _, u := range res.Updates {
+				bars.render(u)
+			}
+			if stopping {
+				bars.close()
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-finished
+	}
+}
+
+// progressBars writes progress updates to a single terminal line, moving to
+// a new line when a task completes or another task reports
+type progressBars struct {
+	w io.Writer
+	// id of the task on the current line, empty if the line is clear
+	id string
+}
+
+func (b *progressBars) render(u progress.Update) {
+	if b.id != "" && b.id != u.ID {
+		fmt.Fprintln(b.w)
+	}
+	// \033[K clears what's left of the previous line
+	fmt.Fprintf(b.w, "\r%s\033[K", formatProgress(u))
+	b.id = u.ID
+	if u.Complete {
+		fmt.Fprintln(b.w)
+		b.id = ""
+	}
+}
+
+// close ends a line left by an unfinished task
+func (b *progressBars) close() {
+	if b.id != "" {
+		fmt.Fprintln(b.w)
+		b.id = ""
+	}
+}
+
+// formatProgress renders an update as a line of text, eg:
+// saving cities [=============>                ]  45% 1.2 MB / 2.7 MB
+func formatProgress(u progress.Update) string {
+	line := u.Name
+	if pct := u.Percent(); pct >= 0 {
+		line = fmt.Sprintf("%s %s %3.0f%%", line, progressBar(pct), pct)
+	}
+	line = fmt.Sprintf("%s %s", line, u.String())
+	if u.Error != "" {
+		line = fmt.Sprintf("%s failed: %s", line, u.Error)
+	}
+	return line
+}
+
+func progressBar(pct float64) string {
+	filled := int(pct / 100 * progressBarWidth)
+	if filled >= progressBarWidth {
+		return "[" + strings.Repeat("=", progressBarWidth) + "]"
+	}
+	return "[" + strings.Repeat("=", filled) + ">" + strings.Repeat(" ", progressBarWidth-filled-1) + "]"
+}
+
+// isTerminal checks if w writes to a terminal
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/qri-io/qri/progress"
+)
+
+func TestFormatProgress(t *testing.T) {
+	cases := []struct {
+		u      progress.Update
+		expect string
+	}{
+		{progress.Update{Name: "saving", Unit: progress.Bytes, Done: 1500, Total: 3000},
+			"saving [===============>              ]  50% 1.5 kB / 3.0 kB"},
+		{progress.Update{Name: "saving", Unit: progress.Bytes, Done: 3000, Total: 3000, Complete: true},
+			"saving [==============================] 100% 3.0 kB / 3.0 kB"},
+		{progress.Update{Name: "running transform", Unit: progress.Entries, Done: 12},
+			"running transform 12 entries"},
+		{progress.Update{Name: "checking", Unit: progress.Steps, Done: 1, Total: 4, Complete: true, Error: "oh noes"},
+			"checking [=======>                      ]  25% 1 steps / 4 steps failed: oh noes"},
+	}
+
+	for i, c := range cases {
+		if got := formatProgress(c.u); got != c.expect {
+			t.Errorf("case %d mismatch.\nexpected: %q\ngot:      %q", i, c.expect, got)
+		}
+	}
+}
+
+func TestProgressBars(t *testing.T) {
+	buf := &bytes.Buffer{}
+	bars := &progressBars{w: buf}
+	bars.render(progress.Update{ID: "1", Name: "a", Unit: progress.Steps, Done: 1})
+	bars.render(progress.Update{ID: "2", Name: "b", Unit: progress.Steps, Done: 1, Complete: true})
+	bars.render(progress.Update{ID: "3", Name: "c", Unit: progress.Steps, Done: 2})
+	bars.close()
+
+	expect := "\ra 1 steps\033[K\n\rb [==============================] 100% 1 steps\033[K\n\rc 2 steps\033[K\n"
+	if buf.String() != expect {
+		t.Errorf("output mismatch.\nexpected: %q\ngot:      %q", expect, buf.String())
+	}
+}
+
+func TestWatchProgressNotTerminal(t *testing.T) {
+	buf := &bytes.Buffer{}
+	stop := watchProgress(buf, nil)
+	stop()
+	if buf.Len() != 0 {
+		t.Errorf("expected nothing written to a non-terminal, got: %q", buf.String())
+	}
+}
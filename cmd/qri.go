@@ -9,13 +9,16 @@ import (
 	"path/filepath"
 	"sync"
 
+	"github.com/qri-io/cafs"
 	ipfs "github.com/qri-io/cafs/ipfs"
 	"github.com/qri-io/qri/config"
 	"github.com/qri-io/qri/lib"
 	"github.com/qri-io/qri/p2p"
 	"github.com/qri-io/qri/repo"
 	"github.com/qri-io/qri/repo/fs"
+	"github.com/qri-io/qri/repo/middleware"
 	"github.com/qri-io/qri/repo/profile"
+	"github.com/qri-io/qri/repo/sqlite"
 	"github.com/qri-io/registry/regclient"
 	"github.com/spf13/cobra"
 )
@@ -50,21 +53,35 @@ https://github.com/qri-io/qri/issues`,
 		NewConfigCommand(opt, ioStreams),
 		NewConnectCommand(opt, ioStreams),
 		NewBodyCommand(opt, ioStreams),
+		NewCheckoutCommand(opt, ioStreams),
 		NewDiffCommand(opt, ioStreams),
+		NewEventsCommand(opt, ioStreams),
 		NewExportCommand(opt, ioStreams),
+		NewFollowCommand(opt, ioStreams),
+		NewFSCKCommand(opt, ioStreams),
+		NewGCCommand(opt, ioStreams),
 		NewGetCommand(opt, ioStreams),
+		NewImportCommand(opt, ioStreams),
 		NewInfoCommand(opt, ioStreams),
 		NewListCommand(opt, ioStreams),
 		NewLogCommand(opt, ioStreams),
+		NewMergeCommand(opt, ioStreams),
 		NewNewCommand(opt, ioStreams),
 		NewPeersCommand(opt, ioStreams),
 		NewRegistryCommand(opt, ioStreams),
 		NewRemoveCommand(opt, ioStreams),
 		NewRenameCommand(opt, ioStreams),
 		NewRenderCommand(opt, ioStreams),
+		NewRepoCommand(opt, ioStreams),
+		NewRequestCommand(opt, ioStreams),
+		NewResetCommand(opt, ioStreams),
 		NewSaveCommand(opt, ioStreams),
 		NewSearchCommand(opt, ioStreams),
 		NewSetupCommand(opt, ioStreams),
+		NewSiteCommand(opt, ioStreams),
+		NewSQLCommand(opt, ioStreams),
+		NewStatusCommand(opt, ioStreams),
+		NewUnfollowCommand(opt, ioStreams),
 		NewUseCommand(opt, ioStreams),
 		NewValidateCommand(opt, ioStreams),
 		NewVersionCommand(opt, ioStreams),
@@ -138,12 +155,8 @@ func (o *QriOptions) init() (err error) {
 			return
 		}
 
-		var fs *ipfs.Filestore
-		fs, err = ipfs.NewFilestore(func(cfg *ipfs.StoreCfg) {
-			cfg.FsRepoPath = o.ipfsFsPath
-			// cfg.Online = online
-		})
-		if err != nil {
+		var fs cafs.Filestore
+		if fs, err = o.newFilestore(); err != nil {
 			return
 		}
 
@@ -159,10 +172,15 @@ func (o *QriOptions) init() (err error) {
 			})
 		}
 
-		o.repo, err = fsrepo.NewRepo(fs, pro, rc, o.qriRepoPath)
+		o.repo, err = o.newRepo(fs, pro, rc)
 		if err != nil {
 			return
 		}
+		if o.config.Repo != nil {
+			if o.repo, err = middleware.Apply(o.repo, o.config.Repo.Middleware); err != nil {
+				return
+			}
+		}
 
 		o.node, err = p2p.NewQriNode(o.repo, o.config.P2P)
 		if err != nil {
@@ -173,6 +191,50 @@ func (o *QriOptions) init() (err error) {
 	return err
 }
 
+// newFilestore creates the content-addressed file store specified by the
+// store section of the config
+func (o *QriOptions) newFilestore() (cafs.Filestore, error) {
+	storeType := "ipfs"
+	if o.config.Store != nil {
+		storeType = o.config.Store.Type
+	}
+
+	switch storeType {
+	case "ipfs":
+		return ipfs.NewFilestore(func(cfg *ipfs.StoreCfg) {
+			cfg.FsRepoPath = o.ipfsFsPath
+			// cfg.Online = online
+		})
+	case "fs":
+		path := o.config.Store.Path
+		if path == "" {
+			path = filepath.Join(o.qriRepoPath, "store")
+		}
+		return fsrepo.NewFilestore(path)
+	case "mem":
+		return cafs.NewMapstore(), nil
+	default:
+		return nil, fmt.Errorf("unknown store type: '%s'", storeType)
+	}
+}
+
+// newRepo creates the repo type specified by the repo section of the config
+func (o *QriOptions) newRepo(fs cafs.Filestore, pro *profile.Profile, rc *regclient.Client) (repo.Repo, error) {
+	repoType := "fs"
+	if o.config.Repo != nil {
+		repoType = o.config.Repo.Type
+	}
+
+	switch repoType {
+	case "fs":
+		return fsrepo.NewRepo(fs, pro, rc, o.qriRepoPath)
+	case "sqlite":
+		return sqliterepo.NewRepo(fs, pro, rc, o.qriRepoPath)
+	default:
+		return nil, fmt.Errorf("unknown repo type: '%s'", repoType)
+	}
+}
+
 // Config returns from internal state
 func (o *QriOptions) Config() (*config.Config, error) {
 	if err := o.init(); err != nil {
@@ -281,3 +343,51 @@ func (o *QriOptions) RenderRequests() (*lib.RenderRequests, error) {
 	}
 	return lib.NewRenderRequests(o.repo, o.rpc), nil
 }
+
+// RepoRequests generates a lib.RepoRequests from internal state
+func (o *QriOptions) RepoRequests() (*lib.RepoRequests, error) {
+	if err := o.init(); err != nil {
+		return nil, err
+	}
+	return lib.NewRepoRequests(o.node, o.rpc), nil
+}
+
+// QueryRequests generates a lib.QueryRequests from internal state
+func (o *QriOptions) QueryRequests() (*lib.QueryRequests, error) {
+	if err := o.init(); err != nil {
+		return nil, err
+	}
+	return lib.NewQueryRequests(o.node, o.rpc), nil
+}
+
+// ChangeRequests generates a lib.ChangeRequests from internal state
+func (o *QriOptions) ChangeRequests() (*lib.ChangeRequests, error) {
+	if err := o.init(); err != nil {
+		return nil, err
+	}
+	return lib.NewChangeRequests(o.node, o.rpc), nil
+}
+
+// SiteRequests generates a lib.SiteRequests from internal state
+func (o *QriOptions) SiteRequests() (*lib.SiteRequests, error) {
+	if err := o.init(); err != nil {
+		return nil, err
+	}
+	return lib.NewSiteRequests(o.node, o.rpc), nil
+}
+
+// FollowRequests generates a lib.FollowRequests from internal state
+func (o *QriOptions) FollowRequests() (*lib.FollowRequests, error) {
+	if err := o.init(); err != nil {
+		return nil, err
+	}
+	return lib.NewFollowRequests(o.node, o.rpc), nil
+}
+
+// ProgressRequests generates a lib.ProgressRequests from internal state
+func (o *QriOptions) ProgressRequests() (*lib.ProgressRequests, error) {
+	if err := o.init(); err != nil {
+		return nil, err
+	}
+	return lib.NewProgressRequests(o.node, o.rpc), nil
+}
@@ -26,9 +26,16 @@ Keep in mind that by default your IPFS repo is capped at 10GB in size, if you
 adjust this cap using IPFS, qri will respect it.
 
 In the future we’ll add a flag that’ll force immediate removal of a dataset from
-both qri & IPFS. Promise.`,
+both qri & IPFS. Promise.
+
+To drop only the most recent versions of a dataset instead of its entire
+history, use the --revisions flag. To reset a dataset to a specific earlier
+version, see ` + "`qri reset`" + `.`,
 		Example: `  remove a dataset named annual_pop:
-  $ qri remove me/annual_pop`,
+  $ qri remove me/annual_pop
+
+  remove the last two versions of annual_pop:
+  $ qri remove --revisions 2 me/annual_pop`,
 		Annotations: map[string]string{
 			"group": "dataset",
 		},
@@ -40,6 +47,8 @@ both qri & IPFS. Promise.`,
 		},
 	}
 
+	cmd.Flags().IntVarP(&o.Revisions, "revisions", "r", 0, "number of versions to remove from the end of history, default removes the entire dataset")
+
 	return cmd
 }
 
@@ -47,7 +56,8 @@ both qri & IPFS. Promise.`,
 type RemoveOptions struct {
 	IOStreams
 
-	Args []string
+	Args      []string
+	Revisions int
 
 	DatasetRequests *lib.DatasetRequests
 }
@@ -75,6 +85,16 @@ func (o *RemoveOptions) Run() error {
 			return err
 		}
 
+		if o.Revisions > 0 {
+			res := &lib.ResetResponse{}
+			if err = o.DatasetRequests.Reset(&lib.ResetParams{Ref: ref, Revisions: o.Revisions}, res); err != nil {
+				return err
+			}
+			printSuccess(o.Out, "removed %d versions of '%s'", len(res.Removed), res.Ref.AliasString())
+			printInfo(o.Out, "path: %s", res.Ref.Path)
+			continue
+		}
+
 		res := false
 		if err = o.DatasetRequests.Remove(&ref, &res); err != nil {
 			if err.Error() == "repo: not found" {
@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"io/ioutil"
+	"os"
 
 	"github.com/qri-io/qri/lib"
 	"github.com/qri-io/qri/repo"
@@ -16,19 +17,32 @@ func NewRenderCommand(f Factory, ioStreams IOStreams) *cobra.Command {
 		Use:   "render",
 		Short: "Execute a template against a dataset",
 		Long: `
-You can use html templates, formatted in the go/html template style, 
+You can use templates, formatted in the go/html template style, 
 to render visualizations from your dataset. These visualizations can be charts, 
 graphs, or just display your dataset in a different format.
 
-Use the ` + "`--output`" + ` flag to save the rendered html to a file.
+Use the ` + "`--output`" + ` flag to save the rendered output to a file.
 
-Use the ` + "`--template`" + ` flag to use a custom template. If no template is
-provided, Qri will render the dataset with a default template.`,
+Use the ` + "`--template`" + ` flag to use a custom template, or ` + "`--template-name`" + `
+to use a template saved with ` + "`qri render template add`" + `. If no template is
+provided, Qri will render the dataset with a default template.
+
+Use the ` + "`--format`" + ` flag to render html, markdown or text. Templates can
+use the helpers formatNumber, column, table, barChart & lineChart.
+
+Use ` + "`--limit`" + ` & ` + "`--offset`" + ` to page through body entries, and
+` + "`--stream`" + ` to render large bodies without reading them into memory.`,
 		Example: `  render a dataset called me/schools:
   $ qri render -o=schools.html me/schools
 
   render a dataset with a custom template:
-  $ qri render --template=template.html me/schools`,
+  $ qri render --template=template.html me/schools
+
+  render the second page of a dataset as markdown:
+  $ qri render --format md --limit 20 --offset 20 me/schools
+
+  render a dataset with a saved template:
+  $ qri render --template-name summary me/schools`,
 		Annotations: map[string]string{
 			"group": "dataset",
 		},
@@ -45,6 +59,11 @@ provided, Qri will render the dataset with a default template.`,
 	cmd.Flags().BoolVarP(&o.All, "all", "a", false, "read all dataset entries (overrides limit, offest)")
 	cmd.Flags().IntVarP(&o.Limit, "limit", "l", 50, "max number of records to read")
 	cmd.Flags().IntVarP(&o.Offset, "offset", "s", 0, "number of records to skip")
+	cmd.Flags().StringVarP(&o.Format, "format", "f", "", "output format. one of: html, md, text. defaults to the template's format")
+	cmd.Flags().StringVarP(&o.TemplateName, "template-name", "n", "", "name of a saved template to render")
+	cmd.Flags().BoolVar(&o.Stream, "stream", false, "stream body entries while rendering")
+
+	cmd.AddCommand(NewRenderTemplateCommand(f, ioStreams))
 
 	return cmd
 }
@@ -53,12 +72,15 @@ provided, Qri will render the dataset with a default template.`,
 type RenderOptions struct {
 	IOStreams
 
-	Ref      string
-	Template string
-	Output   string
-	All      bool
-	Limit    int
-	Offset   int
+	Ref          string
+	Template     string
+	TemplateName string
+	Format       string
+	Output       string
+	All          bool
+	Limit        int
+	Offset       int
+	Stream       bool
 
 	RenderRequests *lib.RenderRequests
 }
@@ -91,24 +113,162 @@ func (o *RenderOptions) Run() (err error) {
 	p := &lib.RenderParams{
 		Ref:            ref,
 		Template:       template,
-		TemplateFormat: "html",
+		TemplateName:   o.TemplateName,
+		TemplateFormat: o.Format,
 		All:            o.All,
 		Limit:          o.Limit,
 		Offset:         o.Offset,
+		Stream:         o.Stream,
+	}
+
+	w := o.Out
+	if o.Output != "" {
+		f, err := os.Create(o.Output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
 	}
 
-	res := []byte{}
-	if err = o.RenderRequests.Render(p, &res); err != nil {
+	if err = o.RenderRequests.RenderTo(w, p); err != nil {
 		if err == repo.ErrEmptyRef {
 			return lib.NewError(err, "peername and dataset name needed in order to render, for example:\n   $ qri render me/dataset_name\nsee `qri render --help` from more info")
 		}
 		return err
 	}
+	return nil
+}
+
+// NewRenderTemplateCommand creates a `qri render template` command for
+// managing named templates
+func NewRenderTemplateCommand(f Factory, ioStreams IOStreams) *cobra.Command {
+	o := &RenderTemplateOptions{IOStreams: ioStreams}
+	cmd := &cobra.Command{
+		Use:   "template",
+		Short: "Manage named render templates",
+		Long: `
+Named templates are saved in your repo & can be rendered by name with
+` + "`qri render --template-name`" + `. Every saved template is also available to
+other templates as a partial:
+
+  {{ template "header" . }}
+
+A dataset viz can refer to a named template with a script path of
+"template:NAME".`,
+		Example: `  save a markdown template called summary:
+  $ qri render template add --format md summary summary.md
+
+  list saved templates:
+  $ qri render template list
+
+  remove a template:
+  $ qri render template remove summary`,
+	}
+
+	add := &cobra.Command{
+		Use:   "add NAME FILE",
+		Short: "Save a template",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(f, args); err != nil {
+				return err
+			}
+			return o.Add()
+		},
+	}
+	add.Flags().StringVarP(&o.Format, "format", "f", "html", "output format of the template. one of: html, md, text")
+
+	list := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List saved templates",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(f, args); err != nil {
+				return err
+			}
+			return o.List()
+		},
+	}
+
+	remove := &cobra.Command{
+		Use:     "remove NAME",
+		Aliases: []string{"rm"},
+		Short:   "Remove a saved template",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(f, args); err != nil {
+				return err
+			}
+			return o.Remove()
+		},
+	}
+
+	cmd.AddCommand(add, list, remove)
+	return cmd
+}
+
+// RenderTemplateOptions encapsulates state for the render template command
+type RenderTemplateOptions struct {
+	IOStreams
+
+	Args   []string
+	Format string
+
+	RenderRequests *lib.RenderRequests
+}
+
+// Complete adds any missing configuration that can only be added just before calling Run
+func (o *RenderTemplateOptions) Complete(f Factory, args []string) (err error) {
+	o.Args = args
+	o.RenderRequests, err = f.RenderRequests()
+	return
+}
+
+// Add saves a template
+func (o *RenderTemplateOptions) Add() error {
+	script, err := ioutil.ReadFile(o.Args[1])
+	if err != nil {
+		return err
+	}
+
+	p := &lib.SaveTemplateParams{
+		Name:   o.Args[0],
+		Format: o.Format,
+		Script: script,
+	}
+	res := &repo.Template{}
+	if err = o.RenderRequests.SaveTemplate(p, res); err != nil {
+		return err
+	}
+	printSuccess(o.Out, "saved %s template %s", res.Format, res.Name)
+	return nil
+}
+
+// List prints saved templates
+func (o *RenderTemplateOptions) List() error {
+	done := true
+	res := []*repo.Template{}
+	if err := o.RenderRequests.ListTemplates(&done, &res); err != nil {
+		return err
+	}
+	if len(res) == 0 {
+		printInfo(o.Out, "no saved templates")
+		return nil
+	}
+	for _, t := range res {
+		fmt.Fprintf(o.Out, "%s\t%s\t%s\n", t.Name, t.Format, t.Path)
+	}
+	return nil
+}
 
-	if o.Output == "" {
-		fmt.Fprint(o.Out, string(res))
-	} else {
-		ioutil.WriteFile(o.Output, res, 0777)
+// Remove deletes a saved template
+func (o *RenderTemplateOptions) Remove() error {
+	removed := false
+	name := o.Args[0]
+	if err := o.RenderRequests.RemoveTemplate(&name, &removed); err != nil {
+		return err
 	}
+	printSuccess(o.Out, "removed template %s", name)
 	return nil
 }
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/qri-io/cafs"
@@ -162,3 +163,58 @@ func TestRenderRun(t *testing.T) {
 		ioReset(in, out, errs)
 	}
 }
+
+func TestRenderTemplateRun(t *testing.T) {
+	streams, in, out, errs := NewTestIOStreams()
+	setNoColor(true)
+
+	f, err := NewTestFactory(nil)
+	if err != nil {
+		t.Fatalf("error creating new test factory: %s", err)
+	}
+
+	o := &RenderTemplateOptions{IOStreams: streams, Format: "html"}
+	if err := o.Complete(f, []string{"page", "testdata/template.html"}); err != nil {
+		t.Fatalf("error completing: %s", err)
+	}
+	if err := o.Add(); err != nil {
+		t.Fatalf("error adding template: %s", err)
+	}
+	ioReset(in, out, errs)
+
+	if err := o.List(); err != nil {
+		t.Fatalf("error listing templates: %s", err)
+	}
+	if !strings.HasPrefix(out.String(), "page\thtml\t") {
+		t.Errorf("expected list to show template 'page', got: '%s'", out.String())
+	}
+	ioReset(in, out, errs)
+
+	rr, err := f.RenderRequests()
+	if err != nil {
+		t.Fatalf("error creating render requests: %s", err)
+	}
+	opt := &RenderOptions{
+		IOStreams:      streams,
+		Ref:            "peer/cities",
+		TemplateName:   "page",
+		Limit:          1,
+		RenderRequests: rr,
+	}
+	if err := opt.Run(); err != nil {
+		t.Fatalf("error rendering named template: %s", err)
+	}
+	expect := "<html><h2>peer/cities</h2><tbody><tr><td>toronto</td><td>40000000</td><td>55.5</td><td>false</td></tr></tbody></html>"
+	if out.String() != expect {
+		t.Errorf("output mismatch. Expected: '%s', Got: '%s'", expect, out.String())
+	}
+	ioReset(in, out, errs)
+
+	o.Args = []string{"page"}
+	if err := o.Remove(); err != nil {
+		t.Fatalf("error removing template: %s", err)
+	}
+	if err := o.Remove(); err == nil {
+		t.Errorf("expected removing a missing template to error")
+	}
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"github.com/qri-io/qri/lib"
+	"github.com/qri-io/qri/repo/archive"
+	"github.com/spf13/cobra"
+)
+
+// NewRepoCommand creates a `qri repo` subcommand for moving an entire repo
+// between machines
+func NewRepoCommand(f Factory, ioStreams IOStreams) *cobra.Command {
+	o := &RepoOptions{IOStreams: ioStreams}
+	cmd := &cobra.Command{
+		Use:   "repo",
+		Short: "Export & import your entire repo",
+		Long: `
+Repo commands bundle everything in your qri repo into a single archive file
+and restore it again. Archives hold every dataset reference, the full history
+of every dataset, known peer profiles, your event log and your config. Use
+them to back up your repo or move it to another machine, regardless of the
+kind of store each machine uses.`,
+		Annotations: map[string]string{
+			"group": "other",
+		},
+	}
+
+	export := &cobra.Command{
+		Use:   "export FILE",
+		Short: "Write your repo to an archive file",
+		Long: `
+Export writes your entire repo to an archive file. Private keys are left out
+of the archived config unless you pass --private-keys. An archive with private
+keys can be used to act as you on the network, keep it somewhere safe.`,
+		Example: `  back up your repo:
+  $ qri repo export backup.qri`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(f, args); err != nil {
+				return err
+			}
+			return o.Export()
+		},
+	}
+	export.Flags().BoolVar(&o.PrivateKeys, "private-keys", false, "include private keys in the archive")
+
+	importCmd := &cobra.Command{
+		Use:   "import FILE",
+		Short: "Read an archive file into your repo",
+		Long: `
+Import reads an archive written by export into your repo. Datasets with names
+already in your repo are skipped. Pass --config to replace your config with
+the archived config. If the archive has no private keys your current profile
+and p2p config are kept.`,
+		Example: `  restore a backup:
+  $ qri repo import backup.qri`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(f, args); err != nil {
+				return err
+			}
+			return o.Import()
+		},
+	}
+	importCmd.Flags().BoolVar(&o.Config, "config", false, "replace config with the archived config")
+
+	cmd.AddCommand(export, importCmd)
+	return cmd
+}
+
+// RepoOptions encapsulates state for the repo command & subcommands
+type RepoOptions struct {
+	IOStreams
+
+	Path        string
+	PrivateKeys bool
+	Config      bool
+
+	RepoRequests *lib.RepoRequests
+}
+
+// Complete adds any missing configuration that can only be added just before calling Run
+func (o *RepoOptions) Complete(f Factory, args []string) (err error) {
+	if f.RPC() != nil {
+		return usingRPCError("repo")
+	}
+	o.Path = args[0]
+	o.RepoRequests, err = f.RepoRequests()
+	return
+}
+
+// Export executes the repo export command
+func (o *RepoOptions) Export() error {
+	man := &archive.Manifest{}
+	if err := o.RepoRequests.Export(&lib.ExportRepoParams{Path: o.Path, PrivateKeys: o.PrivateKeys}, man); err != nil {
+		return err
+	}
+	if man.PrivateKeys {
+		printWarning(o.Out, "archive includes private keys, keep it safe")
+	}
+	printSuccess(o.Out, "exported %d datasets (%d versions) to %s", man.Refs, man.Versions, o.Path)
+	return nil
+}
+
+// Import executes the repo import command
+func (o *RepoOptions) Import() error {
+	res := &archive.ImportResult{}
+	if err := o.RepoRequests.Import(&lib.ImportRepoParams{Path: o.Path, Config: o.Config}, res); err != nil {
+		return err
+	}
+	for _, ref := range res.Skipped {
+		printWarning(o.Out, "skipped %s: name already exists", ref.AliasString())
+	}
+	printSuccess(o.Out, "imported %d datasets (%d versions), %d profiles & %d events from %s", res.Refs, res.Versions, res.Profiles, res.Events, o.Path)
+	if o.Config {
+		printSuccess(o.Out, "restored config")
+	}
+	return nil
+}
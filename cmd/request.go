@@ -0,0 +1,257 @@
+package cmd
+
+import (
+	"github.com/qri-io/qri/lib"
+	"github.com/qri-io/qri/repo"
+	"github.com/spf13/cobra"
+)
+
+// NewRequestCommand creates a `qri request` subcommand for proposing changes
+// to datasets owned by other peers
+func NewRequestCommand(f Factory, ioStreams IOStreams) *cobra.Command {
+	o := &RequestOptions{IOStreams: ioStreams}
+	cmd := &cobra.Command{
+		Use:   "request",
+		Short: "Propose changes to datasets owned by other peers",
+		Long: `
+Change requests let you propose a new version of someone else's dataset. Save
+your changes as a dataset in your own repo, then send it as a change request
+to the peer that owns the original. They can review the proposal and accept
+it, making it the next version of their dataset, or reject it.
+
+A change request is based on the latest version of the target dataset when it
+was sent. If the owner saves a new version before accepting, the request can
+no longer be accepted and needs to be sent again.
+
+Sending requests and listing requests on other peers requires a connection
+to the network, run "qri connect" in another terminal first.`,
+		Annotations: map[string]string{
+			"group": "network",
+		},
+	}
+
+	send := &cobra.Command{
+		Use:   "send",
+		Short: "Send a change request",
+		Long: `
+Send proposes a dataset in your repo as the next version of a dataset owned
+by another peer. The title defaults to the commit title of the proposal.`,
+		Example: `  propose me/better_precip as the next version of b5/precip:
+  $ qri request send b5/precip me/better_precip --title "fix units"`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(f, args); err != nil {
+				return err
+			}
+			return o.Send()
+		},
+	}
+	send.Flags().StringVarP(&o.Title, "title", "t", "", "title of the change request")
+	send.Flags().StringVarP(&o.Message, "message", "m", "", "message describing the change")
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List change requests",
+		Long: `
+List shows change requests you've sent and received, most recent first. Pass
+a dataset reference to only show requests for that dataset. Use --remote to
+list the requests the owner of a dataset has received.`,
+		Example: `  list requests you've received for me/precip:
+  $ qri request list me/precip
+
+  list requests b5 has received for b5/precip:
+  $ qri request list --remote b5/precip`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(f, args); err != nil {
+				return err
+			}
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			return o.List()
+		},
+	}
+	list.Flags().BoolVarP(&o.Remote, "remote", "r", false, "list requests on the peer that owns the dataset")
+	list.Flags().IntVarP(&o.Limit, "limit", "l", 25, "limit results, default 25")
+	list.Flags().IntVarP(&o.Offset, "offset", "o", 0, "offset results, default 0")
+
+	get := &cobra.Command{
+		Use:     "get",
+		Short:   "Show details of a change request",
+		Example: `  $ qri request get /ipfs/QmZwyCLS...`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(f, args); err != nil {
+				return err
+			}
+			return o.Get()
+		},
+	}
+
+	accept := &cobra.Command{
+		Use:   "accept",
+		Short: "Accept a change request",
+		Long: `
+Accept saves the proposed version of a change request as the next version of
+your dataset. The commit records who proposed the change. Transforms included
+in the proposal are not run.`,
+		Example: `  $ qri request accept /ipfs/QmZwyCLS...`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(f, args); err != nil {
+				return err
+			}
+			return o.Accept()
+		},
+	}
+
+	reject := &cobra.Command{
+		Use:     "reject",
+		Short:   "Reject a change request",
+		Example: `  $ qri request reject /ipfs/QmZwyCLS...`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(f, args); err != nil {
+				return err
+			}
+			return o.Reject()
+		},
+	}
+
+	cmd.AddCommand(send, list, get, accept, reject)
+	return cmd
+}
+
+// RequestOptions encapsulates state for the request command & subcommands
+type RequestOptions struct {
+	IOStreams
+
+	Args    []string
+	Title   string
+	Message string
+	Remote  bool
+	Limit   int
+	Offset  int
+
+	ChangeRequests *lib.ChangeRequests
+}
+
+// Complete adds any missing configuration that can only be added just before calling Run
+func (o *RequestOptions) Complete(f Factory, args []string) (err error) {
+	o.Args = args
+	o.ChangeRequests, err = f.ChangeRequests()
+	return
+}
+
+// Validate checks that all user input is valid
+func (o *RequestOptions) Validate() error {
+	if o.Remote && len(o.Args) == 0 {
+		return lib.NewError(lib.ErrBadArgs, "--remote requires a dataset reference, for example:\n    $ qri request list --remote b5/precip")
+	}
+	return nil
+}
+
+// Send executes the send subcommand
+func (o *RequestOptions) Send() error {
+	target, err := repo.ParseDatasetRef(o.Args[0])
+	if err != nil {
+		return err
+	}
+	proposal, err := repo.ParseDatasetRef(o.Args[1])
+	if err != nil {
+		return err
+	}
+
+	p := &lib.SendChangeRequestParams{
+		Target:   target,
+		Proposal: proposal,
+		Title:    o.Title,
+		Message:  o.Message,
+	}
+	res := &repo.ChangeRequest{}
+	if err := o.ChangeRequests.Send(p, res); err != nil {
+		return err
+	}
+	printSuccess(o.Out, "sent change request %s to %s", res.ID, res.Target.AliasString())
+	return nil
+}
+
+// List executes the list subcommand
+func (o *RequestOptions) List() error {
+	p := &lib.ListChangeRequestsParams{
+		Remote: o.Remote,
+		ListParams: lib.ListParams{
+			Limit:  o.Limit,
+			Offset: o.Offset,
+		},
+	}
+	if len(o.Args) > 0 {
+		target, err := repo.ParseDatasetRef(o.Args[0])
+		if err != nil {
+			return err
+		}
+		p.Target = target
+	}
+
+	res := []*repo.ChangeRequest{}
+	if err := o.ChangeRequests.List(p, &res); err != nil {
+		return err
+	}
+	if len(res) == 0 {
+		printInfo(o.Out, "no change requests")
+		return nil
+	}
+	for i, cr := range res {
+		printSuccess(o.Out, "%d. %s", i+o.Offset+1, cr.ID)
+		printChangeRequest(o, cr)
+	}
+	return nil
+}
+
+// Get executes the get subcommand
+func (o *RequestOptions) Get() error {
+	res := &repo.ChangeRequest{}
+	if err := o.ChangeRequests.Get(&o.Args[0], res); err != nil {
+		return err
+	}
+	printSuccess(o.Out, "%s", res.ID)
+	printChangeRequest(o, res)
+	if res.Message != "" {
+		printInfo(o.Out, "\n%s", res.Message)
+	}
+	return nil
+}
+
+// Accept executes the accept subcommand
+func (o *RequestOptions) Accept() error {
+	res := &repo.ChangeRequest{}
+	if err := o.ChangeRequests.Accept(&o.Args[0], res); err != nil {
+		return err
+	}
+	printSuccess(o.Out, "accepted change request from %s, new version of %s: %s", res.Proposer, res.Target.AliasString(), res.Result)
+	return nil
+}
+
+// Reject executes the reject subcommand
+func (o *RequestOptions) Reject() error {
+	res := &repo.ChangeRequest{}
+	if err := o.ChangeRequests.Reject(&o.Args[0], res); err != nil {
+		return err
+	}
+	printSuccess(o.Out, "rejected change request from %s", res.Proposer)
+	return nil
+}
+
+func printChangeRequest(o *RequestOptions, cr *repo.ChangeRequest) {
+	if cr.Title != "" {
+		printInfo(o.Out, "\t%s", cr.Title)
+	}
+	printInfo(o.Out, "\ttarget: %s", cr.Target.String())
+	printInfo(o.Out, "\tproposer: %s", cr.Proposer)
+	printInfo(o.Out, "\tstatus: %s", cr.Status)
+	printInfo(o.Out, "\tcreated: %s", cr.Created.Format("Jan _2 15:04:05"))
+	if cr.Result != "" {
+		printInfo(o.Out, "\tresult: %s", cr.Result)
+	}
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/qri-io/qri/lib"
+)
+
+func TestRequestValidate(t *testing.T) {
+	cases := []struct {
+		args   []string
+		remote bool
+		err    string
+		msg    string
+	}{
+		{[]string{}, false, "", ""},
+		{[]string{"b5/precip"}, false, "", ""},
+		{[]string{}, true, lib.ErrBadArgs.Error(), "--remote requires a dataset reference, for example:\n    $ qri request list --remote b5/precip"},
+		{[]string{"b5/precip"}, true, "", ""},
+	}
+	for i, c := range cases {
+		opt := &RequestOptions{
+			Args:   c.args,
+			Remote: c.remote,
+		}
+
+		err := opt.Validate()
+		if (err == nil && c.err != "") || (err != nil && c.err != err.Error()) {
+			t.Errorf("case %d, mismatched error. Expected: %s, Got: %s", i, c.err, err)
+			continue
+		}
+		if libErr, ok := err.(lib.Error); ok {
+			if libErr.Message() != c.msg {
+				t.Errorf("case %d, mismatched user-friendly message. Expected: '%s', Got: '%s'", i, c.msg, libErr.Message())
+				continue
+			}
+		} else if c.msg != "" {
+			t.Errorf("case %d, mismatched user-friendly message. Expected: '%s', Got: ''", i, c.msg)
+			continue
+		}
+	}
+}
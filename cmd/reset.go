@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"github.com/qri-io/qri/lib"
+	"github.com/spf13/cobra"
+)
+
+// NewResetCommand creates a new `qri reset` cobra command for moving a dataset
+// back to an earlier version
+func NewResetCommand(f Factory, ioStreams IOStreams) *cobra.Command {
+	o := &ResetOptions{IOStreams: ioStreams}
+	cmd := &cobra.Command{
+		Use:   "reset",
+		Short: "Reset a dataset to an earlier version",
+		Long: `
+Reset moves a dataset back to an earlier version in its history. All versions
+saved after that version are removed from the dataset's history, and freed up
+for garbage collection if nothing else references them.
+
+Use ` + "`qri log`" + ` to find the path of the version to reset to.`,
+		Example: `  reset annual_pop to an earlier version:
+  $ qri reset me/annual_pop@/ipfs/QmcQsi93yUryyWvw6mPyDNoKRb7FcBx8QGBAeJ25kXQjnC`,
+		Annotations: map[string]string{
+			"group": "dataset",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(f, args); err != nil {
+				return err
+			}
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			return o.Run()
+		},
+	}
+
+	return cmd
+}
+
+// ResetOptions encapsulates state for the reset command
+type ResetOptions struct {
+	IOStreams
+
+	Ref string
+
+	DatasetRequests *lib.DatasetRequests
+}
+
+// Complete adds any missing configuration that can only be added just before calling Run
+func (o *ResetOptions) Complete(f Factory, args []string) (err error) {
+	if len(args) > 0 {
+		o.Ref = args[0]
+	}
+	o.DatasetRequests, err = f.DatasetRequests()
+	return
+}
+
+// Validate checks that all user input is valid
+func (o *ResetOptions) Validate() error {
+	if o.Ref == "" {
+		return lib.NewError(lib.ErrBadArgs, "please provide the dataset version to reset to, for example:\n    $ qri reset me/dataset@/ipfs/QmHash\nsee `qri reset --help` for more details")
+	}
+	return nil
+}
+
+// Run executes the reset command
+func (o *ResetOptions) Run() error {
+	ref, err := parseCmdLineDatasetRef(o.Ref)
+	if err != nil {
+		return err
+	}
+	if ref.Path == "" {
+		return lib.NewError(lib.ErrBadArgs, "please specify a version path to reset to, for example: me/dataset@/ipfs/QmHash")
+	}
+
+	res := &lib.ResetResponse{}
+	if err = o.DatasetRequests.Reset(&lib.ResetParams{Ref: ref}, res); err != nil {
+		return err
+	}
+
+	printSuccess(o.Out, "reset '%s', removed %d versions", res.Ref.AliasString(), len(res.Removed))
+	printInfo(o.Out, "path: %s", res.Ref.Path)
+	return nil
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/qri-io/qri/lib"
+)
+
+func TestResetValidate(t *testing.T) {
+	cases := []struct {
+		ref string
+		err string
+		msg string
+	}{
+		{"", lib.ErrBadArgs.Error(), "please provide the dataset version to reset to, for example:\n    $ qri reset me/dataset@/ipfs/QmHash\nsee `qri reset --help` for more details"},
+		{"me/test@/ipfs/QmHash", "", ""},
+	}
+	for i, c := range cases {
+		opt := &ResetOptions{
+			Ref: c.ref,
+		}
+
+		err := opt.Validate()
+		if (err == nil && c.err != "") || (err != nil && c.err != err.Error()) {
+			t.Errorf("case %d, mismatched error. Expected: %s, Got: %s", i, c.err, err)
+			continue
+		}
+		if libErr, ok := err.(lib.Error); ok {
+			if libErr.Message() != c.msg {
+				t.Errorf("case %d, mismatched user-friendly message. Expected: '%s', Got: '%s'", i, c.msg, libErr.Message())
+				continue
+			}
+		} else if c.msg != "" {
+			t.Errorf("case %d, mismatched user-friendly message. Expected: '%s', Got: ''", i, c.msg)
+			continue
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/dataset/dsutil"
+	"github.com/qri-io/qri/actions"
 	"github.com/qri-io/qri/lib"
 	"github.com/qri-io/qri/repo"
 	"github.com/spf13/cobra"
@@ -36,12 +37,26 @@ When you make an update and save a dataset that you originally added from a diff
 peer, the dataset gets renamed from ` + "`peers_name/dataset_name`" + ` to ` + "`my_name/dataset_name`" + `.
 
 The ` + "`--message`" + `" and ` + "`--title`" + ` flags allow you to add a commit message and title 
-to the save.`,
+to the save.
+
+Running save without a dataset reference from within a directory created by
+` + "`qri checkout`" + ` saves any changes made in that directory.
+
+Use ` + "`--dry-run`" + ` to run a dataset's transform without saving anything. Dry
+runs print the resulting structure and the first entries of the body. Transforms
+are limited by the transform section of your config, set limits with:
+  $ qri config set transform.timeout 1m`,
 		Example: `  # save updated data to dataset annual_pop:
   qri --body /path/to/data.csv me/annual_pop
 
   # save updated dataset (no data) to annual_pop:
-  qri --file /path/to/dataset.yaml me/annual_pop`,
+  qri --file /path/to/dataset.yaml me/annual_pop
+
+  # save changes in a checked out working directory:
+  qri save --title "updated population counts"
+
+  # preview the output of a transform without saving:
+  qri save --dry-run --file /path/to/dataset.yaml me/annual_pop`,
 		Annotations: map[string]string{
 			"group": "dataset",
 		},
@@ -63,6 +78,8 @@ to the save.`,
 	// cmd.Flags().BoolVarP(&o.ShowValidation, "show-validation", "s", false, "display a list of validation errors upon adding")
 	cmd.Flags().StringSliceVar(&o.Secrets, "secrets", nil, "transform secrets as comma separated key,value,key,value,... sequence")
 	cmd.Flags().BoolVarP(&o.Publish, "publish", "p", false, "publish this dataset to the registry")
+	cmd.Flags().BoolVar(&o.DryRun, "dry-run", false, "run the transform & print the result without saving")
+	cmd.Flags().IntVar(&o.DryRunEntries, "dry-run-entries", lib.DefaultDryRunEntries, "number of body entries to print in a dry run")
 
 	return cmd
 }
@@ -81,8 +98,13 @@ type SaveOptions struct {
 	ShowValidation bool
 	Publish        bool
 	Secrets        []string
+	DryRun         bool
+	DryRunEntries  int
+	// Dir is a checked out working directory to save changes from
+	Dir string
 
-	DatasetRequests *lib.DatasetRequests
+	DatasetRequests  *lib.DatasetRequests
+	ProgressRequests *lib.ProgressRequests
 }
 
 // Complete adds any missing configuration that can only be added just before calling Run
@@ -91,12 +113,26 @@ func (o *SaveOptions) Complete(f Factory, args []string) (err error) {
 		o.Ref = args[0]
 	}
 
-	o.DatasetRequests, err = f.DatasetRequests()
+	// with no reference or files, save changes in a working directory
+	if o.Ref == "" && o.FilePath == "" && o.BodyPath == "" {
+		wd := GetWd()
+		if _, e := actions.WorkingDirRef(wd); e == nil {
+			o.Dir = wd
+		}
+	}
+
+	if o.DatasetRequests, err = f.DatasetRequests(); err != nil {
+		return
+	}
+	o.ProgressRequests, err = f.ProgressRequests()
 	return
 }
 
 // Validate checks that all user input is valid
 func (o *SaveOptions) Validate() error {
+	if o.Dir != "" {
+		return nil
+	}
 	if o.Ref == "" {
 		return lib.NewError(lib.ErrBadArgs, "please provide the peername and dataset name you would like to update, in the format of `peername/dataset_name`\nsee `qri save --help` for more info")
 	}
@@ -108,6 +144,10 @@ func (o *SaveOptions) Validate() error {
 
 // Run executes the save command
 func (o *SaveOptions) Run() (err error) {
+	if o.Dir != "" {
+		return o.saveWorkingDir()
+	}
+
 	ref, err := parseCmdLineDatasetRef(o.Ref)
 	if err != nil && o.FilePath == "" {
 		return lib.NewError(lib.ErrBadArgs, "error parsing dataset reference '"+o.Ref+"'")
@@ -167,19 +207,73 @@ continue?`, true) {
 	}
 
 	p := &lib.SaveParams{
-		Dataset: dsp,
-		Private: false,
-		Publish: o.Publish,
+		Dataset:       dsp,
+		Private:       false,
+		Publish:       o.Publish,
+		DryRun:        o.DryRun,
+		DryRunEntries: o.DryRunEntries,
 	}
 
 	res := &repo.DatasetRef{}
-	if err = o.DatasetRequests.Save(p, res); err != nil {
+	if err = o.save(p, res); err != nil {
 		return err
 	}
 
+	if o.DryRun {
+		return o.printDryRun(res)
+	}
+
 	printSuccess(o.Out, "dataset saved: %s", res)
 	if res.Dataset.Structure.ErrCount > 0 {
 		printWarning(o.Out, fmt.Sprintf("this dataset has %d validation errors", res.Dataset.Structure.ErrCount))
 	}
 	return nil
 }
+
+// printDryRun writes the structure & body entries of a dry run
+func (o *SaveOptions) printDryRun(res *repo.DatasetRef) error {
+	st, err := json.MarshalIndent(res.Dataset.Structure, "", "  ")
+	if err != nil {
+		return err
+	}
+	body, err := json.MarshalIndent(res.Dataset.Body, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	printInfo(o.Out, "dry run of %s, nothing was saved", res.AliasString())
+	printInfo(o.Out, "structure:")
+	fmt.Fprintln(o.Out, string(st))
+	printInfo(o.Out, "first %d entries:", o.DryRunEntries)
+	fmt.Fprintln(o.Out, string(body))
+	return nil
+}
+
+// saveWorkingDir saves changes in a checked out working directory
+func (o *SaveOptions) saveWorkingDir() error {
+	p := &lib.SaveParams{
+		Dataset: &dataset.DatasetPod{
+			Commit: &dataset.CommitPod{
+				Title:   o.Title,
+				Message: o.Message,
+			},
+		},
+		Publish: o.Publish,
+		Dir:     o.Dir,
+	}
+
+	res := &repo.DatasetRef{}
+	if err := o.save(p, res); err != nil {
+		return err
+	}
+
+	printSuccess(o.Out, "dataset saved: %s", res)
+	return nil
+}
+
+// save calls DatasetRequests.Save, rendering progress while it runs
+func (o *SaveOptions) save(p *lib.SaveParams, res *repo.DatasetRef) error {
+	stop := watchProgress(o.ErrOut, o.ProgressRequests)
+	defer stop()
+	return o.DatasetRequests.Save(p, res)
+}
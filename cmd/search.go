@@ -18,10 +18,18 @@ func NewSearchCommand(f Factory, ioStreams IOStreams) *cobra.Command {
 		Long: `
 Search datasets & peers that match your query. Search pings the qri registry. 
 
-Any dataset that has been published to the registry is available for search.`,
+Any dataset that has been published to the registry is available for search.
+
+Use --network to search the datasets of connected qri peers instead of the
+registry. Network search requires a running qri node (qri connect), results
+are ranked by how many peers return a dataset & how well it matches each
+peer's search.`,
 		Example: `
   # search 
-  $ qri search "annual population"`,
+  $ qri search "annual population"
+
+  # search connected peers
+  $ qri search --network "annual population"`,
 		Annotations: map[string]string{
 			"group": "network",
 		},
@@ -37,6 +45,7 @@ Any dataset that has been published to the registry is available for search.`,
 	}
 
 	cmd.Flags().StringVarP(&o.Format, "format", "f", "", "set output format [json]")
+	cmd.Flags().BoolVarP(&o.Network, "network", "n", false, "search connected qri peers instead of the registry")
 
 	return cmd
 }
@@ -47,6 +56,7 @@ type SearchOptions struct {
 	Query          string
 	SearchRequests *lib.SearchRequests
 	Format         string
+	Network        bool
 	// TODO: add support for specifying limit and offset
 	// Limit int
 	// Offset int
@@ -79,6 +89,7 @@ func (o *SearchOptions) Run() (err error) {
 		QueryString: o.Query,
 		Limit:       100,
 		Offset:      0,
+		Network:     o.Network,
 	}
 
 	results := []lib.SearchResult{}
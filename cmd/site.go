@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"github.com/qri-io/qri/lib"
+	"github.com/spf13/cobra"
+)
+
+// NewSiteCommand creates a `qri site` subcommand for publishing datasets as a
+// static website
+func NewSiteCommand(f Factory, ioStreams IOStreams) *cobra.Command {
+	o := &SiteOptions{IOStreams: ioStreams}
+	cmd := &cobra.Command{
+		Use:   "site",
+		Short: "Publish your datasets as a static website",
+		Annotations: map[string]string{
+			"group": "dataset",
+		},
+	}
+
+	build := &cobra.Command{
+		Use:   "build DIR",
+		Short: "Write a static website of your datasets to a directory",
+		Long: `
+Build writes a static website of every dataset in your repo to a directory.
+The site has an index of datasets, a page for each dataset with it's viz,
+history and body downloads in json, csv & cbor, and your profile page.
+
+Links between pages are relative, so the directory can be hosted anywhere.
+Building the same repo twice writes identical files, rebuilding a site
+replaces the previous build. Build won't write to a directory that holds
+anything other than a site.`,
+		Example: `  build a site in the public directory:
+  $ qri site build public`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(f, args); err != nil {
+				return err
+			}
+			return o.Build()
+		},
+	}
+
+	cmd.AddCommand(build)
+	return cmd
+}
+
+// SiteOptions encapsulates state for the site command
+type SiteOptions struct {
+	IOStreams
+
+	Dir string
+
+	SiteRequests *lib.SiteRequests
+}
+
+// Complete adds any missing configuration that can only be added just before calling Run
+func (o *SiteOptions) Complete(f Factory, args []string) (err error) {
+	if f.RPC() != nil {
+		return usingRPCError("site")
+	}
+	o.Dir = args[0]
+	o.SiteRequests, err = f.SiteRequests()
+	return
+}
+
+// Build writes a site
+func (o *SiteOptions) Build() error {
+	res := &lib.SiteManifest{}
+	if err := o.SiteRequests.Build(&lib.BuildSiteParams{Dir: o.Dir}, res); err != nil {
+		return err
+	}
+	printSuccess(o.Out, "built site of %d datasets in %s", len(res.Datasets), o.Dir)
+	return nil
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/lib"
+	"github.com/spf13/cobra"
+)
+
+// NewSQLCommand creates a new `qri sql` cobra command for querying dataset
+// bodies with SQL
+func NewSQLCommand(f Factory, ioStreams IOStreams) *cobra.Command {
+	o := &SQLOptions{IOStreams: ioStreams}
+	cmd := &cobra.Command{
+		Use:   "sql",
+		Short: "Query datasets with SQL",
+		Long: `
+SQL runs a SELECT statement against the bodies of one or more datasets. Table
+names are dataset references, and column names come from the dataset's schema.
+Filters, projections, aggregates (count, sum, avg, min, max), grouping, ordering
+and joins are supported.
+
+Unquoted table names can include a peername, like ` + "`peer/dataset_name`" + `, and
+can be referred to by the dataset name or an alias. Wrap references to specific
+versions in double quotes: ` + "`\"me/dataset_name@/ipfs/QmHash\"`" + `.
+
+Use --save to write the result of a query to a new dataset in your repo.`,
+		Example: `  count cities by country:
+  $ qri sql "SELECT in_usa, count(*) FROM me/cities GROUP BY in_usa"
+
+  join two datasets, writing the result as csv:
+  $ qri sql -f csv "SELECT c.city, p.mayor FROM me/cities c JOIN peer/mayors p ON c.city = p.city"
+
+  save the result of a query as a new dataset:
+  $ qri sql --save big_cities "SELECT * FROM me/cities WHERE pop > 1000000"`,
+		Annotations: map[string]string{
+			"group": "dataset",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(f, args); err != nil {
+				return err
+			}
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			return o.Run()
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.Format, "format", "f", "json", "format to write results in. one of [json,csv]")
+	cmd.Flags().StringVarP(&o.Output, "output", "o", "", "path to write results to, default is stdout")
+	cmd.Flags().StringVarP(&o.SaveAs, "save", "s", "", "save the result as a new dataset with this name")
+	cmd.Flags().BoolVarP(&o.Publish, "publish", "p", false, "publish the saved dataset to the registry")
+
+	return cmd
+}
+
+// SQLOptions encapsulates state for the sql command
+type SQLOptions struct {
+	IOStreams
+
+	Query   string
+	Format  string
+	Output  string
+	SaveAs  string
+	Publish bool
+
+	QueryRequests *lib.QueryRequests
+}
+
+// Complete adds any missing configuration that can only be added just before calling Run
+func (o *SQLOptions) Complete(f Factory, args []string) (err error) {
+	if len(args) > 0 {
+		o.Query = args[0]
+	}
+	o.QueryRequests, err = f.QueryRequests()
+	return
+}
+
+// Validate checks that all user input is valid
+func (o *SQLOptions) Validate() error {
+	if o.Query == "" {
+		return lib.NewError(lib.ErrBadArgs, "please provide a query, for example:\n    $ qri sql \"SELECT * FROM me/dataset_name\"\nsee `qri sql --help` for more details")
+	}
+	if o.Format != "json" && o.Format != "csv" {
+		return lib.NewError(lib.ErrBadArgs, fmt.Sprintf("invalid format '%s', must be one of [json,csv]", o.Format))
+	}
+	if o.Publish && o.SaveAs == "" {
+		return lib.NewError(lib.ErrBadArgs, "--publish requires a dataset name to save to with --save")
+	}
+	return nil
+}
+
+// Run executes the sql command
+func (o *SQLOptions) Run() error {
+	df, err := dataset.ParseDataFormatString(o.Format)
+	if err != nil {
+		return err
+	}
+
+	p := &lib.QueryParams{
+		Query:   o.Query,
+		Format:  df,
+		SaveAs:  o.SaveAs,
+		Publish: o.Publish,
+	}
+	res := &lib.QueryResult{}
+	if err := o.QueryRequests.Query(p, res); err != nil {
+		return err
+	}
+
+	if o.Output != "" {
+		if err := ioutil.WriteFile(o.Output, res.Data, os.ModePerm); err != nil {
+			return err
+		}
+	} else {
+		fmt.Fprintln(o.Out, string(res.Data))
+	}
+
+	if res.Ref != nil {
+		printSuccess(o.ErrOut, "query result saved: %s", res.Ref)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/qri-io/qri/lib"
+)
+
+func TestSQLValidate(t *testing.T) {
+	cases := []struct {
+		query, format, save string
+		publish             bool
+		err                 string
+		msg                 string
+	}{
+		{"", "json", "", false, lib.ErrBadArgs.Error(), "please provide a query, for example:\n    $ qri sql \"SELECT * FROM me/dataset_name\"\nsee `qri sql --help` for more details"},
+		{"SELECT * FROM me/ds", "cbor", "", false, lib.ErrBadArgs.Error(), "invalid format 'cbor', must be one of [json,csv]"},
+		{"SELECT * FROM me/ds", "csv", "", true, lib.ErrBadArgs.Error(), "--publish requires a dataset name to save to with --save"},
+		{"SELECT * FROM me/ds", "json", "ds_copy", true, "", ""},
+	}
+	for i, c := range cases {
+		opt := &SQLOptions{
+			Query:   c.query,
+			Format:  c.format,
+			SaveAs:  c.save,
+			Publish: c.publish,
+		}
+
+		err := opt.Validate()
+		if (err == nil && c.err != "") || (err != nil && c.err != err.Error()) {
+			t.Errorf("case %d, mismatched error. Expected: %s, Got: %s", i, c.err, err)
+			continue
+		}
+		if libErr, ok := err.(lib.Error); ok {
+			if libErr.Message() != c.msg {
+				t.Errorf("case %d, mismatched user-friendly message. Expected: '%s', Got: '%s'", i, c.msg, libErr.Message())
+				continue
+			}
+		} else if c.msg != "" {
+			t.Errorf("case %d, mismatched user-friendly message. Expected: '%s', Got: ''", i, c.msg)
+			continue
+		}
+	}
+}
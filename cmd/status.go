@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/qri-io/dsdiff"
+	"github.com/qri-io/qri/actions"
+	"github.com/qri-io/qri/lib"
+	"github.com/spf13/cobra"
+)
+
+// NewStatusCommand creates a new `qri status` cobra command for showing
+// changes in a working directory
+func NewStatusCommand(f Factory, ioStreams IOStreams) *cobra.Command {
+	o := &StatusOptions{IOStreams: ioStreams}
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show changes in a dataset working directory",
+		Long: `
+Status compares a directory created with ` + "`qri checkout`" + ` to the version
+of the dataset it was checked out from, listing each component that has
+changed. Run status from within the directory, or pass the directory as an
+argument.`,
+		Example: `  # show changes in the current directory:
+  qri status
+
+  # show changes in another directory:
+  qri status annual_pop`,
+		Annotations: map[string]string{
+			"group": "dataset",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(f, args); err != nil {
+				return err
+			}
+			return o.Run()
+		},
+	}
+
+	return cmd
+}
+
+// StatusOptions encapsulates state for the status command
+type StatusOptions struct {
+	IOStreams
+
+	Dir string
+
+	DatasetRequests *lib.DatasetRequests
+}
+
+// Complete adds any missing configuration that can only be added just before calling Run
+func (o *StatusOptions) Complete(f Factory, args []string) (err error) {
+	o.Dir = GetWd()
+	if len(args) > 0 {
+		if o.Dir, err = filepath.Abs(args[0]); err != nil {
+			return err
+		}
+	}
+	o.DatasetRequests, err = f.DatasetRequests()
+	return
+}
+
+// Run executes the status command
+func (o *StatusOptions) Run() error {
+	res := &actions.WorkingDirStatus{}
+	if err := o.DatasetRequests.Status(&o.Dir, res); err != nil {
+		return err
+	}
+
+	printInfo(o.Out, "working directory for %s", res.Ref.AliasString())
+	printInfo(o.Out, "path: %s", res.Ref.Path)
+	if res.Behind {
+		printWarning(o.Out, "%s has newer versions than this directory", res.Ref.AliasString())
+	}
+
+	if len(res.Modified) == 0 {
+		printSuccess(o.Out, "no changes")
+		return nil
+	}
+
+	printInfo(o.Out, "\nchanged components:")
+	for _, component := range res.Modified {
+		printWarning(o.Out, "  %s", component)
+	}
+
+	if len(res.Components) > 0 {
+		diffs, err := dsdiff.MapDiffsToString(res.Components, "listKeys")
+		if err != nil {
+			return err
+		}
+		printDiffs(o.Out, diffs)
+	}
+	return nil
+}
@@ -29,7 +29,9 @@ type Config struct {
 	RPC     *RPC
 	Logging *Logging
 
-	Render *Render
+	Render    *Render
+	Transform *Transform
+	SQL       *SQL
 }
 
 // TODO: There should be no need for a version of DefaultConfig which *does* generate crypto keys
@@ -53,7 +55,9 @@ func DefaultConfig() *Config {
 		RPC:     DefaultRPC(),
 		Logging: DefaultLogging(),
 
-		Render: DefaultRender(),
+		Render:    DefaultRender(),
+		Transform: DefaultTransform(),
+		SQL:       DefaultSQL(),
 	}
 }
 
@@ -72,7 +76,9 @@ func DefaultConfigWithoutKeys() *Config {
 		RPC:     DefaultRPC(),
 		Logging: DefaultLogging(),
 
-		Render: DefaultRender(),
+		Render:    DefaultRender(),
+		Transform: DefaultTransform(),
+		SQL:       DefaultSQL(),
 	}
 }
 
@@ -307,6 +313,18 @@ func (cfg Config) Validate() error {
 	if err := cfg.RPC.Validate(); err != nil {
 		return err
 	}
+	// configs written before transform limits existed have no transform section
+	if cfg.Transform != nil {
+		if err := cfg.Transform.Validate(); err != nil {
+			return err
+		}
+	}
+	// configs written before sql limits existed have no sql section
+	if cfg.SQL != nil {
+		if err := cfg.SQL.Validate(); err != nil {
+			return err
+		}
+	}
 	return cfg.Logging.Validate()
 }
 
@@ -347,6 +365,12 @@ func (cfg *Config) Copy() *Config {
 	if cfg.Render != nil {
 		res.Render = cfg.Render.Copy()
 	}
+	if cfg.Transform != nil {
+		res.Transform = cfg.Transform.Copy()
+	}
+	if cfg.SQL != nil {
+		res.SQL = cfg.SQL.Copy()
+	}
 
 	return res
 }
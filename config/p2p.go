@@ -45,6 +45,11 @@ type P2P struct {
 	// any data that is verifyably posted by the same peer
 	ProfileReplication string `json:"profilereplication"`
 
+	// FollowReplication determines how much of a followed dataset this peer
+	// keeps when a followed peer announces a new version. "metadata" fetches
+	// only the dataset document, "full" also fetches & pins the body
+	FollowReplication string `json:"followreplication,omitempty"`
+
 	// list of addresses to bootsrap qri peers on
 	BootstrapAddrs []string `json:"bootstrapaddrs"`
 }
@@ -81,6 +86,7 @@ func NewP2P() *P2P {
 			"/ip4/35.192.140.245/tcp/4001/ipfs/QmUUVNiTz2K9zQSH9PxerKWXmN1p3DBo3oJXurvYziFzqh", // EDGI
 		},
 		ProfileReplication: "full",
+		FollowReplication:  "metadata",
 	}
 	return p2p
 }
@@ -186,6 +192,15 @@ func (cfg P2P) Validate() error {
           "full"
         ]
       },
+      "followreplication": {
+        "description": "Determines how much of a followed dataset to keep when a followed peer announces a new version. 'metadata' fetches only the dataset document, 'full' also fetches & pins the dataset body",
+        "type": "string",
+        "enum": [
+          "",
+          "metadata",
+          "full"
+        ]
+      },
       "bootstrapaddrs": {
         "description": "List of addresses to bootstrap qri peers on",
         "anyOf": [
@@ -210,6 +225,7 @@ func (cfg *P2P) Copy() *P2P {
 		PrivKey:            cfg.PrivKey,
 		Port:               cfg.Port,
 		ProfileReplication: cfg.ProfileReplication,
+		FollowReplication:  cfg.FollowReplication,
 		HTTPGatewayAddr:    cfg.HTTPGatewayAddr,
 	}
 
@@ -8,8 +8,13 @@ import (
 
 // Repo configures a qri repo
 type Repo struct {
+	// Middleware lists repo middlewares to enable, in order. Entries are a
+	// middleware name, optionally followed by ":" and an argument, eg:
+	// "read-only", "quota:10GB", "webhook:https://example.com/hook"
 	Middleware []string `json:"middleware"`
-	Type       string   `json:"type"`
+	// Type of repo to use, either "fs" for json files within the qri repo
+	// directory or "sqlite" for an embedded sqlite database
+	Type string `json:"type"`
 }
 
 // DefaultRepo creates & returns a new default repo configuration
@@ -40,7 +45,8 @@ func (cfg Repo) Validate() error {
         "description": "Type of repository",
         "type": "string",
         "enum": [
-          "fs"
+          "fs",
+          "sqlite"
         ]
       }
     }
@@ -10,6 +10,20 @@ func TestRepoValidate(t *testing.T) {
 	if err != nil {
 		t.Errorf("error validating default repo: %s", err)
 	}
+
+	for _, typ := range []string{"fs", "sqlite"} {
+		r := DefaultRepo()
+		r.Type = typ
+		if err := r.Validate(); err != nil {
+			t.Errorf("error validating repo type %s: %s", typ, err)
+		}
+	}
+
+	r := DefaultRepo()
+	r.Type = "invalid"
+	if err := r.Validate(); err == nil {
+		t.Error("expected an invalid repo type to error")
+	}
 }
 
 func TestRepoCopy(t *testing.T) {
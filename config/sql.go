@@ -0,0 +1,55 @@
+package config
+
+import (
+	"github.com/qri-io/jsonschema"
+)
+
+// SQL configures limits on the dataset bodies sql queries read into memory.
+// Zero values are unlimited
+type SQL struct {
+	// MaxEntries is the max number of body entries a query can read from a
+	// single dataset
+	MaxEntries int `json:"maxEntries"`
+	// MaxBytes is the max size of a dataset body a query can read, in bytes
+	MaxBytes int64 `json:"maxBytes"`
+}
+
+// DefaultSQL creates a new default SQL configuration
+func DefaultSQL() *SQL {
+	return &SQL{
+		MaxEntries: 1000000,
+		MaxBytes:   1 << 28,
+	}
+}
+
+// Validate validates all fields of sql returning all errors found.
+func (cfg SQL) Validate() error {
+	schema := jsonschema.Must(`{
+    "$schema": "http://json-schema.org/draft-06/schema#",
+    "title": "SQL",
+    "description": "Limits on dataset bodies read by sql queries",
+    "type": "object",
+    "properties": {
+      "maxEntries": {
+        "description": "max number of body entries a query can read from a dataset. 0 means no limit",
+        "type": "integer",
+        "minimum": 0
+      },
+      "maxBytes": {
+        "description": "max size of a dataset body a query can read, in bytes. 0 means no limit",
+        "type": "integer",
+        "minimum": 0
+      }
+    }
+  }`)
+	return validate(schema, &cfg)
+}
+
+// Copy returns a deep copy of the SQL struct
+func (cfg *SQL) Copy() *SQL {
+	res := &SQL{
+		MaxEntries: cfg.MaxEntries,
+		MaxBytes:   cfg.MaxBytes,
+	}
+	return res
+}
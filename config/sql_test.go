@@ -0,0 +1,43 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSQLValidate(t *testing.T) {
+	err := DefaultSQL().Validate()
+	if err != nil {
+		t.Errorf("error validating default sql: %s", err)
+	}
+
+	invalid := []*SQL{
+		{MaxEntries: -1},
+		{MaxBytes: -1},
+	}
+	for i, cfg := range invalid {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("case %d expected error validating sql", i)
+		}
+	}
+}
+
+func TestSQLCopy(t *testing.T) {
+	cases := []struct {
+		sql *SQL
+	}{
+		{DefaultSQL()},
+	}
+	for i, c := range cases {
+		cpy := c.sql.Copy()
+		if !reflect.DeepEqual(cpy, c.sql) {
+			t.Errorf("SQL Copy test case %v, sql structs are not equal: \ncopy: %v, \noriginal: %v", i, cpy, c.sql)
+			continue
+		}
+		cpy.MaxEntries = 1
+		if reflect.DeepEqual(cpy, c.sql) {
+			t.Errorf("SQL Copy test case %v, editing one sql struct should not affect the other: \ncopy: %v, \noriginal: %v", i, cpy, c.sql)
+			continue
+		}
+	}
+}
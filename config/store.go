@@ -4,7 +4,14 @@ import "github.com/qri-io/jsonschema"
 
 // Store configures a qri content addessed file store (cafs)
 type Store struct {
+	// Type of store, one of:
+	//   "ipfs" - an IPFS repo, required for sharing datasets over the network
+	//   "fs"   - a content-addressed directory on the local filesystem
+	//   "mem"  - an in-memory store, contents are lost when qri exits
 	Type string `json:"type"`
+	// Path is the location of an "fs" store, defaults to a "store" directory
+	// within the qri repo
+	Path string `json:"path,omitempty"`
 }
 
 // DefaultStore returns a new default Store configuration
@@ -27,8 +34,14 @@ func (cfg Store) Validate() error {
         "description": "Type of store",
         "type": "string",
         "enum": [
-          "ipfs"
+          "ipfs",
+          "fs",
+          "mem"
         ]
+      },
+      "path": {
+        "description": "Location of an fs store",
+        "type": "string"
       }
     }
   }`)
@@ -39,6 +52,7 @@ func (cfg Store) Validate() error {
 func (cfg *Store) Copy() *Store {
 	res := &Store{
 		Type: cfg.Type,
+		Path: cfg.Path,
 	}
 
 	return res
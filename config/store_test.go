@@ -10,6 +10,21 @@ func TestStoreValidate(t *testing.T) {
 	if err != nil {
 		t.Errorf("error validating default store: %s", err)
 	}
+
+	cases := []struct {
+		store *Store
+		err   bool
+	}{
+		{&Store{Type: "fs", Path: "/tmp/qri_store"}, false},
+		{&Store{Type: "mem"}, false},
+		{&Store{Type: "s3"}, true},
+	}
+	for i, c := range cases {
+		err := c.store.Validate()
+		if (err != nil) != c.err {
+			t.Errorf("case %d error mismatch. expected error: %t, got: %v", i, c.err, err)
+		}
+	}
 }
 
 func TestStoreCopy(t *testing.T) {
@@ -17,6 +32,7 @@ func TestStoreCopy(t *testing.T) {
 		store *Store
 	}{
 		{DefaultStore()},
+		{&Store{Type: "fs", Path: "/tmp/qri_store"}},
 	}
 	for i, c := range cases {
 		cpy := c.store.Copy()
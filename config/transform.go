@@ -0,0 +1,81 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/qri-io/jsonschema"
+)
+
+// Transform configures limits on executing transform scripts. Zero values
+// are unlimited
+type Transform struct {
+	// Timeout is the max wall-clock time a transform can run for, as a
+	// duration string like "30s" or "5m". empty means no time limit
+	Timeout string `json:"timeout"`
+	// MaxEntries is the max number of body entries a transform can output
+	MaxEntries int `json:"maxEntries"`
+	// MaxBytes is the max size of a transform's body output in bytes
+	MaxBytes int64 `json:"maxBytes"`
+}
+
+// DefaultTransform creates a new default Transform configuration
+func DefaultTransform() *Transform {
+	return &Transform{
+		Timeout:  "5m",
+		MaxBytes: 1 << 30,
+	}
+}
+
+// TimeoutDuration parses the Timeout field, an empty timeout is 0
+func (cfg Transform) TimeoutDuration() (time.Duration, error) {
+	if cfg.Timeout == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(cfg.Timeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid transform timeout '%s': %s", cfg.Timeout, err.Error())
+	}
+	return d, nil
+}
+
+// Validate validates all fields of transform returning all errors found.
+func (cfg Transform) Validate() error {
+	schema := jsonschema.Must(`{
+    "$schema": "http://json-schema.org/draft-06/schema#",
+    "title": "Transform",
+    "description": "Limits on executing transform scripts",
+    "type": "object",
+    "properties": {
+      "timeout": {
+        "description": "max time a transform can run for, as a duration string. empty means no limit",
+        "type": "string"
+      },
+      "maxEntries": {
+        "description": "max number of body entries a transform can output. 0 means no limit",
+        "type": "integer",
+        "minimum": 0
+      },
+      "maxBytes": {
+        "description": "max size of transform body output in bytes. 0 means no limit",
+        "type": "integer",
+        "minimum": 0
+      }
+    }
+  }`)
+	if err := validate(schema, &cfg); err != nil {
+		return err
+	}
+	_, err := cfg.TimeoutDuration()
+	return err
+}
+
+// Copy returns a deep copy of the Transform struct
+func (cfg *Transform) Copy() *Transform {
+	res := &Transform{
+		Timeout:    cfg.Timeout,
+		MaxEntries: cfg.MaxEntries,
+		MaxBytes:   cfg.MaxBytes,
+	}
+	return res
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTransformValidate(t *testing.T) {
+	err := DefaultTransform().Validate()
+	if err != nil {
+		t.Errorf("error validating default transform: %s", err)
+	}
+
+	invalid := []*Transform{
+		{Timeout: "ten minutes"},
+		{MaxEntries: -1},
+	}
+	for i, cfg := range invalid {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("case %d expected error validating transform", i)
+		}
+	}
+}
+
+func TestTransformTimeoutDuration(t *testing.T) {
+	d, err := (&Transform{}).TimeoutDuration()
+	if err != nil || d != 0 {
+		t.Errorf("expected empty timeout to be 0, got: %s, %v", d, err)
+	}
+	d, err = DefaultTransform().TimeoutDuration()
+	if err != nil || d != 5*time.Minute {
+		t.Errorf("expected default timeout to be 5m, got: %s, %v", d, err)
+	}
+}
+
+func TestTransformCopy(t *testing.T) {
+	cases := []struct {
+		transform *Transform
+	}{
+		{DefaultTransform()},
+	}
+	for i, c := range cases {
+		cpy := c.transform.Copy()
+		if !reflect.DeepEqual(cpy, c.transform) {
+			t.Errorf("Transform Copy test case %v, transform structs are not equal: \ncopy: %v, \noriginal: %v", i, cpy, c.transform)
+			continue
+		}
+		cpy.Timeout = "1s"
+		if reflect.DeepEqual(cpy, c.transform) {
+			t.Errorf("Transform Copy test case %v, editing one transform struct should not affect the other: \ncopy: %v, \noriginal: %v", i, cpy, c.transform)
+			continue
+		}
+	}
+}
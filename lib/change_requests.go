@@ -0,0 +1,124 @@
+package lib
+
+import (
+	"fmt"
+	"net/rpc"
+
+	"github.com/qri-io/qri/actions"
+	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/repo"
+)
+
+// ChangeRequests encapsulates business logic for proposing changes to
+// datasets owned by other peers
+type ChangeRequests struct {
+	node *p2p.QriNode
+	cli  *rpc.Client
+}
+
+// CoreRequestsName implements the Requests interface
+func (ChangeRequests) CoreRequestsName() string { return "change_requests" }
+
+// NewChangeRequests creates a ChangeRequests pointer from either a node or an
+// rpc.Client
+func NewChangeRequests(node *p2p.QriNode, cli *rpc.Client) *ChangeRequests {
+	if node != nil && cli != nil {
+		panic(fmt.Errorf("both node and client supplied to NewChangeRequests"))
+	}
+	return &ChangeRequests{
+		node: node,
+		cli:  cli,
+	}
+}
+
+// SendChangeRequestParams encapsulates arguments to the Send method
+type SendChangeRequestParams struct {
+	// Target is the dataset to propose a change to
+	Target repo.DatasetRef
+	// Proposal is a dataset version in the local repo to propose as the next
+	// version of Target
+	Proposal repo.DatasetRef
+	// Title & Message describe the proposed change
+	Title   string
+	Message string
+}
+
+// Send proposes a local dataset version as the next version of a dataset
+// owned by another peer
+func (r *ChangeRequests) Send(p *SendChangeRequestParams, res *repo.ChangeRequest) error {
+	if r.cli != nil {
+		return r.cli.Call("ChangeRequests.Send", p, res)
+	}
+	if p.Target.IsEmpty() {
+		return NewError(ErrBadArgs, "a target dataset is required")
+	}
+	if p.Proposal.IsEmpty() {
+		return NewError(ErrBadArgs, "a proposed dataset is required")
+	}
+
+	cr, err := actions.SendChangeRequest(r.node, p.Target, p.Proposal, p.Title, p.Message)
+	if err != nil {
+		return err
+	}
+	*res = *cr
+	return nil
+}
+
+// ListChangeRequestsParams encapsulates arguments to the List method
+type ListChangeRequestsParams struct {
+	ListParams
+	// Target restricts results to requests for a dataset
+	Target repo.DatasetRef
+	// Remote lists the requests the owner of Target has received instead of
+	// those in the local repo
+	Remote bool
+}
+
+// List lists change requests, most recent first
+func (r *ChangeRequests) List(p *ListChangeRequestsParams, res *[]*repo.ChangeRequest) (err error) {
+	if r.cli != nil {
+		return r.cli.Call("ChangeRequests.List", p, res)
+	}
+	*res, err = actions.ListChangeRequests(r.node, p.Target, p.Remote, p.Limit, p.Offset)
+	return
+}
+
+// Get fetches a change request by ID
+func (r *ChangeRequests) Get(id *string, res *repo.ChangeRequest) error {
+	if r.cli != nil {
+		return r.cli.Call("ChangeRequests.Get", id, res)
+	}
+	cr, err := actions.GetChangeRequest(r.node.Repo, *id)
+	if err != nil {
+		return err
+	}
+	*res = *cr
+	return nil
+}
+
+// Accept writes the proposed version of a change request as the next version
+// of the target dataset
+func (r *ChangeRequests) Accept(id *string, res *repo.ChangeRequest) error {
+	if r.cli != nil {
+		return r.cli.Call("ChangeRequests.Accept", id, res)
+	}
+	cr, _, err := actions.AcceptChangeRequest(r.node, *id)
+	if err != nil {
+		return err
+	}
+	*res = *cr
+	return nil
+}
+
+// Reject declines a change request
+func (r *ChangeRequests) Reject(id *string, res *repo.ChangeRequest) error {
+	if r.cli != nil {
+		return r.cli.Call("ChangeRequests.Reject", id, res)
+	}
+	cr, err := actions.RejectChangeRequest(r.node, *id)
+	if err != nil {
+		return err
+	}
+	*res = *cr
+	return nil
+}
@@ -0,0 +1,100 @@
+package lib
+
+import (
+	"testing"
+	"time"
+
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/repo"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestChangeRequests(t *testing.T) {
+	mr, err := testrepo.NewTestRepo(nil)
+	if err != nil {
+		t.Fatalf("error allocating test repo: %s", err.Error())
+	}
+	ref, err := mr.GetRef(repo.DatasetRef{Peername: "peer", Name: "movies"})
+	if err != nil {
+		t.Fatalf("error getting path: %s", err.Error())
+	}
+
+	cfg := config.DefaultP2PForTesting()
+	cfg.Enabled = false
+	node, err := p2p.NewTestableQriNode(mr, cfg)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	req := NewChangeRequests(node.(*p2p.QriNode), nil)
+
+	sendCases := []struct {
+		p   *SendChangeRequestParams
+		err string
+	}{
+		{&SendChangeRequestParams{}, "a target dataset is required"},
+		{&SendChangeRequestParams{Target: ref}, "a proposed dataset is required"},
+		{&SendChangeRequestParams{Target: ref, Proposal: ref}, "peer/movies is your own dataset, save a new version instead of sending a change request"},
+	}
+	for i, c := range sendCases {
+		got := &repo.ChangeRequest{}
+		err := req.Send(c.p, got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("send case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+		}
+	}
+
+	cr := &repo.ChangeRequest{
+		ID:         "/map/proposal",
+		Path:       "/map/proposal",
+		Target:     repo.DatasetRef{Peername: ref.Peername, ProfileID: ref.ProfileID, Name: ref.Name, Path: ref.Path},
+		Proposer:   "other_peer",
+		ProposerID: "QmOtherPeer",
+		Status:     repo.CRStatusOpen,
+		Created:    time.Now(),
+	}
+	if err := mr.PutChangeRequest(cr); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	listCases := []struct {
+		p   *ListChangeRequestsParams
+		res int
+		err string
+	}{
+		{&ListChangeRequestsParams{ListParams: ListParams{Limit: 10}}, 1, ""},
+		{&ListChangeRequestsParams{ListParams: ListParams{Limit: 10}, Target: repo.DatasetRef{Peername: "me", Name: "movies"}}, 1, ""},
+		{&ListChangeRequestsParams{ListParams: ListParams{Limit: 10}, Target: repo.DatasetRef{Peername: "me", Name: "cities"}}, 0, ""},
+		{&ListChangeRequestsParams{ListParams: ListParams{Limit: 10}, Remote: true}, 0, "a target dataset is required to list remote change requests"},
+	}
+	for i, c := range listCases {
+		got := []*repo.ChangeRequest{}
+		err := req.List(c.p, &got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("list case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if len(got) != c.res {
+			t.Errorf("list case %d count mismatch. expected: %d, got: %d", i, c.res, len(got))
+		}
+	}
+
+	id := cr.ID
+	got := &repo.ChangeRequest{}
+	if err := req.Get(&id, got); err != nil {
+		t.Fatal(err.Error())
+	}
+	if got.Proposer != "other_peer" {
+		t.Errorf("expected proposer to be other_peer, got: %s", got.Proposer)
+	}
+
+	if err := req.Reject(&id, got); err != nil {
+		t.Fatal(err.Error())
+	}
+	if got.Status != repo.CRStatusRejected {
+		t.Errorf("expected status to be rejected, got: %s", got.Status)
+	}
+	if err := req.Accept(&id, got); err == nil {
+		t.Error("expected accepting a rejected change request to error")
+	}
+}
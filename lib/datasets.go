@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"net/rpc"
+	"os"
 
 	"github.com/qri-io/cafs"
 
@@ -11,8 +12,10 @@ import (
 	"github.com/qri-io/dsdiff"
 	"github.com/qri-io/jsonschema"
 	"github.com/qri-io/qri/actions"
+	"github.com/qri-io/qri/config"
 	"github.com/qri-io/qri/p2p"
 	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/archive"
 )
 
 // DatasetRequests encapsulates business logic for working with Datasets on Qri
@@ -96,6 +99,31 @@ type SaveParams struct {
 	Dataset *dataset.DatasetPod // dataset to create
 	Private bool                // option to make dataset private. private data is not currently implimented, see https://github.com/qri-io/qri/issues/291 for updates
 	Publish bool
+	// Dir is a working directory created by Checkout to save changes from.
+	// when set, only the commit of Dataset is used
+	Dir string
+	// DryRun executes the dataset's transform without saving, the result
+	// has the resulting dataset & the first DryRunEntries entries of it's body
+	DryRun        bool
+	DryRunEntries int
+}
+
+// DefaultDryRunEntries is the number of body entries a dry run returns when
+// SaveParams.DryRunEntries isn't set
+const DefaultDryRunEntries = 10
+
+// transformOpts configures transforms with secrets & the limits set in
+// Config
+func transformOpts(secrets map[string]string) (*actions.TransformOpts, error) {
+	var cfg *config.Transform
+	if Config != nil {
+		cfg = Config.Transform
+	}
+	limits, err := actions.TransformLimitsFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &actions.TransformOpts{Secrets: secrets, Limits: limits}, nil
 }
 
 // New creates a new qri dataset from a source of data
@@ -116,7 +144,12 @@ func (r *DatasetRequests) New(p *SaveParams, res *repo.DatasetRef) (err error) {
 		defer bodyFile.Close()
 	}
 
-	*res, err = actions.CreateDataset(r.node, p.Dataset.Name, ds, bodyFile, secrets, true)
+	tfOpts, err := transformOpts(secrets)
+	if err != nil {
+		return err
+	}
+
+	*res, err = actions.CreateDataset(r.node, p.Dataset.Name, ds, bodyFile, tfOpts, true)
 	if err != nil {
 		log.Debugf("error creating dataset: %s\n", err.Error())
 		return err
@@ -151,17 +184,40 @@ func (r *DatasetRequests) Save(p *SaveParams, res *repo.DatasetRef) (err error)
 		return fmt.Errorf("option to make dataset private not yet implimented, refer to https://github.com/qri-io/qri/issues/291 for updates")
 	}
 
-	ds, body, secrets, err := actions.UpdateDataset(r.node, p.Dataset)
-	if err != nil {
-		return err
+	if p.DryRun {
+		return r.dryRun(p, res)
 	}
 
-	ref, err := actions.CreateDataset(r.node, p.Dataset.Name, ds, body, secrets, true)
-	if err != nil {
-		log.Debugf("create ds error: %s\n", err.Error())
-		return err
+	var ref repo.DatasetRef
+	if p.Dir != "" {
+		var commit *dataset.CommitPod
+		if p.Dataset != nil {
+			commit = p.Dataset.Commit
+		}
+		tfOpts, err := transformOpts(nil)
+		if err != nil {
+			return err
+		}
+		if ref, err = actions.SaveWorkingDir(r.node, p.Dir, commit, tfOpts.Limits); err != nil {
+			return err
+		}
+	} else {
+		ds, body, secrets, err := actions.UpdateDataset(r.node, p.Dataset)
+		if err != nil {
+			return err
+		}
+		tfOpts, err := transformOpts(secrets)
+		if err != nil {
+			return err
+		}
+
+		ref, err = actions.CreateDataset(r.node, p.Dataset.Name, ds, body, tfOpts, true)
+		if err != nil {
+			log.Debugf("create ds error: %s\n", err.Error())
+			return err
+		}
+		ref.Dataset = ds.Encode()
 	}
-	ref.Dataset = ds.Encode()
 
 	if p.Publish {
 		var done bool
@@ -174,6 +230,42 @@ func (r *DatasetRequests) Save(p *SaveParams, res *repo.DatasetRef) (err error)
 	return nil
 }
 
+// dryRun executes the transform of a dataset update without saving, setting
+// res.Dataset to the result
+func (r *DatasetRequests) dryRun(p *SaveParams, res *repo.DatasetRef) error {
+	if p.Dir != "" {
+		return fmt.Errorf("dry runs of working directories aren't supported")
+	}
+	if p.Dataset == nil {
+		return fmt.Errorf("dataset is required")
+	}
+
+	ds, body, secrets, err := actions.UpdateDataset(r.node, p.Dataset)
+	if err != nil {
+		return err
+	}
+	tfOpts, err := transformOpts(secrets)
+	if err != nil {
+		return err
+	}
+
+	entries := p.DryRunEntries
+	if entries == 0 {
+		entries = DefaultDryRunEntries
+	}
+	pod, err := actions.DryRunTransform(r.node, ds, body, *tfOpts, entries)
+	if err != nil {
+		return err
+	}
+
+	*res = repo.DatasetRef{
+		Peername: p.Dataset.Peername,
+		Name:     p.Dataset.Name,
+		Dataset:  pod,
+	}
+	return nil
+}
+
 // RenameParams defines parameters for Dataset renaming
 type RenameParams struct {
 	Current, New repo.DatasetRef
@@ -229,6 +321,94 @@ func (r *DatasetRequests) Remove(p *repo.DatasetRef, ok *bool) (err error) {
 	return nil
 }
 
+// CheckoutParams defines parameters for checking out a dataset version into
+// a working directory
+type CheckoutParams struct {
+	Ref repo.DatasetRef
+	// Dir is the directory to write to, must be empty or not exist
+	Dir string
+}
+
+// Checkout writes a dataset version to a working directory for editing
+func (r *DatasetRequests) Checkout(p *CheckoutParams, res *repo.DatasetRef) (err error) {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.Checkout", p, res)
+	}
+
+	if p.Dir == "" {
+		return fmt.Errorf("directory is required")
+	}
+	ref := p.Ref
+	if err = DefaultSelectedRef(r.node.Repo, &ref); err != nil {
+		return err
+	}
+	if err = actions.CheckoutDataset(r.node, &ref, p.Dir); err != nil {
+		return err
+	}
+
+	*res = ref
+	return nil
+}
+
+// Status compares a working directory to the dataset version it was checked
+// out from
+func (r *DatasetRequests) Status(dir *string, res *actions.WorkingDirStatus) (err error) {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.Status", dir, res)
+	}
+
+	status, err := actions.GetWorkingDirStatus(r.node, *dir)
+	if err != nil {
+		return err
+	}
+	*res = *status
+	return nil
+}
+
+// ResetParams defines parameters for moving a dataset back to an earlier version
+type ResetParams struct {
+	Ref repo.DatasetRef
+	// Revisions is the number of versions to drop from the head of the dataset's
+	// history. If zero, Ref.Path specifies the version to reset to
+	Revisions int
+}
+
+// ResetResponse is the result of resetting a dataset
+type ResetResponse struct {
+	// Ref is the new head of the dataset
+	Ref repo.DatasetRef `json:"ref"`
+	// Removed lists the paths of versions removed from history
+	Removed []string `json:"removed"`
+}
+
+// Reset moves a dataset back to an earlier version, removing any newer versions
+// from its history
+func (r *DatasetRequests) Reset(p *ResetParams, res *ResetResponse) (err error) {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.Reset", p, res)
+	}
+
+	ref := p.Ref
+	if ref.Peername == "" && ref.Name == "" {
+		return fmt.Errorf("peername & name are required")
+	}
+
+	var removed []string
+	if p.Revisions > 0 {
+		removed, err = actions.RemoveRevisions(r.node, &ref, p.Revisions)
+	} else if ref.Path != "" {
+		removed, err = actions.ResetDataset(r.node, &ref, ref.Path)
+	} else {
+		return fmt.Errorf("either a number of revisions or a version path is required")
+	}
+	if err != nil {
+		return err
+	}
+
+	*res = ResetResponse{Ref: ref, Removed: removed}
+	return nil
+}
+
 // LookupParams defines parameters for looking up the body of a dataset
 type LookupParams struct {
 	Format        dataset.DataFormat
@@ -278,6 +458,109 @@ func (r *DatasetRequests) Add(ref *repo.DatasetRef, res *repo.DatasetRef) (err e
 	return err
 }
 
+// ExportHistoryParams defines parameters for exporting the history of a
+// dataset
+type ExportHistoryParams struct {
+	Ref repo.DatasetRef
+	// Path of the archive file to write
+	Path string
+	// Format of the archive, defaults to tar.gz
+	Format archive.Format
+}
+
+// ExportHistory writes every version of a dataset to an archive file
+func (r *DatasetRequests) ExportHistory(p *ExportHistoryParams, res *archive.Manifest) (err error) {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.ExportHistory", p, res)
+	}
+	if p.Path == "" {
+		return fmt.Errorf("archive path is required")
+	}
+
+	ref := p.Ref
+	if err = DefaultSelectedRef(r.node.Repo, &ref); err != nil {
+		return err
+	}
+
+	f, err := os.Create(p.Path)
+	if err != nil {
+		return fmt.Errorf("error creating archive: %s", err.Error())
+	}
+	defer f.Close()
+
+	man, err := actions.ExportDatasetHistory(r.node, &ref, f, p.Format)
+	if err != nil {
+		os.Remove(p.Path)
+		return err
+	}
+	*res = *man
+	return f.Close()
+}
+
+// ImportParams defines parameters for importing a dataset archive
+type ImportParams struct {
+	// Path of the archive file to read
+	Path string
+	// Name to add the dataset under, defaults to the archived name
+	Name string
+}
+
+// Import reads a dataset archive written by ExportHistory into the repo,
+// recreating the dataset's history
+func (r *DatasetRequests) Import(p *ImportParams, res *archive.ImportResult) (err error) {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.Import", p, res)
+	}
+	if p.Path == "" {
+		return fmt.Errorf("archive path is required")
+	}
+
+	f, err := os.Open(p.Path)
+	if err != nil {
+		return fmt.Errorf("error opening archive: %s", err.Error())
+	}
+	defer f.Close()
+
+	result, err := actions.ImportDatasetHistory(r.node, f, p.Name)
+	if err != nil {
+		return err
+	}
+	*res = *result
+	return nil
+}
+
+// MergeParams defines parameters for merging two versions of a dataset
+type MergeParams struct {
+	// Base is the common ancestor of Ours & Theirs. If empty, the most recent
+	// common ancestor is used
+	Base repo.DatasetRef
+	// Ours is the local dataset the merge will be written to
+	Ours repo.DatasetRef
+	// Theirs is the version to merge into ours
+	Theirs repo.DatasetRef
+}
+
+// Merge performs a three-way merge of two versions of a dataset that share history,
+// writing a new version of Ours on success. Any changes that cannot be merged
+// automatically are listed in res.Conflicts, in which case no version is written
+func (r *DatasetRequests) Merge(p *MergeParams, res *actions.DatasetMergeResult) (err error) {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.Merge", p, res)
+	}
+
+	if err = DefaultSelectedRef(r.node.Repo, &p.Ours); err != nil {
+		return
+	}
+
+	result, err := actions.MergeDatasets(r.node, p.Base, p.Ours, p.Theirs)
+	if err != nil {
+		return err
+	}
+
+	*res = *result
+	return nil
+}
+
 // ValidateDatasetParams defines paremeters for dataset
 // data validation
 type ValidateDatasetParams struct {
@@ -330,10 +613,26 @@ type DiffParams struct {
 	// if DiffAll is false, DiffComponents specifies which components of a dataset to diff
 	// currently supported components include "structure", "data", "meta", "transform", and "viz"
 	DiffComponents map[string]bool
+	// DiffBody calculates a row-level diff of dataset bodies
+	DiffBody bool
+	// PrimaryKey overrides any primary key declared in the dataset schema when
+	// matching body rows
+	PrimaryKey []string
+	// MaxEntries caps the number of body entries held in memory while diffing
+	// MaxChanges caps the number of changed rows returned
+	MaxEntries, MaxChanges int
+}
+
+// DiffResponse is the result of a call to Diff
+type DiffResponse struct {
+	// Components maps dataset component names to their differences
+	Components map[string]*dsdiff.SubDiff
+	// Body holds row-level body differences if DiffParams.DiffBody is true
+	Body *actions.BodyDiff
 }
 
 // Diff computes the diff of two datasets
-func (r *DatasetRequests) Diff(p *DiffParams, diffs *map[string]*dsdiff.SubDiff) (err error) {
+func (r *DatasetRequests) Diff(p *DiffParams, res *DiffResponse) (err error) {
 	refs := []repo.DatasetRef{}
 
 	// Handle `qri use` to get the current default dataset.
@@ -351,6 +650,12 @@ func (r *DatasetRequests) Diff(p *DiffParams, diffs *map[string]*dsdiff.SubDiff)
 		p.Right = refs[1]
 	}
 
-	*diffs, err = actions.DiffDatasets(r.node, p.Left, p.Right, p.DiffAll, p.DiffComponents)
+	if res.Components, err = actions.DiffDatasets(r.node, p.Left, p.Right, p.DiffAll, p.DiffComponents); err != nil {
+		return
+	}
+
+	if p.DiffBody {
+		res.Body, err = actions.DiffBodies(r.node, p.Left, p.Right, p.PrimaryKey, p.MaxEntries, p.MaxChanges)
+	}
 	return
 }
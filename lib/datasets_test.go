@@ -19,6 +19,7 @@ import (
 	"github.com/qri-io/dataset/dstest"
 	"github.com/qri-io/dsdiff"
 	"github.com/qri-io/jsonschema"
+	"github.com/qri-io/qri/actions"
 	"github.com/qri-io/qri/config"
 	"github.com/qri-io/qri/p2p"
 	"github.com/qri-io/qri/p2p/test"
@@ -536,6 +537,55 @@ func TestDatasetRequestsRemove(t *testing.T) {
 	}
 }
 
+func TestDatasetRequestsReset(t *testing.T) {
+	rc, _ := regmock.NewMockServer()
+	mr, err := testrepo.NewTestRepo(rc)
+	if err != nil {
+		t.Fatalf("error allocating test repo: %s", err.Error())
+	}
+	node, err := p2p.NewQriNode(mr, config.DefaultP2PForTesting())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	req := NewDatasetRequests(node, nil)
+	prev, err := mr.GetRef(repo.DatasetRef{Peername: "peer", Name: "cities"})
+	if err != nil {
+		t.Fatalf("error getting cities ref: %s", err.Error())
+	}
+	head := &repo.DatasetRef{}
+	if err := req.Save(&SaveParams{Dataset: &dataset.DatasetPod{Peername: "me", Name: "cities", Meta: &dataset.Meta{Title: "updated cities"}}}, head); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	cases := []struct {
+		p   *ResetParams
+		err string
+	}{
+		{&ResetParams{}, "peername & name are required"},
+		{&ResetParams{Ref: repo.DatasetRef{Peername: "me", Name: "cities"}}, "either a number of revisions or a version path is required"},
+		{&ResetParams{Ref: repo.DatasetRef{Peername: "me", Name: "cities"}, Revisions: 2}, "peer/cities only has 2 versions, cannot remove 2. remove the dataset to delete its entire history"},
+		{&ResetParams{Ref: repo.DatasetRef{Peername: "me", Name: "cities"}, Revisions: 1}, ""},
+	}
+
+	for i, c := range cases {
+		got := &ResetResponse{}
+		err := req.Reset(c.p, got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err == "" {
+			if got.Ref.Path != prev.Path {
+				t.Errorf("case %d expected reset path to be %s, got: %s", i, prev.Path, got.Ref.Path)
+			}
+			if len(got.Removed) != 1 || got.Removed[0] != head.Path {
+				t.Errorf("case %d expected removed versions to be [%s], got: %v", i, head.Path, got.Removed)
+			}
+		}
+	}
+}
+
 func TestDatasetRequestsLookupBody(t *testing.T) {
 	rc, _ := regmock.NewMockServer()
 	mr, err := testrepo.NewTestRepo(rc)
@@ -843,8 +893,8 @@ func TestDatasetRequestsDiff(t *testing.T) {
 			DiffAll:        c.All,
 			DiffComponents: c.Components,
 		}
-		res := map[string]*dsdiff.SubDiff{}
-		err := req.Diff(p, &res)
+		res := &DiffResponse{}
+		err := req.Diff(p, res)
 		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
 			t.Errorf("case %d error mismatch: expected '%s', got '%s'", i, c.err, err.Error())
 		}
@@ -853,7 +903,7 @@ func TestDatasetRequestsDiff(t *testing.T) {
 			continue
 		}
 
-		stringDiffs, err := dsdiff.MapDiffsToString(res, c.displayFormat)
+		stringDiffs, err := dsdiff.MapDiffsToString(res.Components, c.displayFormat)
 		if err != nil {
 			t.Errorf("case %d error mapping to string: %s", i, err.Error())
 		}
@@ -861,4 +911,59 @@ func TestDatasetRequestsDiff(t *testing.T) {
 			t.Errorf("case %d response mistmatch: expected '%s', got '%s'", i, c.expected, stringDiffs)
 		}
 	}
+
+	res := &DiffResponse{}
+	if err := req.Diff(&DiffParams{Left: dsRef1, Right: dsRef2, DiffBody: true}, res); err != nil {
+		t.Fatalf("error diffing bodies: %s", err.Error())
+	}
+	if res.Body == nil {
+		t.Fatal("expected body diff to be populated")
+	}
+	if len(res.Body.Added) != 1 {
+		t.Errorf("expected 1 added row, got %d", len(res.Body.Added))
+	}
+}
+
+func TestDatasetRequestsMerge(t *testing.T) {
+	rc, _ := regmock.NewMockServer()
+	mr, err := testrepo.NewTestRepo(rc)
+	if err != nil {
+		t.Fatalf("error allocating test repo: %s", err.Error())
+	}
+	node, err := p2p.NewQriNode(mr, config.DefaultP2PForTesting())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	movies, err := mr.GetRef(repo.DatasetRef{Peername: "peer", Name: "movies"})
+	if err != nil {
+		t.Fatalf("error getting movies ref: %s", err.Error())
+	}
+	cities, err := mr.GetRef(repo.DatasetRef{Peername: "peer", Name: "cities"})
+	if err != nil {
+		t.Fatalf("error getting cities ref: %s", err.Error())
+	}
+
+	cases := []struct {
+		p   *MergeParams
+		err string
+	}{
+		{&MergeParams{Ours: movies}, "please provide two dataset references to merge"},
+		{&MergeParams{Ours: movies, Theirs: cities}, actions.ErrNoCommonAncestor.Error()},
+		{&MergeParams{Ours: movies, Theirs: movies}, ""},
+	}
+
+	req := NewDatasetRequests(node, nil)
+	for i, c := range cases {
+		got := &actions.DatasetMergeResult{}
+		err := req.Merge(c.p, got)
+
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err == "" && got.Ref.Path != movies.Path {
+			t.Errorf("case %d expected merging a dataset with itself to leave ref unchanged", i)
+		}
+	}
 }
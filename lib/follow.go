@@ -0,0 +1,111 @@
+package lib
+
+import (
+	"fmt"
+	"net/rpc"
+
+	"github.com/qri-io/qri/actions"
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/repo"
+
+	peer "gx/ipfs/QmdVrMn1LhB4ybb8hMVaMLXnA8XRSewMnK6YqXKXoTcRvN/go-libp2p-peer"
+)
+
+// FollowRequests encapsulates business logic for following peers & datasets
+type FollowRequests struct {
+	node *p2p.QriNode
+	cli  *rpc.Client
+}
+
+// CoreRequestsName implements the Requests interface
+func (FollowRequests) CoreRequestsName() string { return "follow" }
+
+// NewFollowRequests creates a FollowRequests pointer from either a node or an
+// rpc.Client
+func NewFollowRequests(node *p2p.QriNode, cli *rpc.Client) *FollowRequests {
+	if node != nil && cli != nil {
+		panic(fmt.Errorf("both node and client supplied to NewFollowRequests"))
+	}
+	return &FollowRequests{
+		node: node,
+		cli:  cli,
+	}
+}
+
+// parseFollow reads a follow from a "peername" or "peername/name" string
+func parseFollow(ref string) (*repo.Follow, error) {
+	dsr, err := repo.ParseDatasetRef(ref)
+	if err != nil {
+		return nil, NewError(err, fmt.Sprintf("'%s' isn't a valid peername or dataset reference", ref))
+	}
+	if dsr.Peername == "" {
+		return nil, NewError(repo.ErrPeernameRequired, "a peername is required to follow, for example: b5 or b5/world_bank_population")
+	}
+	return &repo.Follow{Peername: dsr.Peername, ProfileID: dsr.ProfileID, Name: dsr.Name}, nil
+}
+
+// Follow adds a peer or a dataset to the list of follows. New versions of
+// followed datasets are replicated as followed peers announce them. If the
+// followed peer is connected it's recent versions are replicated right away
+func (r *FollowRequests) Follow(ref *string, res *repo.Follow) error {
+	if r.cli != nil {
+		return r.cli.Call("FollowRequests.Follow", ref, res)
+	}
+
+	f, err := parseFollow(*ref)
+	if err != nil {
+		return err
+	}
+	if err := actions.Follow(r.node.Repo, f); err != nil {
+		return err
+	}
+
+	if r.node.Online && f.ProfileID != "" {
+		var cfg *config.P2P
+		if Config != nil {
+			cfg = Config.P2P
+		}
+		policy := actions.ReplicationPolicy(cfg)
+		for _, pid := range r.node.ClosestConnectedPeers(f.ProfileID, 1) {
+			go func(pid peer.ID) {
+				if _, err := actions.SyncFollowedPeer(r.node, pid, policy); err != nil {
+					log.Debugf("error syncing followed peer: %s", err.Error())
+				}
+			}(pid)
+		}
+	}
+
+	*res = *f
+	return nil
+}
+
+// Unfollow removes a peer or dataset from the list of follows. Replicated
+// versions are kept
+func (r *FollowRequests) Unfollow(ref *string, ok *bool) error {
+	if r.cli != nil {
+		return r.cli.Call("FollowRequests.Unfollow", ref, ok)
+	}
+
+	f, err := parseFollow(*ref)
+	if err != nil {
+		return err
+	}
+	if err := actions.Unfollow(r.node.Repo, f.Peername, f.Name); err != nil {
+		if err == repo.ErrNotFound {
+			return NewError(err, fmt.Sprintf("you aren't following %s", f.String()))
+		}
+		return err
+	}
+	*ok = true
+	return nil
+}
+
+// List lists followed peers & datasets
+func (r *FollowRequests) List(done *bool, res *[]*repo.Follow) (err error) {
+	if r.cli != nil {
+		return r.cli.Call("FollowRequests.List", done, res)
+	}
+	*res, err = actions.ListFollows(r.node.Repo)
+	return err
+}
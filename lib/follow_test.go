@@ -0,0 +1,70 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/repo"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestFollowRequests(t *testing.T) {
+	mr, err := testrepo.NewTestRepo(nil)
+	if err != nil {
+		t.Fatalf("error allocating test repo: %s", err.Error())
+	}
+	node, err := p2p.NewQriNode(mr, config.DefaultP2PForTesting())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	req := NewFollowRequests(node, nil)
+
+	bad := []string{"", "peer", "peer/cities"}
+	for i, ref := range bad {
+		if err := req.Follow(&ref, &repo.Follow{}); err == nil {
+			t.Errorf("case %d: expected following '%s' to error", i, ref)
+		}
+	}
+
+	for _, ref := range []string{"b5", "b5/world_bank_population", "steve/movies"} {
+		res := &repo.Follow{}
+		if err := req.Follow(&ref, res); err != nil {
+			t.Fatalf("error following %s: %s", ref, err.Error())
+		}
+		if res.String() != ref {
+			t.Errorf("follow mismatch. expected: %s, got: %s", ref, res.String())
+		}
+		if res.Created.IsZero() {
+			t.Errorf("expected %s follow to have a created time", ref)
+		}
+	}
+
+	var done bool
+	follows := []*repo.Follow{}
+	if err := req.List(&done, &follows); err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(follows) != 3 {
+		t.Errorf("expected 3 follows, got: %d", len(follows))
+	}
+
+	ref := "b5/world_bank_population"
+	var ok bool
+	if err := req.Unfollow(&ref, &ok); err != nil {
+		t.Fatal(err.Error())
+	}
+	if !ok {
+		t.Error("expected unfollow to be ok")
+	}
+	if err := req.Unfollow(&ref, &ok); err == nil {
+		t.Error("expected unfollowing twice to error")
+	}
+
+	if err := req.List(&done, &follows); err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(follows) != 2 || follows[0].String() != "b5" || follows[1].String() != "steve/movies" {
+		t.Errorf("unexpected follows after unfollow: %v", follows)
+	}
+}
@@ -42,5 +42,11 @@ func Receivers(node *p2p.QriNode) []Requests {
 		NewSearchRequests(node, nil),
 		NewRenderRequests(node.Repo, nil),
 		NewSelectionRequests(node.Repo, nil),
+		NewRepoRequests(node, nil),
+		NewQueryRequests(node, nil),
+		NewChangeRequests(node, nil),
+		NewSiteRequests(node, nil),
+		NewFollowRequests(node, nil),
+		NewProgressRequests(node, nil),
 	}
 }
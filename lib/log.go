@@ -3,10 +3,13 @@ package lib
 import (
 	"fmt"
 	"net/rpc"
+	"time"
 
 	"github.com/qri-io/qri/actions"
 	"github.com/qri-io/qri/p2p"
 	"github.com/qri-io/qri/repo"
+
+	peer "gx/ipfs/QmdVrMn1LhB4ybb8hMVaMLXnA8XRSewMnK6YqXKXoTcRvN/go-libp2p-peer"
 )
 
 // LogRequests encapsulates business logic for the log
@@ -52,3 +55,66 @@ func (r *LogRequests) Log(params *LogParams, res *[]repo.DatasetRef) (err error)
 	*res, err = actions.DatasetLog(r.node, ref, params.Limit, params.Offset)
 	return
 }
+
+// EventsParams defines parameters for the Events method
+type EventsParams struct {
+	ListParams
+	// Reference to the dataset to list events for. An empty reference lists
+	// events for all datasets
+	Ref repo.DatasetRef
+	// Type restricts results to a single event type
+	Type repo.EventType
+	// Actor restricts results to events performed by a peername, profileID
+	// or peerID
+	Actor string
+	// Since & Until restrict results to a window of time
+	Since time.Time
+	Until time.Time
+}
+
+// Events lists repo events for a dataset, most recent first
+func (r *LogRequests) Events(params *EventsParams, res *[]*repo.Event) (err error) {
+	if r.cli != nil {
+		return r.cli.Call("LogRequests.Events", params, res)
+	}
+
+	f := actions.EventFilter{
+		Ref:   params.Ref,
+		Type:  params.Type,
+		Actor: params.Actor,
+		Since: params.Since,
+		Until: params.Until,
+	}
+	*res, err = actions.DatasetEvents(r.node.Repo, f, params.Limit, params.Offset)
+	return
+}
+
+// ReconcileEventsParams defines parameters for the ReconcileEvents method
+type ReconcileEventsParams struct {
+	// PeerID is the base58-encoded ID of the peer to reconcile with
+	PeerID string
+}
+
+// ReconcileEvents keeps the repo in sync with a peer's, applying dataset
+// creations, renames, resets & deletions from the peer's event log that don't
+// conflict with local changes. Conflicts are reported, not applied
+func (r *LogRequests) ReconcileEvents(p *ReconcileEventsParams, res *actions.EventsReconciliation) error {
+	if r.cli != nil {
+		return r.cli.Call("LogRequests.ReconcileEvents", p, res)
+	}
+
+	if !r.node.Online {
+		return fmt.Errorf("error: not connected, run `qri connect` in another window")
+	}
+	id, err := peer.IDB58Decode(p.PeerID)
+	if err != nil {
+		return fmt.Errorf("error decoding peer Id: %s", err.Error())
+	}
+
+	rec, err := actions.ReconcileEvents(r.node, id)
+	if err != nil {
+		return err
+	}
+	*res = *rec
+	return nil
+}
@@ -52,3 +52,46 @@ func TestHistoryRequestsLog(t *testing.T) {
 		}
 	}
 }
+
+func TestHistoryRequestsEvents(t *testing.T) {
+	mr, err := testrepo.NewTestRepo(nil)
+	if err != nil {
+		t.Fatalf("error allocating test repo: %s", err.Error())
+	}
+	movies := repo.DatasetRef{Peername: "peer", Name: "movies"}
+	if err := mr.LogEvent(repo.ETDsCreated, movies); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := mr.LogEvent(repo.ETDsPinned, movies); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	cfg := config.DefaultP2PForTesting()
+	cfg.Enabled = false
+	node, err := p2p.NewTestableQriNode(mr, cfg)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	cases := []struct {
+		p      *EventsParams
+		expect int
+	}{
+		{&EventsParams{ListParams: ListParams{Limit: 10}, Ref: movies}, 2},
+		{&EventsParams{ListParams: ListParams{Limit: 10}, Ref: movies, Type: repo.ETDsPinned}, 1},
+		{&EventsParams{ListParams: ListParams{Limit: 10}, Type: repo.ETDsCreated}, 1},
+		{&EventsParams{ListParams: ListParams{Limit: 10}, Ref: repo.DatasetRef{Peername: "peer", Name: "cities"}}, 0},
+	}
+
+	req := NewLogRequests(node.(*p2p.QriNode), nil)
+	for i, c := range cases {
+		got := []*repo.Event{}
+		if err := req.Events(c.p, &got); err != nil {
+			t.Errorf("case %d unexpected error: %s", i, err.Error())
+			continue
+		}
+		if len(got) != c.expect {
+			t.Errorf("case %d event count mismatch. expected: %d, got: %d", i, c.expect, len(got))
+		}
+	}
+}
@@ -245,7 +245,7 @@ func (d *PeerRequests) GetReferences(p *PeerRefsParams, res *[]repo.DatasetRef)
 	// 	return err
 	// }
 
-	refs, err := d.qriNode.RequestDatasetsList(id, p2p.DatasetsListParams{
+	refs, err := d.qriNode.RequestDatasetsList(context.Background(), id, p2p.DatasetsListParams{
 		Limit:  p.Limit,
 		Offset: p.Offset,
 	})
@@ -0,0 +1,85 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"net/rpc"
+	"time"
+
+	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/progress"
+)
+
+// MaxProgressWait is the longest a progress poll waits for updates
+const MaxProgressWait = time.Second * 30
+
+// ProgressRequests encapsulates business logic for following the progress of
+// long-running operations
+type ProgressRequests struct {
+	node *p2p.QriNode
+	cli  *rpc.Client
+}
+
+// CoreRequestsName implements the Requests interface
+func (ProgressRequests) CoreRequestsName() string { return "progress" }
+
+// NewProgressRequests creates a ProgressRequests pointer from either a node
+// or an rpc.Client
+func NewProgressRequests(node *p2p.QriNode, cli *rpc.Client) *ProgressRequests {
+	if node != nil && cli != nil {
+		panic(fmt.Errorf("both node and client supplied to NewProgressRequests"))
+	}
+	return &ProgressRequests{
+		node: node,
+		cli:  cli,
+	}
+}
+
+// ProgressParams configures a progress poll
+type ProgressParams struct {
+	// Since is the sequence number of the last update seen. Polls with a
+	// negative Since return no updates, only the current sequence number
+	Since int64
+	// Wait is how long to wait for new updates, up to MaxProgressWait
+	Wait time.Duration
+}
+
+// ProgressUpdates is a batch of progress updates
+type ProgressUpdates struct {
+	// Seq is the sequence number of the latest update
+	Seq     int64
+	Updates []progress.Update
+}
+
+// Poll lists progress updates of operations run by this node after
+// p.Since, waiting up to p.Wait for updates if there are none. Updates come
+// from every operation the node runs, not just those of the caller
+func (r *ProgressRequests) Poll(p *ProgressParams, res *ProgressUpdates) error {
+	if r.cli != nil {
+		return r.cli.Call("ProgressRequests.Poll", p, res)
+	}
+
+	stream := r.node.Progress
+	if stream == nil {
+		return fmt.Errorf("this node doesn't report progress")
+	}
+	if p.Since < 0 {
+		*res = ProgressUpdates{Seq: stream.Seq()}
+		return nil
+	}
+
+	wait := p.Wait
+	if wait > MaxProgressWait {
+		wait = MaxProgressWait
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), wait)
+	defer cancel()
+
+	updates := stream.Since(ctx, p.Since)
+	seq := p.Since
+	if len(updates) > 0 {
+		seq = updates[len(updates)-1].Seq
+	}
+	*res = ProgressUpdates{Seq: seq, Updates: updates}
+	return nil
+}
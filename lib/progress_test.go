@@ -0,0 +1,59 @@
+package lib
+
+import (
+	"testing"
+	"time"
+
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/progress"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestProgressRequestsPoll(t *testing.T) {
+	mr, err := testrepo.NewTestRepo(nil)
+	if err != nil {
+		t.Fatalf("error allocating test repo: %s", err.Error())
+	}
+	node, err := p2p.NewQriNode(mr, config.DefaultP2PForTesting())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	req := NewProgressRequests(node, nil)
+
+	res := &ProgressUpdates{}
+	if err := req.Poll(&ProgressParams{Since: -1}, res); err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(res.Updates) != 0 {
+		t.Errorf("expected negative since to return no updates, got %d", len(res.Updates))
+	}
+	start := res.Seq
+
+	res = &ProgressUpdates{}
+	if err := req.Poll(&ProgressParams{Since: start, Wait: time.Millisecond * 10}, res); err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(res.Updates) != 0 || res.Seq != start {
+		t.Errorf("expected poll without updates to time out, got: %#v", res)
+	}
+
+	task := progress.NewTask(node.Progress, "counting", progress.Steps, 2)
+	task.Add(1)
+	task.Finish(nil)
+
+	res = &ProgressUpdates{}
+	if err := req.Poll(&ProgressParams{Since: start, Wait: time.Second}, res); err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(res.Updates) == 0 {
+		t.Fatal("expected updates")
+	}
+	last := res.Updates[len(res.Updates)-1]
+	if last.Name != "counting" || !last.Complete || last.Done != 1 {
+		t.Errorf("unexpected last update: %#v", last)
+	}
+	if res.Seq != last.Seq {
+		t.Errorf("expected seq to match last update. expected: %d, got: %d", last.Seq, res.Seq)
+	}
+}
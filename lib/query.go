@@ -0,0 +1,110 @@
+package lib
+
+import (
+	"fmt"
+	"net/rpc"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/actions"
+	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/sql"
+)
+
+// QueryRequests encapsulates business logic for querying dataset bodies
+// with SQL
+type QueryRequests struct {
+	node *p2p.QriNode
+	cli  *rpc.Client
+}
+
+// CoreRequestsName implements the Requests interface
+func (QueryRequests) CoreRequestsName() string { return "query" }
+
+// NewQueryRequests creates a QueryRequests pointer from either a node
+// or an rpc.Client
+func NewQueryRequests(node *p2p.QriNode, cli *rpc.Client) *QueryRequests {
+	if node != nil && cli != nil {
+		panic(fmt.Errorf("both node and client supplied to NewQueryRequests"))
+	}
+	return &QueryRequests{
+		node: node,
+		cli:  cli,
+	}
+}
+
+// QueryParams defines parameters for the Query method
+type QueryParams struct {
+	// Query is a SQL SELECT statement. Table names are dataset references
+	Query string
+	// Format & FormatConfig control how result data is written, default is json
+	Format       dataset.DataFormat
+	FormatConfig dataset.FormatConfig
+	// SaveAs is the name of a new dataset to write results to. results aren't
+	// saved if SaveAs is empty
+	SaveAs string
+	// Publish the saved dataset to the registry
+	Publish bool
+}
+
+// QueryResult is the output of a query
+type QueryResult struct {
+	Columns []sql.Column `json:"columns"`
+	Data    []byte       `json:"data"`
+	// Ref is the saved dataset, if any
+	Ref *repo.DatasetRef `json:"ref,omitempty"`
+}
+
+// Query executes a SQL query against dataset bodies
+func (r *QueryRequests) Query(p *QueryParams, res *QueryResult) error {
+	if r.cli != nil {
+		return r.cli.Call("QueryRequests.Query", p, res)
+	}
+
+	if p.Query == "" {
+		return NewError(ErrBadArgs, "query is required")
+	}
+
+	var limits actions.QueryLimits
+	if Config != nil {
+		limits = actions.QueryLimitsFromConfig(Config.SQL)
+	}
+	table, err := actions.Query(r.node, p.Query, limits)
+	if err != nil {
+		return err
+	}
+
+	format := p.Format
+	if format == dataset.UnknownDataFormat {
+		format = dataset.JSONDataFormat
+	}
+	st, err := actions.QueryResultStructure(table, format, p.FormatConfig)
+	if err != nil {
+		return err
+	}
+	data, err := actions.QueryResultBody(table, st)
+	if err != nil {
+		return err
+	}
+
+	*res = QueryResult{
+		Columns: table.Columns,
+		Data:    data,
+	}
+
+	if p.SaveAs != "" {
+		ref, err := actions.SaveQueryResult(r.node, p.SaveAs, p.Query, table)
+		if err != nil {
+			return err
+		}
+		if p.Publish {
+			var done bool
+			if err = NewRegistryRequests(r.node, nil).Publish(&PublishParams{Ref: ref, Pin: true}, &done); err != nil {
+				return err
+			}
+		}
+		res.Ref = &ref
+	}
+
+	return nil
+}
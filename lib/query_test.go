@@ -0,0 +1,55 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/p2p"
+	testrepo "github.com/qri-io/qri/repo/test"
+	regmock "github.com/qri-io/registry/regserver/mock"
+)
+
+func TestQueryRequestsQuery(t *testing.T) {
+	rc, _ := regmock.NewMockServer()
+	mr, err := testrepo.NewTestRepo(rc)
+	if err != nil {
+		t.Fatalf("error allocating test repo: %s", err.Error())
+	}
+	node, err := p2p.NewQriNode(mr, config.DefaultP2PForTesting())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	req := NewQueryRequests(node, nil)
+	cases := []struct {
+		p   *QueryParams
+		res string
+		err string
+	}{
+		{&QueryParams{}, "", "query is required"},
+		{&QueryParams{Query: "SELECT nope FROM peer/cities"}, "", "column \"nope\" does not exist"},
+		{&QueryParams{Query: "SELECT city FROM peer/cities WHERE pop > 1000000 ORDER BY city"}, `[["new york"],["toronto"]]`, ""},
+		{&QueryParams{Query: "SELECT in_usa, count(*) AS n FROM peer/cities GROUP BY in_usa ORDER BY n", Format: dataset.CSVDataFormat}, "in_usa,n\nfalse,1\ntrue,4\n", ""},
+	}
+
+	for i, c := range cases {
+		res := &QueryResult{}
+		err := req.Query(c.p, res)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%v'", i, c.err, err)
+			continue
+		}
+		if string(res.Data) != c.res {
+			t.Errorf("case %d result mismatch. expected:\n%s\ngot:\n%s", i, c.res, string(res.Data))
+		}
+	}
+
+	res := &QueryResult{}
+	if err := req.Query(&QueryParams{Query: "SELECT title FROM peer/movies LIMIT 10", SaveAs: "ten_movies"}, res); err != nil {
+		t.Fatal(err.Error())
+	}
+	if res.Ref == nil || res.Ref.Name != "ten_movies" {
+		t.Errorf("expected result to be saved as ten_movies, got: %v", res.Ref)
+	}
+}
@@ -1,9 +1,13 @@
 package lib
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"net/rpc"
+	"time"
 
+	"github.com/qri-io/cafs"
 	"github.com/qri-io/qri/actions"
 	"github.com/qri-io/qri/repo"
 )
@@ -33,11 +37,17 @@ func (RenderRequests) CoreRequestsName() string { return "render" }
 
 // RenderParams defines parameters for the Render method
 type RenderParams struct {
-	Ref            repo.DatasetRef
-	Template       []byte
+	Ref      repo.DatasetRef
+	Template []byte
+	// TemplateName is the name of a template stored in the repo
+	TemplateName string
+	// TemplateFormat is the output format: html, markdown or text. Defaults to
+	// the format of the template being rendered
 	TemplateFormat string
 	All            bool
 	Limit, Offset  int
+	// Stream reads body entries as the template uses them
+	Stream bool
 }
 
 // Render executes a template against a template
@@ -46,14 +56,130 @@ func (r *RenderRequests) Render(p *RenderParams, res *[]byte) (err error) {
 		return r.cli.Call("RenderRequests.Render", p, res)
 	}
 
+	buf := &bytes.Buffer{}
+	if err = r.RenderTo(buf, p); err != nil {
+		return err
+	}
+	*res = buf.Bytes()
+	return nil
+}
+
+// RenderTo executes a template, writing output to w as it's rendered. Over
+// RPC output is written once rendering completes
+func (r *RenderRequests) RenderTo(w io.Writer, p *RenderParams) (err error) {
+	if r.cli != nil {
+		res := []byte{}
+		if err = r.cli.Call("RenderRequests.Render", p, &res); err != nil {
+			return err
+		}
+		_, err = w.Write(res)
+		return err
+	}
+
 	if err := DefaultSelectedRef(r.repo, &p.Ref); err != nil {
 		return err
 	}
 
-	*res, err = actions.Render(r.repo, p.Ref, p.Template, p.Limit, p.Offset, p.All)
+	opts := actions.RenderOpts{
+		Template:     p.Template,
+		TemplateName: p.TemplateName,
+		All:          p.All,
+		Limit:        p.Limit,
+		Offset:       p.Offset,
+		Stream:       p.Stream,
+	}
+	if p.TemplateFormat != "" {
+		if opts.Format, err = actions.ParseRenderFormat(p.TemplateFormat); err != nil {
+			return err
+		}
+	}
+
+	err = actions.RenderTo(w, r.repo, p.Ref, opts)
 	if err == repo.ErrNotFound {
 		return NewError(err, fmt.Sprintf("could not find dataset '%s/%s'", p.Ref.Peername, p.Ref.Name))
 	}
 
 	return err
 }
+
+// SaveTemplateParams defines parameters for saving a named template
+type SaveTemplateParams struct {
+	Name string
+	// Format is the output the template produces: html, markdown or text
+	Format string
+	Script []byte
+}
+
+// SaveTemplate adds a named template to the repo, replacing any template
+// with the same name
+func (r *RenderRequests) SaveTemplate(p *SaveTemplateParams, res *repo.Template) (err error) {
+	if r.cli != nil {
+		return r.cli.Call("RenderRequests.SaveTemplate", p, res)
+	}
+
+	ts, ok := r.repo.(repo.TemplateStore)
+	if !ok {
+		return repo.ErrTemplatesNotSupported
+	}
+	if p.Name == "" {
+		return fmt.Errorf("template name is required")
+	}
+	format, err := actions.ParseRenderFormat(p.Format)
+	if err != nil {
+		return err
+	}
+	if err := actions.ValidateTemplate(p.Name, format, string(p.Script)); err != nil {
+		return err
+	}
+
+	key, err := r.repo.Store().Put(cafs.NewMemfileBytes(p.Name, p.Script), true)
+	if err != nil {
+		return fmt.Errorf("error saving template script: %s", err.Error())
+	}
+	t := &repo.Template{
+		Name:    p.Name,
+		Format:  string(format),
+		Path:    key.String(),
+		Updated: time.Now(),
+	}
+	if err = ts.PutTemplate(t); err != nil {
+		return err
+	}
+	*res = *t
+	return nil
+}
+
+// ListTemplates lists the named templates in the repo
+func (r *RenderRequests) ListTemplates(done *bool, res *[]*repo.Template) (err error) {
+	if r.cli != nil {
+		return r.cli.Call("RenderRequests.ListTemplates", done, res)
+	}
+
+	ts, ok := r.repo.(repo.TemplateStore)
+	if !ok {
+		return repo.ErrTemplatesNotSupported
+	}
+	*res, err = ts.ListTemplates()
+	return err
+}
+
+// RemoveTemplate removes a named template from the repo. Template scripts are
+// left in the store until garbage collected
+func (r *RenderRequests) RemoveTemplate(name *string, ok *bool) (err error) {
+	if r.cli != nil {
+		return r.cli.Call("RenderRequests.RemoveTemplate", name, ok)
+	}
+
+	ts, isStore := r.repo.(repo.TemplateStore)
+	if !isStore {
+		return repo.ErrTemplatesNotSupported
+	}
+	if err = ts.DeleteTemplate(*name); err != nil {
+		if err == repo.ErrNotFound {
+			return NewError(err, fmt.Sprintf("could not find template '%s'", *name))
+		}
+		return err
+	}
+	*ok = true
+	return nil
+}
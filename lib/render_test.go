@@ -126,3 +126,57 @@ func TestRenderRequestsRender(t *testing.T) {
 		}
 	}
 }
+
+func TestRenderRequestsTemplates(t *testing.T) {
+	tr, err := testrepo.NewTestRepo(nil)
+	if err != nil {
+		t.Fatalf("error allocating test repo: %s", err.Error())
+	}
+	reqs := NewRenderRequests(tr, nil)
+
+	if err := reqs.SaveTemplate(&SaveTemplateParams{Format: "md"}, &repo.Template{}); err == nil {
+		t.Errorf("expected saving a template without a name to error")
+	}
+	if err := reqs.SaveTemplate(&SaveTemplateParams{Name: "bad", Format: "pdf"}, &repo.Template{}); err == nil {
+		t.Errorf("expected saving a template with an invalid format to error")
+	}
+	if err := reqs.SaveTemplate(&SaveTemplateParams{Name: "broken", Format: "md", Script: []byte("{{ .Meta.Title ")}, &repo.Template{}); err == nil {
+		t.Errorf("expected saving a template that doesn't parse to error")
+	}
+	if err := reqs.SaveTemplate(&SaveTemplateParams{Name: actions.RootTemplateName, Format: "md", Script: []byte("ok")}, &repo.Template{}); err == nil {
+		t.Errorf("expected saving a template with the reserved name '%s' to error", actions.RootTemplateName)
+	}
+
+	saved := &repo.Template{}
+	p := &SaveTemplateParams{Name: "title", Format: "md", Script: []byte("# {{ .Meta.Title }}")}
+	if err := reqs.SaveTemplate(p, saved); err != nil {
+		t.Fatalf("error saving template: %s", err.Error())
+	}
+	if saved.Format != "markdown" {
+		t.Errorf("template format mismatch. expected: 'markdown', got: '%s'", saved.Format)
+	}
+
+	got := []byte{}
+	if err := reqs.Render(&RenderParams{Ref: repo.DatasetRef{Peername: "me", Name: "movies"}, TemplateName: "title"}, &got); err != nil {
+		t.Fatalf("error rendering named template: %s", err.Error())
+	}
+	if string(got) != "# example movie data" {
+		t.Errorf("render mismatch. expected: '# example movie data', got: '%s'", string(got))
+	}
+
+	list := []*repo.Template{}
+	if err := reqs.ListTemplates(nil, &list); err != nil {
+		t.Fatalf("error listing templates: %s", err.Error())
+	}
+	if len(list) != 1 || list[0].Name != "title" {
+		t.Errorf("expected one template named 'title', got: %v", list)
+	}
+
+	name, ok := "title", false
+	if err := reqs.RemoveTemplate(&name, &ok); err != nil {
+		t.Fatalf("error removing template: %s", err.Error())
+	}
+	if err := reqs.RemoveTemplate(&name, &ok); err == nil {
+		t.Errorf("expected removing a missing template to error")
+	}
+}
@@ -0,0 +1,160 @@
+package lib
+
+import (
+	"fmt"
+	"net/rpc"
+	"os"
+
+	"github.com/qri-io/qri/actions"
+	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/repo/archive"
+)
+
+// RepoRequests encapsulates business logic for maintaining a qri repo
+type RepoRequests struct {
+	node *p2p.QriNode
+	cli  *rpc.Client
+}
+
+// CoreRequestsName implements the Requests interface
+func (r RepoRequests) CoreRequestsName() string { return "repo" }
+
+// NewRepoRequests creates a RepoRequests pointer from either a node
+// or an rpc.Client
+func NewRepoRequests(node *p2p.QriNode, cli *rpc.Client) *RepoRequests {
+	if node != nil && cli != nil {
+		panic(fmt.Errorf("both node and client supplied to NewRepoRequests"))
+	}
+	return &RepoRequests{
+		node: node,
+		cli:  cli,
+	}
+}
+
+// GCParams defines parameters for garbage collection
+type GCParams struct {
+	// DryRun reports what would be removed without removing anything
+	DryRun bool
+}
+
+// GarbageCollect removes unreferenced content from the repo store
+func (r *RepoRequests) GarbageCollect(p *GCParams, res *actions.GCResult) error {
+	if r.cli != nil {
+		return r.cli.Call("RepoRequests.GarbageCollect", p, res)
+	}
+
+	result, err := actions.GarbageCollect(r.node, p.DryRun)
+	if err != nil {
+		return err
+	}
+	*res = *result
+	return nil
+}
+
+// CheckRepoParams defines parameters for checking repo integrity
+type CheckRepoParams struct {
+	// Repair fixes problems that can be fixed
+	Repair bool
+}
+
+// CheckRepo verifies every dataset in the repo, optionally repairing problems
+func (r *RepoRequests) CheckRepo(p *CheckRepoParams, res *actions.CheckRepoResult) error {
+	if r.cli != nil {
+		return r.cli.Call("RepoRequests.CheckRepo", p, res)
+	}
+
+	result, err := actions.CheckRepo(r.node, p.Repair)
+	if err != nil {
+		return err
+	}
+	*res = *result
+	return nil
+}
+
+// ExportRepoParams defines parameters for exporting a repo archive
+type ExportRepoParams struct {
+	// Path of the archive file to write
+	Path string
+	// PrivateKeys includes private keys in the archived config
+	PrivateKeys bool
+}
+
+// Export writes the entire repo, including dataset history, profiles, the
+// event log & config to an archive file
+func (r *RepoRequests) Export(p *ExportRepoParams, res *archive.Manifest) error {
+	if r.cli != nil {
+		return r.cli.Call("RepoRequests.Export", p, res)
+	}
+	if p.Path == "" {
+		return fmt.Errorf("archive path is required")
+	}
+
+	f, err := os.Create(p.Path)
+	if err != nil {
+		return fmt.Errorf("error creating archive: %s", err.Error())
+	}
+	defer f.Close()
+
+	man, err := archive.Export(f, r.node.Repo, archive.ExportOpts{Config: Config, PrivateKeys: p.PrivateKeys})
+	if err != nil {
+		os.Remove(p.Path)
+		return err
+	}
+	*res = *man
+	return f.Close()
+}
+
+// ImportRepoParams defines parameters for importing a repo archive
+type ImportRepoParams struct {
+	// Path of the archive file to read
+	Path string
+	// Config replaces the current config with the archived config. Archives
+	// written without private keys keep the current profile & p2p config
+	Config bool
+}
+
+// Import reads a repo archive into the repo
+func (r *RepoRequests) Import(p *ImportRepoParams, res *archive.ImportResult) error {
+	if r.cli != nil {
+		return r.cli.Call("RepoRequests.Import", p, res)
+	}
+	if p.Path == "" {
+		return fmt.Errorf("archive path is required")
+	}
+
+	f, err := os.Open(p.Path)
+	if err != nil {
+		return fmt.Errorf("error opening archive: %s", err.Error())
+	}
+	defer f.Close()
+
+	result, err := archive.Import(f, r.node.Repo)
+	if err != nil {
+		return err
+	}
+
+	if p.Config {
+		if result.Config == nil {
+			return fmt.Errorf("archive doesn't include config")
+		}
+		cfg := result.Config
+		if !result.Manifest.PrivateKeys && Config != nil {
+			cfg.Profile = Config.Profile
+			cfg.P2P = Config.P2P
+		}
+		if err := cfg.Validate(); err != nil {
+			return fmt.Errorf("error validating archived config: %s", err)
+		}
+		Config = cfg
+		if err := SaveConfig(); err != nil {
+			return err
+		}
+	}
+
+	// private values never leave the process
+	if result.Config != nil {
+		result.Config = result.Config.WithoutPrivateValues()
+	}
+	*res = *result
+	return nil
+}
@@ -0,0 +1,104 @@
+package lib
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/qri-io/qri/actions"
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/repo/archive"
+	testrepo "github.com/qri-io/qri/repo/test"
+	regmock "github.com/qri-io/registry/regserver/mock"
+)
+
+func TestRepoRequestsGarbageCollect(t *testing.T) {
+	rc, _ := regmock.NewMockServer()
+	mr, err := testrepo.NewTestRepo(rc)
+	if err != nil {
+		t.Fatalf("error allocating test repo: %s", err.Error())
+	}
+	node, err := p2p.NewQriNode(mr, config.DefaultP2PForTesting())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	req := NewRepoRequests(node, nil)
+	res := &actions.GCResult{}
+	if err := req.GarbageCollect(&GCParams{DryRun: true}, res); err != nil {
+		t.Fatal(err.Error())
+	}
+	if !res.DryRun {
+		t.Error("expected result to be marked as a dry run")
+	}
+	if res.Reachable == 0 {
+		t.Error("expected test repo to have reachable paths")
+	}
+}
+
+func TestRepoRequestsCheckRepo(t *testing.T) {
+	rc, _ := regmock.NewMockServer()
+	mr, err := testrepo.NewTestRepo(rc)
+	if err != nil {
+		t.Fatalf("error allocating test repo: %s", err.Error())
+	}
+	node, err := p2p.NewQriNode(mr, config.DefaultP2PForTesting())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	req := NewRepoRequests(node, nil)
+	res := &actions.CheckRepoResult{}
+	if err := req.CheckRepo(&CheckRepoParams{}, res); err != nil {
+		t.Fatal(err.Error())
+	}
+	if res.Refs == 0 {
+		t.Error("expected test repo references to be checked")
+	}
+	if res.Versions < res.Refs {
+		t.Errorf("expected at least one version per reference, got %d versions for %d refs", res.Versions, res.Refs)
+	}
+}
+
+func TestRepoRequestsExportImport(t *testing.T) {
+	rc, _ := regmock.NewMockServer()
+	mr, err := testrepo.NewTestRepo(rc)
+	if err != nil {
+		t.Fatalf("error allocating test repo: %s", err.Error())
+	}
+	node, err := p2p.NewQriNode(mr, config.DefaultP2PForTesting())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	dir, err := ioutil.TempDir("", "qri_test_repo_export")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "repo.qri")
+
+	req := NewRepoRequests(node, nil)
+	man := &archive.Manifest{}
+	if err := req.Export(&ExportRepoParams{Path: path}, man); err != nil {
+		t.Fatal(err.Error())
+	}
+	if man.Refs == 0 {
+		t.Error("expected test repo references to be exported")
+	}
+
+	// importing into the same repo skips every reference
+	res := &archive.ImportResult{}
+	if err := req.Import(&ImportRepoParams{Path: path}, res); err != nil {
+		t.Fatal(err.Error())
+	}
+	if res.Refs != 0 || len(res.Skipped) != man.Refs {
+		t.Errorf("expected all %d refs to be skipped, got %d imported, %d skipped", man.Refs, res.Refs, len(res.Skipped))
+	}
+
+	if err := req.Import(&ImportRepoParams{Path: filepath.Join(dir, "missing.qri")}, res); err == nil {
+		t.Error("expected importing a missing archive to error")
+	}
+}
@@ -1,6 +1,7 @@
 package lib
 
 import (
+	"context"
 	"fmt"
 	"net/rpc"
 
@@ -36,6 +37,8 @@ type SearchParams struct {
 	QueryString string `json:"q"`
 	Limit       int    `json:"limit,omitempty"`
 	Offset      int    `json:"offset,omitempty"`
+	// Network searches connected qri peers instead of the registry
+	Network bool `json:"network,omitempty"`
 }
 
 // SearchResult struct
@@ -52,6 +55,9 @@ func (sr *SearchRequests) Search(p *SearchParams, results *[]SearchResult) error
 	if p == nil {
 		return fmt.Errorf("error: search params cannot be nil")
 	}
+	if p.Network {
+		return sr.networkSearch(p, results)
+	}
 
 	reg := sr.node.Repo.Registry()
 	if reg == nil {
@@ -73,3 +79,20 @@ func (sr *SearchRequests) Search(p *SearchParams, results *[]SearchResult) error
 	*results = searchResults
 	return nil
 }
+
+// networkSearch queries connected qri peers
+func (sr *SearchRequests) networkSearch(p *SearchParams, results *[]SearchResult) error {
+	found, err := sr.node.Search(context.Background(), repo.SearchParams{Q: p.QueryString, Limit: p.Limit, Offset: p.Offset})
+	if err != nil {
+		return err
+	}
+
+	searchResults := make([]SearchResult, len(found))
+	for i, result := range found {
+		searchResults[i].Type = "dataset"
+		searchResults[i].ID = result.Ref.AliasString()
+		searchResults[i].Value = result.Ref.Dataset
+	}
+	*results = searchResults
+	return nil
+}
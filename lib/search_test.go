@@ -30,7 +30,7 @@ func TestSearch(t *testing.T) {
 
 	// Case 0 - request with expected result
 	i := 0
-	p := &SearchParams{"cities", 0, 100}
+	p := &SearchParams{QueryString: "cities", Limit: 0, Offset: 100}
 	numResults := 3
 	errString := ""
 
@@ -23,7 +23,8 @@ func Setup(p SetupParams) error {
 		return err
 	}
 
-	if p.SetupIPFS {
+	// only ipfs stores need an IPFS repo
+	if p.SetupIPFS && (p.Config.Store == nil || p.Config.Store.Type == "ipfs") {
 		if err := actions.InitIPFS(p.IPFSFsPath, p.SetupIPFSConfigData); err != nil {
 			return err
 		}
@@ -0,0 +1,356 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"net/rpc"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/actions"
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/repo"
+)
+
+// SiteRequests encapsulates business logic for publishing datasets as a
+// static website
+type SiteRequests struct {
+	node *p2p.QriNode
+	cli  *rpc.Client
+}
+
+// CoreRequestsName implements the Requests interface
+func (SiteRequests) CoreRequestsName() string { return "site" }
+
+// NewSiteRequests creates a SiteRequests pointer from either a node or an
+// rpc.Client
+func NewSiteRequests(node *p2p.QriNode, cli *rpc.Client) *SiteRequests {
+	if node != nil && cli != nil {
+		panic(fmt.Errorf("both node and client supplied to NewSiteRequests"))
+	}
+	return &SiteRequests{
+		node: node,
+		cli:  cli,
+	}
+}
+
+// SiteManifestFilename marks a directory as a built site. Build only writes
+// to directories that are empty or hold a previously built site
+const SiteManifestFilename = "site.json"
+
+// SiteBodyFormats are the formats dataset bodies are published in
+var SiteBodyFormats = []dataset.DataFormat{
+	dataset.JSONDataFormat,
+	dataset.CSVDataFormat,
+	dataset.CBORDataFormat,
+}
+
+// BuildSiteParams defines parameters for building a static site
+type BuildSiteParams struct {
+	// Dir is the directory to write the site to
+	Dir string
+}
+
+// SiteManifest lists the contents of a built site
+type SiteManifest struct {
+	// Datasets lists the alias of each published dataset
+	Datasets []string `json:"datasets"`
+	// Files lists every file in the site, relative to the site directory
+	Files []string `json:"files"`
+}
+
+// Build writes a static website of every dataset in the repo to a
+// directory, overwriting any site previously built there. The site has an
+// index of datasets, a page for each dataset showing it's viz, history &
+// body downloads, and a profile page. Building the same repo twice writes
+// identical files, so sites can be diffed & hosted anywhere
+func (r *SiteRequests) Build(p *BuildSiteParams, res *SiteManifest) (err error) {
+	if r.cli != nil {
+		return r.cli.Call("SiteRequests.Build", p, res)
+	}
+	if p.Dir == "" {
+		return fmt.Errorf("directory is required")
+	}
+	if err = prepareSiteDir(p.Dir); err != nil {
+		return err
+	}
+
+	sb := &siteBuilder{node: r.node, dir: p.Dir, manifest: &SiteManifest{}}
+	if err = sb.build(); err != nil {
+		return err
+	}
+
+	*res = *sb.manifest
+	return nil
+}
+
+// prepareSiteDir clears a previously built site from dir, erroring if dir
+// holds anything else
+func prepareSiteDir(dir string) error {
+	fis, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return os.MkdirAll(dir, os.ModePerm)
+	} else if err != nil {
+		return err
+	}
+	if len(fis) == 0 {
+		return nil
+	}
+	if _, err := os.Stat(filepath.Join(dir, SiteManifestFilename)); err != nil {
+		return fmt.Errorf("directory %s isn't empty & doesn't contain a built site", dir)
+	}
+
+	for _, fi := range fis {
+		if err := os.RemoveAll(filepath.Join(dir, fi.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sitePage is the data for a page of a site. Root is the relative path from
+// the page to the site directory
+type sitePage struct {
+	Title    string
+	Root     string
+	Profile  *config.ProfilePod
+	Datasets []*siteDataset
+	Dataset  *siteDataset
+}
+
+// siteDataset is the data for a dataset page
+type siteDataset struct {
+	Ref       repo.DatasetRef
+	Alias     string
+	Dir       string
+	History   []repo.DatasetRef
+	Downloads []string
+}
+
+type siteBuilder struct {
+	node     *p2p.QriNode
+	dir      string
+	manifest *SiteManifest
+}
+
+func (sb *siteBuilder) build() error {
+	r := sb.node.Repo
+	count, err := r.RefCount()
+	if err != nil {
+		return err
+	}
+	refs, err := r.References(count, 0)
+	if err != nil {
+		return err
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].AliasString() < refs[j].AliasString() })
+
+	pro, err := r.Profile()
+	if err != nil {
+		return err
+	}
+	pp, err := pro.Encode()
+	if err != nil {
+		return err
+	}
+	// sites are public & shouldn't change with a node's connection state
+	pp.PrivKey = ""
+	pp.Online = false
+
+	datasets := make([]*siteDataset, len(refs))
+	for i, ref := range refs {
+		if datasets[i], err = sb.buildDataset(ref); err != nil {
+			return fmt.Errorf("building %s: %s", ref.AliasString(), err.Error())
+		}
+		sb.manifest.Datasets = append(sb.manifest.Datasets, ref.AliasString())
+	}
+
+	if err = sb.writeTemplate("profile.html", siteProfileTemplate, &sitePage{
+		Title:   pp.Peername,
+		Profile: pp,
+	}); err != nil {
+		return err
+	}
+	if err = sb.writeTemplate("index.html", siteIndexTemplate, &sitePage{
+		Title:    pp.Peername,
+		Profile:  pp,
+		Datasets: datasets,
+	}); err != nil {
+		return err
+	}
+
+	sort.Strings(sb.manifest.Files)
+	data, err := json.MarshalIndent(sb.manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(sb.dir, SiteManifestFilename), data, 0644)
+}
+
+// buildDataset writes the page, viz & body downloads for a dataset
+func (sb *siteBuilder) buildDataset(ref repo.DatasetRef) (*siteDataset, error) {
+	sd := &siteDataset{
+		Alias: ref.AliasString(),
+		Dir:   filepath.ToSlash(filepath.Join(ref.Peername, ref.Name)),
+	}
+
+	logr := NewLogRequests(sb.node, nil)
+	p := &LogParams{Ref: ref, ListParams: ListParams{Limit: -1}}
+	if err := logr.Log(p, &sd.History); err != nil {
+		return nil, err
+	}
+	if len(sd.History) == 0 {
+		return nil, fmt.Errorf("dataset has no history")
+	}
+	sd.Ref = sd.History[0]
+
+	viz, err := actions.Render(sb.node.Repo, ref, nil, 0, 0, true)
+	if err != nil {
+		return nil, err
+	}
+	if err = sb.writeFile(sd.Dir+"/viz.html", viz); err != nil {
+		return nil, err
+	}
+
+	for _, df := range SiteBodyFormats {
+		_, data, err := actions.LookupBody(sb.node, sd.Ref.Path, df, siteFormatConfig(df), 0, 0, true)
+		if err != nil {
+			// not every body can be written in every format, csv needs tabular
+			// data for example
+			log.Debugf("skipping %s body for %s: %s", df, sd.Alias, err.Error())
+			continue
+		}
+		name := "body." + df.String()
+		if err = sb.writeFile(sd.Dir+"/"+name, data); err != nil {
+			return nil, err
+		}
+		sd.Downloads = append(sd.Downloads, name)
+	}
+
+	err = sb.writeTemplate(sd.Dir+"/index.html", siteDatasetTemplate, &sitePage{
+		Title:   sd.Alias,
+		Root:    "../../",
+		Dataset: sd,
+	})
+	return sd, err
+}
+
+func siteFormatConfig(df dataset.DataFormat) dataset.FormatConfig {
+	if df == dataset.CSVDataFormat {
+		return &dataset.CSVOptions{HeaderRow: true}
+	}
+	return nil
+}
+
+func (sb *siteBuilder) writeTemplate(name, tmplText string, page *sitePage) error {
+	tmpl, err := template.New("page").Funcs(template.FuncMap{
+		"date": func(t time.Time) string { return t.UTC().Format("Jan 2, 2006 15:04 UTC") },
+	}).Parse(siteLayoutTemplate + tmplText)
+	if err != nil {
+		return err
+	}
+
+	buf := &bytes.Buffer{}
+	if err = tmpl.Execute(buf, page); err != nil {
+		return fmt.Errorf("rendering %s: %s", name, err.Error())
+	}
+	return sb.writeFile(name, buf.Bytes())
+}
+
+// writeFile writes a file to the site, name is slash-separated & relative to
+// the site directory
+func (sb *siteBuilder) writeFile(name string, data []byte) error {
+	path := filepath.Join(sb.dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		return err
+	}
+	sb.manifest.Files = append(sb.manifest.Files, name)
+	return nil
+}
+
+const siteLayoutTemplate = `{{ define "header" }}<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>{{ .Title }}</title>
+  <style type="text/css">
+    body { margin: 0 auto; max-width: 960px; padding: 20px; font-family: "avenir next", "avenir", sans-serif; font-size: 16px; color: #0f0f0f; }
+    a { color: #0061A6; }
+    table { border-collapse: collapse; width: 100%; }
+    th, td { text-align: left; padding: 6px 8px; border-bottom: 1px solid #e0e0e0; }
+    iframe { width: 100%; height: 600px; border: 1px solid #e0e0e0; }
+    .path { font-family: monospace; font-size: 12px; color: #888; }
+  </style>
+</head>
+<body>
+  <nav><a href="{{ .Root }}index.html">datasets</a> · <a href="{{ .Root }}profile.html">profile</a></nav>
+{{ end }}{{ define "footer" }}</body>
+</html>
+{{ end }}`
+
+const siteIndexTemplate = `{{ template "header" . }}
+  <h1>{{ .Profile.Peername }}</h1>
+  {{ if .Profile.Description }}<p>{{ .Profile.Description }}</p>{{ end }}
+  <table>
+    <thead><tr><th>dataset</th><th>title</th><th>updated</th></tr></thead>
+    <tbody>
+    {{ range .Datasets }}<tr>
+      <td><a href="{{ .Dir }}/index.html">{{ .Alias }}</a></td>
+      <td>{{ with .Ref.Dataset.Meta }}{{ .Title }}{{ end }}</td>
+      <td>{{ with .Ref.Dataset.Commit }}{{ date .Timestamp }}{{ end }}</td>
+    </tr>
+    {{ end }}</tbody>
+  </table>
+{{ template "footer" }}`
+
+const siteDatasetTemplate = `{{ template "header" . }}
+  {{ with .Dataset }}
+  <h1>{{ .Alias }}</h1>
+  {{ with .Ref.Dataset.Meta }}{{ if .Title }}<h2>{{ .Title }}</h2>{{ end }}{{ if .Description }}<p>{{ .Description }}</p>{{ end }}{{ end }}
+  <p class="path">{{ .Ref.Path }}</p>
+  <h3>download</h3>
+  <ul>
+  {{ range .Downloads }}<li><a href="{{ . }}">{{ . }}</a></li>
+  {{ end }}</ul>
+  <h3>viz</h3>
+  <iframe src="viz.html"></iframe>
+  <h3>history</h3>
+  <table>
+    <thead><tr><th>commit</th><th>timestamp</th><th>path</th></tr></thead>
+    <tbody>
+    {{ range .History }}<tr>
+      <td>{{ with .Dataset.Commit }}{{ .Title }}{{ end }}</td>
+      <td>{{ with .Dataset.Commit }}{{ date .Timestamp }}{{ end }}</td>
+      <td class="path">{{ .Path }}</td>
+    </tr>
+    {{ end }}</tbody>
+  </table>
+  {{ end }}
+{{ template "footer" }}`
+
+const siteProfileTemplate = `{{ template "header" . }}
+  {{ with .Profile }}
+  <h1>{{ .Peername }}</h1>
+  {{ if .Name }}<h2>{{ .Name }}</h2>{{ end }}
+  {{ if .Description }}<p>{{ .Description }}</p>{{ end }}
+  <table>
+    <tbody>
+      <tr><th>id</th><td class="path">{{ .ID }}</td></tr>
+      {{ if .HomeURL }}<tr><th>home</th><td><a href="{{ .HomeURL }}">{{ .HomeURL }}</a></td></tr>{{ end }}
+      {{ if .Twitter }}<tr><th>twitter</th><td>{{ .Twitter }}</td></tr>{{ end }}
+      {{ if .Email }}<tr><th>email</th><td>{{ .Email }}</td></tr>{{ end }}
+      <tr><th>joined</th><td>{{ date .Created }}</td></tr>
+    </tbody>
+  </table>
+  {{ end }}
+{{ template "footer" }}`
@@ -0,0 +1,86 @@
+package lib
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/p2p"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestSiteRequestsBuild(t *testing.T) {
+	mr, err := testrepo.NewTestRepo(nil)
+	if err != nil {
+		t.Fatalf("error allocating test repo: %s", err.Error())
+	}
+	node, err := p2p.NewQriNode(mr, config.DefaultP2PForTesting())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	tmp, err := ioutil.TempDir("", "qri_site_build")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(tmp)
+
+	req := NewSiteRequests(node, nil)
+	if err := req.Build(&BuildSiteParams{}, &SiteManifest{}); err == nil {
+		t.Error("expected building without a directory to error")
+	}
+
+	dir := filepath.Join(tmp, "site")
+	a := &SiteManifest{}
+	if err := req.Build(&BuildSiteParams{Dir: dir}, a); err != nil {
+		t.Fatalf("error building site: %s", err.Error())
+	}
+
+	count, err := mr.RefCount()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(a.Datasets) != count {
+		t.Errorf("expected %d datasets, got: %d", count, len(a.Datasets))
+	}
+	for _, name := range []string{"index.html", "profile.html", "peer/movies/index.html", "peer/movies/viz.html", "peer/movies/body.json", SiteManifestFilename} {
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+			t.Errorf("expected site to contain %s: %s", name, err.Error())
+		}
+	}
+
+	index, err := ioutil.ReadFile(filepath.Join(dir, "index.html"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// rebuilding must write identical files
+	b := &SiteManifest{}
+	if err := req.Build(&BuildSiteParams{Dir: dir}, b); err != nil {
+		t.Fatalf("error rebuilding site: %s", err.Error())
+	}
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("rebuilt manifest mismatch.\nexpected: %v\ngot: %v", a, b)
+	}
+	rebuilt, err := ioutil.ReadFile(filepath.Join(dir, "index.html"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if string(index) != string(rebuilt) {
+		t.Error("expected rebuilt index to match")
+	}
+
+	other := filepath.Join(tmp, "other")
+	if err := os.MkdirAll(other, os.ModePerm); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := ioutil.WriteFile(filepath.Join(other, "notes.txt"), []byte("keep me"), 0644); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := req.Build(&BuildSiteParams{Dir: other}, &SiteManifest{}); err == nil {
+		t.Error("expected building into a directory with other files to error")
+	}
+}
@@ -0,0 +1,254 @@
+package p2p
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/qri/repo"
+
+	peer "gx/ipfs/QmdVrMn1LhB4ybb8hMVaMLXnA8XRSewMnK6YqXKXoTcRvN/go-libp2p-peer"
+)
+
+// MtChangeRequest is a message for sending, listing & updating the status of
+// change requests
+const MtChangeRequest = MsgType("change_request")
+
+// change request message actions, set with the "action" header
+const (
+	crActionSend   = "send"
+	crActionList   = "list"
+	crActionStatus = "status"
+)
+
+// ChangeRequestsParams encapsulates options for listing a peer's change
+// requests for a dataset
+type ChangeRequestsParams struct {
+	Target        repo.DatasetRef
+	Limit, Offset int
+}
+
+// SendChangeRequest delivers a change request to the peer that owns the
+// target dataset, returning the request as it was stored by the receiver.
+// Receivers record this peer's profile as the proposer
+func (n *QriNode) SendChangeRequest(ctx context.Context, pid peer.ID, cr *repo.ChangeRequest) (*repo.ChangeRequest, error) {
+	log.Debugf("%s: SendChangeRequest %s", n.ID, cr.ID)
+
+	res, err := n.changeRequestMessage(ctx, pid, crActionSend, cr)
+	if err != nil {
+		return nil, err
+	}
+	got := &repo.ChangeRequest{}
+	err = json.Unmarshal(res.Body, got)
+	return got, err
+}
+
+// RequestChangeRequests lists the change requests a peer has received for a
+// dataset
+func (n *QriNode) RequestChangeRequests(ctx context.Context, pid peer.ID, p ChangeRequestsParams) ([]*repo.ChangeRequest, error) {
+	log.Debugf("%s: RequestChangeRequests %s", n.ID, p.Target)
+
+	res, err := n.changeRequestMessage(ctx, pid, crActionList, p)
+	if err != nil {
+		return nil, err
+	}
+	crs := []*repo.ChangeRequest{}
+	err = json.Unmarshal(res.Body, &crs)
+	return crs, err
+}
+
+// SendChangeRequestStatus tells the proposer of a change request that it's been
+// accepted or rejected
+func (n *QriNode) SendChangeRequestStatus(ctx context.Context, pid peer.ID, cr *repo.ChangeRequest) error {
+	log.Debugf("%s: SendChangeRequestStatus %s %s", n.ID, cr.ID, cr.Status)
+
+	_, err := n.changeRequestMessage(ctx, pid, crActionStatus, cr)
+	return err
+}
+
+// changeRequestMessage sends a change request message & waits for the reply.
+// errors encountered by the receiving peer are returned in the "error" header
+func (n *QriNode) changeRequestMessage(ctx context.Context, pid peer.ID, action string, body interface{}) (res Message, err error) {
+	if pid == n.ID {
+		return res, fmt.Errorf("cannot send change requests to yourself")
+	}
+
+	req, err := NewJSONBodyMessage(n.ID, MtChangeRequest, body)
+	if err != nil {
+		return
+	}
+	req = req.WithHeaders("phase", "request", "action", action)
+
+	if res, err = n.requestPeer(ctx, req, pid); err != nil {
+		return
+	}
+	if msg := res.Header("error"); msg != "" {
+		return res, fmt.Errorf("%s", msg)
+	}
+	return res, nil
+}
+
+func (n *QriNode) handleChangeRequest(ws *WrappedStream, msg Message) (hangup bool) {
+	hangup = true
+
+	if msg.Header("phase") != "request" {
+		return
+	}
+
+	var (
+		body interface{}
+		err  error
+	)
+
+	// who sent a message is taken from the connection, not the message
+	from := ws.stream.Conn().RemotePeer()
+
+	if store, ok := n.Repo.(repo.ChangeRequestStore); !ok {
+		err = repo.ErrChangeRequestsNotSupported
+	} else {
+		switch msg.Header("action") {
+		case crActionSend:
+			body, err = n.receiveChangeRequest(store, from, msg)
+		case crActionList:
+			p := ChangeRequestsParams{}
+			if err = json.Unmarshal(msg.Body, &p); err == nil {
+				if p.Limit <= 0 || p.Limit > listMax {
+					p.Limit = listMax
+				}
+				body, err = store.ChangeRequestsForTarget(p.Target, p.Limit, p.Offset)
+			}
+		case crActionStatus:
+			body, err = n.receiveChangeRequestStatus(store, from, msg)
+		default:
+			err = fmt.Errorf("unrecognized change request action: '%s'", msg.Header("action"))
+		}
+	}
+
+	reply := msg
+	if err == nil {
+		reply, err = msg.UpdateJSON(body)
+	}
+	if err != nil {
+		log.Debug(err.Error())
+		reply = msg.Update(nil).WithHeaders("phase", "response", "error", err.Error())
+	} else {
+		reply = reply.WithHeaders("phase", "response")
+	}
+
+	if err := ws.sendMessage(reply); err != nil {
+		log.Debug(err.Error())
+	}
+	return
+}
+
+// receiveChangeRequest stores a change request sent by another peer. Requests
+// must target a dataset owned by this peer. The proposer is the profile of the
+// sending peer & the ID is the proposed path, whatever the request claims.
+// Only the proposer of a request can send it again. The proposed version is
+// fetched in the background
+func (n *QriNode) receiveChangeRequest(store repo.ChangeRequestStore, from peer.ID, msg Message) (*repo.ChangeRequest, error) {
+	cr := &repo.ChangeRequest{}
+	if err := json.Unmarshal(msg.Body, cr); err != nil {
+		return nil, err
+	}
+	if cr.Path == "" {
+		return nil, fmt.Errorf("change request path is required")
+	}
+	cr.ID = cr.Path
+
+	proposer, err := n.Repo.Profiles().PeerProfile(from)
+	if err != nil {
+		return nil, fmt.Errorf("no profile for peer %s, connect to qri before sending change requests", from.Pretty())
+	}
+	cr.Proposer = proposer.Peername
+	cr.ProposerID = proposer.ID
+
+	pro, err := n.Repo.Profile()
+	if err != nil {
+		return nil, err
+	}
+	target := cr.Target
+	if err := repo.CanonicalizeDatasetRef(n.Repo, &target); err != nil {
+		return nil, fmt.Errorf("error with target dataset %s: %s", cr.Target.AliasString(), err.Error())
+	}
+	if target.ProfileID != pro.ID {
+		return nil, fmt.Errorf("%s doesn't own dataset %s", pro.Peername, cr.Target.AliasString())
+	}
+
+	if prev, err := store.GetChangeRequest(cr.ID); err == nil {
+		if prev.ProposerID != cr.ProposerID {
+			return nil, fmt.Errorf("change request %s was made by another peer", cr.ID)
+		}
+		if prev.Status != repo.CRStatusOpen {
+			return nil, fmt.Errorf("change request %s has already been %s", cr.ID, prev.Status)
+		}
+	}
+
+	cr.Target.ProfileID = target.ProfileID
+	cr.Status = repo.CRStatusOpen
+	cr.Result = ""
+	cr.Updated = time.Now()
+	if cr.Created.IsZero() {
+		cr.Created = cr.Updated
+	}
+
+	if err := store.PutChangeRequest(cr); err != nil {
+		return nil, err
+	}
+	go n.fetchProposal(cr)
+	return cr, nil
+}
+
+// fetchProposal copies the proposed version of a change request into this
+// node's store & pins it, so the proposal is still around when the request is
+// accepted
+func (n *QriNode) fetchProposal(cr *repo.ChangeRequest) {
+	store := n.Repo.Store()
+	key := datastore.NewKey(strings.TrimSuffix(cr.Path, "/"+dsfs.PackageFileDataset.String()))
+
+	var err error
+	if fetcher, ok := store.(cafs.Fetcher); ok {
+		_, err = fetcher.Fetch(cafs.SourceAny, key)
+	} else if has, e := store.Has(key); e != nil || !has {
+		_, err = n.FetchDataset(n.Context(), &repo.DatasetRef{ProfileID: cr.ProposerID, Path: cr.Path}, nil)
+	}
+	if err == nil {
+		if pinner, ok := store.(cafs.Pinner); ok {
+			err = pinner.Pin(key, true)
+		}
+	}
+	if err != nil {
+		log.Debugf("error fetching proposal of change request %s: %s", cr.ID, err.Error())
+	}
+}
+
+// receiveChangeRequestStatus updates the status of a change request this peer
+// sent to another peer
+func (n *QriNode) receiveChangeRequestStatus(store repo.ChangeRequestStore, from peer.ID, msg Message) (*repo.ChangeRequest, error) {
+	update := &repo.ChangeRequest{}
+	if err := json.Unmarshal(msg.Body, update); err != nil {
+		return nil, err
+	}
+
+	cr, err := store.GetChangeRequest(update.ID)
+	if err != nil {
+		return nil, fmt.Errorf("change request %s: %s", update.ID, err.Error())
+	}
+	if update.Status != repo.CRStatusAccepted && update.Status != repo.CRStatusRejected {
+		return nil, fmt.Errorf("invalid change request status: '%s'", update.Status)
+	}
+	// only the owner of the target dataset can change the status of a request
+	if pro, err := n.Repo.Profiles().PeerProfile(from); err != nil || pro.ID != cr.Target.ProfileID {
+		return nil, fmt.Errorf("peer %s cannot update change request %s", from.Pretty(), cr.ID)
+	}
+
+	cr.Status = update.Status
+	cr.Result = update.Result
+	cr.Updated = time.Now()
+	return cr, store.PutChangeRequest(cr)
+}
@@ -0,0 +1,138 @@
+package p2p
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/dataset/dsfs"
+	p2ptest "github.com/qri-io/qri/p2p/test"
+	"github.com/qri-io/qri/repo"
+)
+
+func TestChangeRequestMessages(t *testing.T) {
+	ctx := context.Background()
+	f := p2ptest.NewTestNodeFactory(NewTestableQriNode)
+	testPeers, err := p2ptest.NewTestNetwork(ctx, f, 3)
+	if err != nil {
+		t.Fatalf("error creating network: %s", err.Error())
+	}
+	if err := p2ptest.ConnectQriPeers(ctx, testPeers); err != nil {
+		t.Fatalf("error connecting peers: %s", err.Error())
+	}
+
+	// node a has a dataset named movies, node b has cities
+	a := testPeers[0].(*QriNode)
+	b := testPeers[1].(*QriNode)
+	c := testPeers[2].(*QriNode)
+
+	apro, err := a.Repo.Profile()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	bpro, err := b.Repo.Profile()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	target := repo.DatasetRef{Peername: "test-repo-1", Name: "cities"}
+	// claim to be b, receivers should record the sending peer as proposer
+	cr := &repo.ChangeRequest{
+		ID:         "/map/proposal",
+		Path:       "/map/proposal",
+		Target:     target,
+		Proposer:   bpro.Peername,
+		ProposerID: bpro.ID,
+		Title:      "fix city populations",
+	}
+
+	got, err := a.SendChangeRequest(ctx, b.ID, cr)
+	if err != nil {
+		t.Fatalf("error sending change request: %s", err.Error())
+	}
+	if got.Status != repo.CRStatusOpen || got.Target.ProfileID == "" {
+		t.Errorf("expected stored change request to be open with a canonical target, got: %v", got)
+	}
+	if got.Proposer != apro.Peername || got.ProposerID != apro.ID {
+		t.Errorf("expected proposer to be the sending peer %s, got: %s (%s)", apro.Peername, got.Proposer, got.ProposerID)
+	}
+
+	// requests are identified by their proposed path, other peers can't replace them
+	if _, err := c.SendChangeRequest(ctx, b.ID, &repo.ChangeRequest{ID: "/map/other", Path: cr.Path, Target: target, Title: "not a's request"}); err == nil {
+		t.Error("expected sending a change request another peer made to error")
+	}
+
+	if _, err := a.SendChangeRequest(ctx, b.ID, &repo.ChangeRequest{ID: "/map/nope", Path: "/map/nope", Target: repo.DatasetRef{Peername: "test-repo-1", Name: "movies"}}); err == nil {
+		t.Error("expected sending a change request for a missing dataset to error")
+	}
+
+	crs, err := a.RequestChangeRequests(ctx, b.ID, ChangeRequestsParams{Target: target, Limit: 10})
+	if err != nil {
+		t.Fatalf("error listing change requests: %s", err.Error())
+	}
+	if len(crs) != 1 || crs[0].ID != cr.ID {
+		t.Fatalf("expected one change request for %s, got: %v", target, crs)
+	}
+
+	// a keeps a copy of the request it sent
+	sent := *crs[0]
+	if err := a.Repo.(repo.ChangeRequestStore).PutChangeRequest(&sent); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	accepted := sent
+	accepted.Status = repo.CRStatusAccepted
+	accepted.Result = "/map/cities2"
+	if err := a.SendChangeRequestStatus(ctx, b.ID, &accepted); err == nil {
+		t.Error("expected status update from a peer that doesn't own the target to error")
+	}
+	if err := b.SendChangeRequestStatus(ctx, a.ID, &accepted); err != nil {
+		t.Fatalf("error sending change request status: %s", err.Error())
+	}
+
+	updated, err := a.Repo.(repo.ChangeRequestStore).GetChangeRequest(cr.ID)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if updated.Status != repo.CRStatusAccepted || updated.Result != "/map/cities2" {
+		t.Errorf("expected sent change request to be marked accepted, got: %v", updated)
+	}
+}
+
+func TestChangeRequestFetchesProposal(t *testing.T) {
+	ctx := context.Background()
+	f := p2ptest.NewTestNodeFactory(NewTestableQriNode)
+	testPeers, err := p2ptest.NewTestNetwork(ctx, f, 2)
+	if err != nil {
+		t.Fatalf("error creating network: %s", err.Error())
+	}
+	if err := p2ptest.ConnectQriPeers(ctx, testPeers); err != nil {
+		t.Fatalf("error connecting peers: %s", err.Error())
+	}
+	a := testPeers[0].(*QriNode)
+	b := testPeers[1].(*QriNode)
+
+	refs, err := a.Repo.References(10, 0)
+	if err != nil || len(refs) == 0 {
+		t.Fatalf("expected node a to have datasets: %v", err)
+	}
+	proposal := refs[0].Path
+
+	cr := &repo.ChangeRequest{
+		Path:   proposal,
+		Target: repo.DatasetRef{Peername: "test-repo-1", Name: "cities"},
+	}
+	if _, err := a.SendChangeRequest(ctx, b.ID, cr); err != nil {
+		t.Fatalf("error sending change request: %s", err.Error())
+	}
+
+	key := datastore.NewKey(strings.TrimSuffix(proposal, "/"+dsfs.PackageFileDataset.String()))
+	for i := 0; i < 50; i++ {
+		if has, _ := b.Repo.Store().Has(key); has {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Errorf("expected proposed version %s to be fetched by the receiver", proposal)
+}
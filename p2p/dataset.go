@@ -1,8 +1,8 @@
 package p2p
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
 
 	"github.com/ipfs/go-datastore"
 	"github.com/qri-io/dataset/dsfs"
@@ -15,8 +15,10 @@ const MtDatasetInfo = MsgType("dataset_info")
 // RequestDataset fetches info about a dataset from qri peers
 // It's expected the local peer has attempted to canonicalize the reference
 // before sending to the network
-// ref is used as an outparam, populating with data on success
-func (n *QriNode) RequestDataset(ref *repo.DatasetRef) (err error) {
+// ref is used as an outparam, populating with data on success. Peers are
+// asked concurrently, the first peer to respond with the dataset wins.
+// ErrNotFound is returned if no peer has the dataset
+func (n *QriNode) RequestDataset(ctx context.Context, ref *repo.DatasetRef) (err error) {
 	log.Debugf("%s RequestDataset %s", n.ID, ref)
 
 	// if peer ID is *our* peer.ID check for local dataset
@@ -33,40 +35,35 @@ func (n *QriNode) RequestDataset(ref *repo.DatasetRef) (err error) {
 		}
 	}
 
-	pids := n.ClosestConnectedPeers(ref.ProfileID, 15)
+	pids := n.ClosestConnectedPeers(ref.ProfileID, maxResolvePeers)
 	if len(pids) == 0 {
 		// TODO - start checking peerstore peers?
 		// something else should probably be trying to establish
 		// rolling connections
-		return fmt.Errorf("no connected peers")
+		return ErrNoConnectedPeers
 	}
 
-	replies := make(chan Message)
 	req, err := NewJSONBodyMessage(n.ID, MtDatasetInfo, ref)
-	req = req.WithHeaders("phase", "request")
 	if err != nil {
 		log.Debug(err.Error())
 		return err
 	}
+	req = req.WithHeaders("phase", "request")
 
-	for _, pid := range pids {
-
-		if err := n.SendMessage(req, replies, pid); err != nil {
-			log.Debugf("%s err: %s", pid, err.Error())
-			continue
-			// return err
-		}
-
-		res := <-replies
-		dsr := repo.DatasetRef{}
-		if err := json.Unmarshal(res.Body, &dsr); err == nil {
-			if dsr.Dataset != nil {
-				*ref = dsr
-				break
-			}
+	dsr := repo.DatasetRef{}
+	_, err = n.requestFirst(ctx, req, pids, func(res Message) bool {
+		found := repo.DatasetRef{}
+		if err := json.Unmarshal(res.Body, &found); err != nil || found.Dataset == nil {
+			return false
 		}
+		dsr = found
+		return true
+	})
+	if err != nil {
+		return err
 	}
 
+	*ref = dsr
 	return nil
 }
 
@@ -1,6 +1,7 @@
 package p2p
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
@@ -23,8 +24,9 @@ type DatasetsListParams struct {
 	Offset int
 }
 
-// RequestDatasetsList gets a list of a peer's datasets
-func (n *QriNode) RequestDatasetsList(pid peer.ID, p DatasetsListParams) ([]repo.DatasetRef, error) {
+// RequestDatasetsList gets a list of a peer's datasets, waiting up to
+// PeerRequestTimeout for the peer to respond
+func (n *QriNode) RequestDatasetsList(ctx context.Context, pid peer.ID, p DatasetsListParams) ([]repo.DatasetRef, error) {
 	log.Debugf("%s RequestDatasetList: %s", n.ID, pid)
 
 	if pid == n.ID {
@@ -44,14 +46,12 @@ func (n *QriNode) RequestDatasetsList(pid peer.ID, p DatasetsListParams) ([]repo
 
 	req = req.WithHeaders("phase", "request")
 
-	replies := make(chan Message)
-	err = n.SendMessage(req, replies, pid)
+	res, err := n.requestPeer(ctx, req, pid)
 	if err != nil {
 		log.Debug(err.Error())
-		return nil, fmt.Errorf("send dataset info message error: %s", err.Error())
+		return nil, fmt.Errorf("error requesting dataset list: %s", err.Error())
 	}
 
-	res := <-replies
 	ref := []repo.DatasetRef{}
 	err = json.Unmarshal(res.Body, &ref)
 	return ref, err
@@ -1,6 +1,7 @@
 package p2p
 
 import (
+	"context"
 	"encoding/json"
 	"time"
 
@@ -18,8 +19,9 @@ type EventsParams struct {
 	Since         time.Time
 }
 
-// RequestEventsList fetches a log of events from a peer
-func (n *QriNode) RequestEventsList(pid peer.ID, p EventsParams) ([]*repo.Event, error) {
+// RequestEventsList fetches a log of events from a peer, waiting up to
+// PeerRequestTimeout for the peer to respond
+func (n *QriNode) RequestEventsList(ctx context.Context, pid peer.ID, p EventsParams) ([]*repo.Event, error) {
 	log.Debugf("%s: RequestEventsList", n.ID)
 
 	if pid == n.ID {
@@ -34,16 +36,39 @@ func (n *QriNode) RequestEventsList(pid peer.ID, p EventsParams) ([]*repo.Event,
 
 	req = req.WithHeaders("phase", "request")
 
-	replies := make(chan Message)
-	n.SendMessage(req, replies, pid)
+	res, err := n.requestPeer(ctx, req, pid)
+	if err != nil {
+		return nil, err
+	}
 
-	res := <-replies
 	events := []*repo.Event{}
 	err = json.Unmarshal(res.Body, &events)
 
 	return events, err
 }
 
+// AnnounceEvents sends events to all connected qri peers, letting peers that
+// follow this node know about new dataset versions. Announcements have no
+// response, listeners pick them up with ReceiveMessages
+func (n *QriNode) AnnounceEvents(events ...*repo.Event) error {
+	pids := n.ConnectedQriPeerIDs()
+	log.Debugf("%s AnnounceEvents to %d peers", n.ID, len(pids))
+
+	msg, err := NewJSONBodyMessage(n.ID, MtEvents, events)
+	if err != nil {
+		return err
+	}
+	msg = msg.WithHeaders("phase", "announce")
+
+	go func() {
+		if err := n.SendMessage(msg, nil, pids...); err != nil {
+			log.Debugf("announce events error: %s", err.Error())
+		}
+	}()
+
+	return nil
+}
+
 func (n *QriNode) handleEvents(ws *WrappedStream, msg Message) (hangup bool) {
 	hangup = true
 
@@ -71,6 +96,9 @@ func (n *QriNode) handleEvents(ws *WrappedStream, msg Message) (hangup bool) {
 			log.Debug(err.Error())
 			return
 		}
+	case "announce":
+		// announcements are handled by message receivers
+		log.Debugf("%s received events announcement from %s", n.ID, msg.Initiator)
 	}
 
 	return
@@ -39,7 +39,7 @@ func TestRequestEventsList(t *testing.T) {
 			go func(p1, p2 *QriNode) {
 				defer wg.Done()
 
-				events, err := p1.RequestEventsList(p2.ID, EventsParams{Limit: 10, Offset: 0})
+				events, err := p1.RequestEventsList(ctx, p2.ID, EventsParams{Limit: 10, Offset: 0})
 				if err != nil {
 					t.Errorf("%s -> %s error: %s", p1.ID.Pretty(), p2.ID.Pretty(), err.Error())
 				}
@@ -1,6 +1,11 @@
 package p2p
 
 import (
+	"context"
+	"encoding/json"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/dataset/dsfs"
 	"github.com/qri-io/qri/repo"
 )
 
@@ -9,100 +14,98 @@ const (
 	MtDatasetLog = MsgType("dataset_log")
 )
 
-// RequestDatasetLog gets the log information of Peer's dataset
-func (n *QriNode) RequestDatasetLog(ref repo.DatasetRef, limit, offset int) ([]repo.DatasetRef, error) {
-	// id, err := n.Repo.Peers().IPFSPeerID(ref.Peername)
-	// if err != nil {
-	// 	return nil, fmt.Errorf("error getting peer IPFS id: %s", err.Error())
-	// }
-	// res, err := n.SendMessage(id, &Message{
-	// 	Type:    MtDatasetLog,
-	// 	Phase:   MpRequest,
-	// 	Payload: ref,
-	// })
-	// if err != nil {
-	// 	log.Debugf("send dataset log message error: %s", err.Error())
-	// 	return nil, err
-	// }
-
-	// data, err := json.Marshal(res.Payload)
-	// if err != nil {
-	// 	log.Debug(err.Error())
-	// 	return nil, err
-	// }
-
-	resref := []repo.DatasetRef{}
-	// err = json.Unmarshal(data, &resref)
-	// if len(resref) == 0 && err != nil {
-	// 	err = fmt.Errorf("no log found")
-	// }
-
-	return resref, nil
+// datasetLogParams is the body of a dataset log request
+type datasetLogParams struct {
+	Ref           repo.DatasetRef
+	Limit, Offset int
+}
+
+// RequestDatasetLog gets the log information of Peer's dataset. Peers are
+// asked concurrently, the first peer to respond with a log wins. ErrNotFound
+// is returned if no peer has the dataset
+func (n *QriNode) RequestDatasetLog(ctx context.Context, ref repo.DatasetRef, limit, offset int) ([]repo.DatasetRef, error) {
+	log.Debugf("%s RequestDatasetLog %s", n.ID, ref)
+
+	if !n.Online {
+		return nil, ErrNotConnected
+	}
+
+	pids := n.ClosestConnectedPeers(ref.ProfileID, maxResolvePeers)
+	if len(pids) == 0 {
+		return nil, ErrNoConnectedPeers
+	}
+
+	req, err := NewJSONBodyMessage(n.ID, MtDatasetLog, datasetLogParams{Ref: ref, Limit: limit, Offset: offset})
+	if err != nil {
+		log.Debug(err.Error())
+		return nil, err
+	}
+	req = req.WithHeaders("phase", "request")
+
+	rlog := []repo.DatasetRef{}
+	_, err = n.requestFirst(ctx, req, pids, func(res Message) bool {
+		refs := []repo.DatasetRef{}
+		if err := json.Unmarshal(res.Body, &refs); err != nil || len(refs) == 0 {
+			return false
+		}
+		rlog = refs
+		return true
+	})
+	return rlog, err
 }
 
-func (n *QriNode) datasetsHistoryHandler(ws *WrappedStream, msg Message) (hangup bool) {
-	// data, err := json.Marshal(msg.Payload)
-	// if err != nil {
-	// 	log.Debug(err.Error())
-	// }
-
-	return false
-
-	// ref := repo.DatasetRef{}
-	// if err = json.Unmarshal(data, &ref); err != nil {
-	// 	log.Infof(err.Error())
-	// 	return &Message{
-	// 		Type:    MtDatasetLog,
-	// 		Phase:   MpError,
-	// 		Payload: err,
-	// 	}
-	// }
-
-	// ref, err = n.Repo.GetRef(ref)
-	// if err != nil {
-	// 	return &Message{
-	// 		Type:    MtDatasetLog,
-	// 		Phase:   MpError,
-	// 		Payload: err,
-	// 	}
-	// }
-	// // TODO: probably shouldn't write over ref.Path if ref.Path is set, but
-	// // until we make the changes to the way we use hashes to make them
-	// // more consistent, this feels safer.
-	// // ref.Path = path.String()
-
-	// log := []repo.DatasetRef{}
-	// limit := 50
-
-	// for {
-	// 	ref.Dataset, err = n.Repo.GetDataset(datastore.NewKey(ref.Path))
-	// 	if err != nil {
-	// 		return &Message{
-	// 			Type:    MtDatasetLog,
-	// 			Phase:   MpError,
-	// 			Payload: err,
-	// 		}
-	// 	}
-	// 	log = append(log, ref)
-
-	// 	limit--
-	// 	if limit == 0 || ref.Dataset.PreviousPath == "" {
-	// 		break
-	// 	}
-
-	// 	ref, err = repo.ParseDatasetRef(ref.Dataset.PreviousPath)
-
-	// 	if err != nil {
-	// 		return &Message{
-	// 			Type:    MtDatasetLog,
-	// 			Phase:   MpError,
-	// 			Payload: err,
-	// 		}
-	// 	}
-	// }
-	// return &Message{
-	// 	Type:    MtDatasetLog,
-	// 	Phase:   MpResponse,
-	// 	Payload: &log,
-	// }
+func (n *QriNode) handleDatasetLog(ws *WrappedStream, msg Message) (hangup bool) {
+	hangup = true
+
+	switch msg.Header("phase") {
+	case "request":
+		p := datasetLogParams{}
+		if err := json.Unmarshal(msg.Body, &p); err != nil {
+			log.Debug(err.Error())
+			return
+		}
+		if p.Limit <= 0 || p.Limit > listMax {
+			p.Limit = listMax
+		}
+
+		rlog := []repo.DatasetRef{}
+		if err := repo.CanonicalizeDatasetRef(n.Repo, &p.Ref); err == nil {
+			rlog = n.datasetLog(p.Ref, p.Limit, p.Offset)
+		}
+
+		res, err := msg.UpdateJSON(rlog)
+		if err != nil {
+			log.Debug(err.Error())
+			return
+		}
+		res = res.WithHeaders("phase", "response")
+		if err := ws.sendMessage(res); err != nil {
+			log.Debug(err.Error())
+			return
+		}
+	}
+
+	return
+}
+
+// datasetLog walks the history of a dataset from the local store, stopping at
+// the first version that can't be loaded
+func (n *QriNode) datasetLog(ref repo.DatasetRef, limit, offset int) []repo.DatasetRef {
+	rlog := []repo.DatasetRef{}
+	for ref.Path != "" && len(rlog) < limit {
+		ds, err := dsfs.LoadDataset(n.Repo.Store(), datastore.NewKey(ref.Path))
+		if err != nil {
+			log.Debug(err.Error())
+			break
+		}
+		ref.Dataset = ds.Encode()
+
+		if offset > 0 {
+			offset--
+		} else {
+			rlog = append(rlog, ref)
+		}
+		ref.Path = ref.Dataset.PreviousPath
+	}
+	return rlog
 }
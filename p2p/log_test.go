@@ -0,0 +1,54 @@
+package p2p
+
+import (
+	"context"
+	"testing"
+
+	"github.com/qri-io/qri/p2p/test"
+	"github.com/qri-io/qri/repo"
+)
+
+func TestRequestDatasetLog(t *testing.T) {
+	ctx := context.Background()
+	factory := p2ptest.NewTestNodeFactory(NewTestableQriNode)
+	testPeers, err := p2ptest.NewTestDirNetwork(ctx, factory)
+	if err != nil {
+		t.Fatalf("error creating network: %s", err.Error())
+	}
+	if err := p2ptest.ConnectNodes(ctx, testPeers); err != nil {
+		t.Fatalf("error connecting peers: %s", err.Error())
+	}
+
+	peers := make([]*QriNode, len(testPeers))
+	for i, node := range testPeers {
+		peers[i] = node.(*QriNode)
+	}
+
+	refs, err := peers[1].Repo.References(10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(refs) == 0 {
+		t.Fatal("expected peer 1 to have datasets")
+	}
+
+	ref := repo.DatasetRef{Peername: refs[0].Peername, Name: refs[0].Name}
+	rlog, err := peers[0].RequestDatasetLog(ctx, ref, 10, 0)
+	if err != nil {
+		t.Fatalf("RequestDatasetLog error: %s", err.Error())
+	}
+	if len(rlog) == 0 {
+		t.Fatal("expected log to have at least one entry")
+	}
+	if rlog[0].Path != refs[0].Path {
+		t.Errorf("log head path mismatch. expected: %s, got: %s", refs[0].Path, rlog[0].Path)
+	}
+	if rlog[0].Dataset == nil {
+		t.Error("expected log entries to include datasets")
+	}
+
+	missing := repo.DatasetRef{Peername: refs[0].Peername, Name: "not_a_dataset"}
+	if _, err := peers[0].RequestDatasetLog(ctx, missing, 10, 0); err != ErrNotFound {
+		t.Errorf("expected missing dataset to return ErrNotFound, got: %v", err)
+	}
+}
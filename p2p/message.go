@@ -37,6 +37,12 @@ type Message struct {
 	provider peer.ID
 }
 
+// Provider gives the peer that sent a received message, read from the stream
+// it arrived on. Unlike Initiator, which senders set, it can't be forged
+func (m Message) Provider() peer.ID {
+	return m.provider
+}
+
 // Update returns a new message with an updated body
 func (m Message) Update(body []byte) Message {
 	return Message{
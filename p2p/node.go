@@ -8,6 +8,7 @@ import (
 	"github.com/qri-io/cafs/ipfs"
 	"github.com/qri-io/qri/config"
 	"github.com/qri-io/qri/p2p/test"
+	"github.com/qri-io/qri/progress"
 	"github.com/qri-io/qri/repo"
 
 	net "gx/ipfs/QmPjvxTpVH8qJyQDnxnsxF9kv9jezKD1kozz1hs3fCGsNh/go-libp2p-net"
@@ -51,6 +52,10 @@ type QriNode struct {
 	// BootstrapAddrs is a list of multiaddresses to bootrap *qri* from (not IPFS)
 	BootstrapAddrs []string
 
+	// Progress streams updates from long-running operations performed with
+	// this node, like saving datasets & fetching them from peers
+	Progress *progress.Stream
+
 	// handlers maps this nodes registered handlers. This works in a way similary to a router
 	// in traditional client/server models, but messages are flying around all over the place
 	// instead of a request/response pattern
@@ -64,6 +69,14 @@ type QriNode struct {
 	receivers []chan Message
 	// profileReplication sets what to do when this node sees it's own profile
 	profileReplication string
+	// manifests caches transfer manifests of datasets this node has sent,
+	// keyed by path
+	manifests *sync.Map
+
+	// overdueTransform is the path of a transform script this node is still
+	// running after it exceeded it's time limit, empty when there isn't one
+	overdueTransform   string
+	overdueTransformMu sync.Mutex
 }
 
 // Assert that conversions needed by the tests are valid.
@@ -92,7 +105,9 @@ func NewQriNode(r repo.Repo, p2pconf *config.P2P) (node *QriNode, err error) {
 		Repo:               r,
 		ctx:                context.Background(),
 		BootstrapAddrs:     p2pconf.QriBootstrapAddrs,
+		Progress:           progress.NewStream(),
 		msgState:           &sync.Map{},
+		manifests:          &sync.Map{},
 		msgChan:            make(chan Message),
 		profileReplication: p2pconf.ProfileReplication,
 	}
@@ -112,7 +127,7 @@ func (n *QriNode) Connect() (err error) {
 		// If the underlying content-addressed-filestore is an ipfs
 		// node, it has built-in p2p, overlay the qri protocol
 		// on the ipfs node's p2p connections.
-		if ipfsfs, ok := n.Repo.Store().(*ipfs_filestore.Filestore); ok {
+		if ipfsfs, ok := repo.BaseStore(n.Repo.Store()).(*ipfs_filestore.Filestore); ok {
 			if !ipfsfs.Online() {
 				if err := ipfsfs.GoOnline(); err != nil {
 					return err
@@ -208,7 +223,7 @@ func (n *QriNode) echoMessages() {
 
 // IPFSNode returns the underlying IPFS node if this Qri Node is running on IPFS
 func (n *QriNode) IPFSNode() (*core.IpfsNode, error) {
-	if ipfsfs, ok := n.Repo.Store().(*ipfs_filestore.Filestore); ok {
+	if ipfsfs, ok := repo.BaseStore(n.Repo.Store()).(*ipfs_filestore.Filestore); ok {
 		return ipfsfs.Node(), nil
 	}
 	return nil, fmt.Errorf("not using IPFS")
@@ -250,6 +265,30 @@ func (n *QriNode) Context() context.Context {
 	return n.ctx
 }
 
+// OverdueTransform gives the script path of a transform this node is still
+// running after it exceeded it's time limit, if there is one. Skylark can't
+// interrupt a running script, nodes refuse new transforms until it finishes
+func (n *QriNode) OverdueTransform() (script string, ok bool) {
+	n.overdueTransformMu.Lock()
+	defer n.overdueTransformMu.Unlock()
+	return n.overdueTransform, n.overdueTransform != ""
+}
+
+// SetOverdueTransform marks a transform script as still running past it's
+// time limit
+func (n *QriNode) SetOverdueTransform(script string) {
+	n.overdueTransformMu.Lock()
+	n.overdueTransform = script
+	n.overdueTransformMu.Unlock()
+}
+
+// ClearOverdueTransform lifts the refusal to run transforms while an overdue
+// transform runs. It's called once the overdue script finishes, clearing it
+// early allows transforms to run alongside the overdue script
+func (n *QriNode) ClearOverdueTransform() {
+	n.SetOverdueTransform("")
+}
+
 // TODO - finish. We need a proper termination & cleanup process
 // func (n *QriNode) Close() error {
 // 	if node, err := n.IPFSNode(); err == nil {
@@ -283,13 +322,19 @@ func makeBasicHost(ctx context.Context, ps pstore.Peerstore, p2pconf *config.P2P
 
 // SendMessage opens a stream & sends a message from p to one ore more peerIDs
 func (n *QriNode) SendMessage(msg Message, replies chan Message, pids ...peer.ID) error {
+	return n.sendMessageContext(n.Context(), msg, replies, pids...)
+}
+
+// sendMessageContext is SendMessage with a context that bounds opening
+// streams to peers
+func (n *QriNode) sendMessageContext(ctx context.Context, msg Message, replies chan Message, pids ...peer.ID) error {
 	for _, peerID := range pids {
 		if peerID == n.ID {
 			// can't send messages to yourself, silly
 			continue
 		}
 
-		s, err := n.Host.NewStream(n.Context(), peerID, QriProtocolID)
+		s, err := n.Host.NewStream(ctx, peerID, QriProtocolID)
 		if err != nil {
 			return fmt.Errorf("error opening stream: %s", err.Error())
 		}
@@ -388,5 +433,10 @@ func MakeHandlers(n *QriNode) map[MsgType]HandlerFunc {
 		MtEvents:            n.handleEvents,
 		MtConnected:         n.handleConnected,
 		MtResolveDatasetRef: n.handleResolveDatasetRef,
+		MtChangeRequest:     n.handleChangeRequest,
+		MtSearch:            n.handleSearchRequest,
+		MtDatasetLog:        n.handleDatasetLog,
+		MtTransferManifest:  n.handleTransferManifest,
+		MtTransferBlocks:    n.handleTransferBlocks,
 	}
 }
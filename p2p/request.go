@@ -0,0 +1,84 @@
+package p2p
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	peer "gx/ipfs/QmdVrMn1LhB4ybb8hMVaMLXnA8XRSewMnK6YqXKXoTcRvN/go-libp2p-peer"
+)
+
+// PeerRequestTimeout is how long a request waits for a single peer to
+// respond before giving up on that peer
+var PeerRequestTimeout = time.Second * 10
+
+// maxResolvePeers is the highest number of peers asked to resolve a dataset
+const maxResolvePeers = 15
+
+// ErrNotFound is returned when no peer responded with what was asked for
+var ErrNotFound = fmt.Errorf("not found on any connected peer")
+
+// ErrNoConnectedPeers is returned when a request needs connected peers, but
+// there are none
+var ErrNoConnectedPeers = fmt.Errorf("no connected peers")
+
+// requestPeer sends a request to a single peer, waiting for it's reply until
+// PeerRequestTimeout elapses or ctx is done
+func (n *QriNode) requestPeer(ctx context.Context, req Message, pid peer.ID) (Message, error) {
+	ctx, cancel := context.WithTimeout(ctx, PeerRequestTimeout)
+	defer cancel()
+
+	// buffered so a late reply doesn't block once we've stopped listening
+	replies := make(chan Message, 1)
+	if err := n.sendMessageContext(ctx, req, replies, pid); err != nil {
+		return Message{}, err
+	}
+
+	select {
+	case res := <-replies:
+		return res, nil
+	case <-ctx.Done():
+		return Message{}, fmt.Errorf("peer %s didn't respond: %s", pid.Pretty(), ctx.Err())
+	}
+}
+
+// requestFirst sends a request to peers concurrently, returning the first
+// reply accepted by valid. Each peer gets PeerRequestTimeout to respond,
+// ErrNotFound is returned if no peer gave a valid reply
+func (n *QriNode) requestFirst(ctx context.Context, req Message, pids []peer.ID, valid func(Message) bool) (Message, error) {
+	if len(pids) == 0 {
+		return Message{}, ErrNoConnectedPeers
+	}
+
+	// stop waiting on remaining peers once there's an answer
+	reqCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type reply struct {
+		msg Message
+		err error
+	}
+	replies := make(chan reply, len(pids))
+	for _, pid := range pids {
+		go func(pid peer.ID) {
+			msg, err := n.requestPeer(reqCtx, req, pid)
+			replies <- reply{msg, err}
+		}(pid)
+	}
+
+	for range pids {
+		r := <-replies
+		if r.err != nil {
+			log.Debug(r.err.Error())
+			continue
+		}
+		if valid(r.msg) {
+			return r.msg, nil
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return Message{}, err
+	}
+	return Message{}, ErrNotFound
+}
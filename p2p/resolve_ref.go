@@ -1,8 +1,8 @@
 package p2p
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
 
 	"github.com/qri-io/qri/repo"
 )
@@ -10,43 +10,42 @@ import (
 // MtResolveDatasetRef resolves a dataset reference
 const MtResolveDatasetRef = MsgType("resolve_dataset_ref")
 
-// ResolveDatasetRef completes a dataset reference
-func (n *QriNode) ResolveDatasetRef(ref *repo.DatasetRef) (err error) {
+// ResolveDatasetRef completes a dataset reference, asking connected peers
+// concurrently. The first peer to resolve the reference wins. ErrNotFound is
+// returned if no peer can resolve it
+func (n *QriNode) ResolveDatasetRef(ctx context.Context, ref *repo.DatasetRef) (err error) {
 	log.Debugf("%s ResolveDatasetRef %s", n.ID, ref)
 
 	if !n.Online {
 		return ErrNotConnected
 	}
 
-	pids := n.ClosestConnectedPeers(ref.ProfileID, 15)
+	pids := n.ClosestConnectedPeers(ref.ProfileID, maxResolvePeers)
 	if len(pids) == 0 {
-		return fmt.Errorf("no connected peers")
+		return ErrNoConnectedPeers
 	}
 
-	replies := make(chan Message)
 	req, err := NewJSONBodyMessage(n.ID, MtResolveDatasetRef, ref)
-	req = req.WithHeaders("phase", "request")
 	if err != nil {
 		log.Debug(err.Error())
 		return err
 	}
+	req = req.WithHeaders("phase", "request")
 
-	for _, pid := range pids {
-		if err := n.SendMessage(req, replies, pid); err != nil {
-			log.Debugf("%s err: %s", pid, err.Error())
-			continue
-		}
-
-		res := <-replies
-		dsr := repo.DatasetRef{}
-		if err := json.Unmarshal(res.Body, &dsr); err == nil {
-			if dsr.Path != "" {
-				*ref = dsr
-				break
-			}
+	dsr := repo.DatasetRef{}
+	_, err = n.requestFirst(ctx, req, pids, func(res Message) bool {
+		resolved := repo.DatasetRef{}
+		if err := json.Unmarshal(res.Body, &resolved); err != nil || resolved.Path == "" {
+			return false
 		}
+		dsr = resolved
+		return true
+	})
+	if err != nil {
+		return err
 	}
 
+	*ref = dsr
 	return nil
 }
 
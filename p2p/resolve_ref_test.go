@@ -4,6 +4,7 @@ import (
 	"context"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/qri-io/qri/p2p/test"
 	"github.com/qri-io/qri/repo"
@@ -49,7 +50,7 @@ func TestResolveDatasetRef(t *testing.T) {
 			go func(p *QriNode) {
 				defer wg.Done()
 				ref := repo.DatasetRef{Peername: "tim", Name: "bar"}
-				if err := p.ResolveDatasetRef(&ref); err != nil {
+				if err := p.ResolveDatasetRef(ctx, &ref); err != nil {
 					t.Errorf("%s ResolveDatasetRef error: %s", p.ID, err.Error())
 				}
 				if ref.String() != expect {
@@ -63,3 +64,65 @@ func TestResolveDatasetRef(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestResolveDatasetRefSlowPeers(t *testing.T) {
+	ctx := context.Background()
+	factory := p2ptest.NewTestNodeFactory(NewTestableQriNode)
+	testPeers, err := p2ptest.NewTestDirNetwork(ctx, factory)
+	if err != nil {
+		t.Fatalf("error creating network: %s", err.Error())
+	}
+
+	peers := make([]*QriNode, len(testPeers))
+	for i, node := range testPeers {
+		peers[i] = node.(*QriNode)
+	}
+
+	// every peer but 0 & 4 accepts resolve requests but never responds
+	silent := func(ws *WrappedStream, msg Message) bool { return false }
+	for i, p := range peers {
+		if i != 0 && i != 4 {
+			p.handlers[MtResolveDatasetRef] = silent
+		}
+	}
+
+	if err = p2ptest.ConnectNodes(ctx, testPeers); err != nil {
+		t.Fatalf("error connecting peers: %s", err.Error())
+	}
+
+	prevTimeout := PeerRequestTimeout
+	PeerRequestTimeout = time.Millisecond * 500
+	defer func() { PeerRequestTimeout = prevTimeout }()
+
+	p, err := peers[4].Repo.Profile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := peers[4].Repo.PutRef(repo.DatasetRef{Peername: p.Peername, Name: "bar", ProfileID: p.ID, Path: "/ipfs/QmXSGsgt8Bn8jepw7beXibYUfWSJVU2SzP3TpkioQVUrmM"}); err != nil {
+		t.Fatalf("error putting ref in repo: %s", err.Error())
+	}
+
+	ref := repo.DatasetRef{Peername: p.Peername, Name: "bar"}
+	if err := peers[0].ResolveDatasetRef(ctx, &ref); err != nil {
+		t.Errorf("expected slow peers not to prevent resolving. error: %s", err.Error())
+	}
+	if ref.Path != "/ipfs/QmXSGsgt8Bn8jepw7beXibYUfWSJVU2SzP3TpkioQVUrmM" {
+		t.Errorf("path mismatch. expected: %s, got: %s", "/ipfs/QmXSGsgt8Bn8jepw7beXibYUfWSJVU2SzP3TpkioQVUrmM", ref.Path)
+	}
+
+	start := time.Now()
+	missing := repo.DatasetRef{Peername: p.Peername, Name: "missing"}
+	if err := peers[0].ResolveDatasetRef(ctx, &missing); err != ErrNotFound {
+		t.Errorf("expected missing ref to return ErrNotFound, got: %v", err)
+	}
+	// peers are asked concurrently, so waiting on slow peers shouldn't add up
+	if elapsed := time.Since(start); elapsed > PeerRequestTimeout*3 {
+		t.Errorf("resolving a missing ref took too long: %s", elapsed)
+	}
+
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := peers[0].ResolveDatasetRef(cctx, &repo.DatasetRef{Peername: p.Peername, Name: "missing"}); err != context.Canceled {
+		t.Errorf("expected canceled context to return context.Canceled, got: %v", err)
+	}
+}
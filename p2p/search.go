@@ -1,76 +1,194 @@
 package p2p
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/dataset/dsfs"
 	"github.com/qri-io/qri/repo"
-	// pstore "gx/ipfs/QmPgDWmTmuzvP7QE5zwo1TmjbJme9pmZHNujB2453jkCTr/go-libp2p-peerstore"
+
+	peer "gx/ipfs/QmdVrMn1LhB4ybb8hMVaMLXnA8XRSewMnK6YqXKXoTcRvN/go-libp2p-peer"
 )
 
 // MtSearch is a search message
 const MtSearch = MsgType("search")
 
-// Search broadcasts a search request to all connected peers, aggregating results
-func (n *QriNode) Search(terms string, limit, offset int) (res []*repo.DatasetRef, err error) {
-	// responses, err := n.BroadcastMessage(&Message{
-	// 	Phase: MpRequest,
-	// 	Type:  MtSearch,
-	// 	Payload: &repo.SearchParams{
-	// 		Q:      terms,
-	// 		Limit:  limit,
-	// 		Offset: offset,
-	// 	},
-	// })
-	// if err != nil {
-	// 	return nil, err
-	// }
-
-	// datasets := []*repo.DatasetRef{}
-
-	// for _, r := range responses {
-	// 	data, err := json.Marshal(r.Payload)
-	// 	if err != nil {
-	// 		return datasets, err
-	// 	}
-	// 	ds := []*repo.DatasetRef{}
-	// 	if err := json.Unmarshal(data, &ds); err != nil {
-	// 		return datasets, err
-	// 	}
-	// 	datasets = append(datasets, ds...)
-	// }
-
-	return res, nil
+// SearchTimeout is how long Search waits for peers to respond when the
+// search context has no deadline
+var SearchTimeout = time.Second * 5
+
+// SearchResult is a dataset found by searching the network
+type SearchResult struct {
+	Ref repo.DatasetRef
+	// Score ranks results, higher scores rank first. Datasets returned by more
+	// peers & ranked higher by each peer score higher
+	Score float64
+	// PeerIDs lists the peers that returned this dataset
+	PeerIDs []string
+}
+
+// Search sends a search request to all connected qri peers, each peer
+// searches it's own index. Results are merged, deduplicated by path & ranked.
+// Peers that don't respond before the context deadline are left out
+func (n *QriNode) Search(ctx context.Context, p repo.SearchParams) ([]SearchResult, error) {
+	if !n.Online {
+		return nil, fmt.Errorf("not connected to p2p network")
+	}
+	if p.Limit <= 0 || p.Limit > listMax {
+		p.Limit = listMax
+	}
+
+	pids := n.ConnectedQriPeerIDs()
+	if len(pids) == 0 {
+		return nil, ErrNoConnectedPeers
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, SearchTimeout)
+		defer cancel()
+	}
+
+	// peers rank results before offset is applied, ask each for enough results
+	// to fill the requested page
+	req, err := NewJSONBodyMessage(n.ID, MtSearch, repo.SearchParams{Q: p.Q, Limit: p.Limit + p.Offset})
+	if err != nil {
+		log.Debug(err.Error())
+		return nil, err
+	}
+	req = req.WithHeaders("phase", "request")
+
+	// ask peers concurrently, each gets PeerRequestTimeout to respond within
+	// the search deadline
+	type reply struct {
+		msg Message
+		err error
+	}
+	replies := make(chan reply, len(pids))
+	for _, pid := range pids {
+		go func(pid peer.ID) {
+			msg, err := n.requestPeer(ctx, req, pid)
+			replies <- reply{msg, err}
+		}(pid)
+	}
+
+	results := map[string]*SearchResult{}
+	for i := range pids {
+		select {
+		case r := <-replies:
+			if r.err != nil {
+				log.Debug(r.err.Error())
+				continue
+			}
+			refs := []repo.DatasetRef{}
+			if err := json.Unmarshal(r.msg.Body, &refs); err != nil {
+				log.Debugf("%s err: %s", r.msg.provider, err.Error())
+				continue
+			}
+			mergeSearchResults(results, r.msg.provider, refs)
+		case <-ctx.Done():
+			log.Debugf("search finished with %d of %d peers responding", i, len(pids))
+			return rankSearchResults(results, p.Limit, p.Offset), nil
+		}
+	}
+
+	return rankSearchResults(results, p.Limit, p.Offset), nil
+}
+
+// mergeSearchResults adds a peer's ranked results to a set of results keyed
+// by path. Each result scores the reciprocal of it's rank
+func mergeSearchResults(results map[string]*SearchResult, pid peer.ID, refs []repo.DatasetRef) {
+	for rank, ref := range refs {
+		if ref.Path == "" {
+			continue
+		}
+		r, ok := results[ref.Path]
+		if !ok {
+			r = &SearchResult{Ref: ref}
+			results[ref.Path] = r
+		}
+		if r.Ref.Dataset == nil {
+			r.Ref.Dataset = ref.Dataset
+		}
+		r.Score += 1 / float64(rank+1)
+		r.PeerIDs = append(r.PeerIDs, pid.Pretty())
+	}
+}
+
+// rankSearchResults orders results by score, then path, returning a page
+func rankSearchResults(results map[string]*SearchResult, limit, offset int) []SearchResult {
+	ranked := make([]SearchResult, 0, len(results))
+	for _, r := range results {
+		sort.Strings(r.PeerIDs)
+		ranked = append(ranked, *r)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Score == ranked[j].Score {
+			return ranked[i].Ref.Path < ranked[j].Ref.Path
+		}
+		return ranked[i].Score > ranked[j].Score
+	})
+
+	if offset >= len(ranked) {
+		return []SearchResult{}
+	}
+	ranked = ranked[offset:]
+	if limit > 0 && limit < len(ranked) {
+		ranked = ranked[:limit]
+	}
+	return ranked
 }
 
 func (n *QriNode) handleSearchRequest(ws *WrappedStream, msg Message) (hangup bool) {
-	// log.Debug("handling search request")
-	// data, err := json.Marshal(msg.Payload)
-	// if err != nil {
-	// 	log.Info(err.Error())
-	// 	return true
-	// }
-	// p := &repo.SearchParams{}
-	// if err := json.Unmarshal(data, p); err != nil {
-	// 	log.Info("unmarshal search request error:", err.Error())
-	// 	return true
-	// }
-
-	// // results, err := search.Search(n.Repo, n.Store, search.NewDatasetQuery(p.Query, p.Limit, p.Offset))
-	// if s, ok := n.Repo.(repo.Searchable); ok {
-	// 	results, err := s.Search(*p)
-	// 	if err != nil {
-	// 		log.Info("search error:", err.Error())
-	// 		return nil
-	// 	}
-	// 	return &Message{
-	// 		Phase:   MpResponse,
-	// 		Type:    MtSearch,
-	// 		Payload: results,
-	// 	}
-	// }
-
-	// &Message{
-	//     Phase:   MpError,
-	//     Type:    MtSearch,
-	//     Payload: fmt.Errorf("repo doesn't support search"),
-	//   }
+	hangup = true
+
+	switch msg.Header("phase") {
+	case "request":
+		p := repo.SearchParams{}
+		if err := json.Unmarshal(msg.Body, &p); err != nil {
+			log.Debugf("%s %s", n.ID, err.Error())
+			return
+		}
+		if p.Limit <= 0 || p.Limit > listMax {
+			p.Limit = listMax
+		}
+
+		// always reply, peers that can't search answer with no results so
+		// requesters don't wait on them
+		refs := []repo.DatasetRef{}
+		if s, ok := n.Repo.(repo.Searchable); ok {
+			found, err := s.Search(p)
+			if err != nil {
+				log.Debugf("search error: %s", err.Error())
+			}
+			for _, ref := range found {
+				if ref.Dataset == nil && ref.Path != "" {
+					ds, err := dsfs.LoadDataset(n.Repo.Store(), datastore.NewKey(ref.Path))
+					if err != nil {
+						log.Debugf("error loading dataset at path %s: %s", ref.Path, err.Error())
+						continue
+					}
+					ref.Dataset = ds.Encode()
+				}
+				refs = append(refs, ref)
+			}
+		}
+
+		reply, err := msg.UpdateJSON(refs)
+		if err != nil {
+			log.Debug(err.Error())
+			return
+		}
+		reply = reply.WithHeaders("phase", "response")
+		if err := ws.sendMessage(reply); err != nil {
+			log.Debug(err.Error())
+			return
+		}
+	}
+
 	return
 }
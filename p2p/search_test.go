@@ -0,0 +1,106 @@
+package p2p
+
+import (
+	"context"
+	"testing"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/p2p/test"
+	"github.com/qri-io/qri/repo"
+
+	peer "gx/ipfs/QmdVrMn1LhB4ybb8hMVaMLXnA8XRSewMnK6YqXKXoTcRvN/go-libp2p-peer"
+)
+
+// searchableRepo answers every search with a fixed list of refs
+type searchableRepo struct {
+	repo.Repo
+	refs []repo.DatasetRef
+}
+
+func (r searchableRepo) Search(p repo.SearchParams) ([]repo.DatasetRef, error) {
+	return r.refs, nil
+}
+
+func TestSearch(t *testing.T) {
+	ctx := context.Background()
+	factory := p2ptest.NewTestNodeFactory(NewTestableQriNode)
+	testPeers, err := p2ptest.NewTestDirNetwork(ctx, factory)
+	if err != nil {
+		t.Fatalf("error creating network: %s", err.Error())
+	}
+	if err := p2ptest.ConnectNodes(ctx, testPeers); err != nil {
+		t.Fatalf("error connecting peers: %s", err.Error())
+	}
+
+	peers := make([]*QriNode, len(testPeers))
+	for i, node := range testPeers {
+		peers[i] = node.(*QriNode)
+	}
+
+	refs := []repo.DatasetRef{
+		{Peername: "a", Name: "cities", Path: "/map/cities", Dataset: &dataset.DatasetPod{Meta: &dataset.Meta{Title: "cities"}}},
+		{Peername: "b", Name: "movies", Path: "/map/movies", Dataset: &dataset.DatasetPod{Meta: &dataset.Meta{Title: "movies"}}},
+	}
+	// every peer but the first knows about cities, only the last about movies
+	for i, p := range peers[1:] {
+		sr := searchableRepo{Repo: p.Repo, refs: refs[:1]}
+		if i == len(peers)-2 {
+			sr.refs = refs
+		}
+		p.Repo = sr
+	}
+
+	results, err := peers[0].Search(ctx, repo.SearchParams{Q: "cities"})
+	if err != nil {
+		t.Fatalf("search error: %s", err.Error())
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got: %d", len(results))
+	}
+	if results[0].Ref.Path != "/map/cities" {
+		t.Errorf("expected cities to rank first, got: %s", results[0].Ref.Path)
+	}
+	if len(results[0].PeerIDs) != len(peers)-1 {
+		t.Errorf("expected cities from %d peers, got: %d", len(peers)-1, len(results[0].PeerIDs))
+	}
+	if results[1].Ref.Dataset == nil || results[1].Ref.Dataset.Meta.Title != "movies" {
+		t.Errorf("expected result dataset to be returned")
+	}
+}
+
+func TestRankSearchResults(t *testing.T) {
+	results := map[string]*SearchResult{}
+	mergeSearchResults(results, peer.ID("a"), []repo.DatasetRef{{Path: "/map/1"}, {Path: "/map/2"}, {Path: "/map/3"}})
+	mergeSearchResults(results, peer.ID("b"), []repo.DatasetRef{{Path: "/map/2"}, {Path: "/map/3"}, {Path: ""}})
+
+	if len(results) != 3 {
+		t.Fatalf("expected 3 merged results, got: %d", len(results))
+	}
+
+	cases := []struct {
+		limit, offset int
+		paths         []string
+	}{
+		{0, 0, []string{"/map/2", "/map/1", "/map/3"}},
+		{1, 0, []string{"/map/2"}},
+		{2, 1, []string{"/map/1", "/map/3"}},
+		{10, 3, []string{}},
+	}
+
+	for i, c := range cases {
+		got := rankSearchResults(results, c.limit, c.offset)
+		if len(got) != len(c.paths) {
+			t.Errorf("case %d result count mismatch. expected: %d, got: %d", i, len(c.paths), len(got))
+			continue
+		}
+		for j, r := range got {
+			if r.Ref.Path != c.paths[j] {
+				t.Errorf("case %d result %d path mismatch. expected: %s, got: %s", i, j, c.paths[j], r.Ref.Path)
+			}
+		}
+	}
+
+	if got := rankSearchResults(results, 1, 0); len(got[0].PeerIDs) != 2 {
+		t.Errorf("expected top result to list 2 peers, got: %d", len(got[0].PeerIDs))
+	}
+}
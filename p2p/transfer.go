@@ -0,0 +1,686 @@
+package p2p
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/multiformats/go-multihash"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/qri/repo"
+
+	peer "gx/ipfs/QmdVrMn1LhB4ybb8hMVaMLXnA8XRSewMnK6YqXKXoTcRvN/go-libp2p-peer"
+)
+
+const (
+	// MtTransferManifest requests the manifest of blocks that make up a
+	// dataset version
+	MtTransferManifest = MsgType("transfer_manifest")
+	// MtTransferBlocks requests blocks listed in a transfer manifest. Blocks
+	// are sent one message each, followed by a response message
+	MtTransferBlocks = MsgType("transfer_blocks")
+)
+
+const (
+	// TransferFileBody is the name of a dataset body in a transfer manifest
+	TransferFileBody = "body"
+	// TransferFileTransform is the name of a transform script in a transfer
+	// manifest
+	TransferFileTransform = "transform"
+	// TransferFileViz is the name of a viz script in a transfer manifest
+	TransferFileViz = "viz"
+)
+
+// TransferBlockSize is the number of bytes files are split into for transfer
+var TransferBlockSize = 256 * 1024
+
+// TransferCacheDir is where received blocks are kept until a transfer
+// completes. Blocks outlive failed transfers, so an interrupted transfer only
+// requests blocks it hasn't already received when it's tried again
+var TransferCacheDir = filepath.Join(os.TempDir(), "qri_transfers")
+
+// ErrBlockHashMismatch is returned when a received block doesn't match it's
+// hash
+var ErrBlockHashMismatch = fmt.Errorf("block doesn't match it's hash")
+
+// ErrTransferMismatch is returned when a transferred dataset doesn't hash to
+// the path it was requested by
+var ErrTransferMismatch = fmt.Errorf("transferred dataset doesn't match it's path")
+
+// TransferManifest lists the blocks that make up a dataset version
+type TransferManifest struct {
+	// Path of the dataset version on the sending peer
+	Path string
+	// Dataset is the dataset document, with components inline
+	Dataset *dataset.Dataset
+	// Files lists the body & scripts of the dataset, split into blocks
+	Files []TransferFile
+}
+
+// TransferFile is a file of a dataset split into blocks
+type TransferFile struct {
+	// Name is one of TransferFileBody, TransferFileTransform or TransferFileViz
+	Name string
+	Size int64
+	// Blocks are the hashes of each block in the file, in order
+	Blocks []string
+}
+
+// TransferProgress reports the state of a dataset transfer. Blocks that were
+// already received by an earlier attempt count as transferred
+type TransferProgress struct {
+	Path        string
+	Blocks      int
+	TotalBlocks int
+	Bytes       int64
+	TotalBytes  int64
+}
+
+// transferRequest is the body of manifest & block requests
+type transferRequest struct {
+	Path   string
+	Hashes []string `json:",omitempty"`
+}
+
+// transferResponse ends a block request, counting the blocks that were sent
+type transferResponse struct {
+	Sent int
+}
+
+// FetchDataset transfers a dataset version from connected peers into this
+// node's store, block by block. The manifest is requested from the closest
+// peers to ref's profile, the peer that sends the manifest is asked for
+// missing blocks first, falling back to other peers. Each block is checked
+// against it's hash as it arrives. The manifest is sent by the peer, so the
+// dataset it assembles is only accepted if writing it to the local store gives
+// ref.Path, which requires this node to address content the same way as the
+// requested path. FetchDataset returns the local path. progress is called as
+// blocks arrive, and may be nil
+func (n *QriNode) FetchDataset(ctx context.Context, ref *repo.DatasetRef, progress func(TransferProgress)) (string, error) {
+	log.Debugf("%s FetchDataset %s", n.ID, ref)
+
+	if !n.Online {
+		return "", ErrNotConnected
+	}
+
+	store := n.Repo.Store()
+	if prefix := "/" + store.PathPrefix() + "/"; !strings.HasPrefix(ref.Path, prefix) {
+		return "", fmt.Errorf("can't verify a transfer of %s into a store that addresses content with %s", ref.Path, prefix)
+	}
+
+	pids := n.ClosestConnectedPeers(ref.ProfileID, maxResolvePeers)
+	if len(pids) == 0 {
+		return "", ErrNoConnectedPeers
+	}
+
+	req, err := NewJSONBodyMessage(n.ID, MtTransferManifest, transferRequest{Path: ref.Path})
+	if err != nil {
+		return "", err
+	}
+	req = req.WithHeaders("phase", "request")
+
+	man := &TransferManifest{}
+	res, err := n.requestFirst(ctx, req, pids, func(res Message) bool {
+		m := &TransferManifest{}
+		if err := json.Unmarshal(res.Body, m); err != nil || m.Dataset == nil || m.Path != ref.Path {
+			return false
+		}
+		man = m
+		return true
+	})
+	if err != nil {
+		return "", err
+	}
+	if err := man.validate(); err != nil {
+		return "", err
+	}
+
+	// ask the peer that had the manifest first
+	sources := []peer.ID{res.provider}
+	for _, pid := range pids {
+		if pid != res.provider {
+			sources = append(sources, pid)
+		}
+	}
+
+	cache := blockCache{dir: TransferCacheDir}
+	if err := n.transferBlocks(ctx, cache, man, sources, progress); err != nil {
+		return "", err
+	}
+
+	key, err := writeTransfer(store, cache, man)
+	if err != nil {
+		return "", fmt.Errorf("error writing transferred dataset: %s", err.Error())
+	}
+	cache.remove(man)
+
+	if transferPath(key.String()) != transferPath(ref.Path) {
+		log.Debugf("transfer of %s from %s wrote %s", ref.Path, res.provider, key)
+		if err := store.Delete(key); err != nil {
+			log.Debugf("error removing mismatched transfer %s: %s", key, err.Error())
+		}
+		return "", ErrTransferMismatch
+	}
+	return key.String(), nil
+}
+
+// transferBlocks requests the blocks of a manifest that aren't in cache from
+// a list of peers, stopping once every block is cached
+func (n *QriNode) transferBlocks(ctx context.Context, cache blockCache, man *TransferManifest, pids []peer.ID, progress func(TransferProgress)) error {
+	if err := os.MkdirAll(cache.dir, os.ModePerm); err != nil {
+		return fmt.Errorf("error creating transfer cache: %s", err.Error())
+	}
+
+	// files can share blocks, progress counts each distinct block once
+	prog := TransferProgress{Path: man.Path}
+	missing := map[string]bool{}
+	sizes := man.blockSizes()
+	for hash, size := range sizes {
+		prog.TotalBlocks++
+		prog.TotalBytes += size
+		if cache.has(hash) {
+			prog.Blocks++
+			prog.Bytes += size
+		} else {
+			missing[hash] = true
+		}
+	}
+	report := func() {
+		if progress != nil {
+			progress(prog)
+		}
+	}
+	report()
+
+	for _, pid := range pids {
+		if len(missing) == 0 {
+			break
+		}
+		hashes := make([]string, 0, len(missing))
+		for hash := range missing {
+			hashes = append(hashes, hash)
+		}
+
+		err := n.requestBlocks(ctx, pid, man.Path, hashes, func(hash string, data []byte) error {
+			if !missing[hash] {
+				return fmt.Errorf("received unrequested block %s", hash)
+			}
+			if err := verifyBlock(hash, data); err != nil {
+				return err
+			}
+			if err := cache.put(hash, data); err != nil {
+				return err
+			}
+			delete(missing, hash)
+			prog.Blocks++
+			prog.Bytes += sizes[hash]
+			report()
+			return nil
+		})
+		if err != nil {
+			log.Debugf("error transferring blocks from %s: %s", pid.Pretty(), err.Error())
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("transfer incomplete, %d of %d blocks missing", len(missing), prog.TotalBlocks)
+	}
+	return nil
+}
+
+// requestBlocks asks a peer for blocks by hash, calling onBlock for each
+// block that arrives. requestBlocks waits up to PeerRequestTimeout for each
+// message. An error from onBlock ends the request
+func (n *QriNode) requestBlocks(ctx context.Context, pid peer.ID, path string, hashes []string, onBlock func(hash string, data []byte) error) error {
+	req, err := NewJSONBodyMessage(n.ID, MtTransferBlocks, transferRequest{Path: path, Hashes: hashes})
+	if err != nil {
+		return err
+	}
+	req = req.WithHeaders("phase", "request")
+
+	replies := make(chan Message, 8)
+	if err := n.sendMessageContext(ctx, req, replies, pid); err != nil {
+		return err
+	}
+
+	// blocks & the response may arrive in any order
+	received, expect := 0, -1
+	for expect < 0 || received < expect {
+		select {
+		case msg := <-replies:
+			switch msg.Header("phase") {
+			case "block":
+				if err := onBlock(msg.Header("hash"), msg.Body); err != nil {
+					return err
+				}
+				received++
+			case "response":
+				res := transferResponse{}
+				if err := json.Unmarshal(msg.Body, &res); err != nil {
+					return err
+				}
+				expect = res.Sent
+			}
+		case <-time.After(PeerRequestTimeout):
+			return fmt.Errorf("peer %s stopped responding after %d blocks", pid.Pretty(), received)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (n *QriNode) handleTransferManifest(ws *WrappedStream, msg Message) (hangup bool) {
+	hangup = true
+
+	switch msg.Header("phase") {
+	case "request":
+		p := transferRequest{}
+		if err := json.Unmarshal(msg.Body, &p); err != nil {
+			log.Debug(err.Error())
+			return
+		}
+
+		var body interface{}
+		if man, err := n.transferManifest(p.Path); err == nil {
+			body = man
+		} else {
+			log.Debugf("%s can't transfer %s: %s", n.ID, p.Path, err.Error())
+		}
+
+		res, err := msg.UpdateJSON(body)
+		if err != nil {
+			log.Debug(err.Error())
+			return
+		}
+		res = res.WithHeaders("phase", "response")
+		if err := ws.sendMessage(res); err != nil {
+			log.Debug(err.Error())
+			return
+		}
+	}
+
+	return
+}
+
+func (n *QriNode) handleTransferBlocks(ws *WrappedStream, msg Message) (hangup bool) {
+	hangup = true
+
+	switch msg.Header("phase") {
+	case "request":
+		p := transferRequest{}
+		if err := json.Unmarshal(msg.Body, &p); err != nil {
+			log.Debug(err.Error())
+			return
+		}
+
+		sent := 0
+		if man, err := n.transferManifest(p.Path); err == nil {
+			wanted := map[string]bool{}
+			for _, hash := range p.Hashes {
+				wanted[hash] = true
+			}
+			err = eachBlock(n.Repo.Store(), man, func(hash string, data []byte) error {
+				if !wanted[hash] {
+					return nil
+				}
+				// only send duplicate blocks once
+				delete(wanted, hash)
+				block := msg.Update(data).WithHeaders("phase", "block", "hash", hash)
+				if err := ws.sendMessage(block); err != nil {
+					return err
+				}
+				sent++
+				return nil
+			})
+			if err != nil {
+				log.Debug(err.Error())
+			}
+		}
+
+		res, err := msg.UpdateJSON(transferResponse{Sent: sent})
+		if err != nil {
+			log.Debug(err.Error())
+			return
+		}
+		res = res.WithHeaders("phase", "response")
+		if err := ws.sendMessage(res); err != nil {
+			log.Debug(err.Error())
+			return
+		}
+	case "block":
+		// keep reading until the response arrives
+		hangup = false
+	}
+
+	return
+}
+
+// transferManifest gives the manifest for a dataset version in this node's
+// store. Manifests are kept once they're built, so peers requesting blocks
+// don't cause the dataset to be hashed again
+func (n *QriNode) transferManifest(path string) (*TransferManifest, error) {
+	if man, ok := n.manifests.Load(path); ok {
+		return man.(*TransferManifest), nil
+	}
+
+	store := n.Repo.Store()
+	ds, err := dsfs.LoadDataset(store, datastore.NewKey(path))
+	if err != nil {
+		return nil, err
+	}
+
+	man := &TransferManifest{Path: path, Dataset: ds}
+	for _, name := range transferFileNames(ds) {
+		f, err := openTransferFile(store, ds, name)
+		if err != nil {
+			return nil, err
+		}
+		tf := TransferFile{Name: name}
+		err = readBlocks(f, func(data []byte) error {
+			hash, err := blockHash(data)
+			if err != nil {
+				return err
+			}
+			tf.Size += int64(len(data))
+			tf.Blocks = append(tf.Blocks, hash)
+			return nil
+		})
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		man.Files = append(man.Files, tf)
+	}
+
+	n.manifests.Store(path, man)
+	return man, nil
+}
+
+// validate checks a manifest received from a peer is safe to use
+func (man *TransferManifest) validate() error {
+	for _, f := range man.Files {
+		switch f.Name {
+		case TransferFileBody, TransferFileTransform, TransferFileViz:
+		default:
+			return fmt.Errorf("invalid transfer manifest: unknown file '%s'", f.Name)
+		}
+		for _, hash := range f.Blocks {
+			// hashes name files in the transfer cache
+			if mh, err := multihash.FromB58String(hash); err != nil {
+				return fmt.Errorf("invalid transfer manifest: %s", err.Error())
+			} else if dec, err := multihash.Decode(mh); err != nil || dec.Code != multihash.SHA2_256 {
+				return fmt.Errorf("invalid transfer manifest: unsupported block hash %s", hash)
+			}
+		}
+	}
+	return nil
+}
+
+// blockSizes maps the hash of each block in a manifest to it's size
+func (man *TransferManifest) blockSizes() map[string]int64 {
+	sizes := map[string]int64{}
+	for _, f := range man.Files {
+		for i, hash := range f.Blocks {
+			sizes[hash] = blockSize(f, i)
+		}
+	}
+	return sizes
+}
+
+// blockSize gives the size of the ith block of a file. Every block but the
+// last is TransferBlockSize bytes
+func blockSize(f TransferFile, i int) int64 {
+	if i < len(f.Blocks)-1 {
+		return int64(TransferBlockSize)
+	}
+	return f.Size - int64(TransferBlockSize*(len(f.Blocks)-1))
+}
+
+// transferFileNames lists the files a dataset needs transferred. Viz that
+// refer to a named template have no script to transfer
+func transferFileNames(ds *dataset.Dataset) []string {
+	names := []string{TransferFileBody}
+	if ds.Transform != nil && ds.Transform.ScriptPath != "" {
+		names = append(names, TransferFileTransform)
+	}
+	if ds.Viz != nil && ds.Viz.ScriptPath != "" {
+		if _, named := repo.TemplateName(ds.Viz.ScriptPath); !named {
+			names = append(names, TransferFileViz)
+		}
+	}
+	return names
+}
+
+// openTransferFile opens a file of a dataset by it's manifest name
+func openTransferFile(store cafs.Filestore, ds *dataset.Dataset, name string) (cafs.File, error) {
+	switch name {
+	case TransferFileBody:
+		return dsfs.LoadBody(store, ds)
+	case TransferFileTransform:
+		return store.Get(datastore.NewKey(ds.Transform.ScriptPath))
+	case TransferFileViz:
+		return store.Get(datastore.NewKey(ds.Viz.ScriptPath))
+	}
+	return nil, fmt.Errorf("unknown transfer file: %s", name)
+}
+
+// eachBlock reads the blocks of every file in a manifest from a store,
+// calling fn for each block in order
+func eachBlock(store cafs.Filestore, man *TransferManifest, fn func(hash string, data []byte) error) error {
+	for _, tf := range man.Files {
+		f, err := openTransferFile(store, man.Dataset, tf.Name)
+		if err != nil {
+			return err
+		}
+		i := 0
+		err = readBlocks(f, func(data []byte) error {
+			if i >= len(tf.Blocks) {
+				return fmt.Errorf("%s has changed since it's manifest was built", tf.Name)
+			}
+			hash := tf.Blocks[i]
+			i++
+			return fn(hash, data)
+		})
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readBlocks splits a reader into TransferBlockSize blocks
+func readBlocks(r io.Reader, fn func(data []byte) error) error {
+	buf := make([]byte, TransferBlockSize)
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			data := make([]byte, n)
+			copy(data, buf[:n])
+			if err := fn(data); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// blockHash gives the base58-encoded sha256 multihash of a block
+func blockHash(data []byte) (string, error) {
+	mh, err := multihash.Sum(data, multihash.SHA2_256, -1)
+	if err != nil {
+		return "", err
+	}
+	return mh.B58String(), nil
+}
+
+// verifyBlock checks data matches a block hash
+func verifyBlock(hash string, data []byte) error {
+	sum, err := blockHash(data)
+	if err != nil {
+		return err
+	}
+	if sum != hash {
+		return ErrBlockHashMismatch
+	}
+	return nil
+}
+
+// transferPath removes any trailing package file from a dataset path
+func transferPath(path string) string {
+	return strings.TrimSuffix(path, "/"+dsfs.PackageFileDataset.String())
+}
+
+// writeTransfer assembles the files of a fully-cached manifest & writes the
+// dataset to a store, returning it's key. Nothing is pinned, the dataset
+// hasn't been checked against the path it was requested by yet
+func writeTransfer(store cafs.Filestore, cache blockCache, man *TransferManifest) (datastore.Key, error) {
+	ds := man.Dataset
+	var body cafs.File
+	for _, tf := range man.Files {
+		r, err := cache.open(tf)
+		if err != nil {
+			return datastore.NewKey(""), err
+		}
+		switch tf.Name {
+		case TransferFileBody:
+			body = cafs.NewMemfileReader(transferBodyFilename(ds), r)
+		case TransferFileTransform, TransferFileViz:
+			key, err := store.Put(cafs.NewMemfileReader(tf.Name, r), false)
+			r.Close()
+			if err != nil {
+				return datastore.NewKey(""), err
+			}
+			if tf.Name == TransferFileTransform {
+				ds.Transform.ScriptPath = key.String()
+			} else {
+				ds.Viz.ScriptPath = key.String()
+			}
+		}
+	}
+	if body == nil {
+		return datastore.NewKey(""), fmt.Errorf("transfer manifest has no body")
+	}
+	defer body.Close()
+
+	ds.BodyPath = ""
+	return dsfs.WriteDataset(store, ds, body, false)
+}
+
+// transferBodyFilename names a body file with an extension for it's data
+// format
+func transferBodyFilename(ds *dataset.Dataset) string {
+	if ds.Structure != nil && ds.Structure.Format != dataset.UnknownDataFormat {
+		return "body." + ds.Structure.Format.String()
+	}
+	return "body"
+}
+
+// blockCache keeps received blocks on disk, one file per block named by it's
+// hash
+type blockCache struct {
+	dir string
+}
+
+func (c blockCache) path(hash string) string {
+	return filepath.Join(c.dir, hash)
+}
+
+func (c blockCache) has(hash string) bool {
+	_, err := os.Stat(c.path(hash))
+	return err == nil
+}
+
+// put writes a block, moving it into place once it's fully written so an
+// interrupted write never leaves a partial block
+func (c blockCache) put(hash string, data []byte) error {
+	tmp, err := ioutil.TempFile(c.dir, ".block-")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path(hash))
+}
+
+// open reads the cached blocks of a file in order. Blocks are opened one at a
+// time as they're read
+func (c blockCache) open(tf TransferFile) (io.ReadCloser, error) {
+	for _, hash := range tf.Blocks {
+		if !c.has(hash) {
+			return nil, fmt.Errorf("block %s isn't cached", hash)
+		}
+	}
+	return &blockReader{cache: c, hashes: tf.Blocks}, nil
+}
+
+// remove drops the blocks of a manifest from the cache
+func (c blockCache) remove(man *TransferManifest) {
+	for _, f := range man.Files {
+		for _, hash := range f.Blocks {
+			os.Remove(c.path(hash))
+		}
+	}
+}
+
+// blockReader reads a sequence of cached blocks
+type blockReader struct {
+	cache  blockCache
+	hashes []string
+	cur    *os.File
+}
+
+func (r *blockReader) Read(p []byte) (int, error) {
+	for {
+		if r.cur == nil {
+			if len(r.hashes) == 0 {
+				return 0, io.EOF
+			}
+			f, err := os.Open(r.cache.path(r.hashes[0]))
+			if err != nil {
+				return 0, err
+			}
+			r.cur, r.hashes = f, r.hashes[1:]
+		}
+		n, err := r.cur.Read(p)
+		if err == io.EOF {
+			r.cur.Close()
+			r.cur = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (r *blockReader) Close() error {
+	if r.cur != nil {
+		return r.cur.Close()
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package p2p
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/qri/p2p/test"
+	"github.com/qri-io/qri/repo"
+)
+
+func TestFetchDataset(t *testing.T) {
+	ctx := context.Background()
+	factory := p2ptest.NewTestNodeFactory(NewTestableQriNode)
+	testPeers, err := p2ptest.NewTestDirNetwork(ctx, factory)
+	if err != nil {
+		t.Fatalf("error creating network: %s", err.Error())
+	}
+	if err := p2ptest.ConnectNodes(ctx, testPeers); err != nil {
+		t.Fatalf("error connecting peers: %s", err.Error())
+	}
+
+	peers := make([]*QriNode, len(testPeers))
+	for i, node := range testPeers {
+		peers[i] = node.(*QriNode)
+	}
+
+	dir, err := ioutil.TempDir("", "qri_test_transfers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	prevDir, prevSize := TransferCacheDir, TransferBlockSize
+	TransferCacheDir, TransferBlockSize = dir, 64
+	defer func() { TransferCacheDir, TransferBlockSize = prevDir, prevSize }()
+
+	refs, err := peers[1].Repo.References(10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(refs) == 0 {
+		t.Fatal("expected peer 1 to have datasets")
+	}
+	ref := refs[0]
+
+	man, err := peers[1].transferManifest(ref.Path)
+	if err != nil {
+		t.Fatalf("error building manifest: %s", err.Error())
+	}
+	if len(man.Files) == 0 || man.Files[0].Name != TransferFileBody {
+		t.Fatalf("expected manifest to start with the body, got: %v", man.Files)
+	}
+	if len(man.Files[0].Blocks) < 2 {
+		t.Fatalf("expected body to span multiple blocks, got %d", len(man.Files[0].Blocks))
+	}
+
+	// cache the first block, as if an earlier transfer was interrupted
+	first := man.Files[0].Blocks[0]
+	err = eachBlock(peers[1].Repo.Store(), man, func(hash string, data []byte) error {
+		if hash == first {
+			return blockCache{dir: dir}.put(hash, data)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reports := []TransferProgress{}
+	path, err := peers[0].FetchDataset(ctx, &ref, func(p TransferProgress) {
+		reports = append(reports, p)
+	})
+	if err != nil {
+		t.Fatalf("FetchDataset error: %s", err.Error())
+	}
+
+	if len(reports) == 0 {
+		t.Fatal("expected progress to be reported")
+	}
+	if reports[0].Blocks != 1 {
+		t.Errorf("expected cached block to count as transferred, got %d blocks", reports[0].Blocks)
+	}
+	last := reports[len(reports)-1]
+	if last.Blocks != last.TotalBlocks || last.Bytes != last.TotalBytes {
+		t.Errorf("expected transfer to finish, got: %#v", last)
+	}
+
+	ds, err := dsfs.LoadDataset(peers[0].Repo.Store(), datastore.NewKey(path))
+	if err != nil {
+		t.Fatalf("error loading transferred dataset: %s", err.Error())
+	}
+	got, err := loadBodyBytes(peers[0].Repo, ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect, err := loadBodyBytes(peers[1].Repo, man.Dataset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, expect) {
+		t.Errorf("transferred body mismatch.\nexpected: %s\ngot: %s", string(expect), string(got))
+	}
+
+	if infos, err := ioutil.ReadDir(dir); err != nil {
+		t.Fatal(err)
+	} else if len(infos) != 0 {
+		t.Errorf("expected cached blocks to be removed after transfer, %d remain", len(infos))
+	}
+
+	missing := repo.DatasetRef{Path: "/map/QmNotAPathThatExistsAnywhereXXXXXXXXXXXXXXXXX"}
+	if _, err := peers[0].FetchDataset(ctx, &missing, nil); err != ErrNotFound {
+		t.Errorf("expected missing dataset to return ErrNotFound, got: %v", err)
+	}
+
+	// a peer that answers with a different dataset than the one requested
+	tampered := &dataset.Dataset{}
+	tampered.Assign(man.Dataset)
+	tampered.Meta = &dataset.Meta{Title: "not the requested dataset"}
+	peers[1].manifests.Store(ref.Path, &TransferManifest{Path: man.Path, Dataset: tampered, Files: man.Files})
+	defer peers[1].manifests.Delete(ref.Path)
+	if _, err := peers[0].FetchDataset(ctx, &ref, nil); err != ErrTransferMismatch {
+		t.Errorf("expected tampered manifest to return ErrTransferMismatch, got: %v", err)
+	}
+
+	other := repo.DatasetRef{Path: "/ipfs/QmNotAPathThatExistsAnywhereXXXXXXXXXXXXXXXX"}
+	if _, err := peers[0].FetchDataset(ctx, &other, nil); err == nil {
+		t.Error("expected a path this store can't verify to error")
+	}
+}
+
+func TestVerifyBlock(t *testing.T) {
+	data := []byte("block data")
+	hash, err := blockHash(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyBlock(hash, data); err != nil {
+		t.Errorf("expected block to verify, got: %s", err.Error())
+	}
+	if err := verifyBlock(hash, []byte("tampered data")); err != ErrBlockHashMismatch {
+		t.Errorf("expected ErrBlockHashMismatch, got: %v", err)
+	}
+}
+
+func TestTransferManifestValidate(t *testing.T) {
+	hash, err := blockHash([]byte("block data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		man TransferManifest
+		err bool
+	}{
+		{TransferManifest{Files: []TransferFile{{Name: TransferFileBody, Blocks: []string{hash}}}}, false},
+		{TransferManifest{Files: []TransferFile{{Name: "config", Blocks: []string{hash}}}}, true},
+		{TransferManifest{Files: []TransferFile{{Name: TransferFileBody, Blocks: []string{"../../etc/passwd"}}}}, true},
+	}
+
+	for i, c := range cases {
+		if err := c.man.validate(); (err != nil) != c.err {
+			t.Errorf("case %d error mismatch. expected error: %t, got: %v", i, c.err, err)
+		}
+	}
+}
+
+func loadBodyBytes(r repo.Repo, ds *dataset.Dataset) ([]byte, error) {
+	f, err := dsfs.LoadBody(r.Store(), ds)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ioutil.ReadAll(f)
+}
@@ -0,0 +1,207 @@
+// Package progress reports the progress of long-running operations like
+// saving large bodies, running transforms & fetching datasets from peers.
+// Operations report Updates through a Task, which sends them to a Reporter.
+// A Stream is a Reporter that keeps recent updates for any number of
+// readers, so progress can be rendered in a terminal, relayed over RPC or
+// sent to API clients as it happens
+package progress
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Unit is the unit of work an update counts
+type Unit string
+
+const (
+	// Bytes counts bytes read or transferred
+	Bytes = Unit("bytes")
+	// Entries counts body entries processed
+	Entries = Unit("entries")
+	// Blocks counts blocks fetched
+	Blocks = Unit("blocks")
+	// Steps counts discrete pieces of work, like dataset versions checked
+	Steps = Unit("steps")
+)
+
+// Update is the state of a task at a point in time
+type Update struct {
+	// Seq orders updates in a Stream, set when an update is added
+	Seq int64 `json:"seq"`
+	// ID identifies the task an update belongs to
+	ID string `json:"id"`
+	// Name describes the task, eg: "saving body"
+	Name string `json:"name"`
+	Unit Unit   `json:"unit"`
+	Done int64  `json:"done"`
+	// Total is the amount of work the task has to do, zero when it's unknown
+	Total int64 `json:"total,omitempty"`
+	// Complete is true for the last update of a task
+	Complete bool      `json:"complete,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+// Percent gives how much of it's total a task has done, from 0 to 100.
+// Percent is -1 when the total is unknown
+func (u Update) Percent() float64 {
+	if u.Total <= 0 {
+		if u.Complete {
+			return 100
+		}
+		return -1
+	}
+	pct := float64(u.Done) / float64(u.Total) * 100
+	if pct > 100 {
+		pct = 100
+	}
+	return pct
+}
+
+// String formats the amount of work done, eg: "1.2 MB / 3.0 MB"
+func (u Update) String() string {
+	if u.Total > 0 {
+		return fmt.Sprintf("%s / %s", formatAmount(u.Unit, u.Done), formatAmount(u.Unit, u.Total))
+	}
+	return formatAmount(u.Unit, u.Done)
+}
+
+func formatAmount(unit Unit, n int64) string {
+	if unit != Bytes {
+		return fmt.Sprintf("%d %s", n, unit)
+	}
+	const k = 1000
+	if n < k {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(k), 0
+	for m := n / k; m >= k; m /= k {
+		div *= k
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "kMGTPE"[exp])
+}
+
+// Reporter receives progress updates
+type Reporter interface {
+	Report(u Update)
+}
+
+// ReporterFunc adapts a function to the Reporter interface
+type ReporterFunc func(u Update)
+
+// Report implements the Reporter interface
+func (f ReporterFunc) Report(u Update) {
+	f(u)
+}
+
+// ReportInterval is the shortest time between updates a task reports, to
+// keep fast tasks from flooding reporters. The first & last updates of a task
+// are always reported
+var ReportInterval = time.Millisecond * 200
+
+// taskCount numbers tasks for IDs
+var taskCount int64
+
+// Task tracks the progress of a single operation. Tasks are safe for
+// concurrent use. A task with a nil reporter tracks progress without
+// reporting it
+type Task struct {
+	r Reporter
+
+	lock     sync.Mutex
+	u        Update
+	reported time.Time
+}
+
+// NewTask starts a task, reporting it's first update
+func NewTask(r Reporter, name string, unit Unit, total int64) *Task {
+	t := &Task{
+		r: r,
+		u: Update{
+			ID:    fmt.Sprintf("%d", atomic.AddInt64(&taskCount, 1)),
+			Name:  name,
+			Unit:  unit,
+			Total: total,
+		},
+	}
+	t.lock.Lock()
+	t.report(true)
+	t.lock.Unlock()
+	return t
+}
+
+// Add records n more units of work done
+func (t *Task) Add(n int64) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.u.Done += n
+	t.report(false)
+}
+
+// Set records the amount of work done & the task's total
+func (t *Task) Set(done, total int64) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.u.Done, t.u.Total = done, total
+	t.report(false)
+}
+
+// Finish completes a task, recording err if the task failed. Finishing a
+// task more than once has no effect
+func (t *Task) Finish(err error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.u.Complete {
+		return
+	}
+	t.u.Complete = true
+	if err != nil {
+		t.u.Error = err.Error()
+	}
+	t.report(true)
+}
+
+// Update gives the current state of the task
+func (t *Task) Update() Update {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.u
+}
+
+// report sends the current state to the task's reporter, unless an update
+// was reported within ReportInterval. t.lock must be held
+func (t *Task) report(force bool) {
+	if t.r == nil {
+		return
+	}
+	now := time.Now()
+	if !force && now.Sub(t.reported) < ReportInterval {
+		return
+	}
+	t.reported = now
+	t.u.Time = now
+	t.r.Report(t.u)
+}
+
+// Reader counts bytes read from r as work done by the task
+func (t *Task) Reader(r io.Reader) io.Reader {
+	return &reader{r: r, t: t}
+}
+
+type reader struct {
+	r io.Reader
+	t *Task
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		r.t.Add(int64(n))
+	}
+	return n, err
+}
@@ -0,0 +1,92 @@
+package progress
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestTask(t *testing.T) {
+	prev := ReportInterval
+	ReportInterval = time.Hour
+	defer func() { ReportInterval = prev }()
+
+	updates := []Update{}
+	r := ReporterFunc(func(u Update) { updates = append(updates, u) })
+
+	task := NewTask(r, "saving body", Bytes, 100)
+	task.Add(10)
+	task.Add(20)
+	if len(updates) != 1 {
+		t.Errorf("expected updates within ReportInterval to be dropped, got %d updates", len(updates))
+	}
+	if got := task.Update().Done; got != 30 {
+		t.Errorf("expected 30 done, got %d", got)
+	}
+
+	task.Finish(fmt.Errorf("oh noes"))
+	task.Finish(nil)
+	if len(updates) != 2 {
+		t.Fatalf("expected finish to report once, got %d updates", len(updates))
+	}
+	last := updates[1]
+	if !last.Complete || last.Error != "oh noes" || last.Done != 30 {
+		t.Errorf("unexpected final update: %#v", last)
+	}
+	if updates[0].ID != last.ID || updates[0].ID == "" {
+		t.Errorf("expected updates to share a task id, got: '%s', '%s'", updates[0].ID, last.ID)
+	}
+
+	other := NewTask(nil, "silent", Steps, 0)
+	other.Finish(nil)
+	if other.Update().ID == last.ID {
+		t.Error("expected tasks to have distinct ids")
+	}
+}
+
+func TestTaskReader(t *testing.T) {
+	task := NewTask(nil, "reading", Bytes, 0)
+	data, err := ioutil.ReadAll(task.Reader(bytes.NewReader(make([]byte, 1234))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if int64(len(data)) != task.Update().Done {
+		t.Errorf("expected %d bytes done, got %d", len(data), task.Update().Done)
+	}
+}
+
+func TestUpdatePercent(t *testing.T) {
+	cases := []struct {
+		u      Update
+		expect float64
+	}{
+		{Update{Done: 5}, -1},
+		{Update{Done: 5, Complete: true}, 100},
+		{Update{Done: 5, Total: 20}, 25},
+		{Update{Done: 30, Total: 20}, 100},
+	}
+	for i, c := range cases {
+		if got := c.u.Percent(); got != c.expect {
+			t.Errorf("case %d: expected %f, got %f", i, c.expect, got)
+		}
+	}
+}
+
+func TestUpdateString(t *testing.T) {
+	cases := []struct {
+		u      Update
+		expect string
+	}{
+		{Update{Unit: Bytes, Done: 512}, "512 B"},
+		{Update{Unit: Bytes, Done: 1500, Total: 2500000}, "1.5 kB / 2.5 MB"},
+		{Update{Unit: Bytes, Done: 3200000000}, "3.2 GB"},
+		{Update{Unit: Entries, Done: 10, Total: 40}, "10 entries / 40 entries"},
+	}
+	for i, c := range cases {
+		if got := c.u.String(); got != c.expect {
+			t.Errorf("case %d: expected '%s', got '%s'", i, c.expect, got)
+		}
+	}
+}
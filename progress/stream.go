@@ -0,0 +1,87 @@
+package progress
+
+import (
+	"context"
+	"sync"
+)
+
+// StreamSize is the number of recent updates a Stream keeps for readers
+// that haven't caught up
+const StreamSize = 256
+
+// Stream is a Reporter that keeps the most recent updates it's sent, in
+// order. Readers ask for updates after the last sequence number they've
+// seen, waiting for new ones to arrive. Readers that fall more than
+// StreamSize updates behind miss the updates in between
+type Stream struct {
+	lock    sync.Mutex
+	seq     int64
+	recent  []Update
+	changed chan struct{}
+}
+
+// NewStream creates an empty stream
+func NewStream() *Stream {
+	return &Stream{changed: make(chan struct{})}
+}
+
+// Report implements the Reporter interface, adding an update to the stream.
+// Reporting to a nil stream does nothing
+func (s *Stream) Report(u Update) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.seq++
+	u.Seq = s.seq
+	s.recent = append(s.recent, u)
+	if len(s.recent) > StreamSize {
+		s.recent = s.recent[len(s.recent)-StreamSize:]
+	}
+
+	// wake up waiting readers
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// Seq gives the sequence number of the latest update in the stream
+func (s *Stream) Seq() int64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.seq
+}
+
+// Since gives updates with a sequence number after seq, oldest first. If
+// there are none Since waits for one to arrive until ctx is done, returning
+// no updates if none did
+func (s *Stream) Since(ctx context.Context, seq int64) []Update {
+	for {
+		s.lock.Lock()
+		updates := s.since(seq)
+		changed := s.changed
+		s.lock.Unlock()
+
+		if len(updates) > 0 {
+			return updates
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// since lists updates after seq. s.lock must be held
+func (s *Stream) since(seq int64) []Update {
+	for i, u := range s.recent {
+		if u.Seq > seq {
+			updates := make([]Update, len(s.recent)-i)
+			copy(updates, s.recent[i:])
+			return updates
+		}
+	}
+	return nil
+}
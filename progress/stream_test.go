@@ -0,0 +1,48 @@
+package progress
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStream(t *testing.T) {
+	s := NewStream()
+	ctx := context.Background()
+
+	s.Report(Update{ID: "a"})
+	s.Report(Update{ID: "b"})
+	if s.Seq() != 2 {
+		t.Errorf("expected seq 2, got %d", s.Seq())
+	}
+
+	got := s.Since(ctx, 0)
+	if len(got) != 2 || got[0].ID != "a" || got[1].Seq != 2 {
+		t.Errorf("unexpected updates: %#v", got)
+	}
+	if got = s.Since(ctx, 1); len(got) != 1 || got[0].ID != "b" {
+		t.Errorf("unexpected updates since 1: %#v", got)
+	}
+
+	// readers wait for new updates
+	done := make(chan []Update)
+	go func() { done <- s.Since(ctx, 2) }()
+	time.Sleep(time.Millisecond * 20)
+	s.Report(Update{ID: "c"})
+	if got = <-done; len(got) != 1 || got[0].ID != "c" {
+		t.Errorf("expected waiting reader to get new update, got: %#v", got)
+	}
+
+	wctx, cancel := context.WithTimeout(ctx, time.Millisecond*20)
+	defer cancel()
+	if got = s.Since(wctx, s.Seq()); got != nil {
+		t.Errorf("expected no updates once context is done, got: %#v", got)
+	}
+
+	for i := 0; i < StreamSize*2; i++ {
+		s.Report(Update{})
+	}
+	if got = s.Since(ctx, 0); len(got) != StreamSize {
+		t.Errorf("expected stream to keep %d updates, got %d", StreamSize, len(got))
+	}
+}
@@ -0,0 +1,541 @@
+// Package archive writes an entire qri repo or the history of a single
+// dataset to a portable file & reads it back into any repo.Repo. Archives are
+// gzipped tar or zip files. Datasets are stored as fully-loaded versions
+// alongside their bodies & scripts, not as store blocks, so an archive can be
+// imported into a repo that uses a different kind of store. Entries are
+// written in the order they're needed on import:
+//
+//	manifest.json                  archive details
+//	config.json                    qri config, if included
+//	profiles.json                  known peer profiles
+//	versions/[n]/transform.sky     transform script, if any
+//	versions/[n]/viz.html          viz template, if any
+//	versions/[n]/dataset.json      a dataset version & it's original path
+//	versions/[n]/body.[format]     dataset body
+//	refs.json                      dataset references
+//	events.json                    the event log, oldest first
+//
+// Versions are written oldest first, so every PreviousPath in a version
+// refers to an earlier entry. Dataset archives have no config, profiles or
+// events, and list their versions in the manifest
+package archive
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	golog "github.com/ipfs/go-log"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+)
+
+var log = golog.Logger("repo/archive")
+
+// Version is the archive format version written by Export
+const Version = 1
+
+const (
+	fileManifest  = "manifest.json"
+	fileConfig    = "config.json"
+	fileProfiles  = "profiles.json"
+	fileRefs      = "refs.json"
+	fileEvents    = "events.json"
+	dirVersions   = "versions/"
+	fileDataset   = "dataset.json"
+	fileTransform = "transform.sky"
+	fileViz       = "viz.html"
+)
+
+// Manifest describes the contents of an archive
+type Manifest struct {
+	Version   int        `json:"version"`
+	Created   time.Time  `json:"created"`
+	Peername  string     `json:"peername"`
+	ProfileID profile.ID `json:"profileID"`
+	// Refs & Versions count dataset references & dataset versions, including
+	// history
+	Refs     int `json:"refs"`
+	Versions int `json:"versions"`
+	// PrivateKeys is true if the archived config includes private keys
+	PrivateKeys bool `json:"privateKeys"`
+	// History lists every version in a dataset archive, oldest first. Repo
+	// archives don't list versions
+	History []*VersionInfo `json:"history,omitempty"`
+}
+
+// ExportOpts configures Export
+type ExportOpts struct {
+	// Config to include in the archive. Optional
+	Config *config.Config
+	// PrivateKeys keeps private keys in the archived config. Archives with
+	// private keys can be used to impersonate the repo's peer, keep them safe
+	PrivateKeys bool
+	// Format of the archive, defaults to FormatTarGz
+	Format Format
+}
+
+// version is the dataset.json entry of an archived version
+type version struct {
+	// Path is the path of the version in the exported repo
+	Path    string           `json:"path"`
+	Dataset *dataset.Dataset `json:"dataset"`
+}
+
+// Export writes every reference, the full history of every dataset, all
+// profiles & the event log of a repo to w
+func Export(w io.Writer, r repo.Repo, opts ExportOpts) (*Manifest, error) {
+	pro, err := r.Profile()
+	if err != nil {
+		return nil, err
+	}
+	count, err := r.RefCount()
+	if err != nil {
+		return nil, err
+	}
+	refs, err := r.References(count, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	man := &Manifest{
+		Version:     Version,
+		Created:     time.Now(),
+		Peername:    pro.Peername,
+		ProfileID:   pro.ID,
+		Refs:        len(refs),
+		PrivateKeys: opts.Config != nil && opts.PrivateKeys,
+	}
+
+	ew, err := newEntryWriter(w, opts.Format)
+	if err != nil {
+		return nil, err
+	}
+	aw := &writer{ew: ew, store: r.Store(), written: map[string]bool{}}
+
+	if err := aw.writeJSON(fileManifest, man); err != nil {
+		return nil, err
+	}
+	if opts.Config != nil {
+		cfg := opts.Config
+		if !opts.PrivateKeys {
+			cfg = cfg.WithoutPrivateValues()
+		}
+		if err := aw.writeJSON(fileConfig, cfg); err != nil {
+			return nil, err
+		}
+	}
+	if err := aw.writeProfiles(r.Profiles()); err != nil {
+		return nil, err
+	}
+
+	for _, ref := range refs {
+		if err := aw.writeHistory(ref.Path); err != nil {
+			return nil, fmt.Errorf("error exporting %s: %s", ref, err.Error())
+		}
+	}
+	man.Versions = aw.versions
+
+	for i, ref := range refs {
+		refs[i] = repo.DatasetRef{Peername: ref.Peername, ProfileID: ref.ProfileID, Name: ref.Name, Path: ref.Path}
+	}
+	if err := aw.writeJSON(fileRefs, refs); err != nil {
+		return nil, err
+	}
+
+	events, err := r.Events(-1, 0)
+	if err != nil {
+		return nil, err
+	}
+	// events are listed newest first, archive them in the order they happened
+	for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+		events[i], events[j] = events[j], events[i]
+	}
+	if err := aw.writeJSON(fileEvents, events); err != nil {
+		return nil, err
+	}
+
+	return man, ew.Close()
+}
+
+// writer writes archive entries
+type writer struct {
+	ew       entryWriter
+	store    cafs.Filestore
+	written  map[string]bool
+	versions int
+}
+
+func (aw *writer) writeProfiles(ps profile.Store) error {
+	pods := []*config.ProfilePod{}
+	if ps != nil {
+		profiles, err := ps.List()
+		if err != nil {
+			return err
+		}
+		for _, p := range profiles {
+			pod, err := p.Encode()
+			if err != nil {
+				return err
+			}
+			pod.PrivKey = ""
+			pod.Online = false
+			pods = append(pods, pod)
+		}
+	}
+	return aw.writeJSON(fileProfiles, pods)
+}
+
+// writeHistory writes every version in the history of a dataset that hasn't
+// already been written, oldest first
+func (aw *writer) writeHistory(head string) error {
+	history, err := aw.loadHistory(head)
+	if err != nil {
+		return err
+	}
+	for _, v := range history {
+		if err := aw.writeVersion(v); err != nil {
+			return err
+		}
+		aw.written[v.Path] = true
+	}
+	return nil
+}
+
+// loadHistory loads the versions of a dataset that haven't already been
+// written, oldest first
+func (aw *writer) loadHistory(head string) ([]*version, error) {
+	var history []*version
+	for p := head; p != "" && p != "/" && !aw.written[p]; {
+		ds, err := dsfs.LoadDataset(aw.store, datastore.NewKey(p))
+		if err != nil {
+			return nil, fmt.Errorf("error loading version %s: %s", p, err.Error())
+		}
+		history = append([]*version{{Path: p, Dataset: ds}}, history...)
+		p = ds.PreviousPath
+	}
+	return history, nil
+}
+
+func (aw *writer) writeVersion(v *version) error {
+	aw.versions++
+	dir := fmt.Sprintf("%s%06d/", dirVersions, aw.versions)
+	ds := v.Dataset
+
+	if ds.Transform != nil && ds.Transform.ScriptPath != "" {
+		if err := aw.writeStoreFile(dir+fileTransform, ds.Transform.ScriptPath); err != nil {
+			return fmt.Errorf("error exporting transform script: %s", err.Error())
+		}
+	}
+	if ds.Viz != nil && ds.Viz.ScriptPath != "" {
+		// viz that refer to a named template have no script to archive
+		if _, named := repo.TemplateName(ds.Viz.ScriptPath); !named {
+			if err := aw.writeStoreFile(dir+fileViz, ds.Viz.ScriptPath); err != nil {
+				return fmt.Errorf("error exporting viz: %s", err.Error())
+			}
+		}
+	}
+	if err := aw.writeJSON(dir+fileDataset, v); err != nil {
+		return err
+	}
+
+	body, err := dsfs.LoadBody(aw.store, ds)
+	if err != nil {
+		return fmt.Errorf("error loading body: %s", err.Error())
+	}
+	defer body.Close()
+	return aw.writeReader(dir+bodyFilename(ds), body)
+}
+
+func (aw *writer) writeStoreFile(name, p string) error {
+	f, err := aw.store.Get(datastore.NewKey(p))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return aw.writeReader(name, f)
+}
+
+func (aw *writer) writeJSON(name string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return aw.writeBytes(name, data)
+}
+
+func (aw *writer) writeBytes(name string, data []byte) error {
+	return aw.ew.writeEntry(name, int64(len(data)), bytes.NewReader(data))
+}
+
+// writeReader writes the contents of r as an entry. tar headers need the
+// size of an entry up front, so contents are buffered in a temp file to keep
+// large bodies out of memory
+func (aw *writer) writeReader(name string, r io.Reader) error {
+	tmp, err := ioutil.TempFile("", "qri_archive_")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	size, err := io.Copy(tmp, r)
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	return aw.ew.writeEntry(name, size, tmp)
+}
+
+// bodyFilename names a body entry with an extension for it's data format
+func bodyFilename(ds *dataset.Dataset) string {
+	if ds.Structure != nil && ds.Structure.Format != dataset.UnknownDataFormat {
+		return "body." + ds.Structure.Format.String()
+	}
+	return "body"
+}
+
+// ImportResult describes the outcome of importing an archive
+type ImportResult struct {
+	Manifest *Manifest `json:"manifest"`
+	// Config is the archived config, if the archive has one. Import doesn't
+	// apply config, that's up to the caller
+	Config *config.Config `json:"config,omitempty"`
+	// Counts of imported items
+	Refs     int `json:"refs"`
+	Versions int `json:"versions"`
+	Profiles int `json:"profiles"`
+	Events   int `json:"events"`
+	// Imported lists references added to the repo
+	Imported []repo.DatasetRef `json:"imported,omitempty"`
+	// Skipped lists references that weren't imported because the name is
+	// already in use in the repo
+	Skipped []repo.DatasetRef `json:"skipped,omitempty"`
+	// Changed maps archived version paths to imported paths for versions that
+	// didn't import to the same path
+	Changed map[string]string `json:"changed,omitempty"`
+}
+
+// Import reads an archive written by Export into a repo. Every version is
+// re-written to the repo's store, so version paths change if the store
+// addresses content differently than the store that was exported. References,
+// previous paths & events are updated to match
+func Import(rd io.Reader, r repo.Repo) (*ImportResult, error) {
+	ai := newImporter(r)
+	err := eachEntry(rd, func(name string, er io.Reader) error {
+		if ai.res.Manifest == nil && name != fileManifest {
+			return fmt.Errorf("invalid archive: missing %s", fileManifest)
+		}
+		if err := ai.readEntry(name, er); err != nil {
+			return fmt.Errorf("error importing %s: %s", name, err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if ai.res.Manifest == nil {
+		return nil, fmt.Errorf("invalid archive: missing %s", fileManifest)
+	}
+	return ai.res, nil
+}
+
+func newImporter(r repo.Repo) *importer {
+	return &importer{
+		r:       r,
+		res:     &ImportResult{},
+		paths:   map[string]string{},
+		scripts: map[string]string{},
+	}
+}
+
+// importer holds state while reading archive entries
+type importer struct {
+	r   repo.Repo
+	res *ImportResult
+	// name replaces the name of imported references if set
+	name string
+	// paths maps exported version paths to imported paths
+	paths map[string]string
+	// current is the version being imported, scripts holds the store paths
+	// of it's imported scripts
+	current *version
+	scripts map[string]string
+}
+
+func (ai *importer) readEntry(name string, rd io.Reader) error {
+	switch {
+	case name == fileManifest:
+		man := &Manifest{}
+		if err := json.NewDecoder(rd).Decode(man); err != nil {
+			return err
+		}
+		if man.Version > Version {
+			return fmt.Errorf("archive version %d is newer than supported version %d, upgrade qri to import it", man.Version, Version)
+		}
+		ai.res.Manifest = man
+	case name == fileConfig:
+		cfg := &config.Config{}
+		if err := json.NewDecoder(rd).Decode(cfg); err != nil {
+			return err
+		}
+		ai.res.Config = cfg
+	case name == fileProfiles:
+		return ai.readProfiles(rd)
+	case strings.HasPrefix(name, dirVersions):
+		return ai.readVersionEntry(path.Base(name), rd)
+	case name == fileRefs:
+		return ai.readRefs(rd)
+	case name == fileEvents:
+		return ai.readEvents(rd)
+	default:
+		log.Debugf("skipping unknown archive entry: %s", name)
+	}
+	return nil
+}
+
+func (ai *importer) readProfiles(rd io.Reader) error {
+	pods := []*config.ProfilePod{}
+	if err := json.NewDecoder(rd).Decode(&pods); err != nil {
+		return err
+	}
+	ps := ai.r.Profiles()
+	if ps == nil {
+		return nil
+	}
+	for _, pod := range pods {
+		pro := &profile.Profile{}
+		if err := pro.Decode(pod); err != nil {
+			return err
+		}
+		if err := ps.PutProfile(pro); err != nil {
+			return err
+		}
+		ai.res.Profiles++
+	}
+	return nil
+}
+
+// readVersionEntry imports a file of a version. Scripts are added to the
+// store as they're read, the dataset is written to the store along with it's
+// body, which is the last entry of a version
+func (ai *importer) readVersionEntry(name string, rd io.Reader) error {
+	switch name {
+	case fileTransform, fileViz:
+		key, err := ai.r.Store().Put(cafs.NewMemfileReader(name, rd), true)
+		if err != nil {
+			return err
+		}
+		ai.scripts[name] = key.String()
+	case fileDataset:
+		v := &version{}
+		if err := json.NewDecoder(rd).Decode(v); err != nil {
+			return err
+		}
+		if v.Dataset == nil {
+			return fmt.Errorf("version %s has no dataset", v.Path)
+		}
+		ai.current = v
+	default:
+		if ai.current == nil {
+			return fmt.Errorf("body has no dataset")
+		}
+		v, ds := ai.current, ai.current.Dataset
+		ai.current = nil
+
+		if ds.PreviousPath != "" && ds.PreviousPath != "/" {
+			prev, ok := ai.paths[ds.PreviousPath]
+			if !ok {
+				return fmt.Errorf("previous version %s of %s isn't in the archive", ds.PreviousPath, v.Path)
+			}
+			ds.PreviousPath = prev
+		}
+		// scripts that weren't archived keep their original path, which is
+		// empty or a named template reference
+		if p, ok := ai.scripts[fileTransform]; ok && ds.Transform != nil {
+			ds.Transform.ScriptPath = p
+		}
+		if p, ok := ai.scripts[fileViz]; ok && ds.Viz != nil {
+			ds.Viz.ScriptPath = p
+		}
+		ai.scripts = map[string]string{}
+		ds.BodyPath = ""
+
+		key, err := dsfs.WriteDataset(ai.r.Store(), ds, cafs.NewMemfileReader(name, rd), true)
+		if err != nil {
+			return err
+		}
+		ai.paths[v.Path] = key.String()
+		if key.String() != v.Path {
+			if ai.res.Changed == nil {
+				ai.res.Changed = map[string]string{}
+			}
+			ai.res.Changed[v.Path] = key.String()
+		}
+		ai.res.Versions++
+	}
+	return nil
+}
+
+func (ai *importer) readRefs(rd io.Reader) error {
+	refs := []repo.DatasetRef{}
+	if err := json.NewDecoder(rd).Decode(&refs); err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		p, ok := ai.paths[ref.Path]
+		if !ok {
+			return fmt.Errorf("version %s of %s isn't in the archive", ref.Path, ref.AliasString())
+		}
+		ref.Path = p
+		if ai.name != "" {
+			ref.Name = ai.name
+		}
+
+		if err := ai.r.PutRef(ref); err == repo.ErrNameTaken {
+			ai.res.Skipped = append(ai.res.Skipped, ref)
+			continue
+		} else if err != nil {
+			return err
+		}
+		ai.res.Imported = append(ai.res.Imported, ref)
+		ai.res.Refs++
+	}
+	return nil
+}
+
+func (ai *importer) readEvents(rd io.Reader) error {
+	events := []*repo.Event{}
+	if err := json.NewDecoder(rd).Decode(&events); err != nil {
+		return err
+	}
+	for _, e := range events {
+		if p, ok := ai.paths[e.Ref.Path]; ok {
+			e.Ref.Path = p
+		}
+		if e.Params != nil {
+			if p, ok := ai.paths[e.Params.PrevPath]; ok {
+				e.Params.PrevPath = p
+			}
+		}
+		if err := ai.r.LogEventDetails(e.Type, e.Time, e.PeerID, e.Ref, e.Params); err != nil {
+			return err
+		}
+		ai.res.Events++
+	}
+	return nil
+}
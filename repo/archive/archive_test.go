@@ -0,0 +1,119 @@
+package archive
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestExportImport(t *testing.T) {
+	src, err := testrepo.NewTestRepo(nil)
+	if err != nil {
+		t.Fatalf("error allocating test repo: %s", err.Error())
+	}
+	pro, err := src.Profile()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	cfg := config.DefaultConfigForTesting()
+	buf := &bytes.Buffer{}
+	man, err := Export(buf, src, ExportOpts{Config: cfg})
+	if err != nil {
+		t.Fatalf("export error: %s", err.Error())
+	}
+	count, err := src.RefCount()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if man.Refs != count {
+		t.Errorf("expected manifest to count %d refs, got: %d", count, man.Refs)
+	}
+	if man.Versions < man.Refs {
+		t.Errorf("expected at least one version per ref, got %d versions for %d refs", man.Versions, man.Refs)
+	}
+	if man.PrivateKeys {
+		t.Error("expected archive to exclude private keys")
+	}
+
+	dst, err := repo.NewMemRepo(pro, cafs.NewMapstore(), profile.NewMemStore(), nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	res, err := Import(bytes.NewReader(buf.Bytes()), dst)
+	if err != nil {
+		t.Fatalf("import error: %s", err.Error())
+	}
+	if res.Refs != man.Refs || res.Versions != man.Versions {
+		t.Errorf("expected %d refs & %d versions to be imported, got: %d refs, %d versions", man.Refs, man.Versions, res.Refs, res.Versions)
+	}
+	if res.Config == nil {
+		t.Error("expected archived config to be returned")
+	} else if res.Config.Profile.PrivKey != "" || res.Config.P2P.PrivKey != "" {
+		t.Error("expected archived config to have no private keys")
+	}
+
+	refs, err := dst.References(count, 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	for _, ref := range refs {
+		srcRef, err := src.GetRef(repo.DatasetRef{Peername: ref.Peername, Name: ref.Name})
+		if err != nil {
+			t.Errorf("%s: error getting source ref: %s", ref.AliasString(), err.Error())
+			continue
+		}
+		srcDs, err := dsfs.LoadDataset(src.Store(), datastore.NewKey(srcRef.Path))
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		ds, err := dsfs.LoadDataset(dst.Store(), datastore.NewKey(ref.Path))
+		if err != nil {
+			t.Errorf("%s: error loading imported dataset: %s", ref.AliasString(), err.Error())
+			continue
+		}
+		if ds.Commit.Title != srcDs.Commit.Title {
+			t.Errorf("%s: commit title mismatch. expected: %s, got: %s", ref.AliasString(), srcDs.Commit.Title, ds.Commit.Title)
+		}
+		if (ds.PreviousPath == "") != (srcDs.PreviousPath == "") {
+			t.Errorf("%s: expected imported history to match source history", ref.AliasString())
+		}
+
+		srcBody, err := dsfs.LoadBody(src.Store(), srcDs)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		expect, _ := ioutil.ReadAll(srcBody)
+		body, err := dsfs.LoadBody(dst.Store(), ds)
+		if err != nil {
+			t.Errorf("%s: error loading imported body: %s", ref.AliasString(), err.Error())
+			continue
+		}
+		got, _ := ioutil.ReadAll(body)
+		if !bytes.Equal(expect, got) {
+			t.Errorf("%s: body mismatch", ref.AliasString())
+		}
+	}
+
+	// importing again skips existing names
+	if res, err = Import(bytes.NewReader(buf.Bytes()), dst); err != nil {
+		t.Fatal(err.Error())
+	}
+	if res.Refs != 0 || len(res.Skipped) != man.Refs {
+		t.Errorf("expected re-import to skip %d refs, got: %d imported, %d skipped", man.Refs, res.Refs, len(res.Skipped))
+	}
+}
+
+func TestImportInvalid(t *testing.T) {
+	if _, err := Import(bytes.NewReader([]byte("not an archive")), nil); err == nil {
+		t.Error("expected invalid archive to error")
+	}
+}
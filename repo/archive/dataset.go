@@ -0,0 +1,141 @@
+package archive
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+)
+
+// VersionInfo describes a version in a dataset archive
+type VersionInfo struct {
+	// Dir is the archive directory holding the version
+	Dir          string    `json:"dir"`
+	Path         string    `json:"path"`
+	PreviousPath string    `json:"previousPath,omitempty"`
+	Title        string    `json:"title,omitempty"`
+	Timestamp    time.Time `json:"timestamp,omitempty"`
+}
+
+// ExportDataset writes every version in the history of a dataset to w, along
+// with the dataset author's profile if the repo has it. ref must have a path
+func ExportDataset(w io.Writer, r repo.Repo, ref repo.DatasetRef, format Format) (*Manifest, error) {
+	if ref.Path == "" {
+		return nil, fmt.Errorf("dataset path is required")
+	}
+
+	ew, err := newEntryWriter(w, format)
+	if err != nil {
+		return nil, err
+	}
+	aw := &writer{ew: ew, store: r.Store(), written: map[string]bool{}}
+
+	history, err := aw.loadHistory(ref.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	man := &Manifest{
+		Version:   Version,
+		Created:   time.Now(),
+		Peername:  ref.Peername,
+		ProfileID: ref.ProfileID,
+		Refs:      1,
+		Versions:  len(history),
+		History:   make([]*VersionInfo, len(history)),
+	}
+	for i, v := range history {
+		info := &VersionInfo{
+			Dir:          fmt.Sprintf("%s%06d/", dirVersions, i+1),
+			Path:         v.Path,
+			PreviousPath: v.Dataset.PreviousPath,
+		}
+		if v.Dataset.Commit != nil {
+			info.Title = v.Dataset.Commit.Title
+			info.Timestamp = v.Dataset.Commit.Timestamp
+		}
+		man.History[i] = info
+	}
+
+	if err := aw.writeJSON(fileManifest, man); err != nil {
+		return nil, err
+	}
+
+	pods := []*config.ProfilePod{}
+	if ps := r.Profiles(); ps != nil && ref.ProfileID != "" {
+		if pro, err := ps.GetProfile(ref.ProfileID); err == nil {
+			pod, err := pro.Encode()
+			if err != nil {
+				return nil, err
+			}
+			pod.PrivKey = ""
+			pod.Online = false
+			pods = append(pods, pod)
+		} else if err != profile.ErrNotFound {
+			return nil, err
+		}
+	}
+	if err := aw.writeJSON(fileProfiles, pods); err != nil {
+		return nil, err
+	}
+
+	for _, v := range history {
+		if err := aw.writeVersion(v); err != nil {
+			return nil, fmt.Errorf("error exporting %s: %s", v.Path, err.Error())
+		}
+	}
+
+	trimmed := repo.DatasetRef{Peername: ref.Peername, ProfileID: ref.ProfileID, Name: ref.Name, Path: ref.Path}
+	if err := aw.writeJSON(fileRefs, []repo.DatasetRef{trimmed}); err != nil {
+		return nil, err
+	}
+
+	return man, ew.Close()
+}
+
+// ImportDataset reads a dataset archive written by ExportDataset into a repo,
+// recreating the dataset's history. Versions keep their paths when the repo's
+// store addresses content the same way as the exporting store, versions that
+// change path are listed in the result. If name is set the dataset is added
+// under that name. Unlike Import, adding a dataset with a name that's taken is
+// an error
+func ImportDataset(rd io.Reader, r repo.Repo, name string) (*ImportResult, error) {
+	ai := newImporter(r)
+	ai.name = name
+
+	err := eachEntry(rd, func(entry string, er io.Reader) error {
+		if ai.res.Manifest == nil {
+			if entry != fileManifest {
+				return fmt.Errorf("invalid archive: missing %s", fileManifest)
+			}
+			if err := ai.readEntry(entry, er); err != nil {
+				return err
+			}
+			if ai.res.Manifest.History == nil {
+				return fmt.Errorf("archive is a repo archive, not a dataset archive")
+			}
+			return nil
+		}
+		if entry == fileConfig || entry == fileEvents {
+			return nil
+		}
+		if err := ai.readEntry(entry, er); err != nil {
+			return fmt.Errorf("error importing %s: %s", entry, err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if ai.res.Manifest == nil {
+		return nil, fmt.Errorf("invalid archive: missing %s", fileManifest)
+	}
+	if len(ai.res.Skipped) > 0 {
+		return nil, repo.ErrNameTaken
+	}
+	return ai.res, nil
+}
@@ -0,0 +1,181 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+)
+
+// Format is the container format of an archive
+type Format string
+
+const (
+	// FormatTarGz is a gzipped tar file, the default format
+	FormatTarGz = Format("tar.gz")
+	// FormatZip is a zip file
+	FormatZip = Format("zip")
+)
+
+// ParseFormat reads a format from a string, accepting "tar", "tgz" & "tar.gz"
+// for FormatTarGz. An empty string is FormatTarGz
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(strings.TrimPrefix(s, ".")) {
+	case "", "tar", "tgz", "tar.gz":
+		return FormatTarGz, nil
+	case "zip":
+		return FormatZip, nil
+	default:
+		return "", fmt.Errorf("invalid archive format: '%s', options are tar.gz & zip", s)
+	}
+}
+
+// Ext returns the file extension for a format, including the leading dot
+func (f Format) Ext() string {
+	if f == FormatZip {
+		return ".zip"
+	}
+	return ".tar.gz"
+}
+
+// entryWriter writes named entries to an archive
+type entryWriter interface {
+	writeEntry(name string, size int64, r io.Reader) error
+	Close() error
+}
+
+func newEntryWriter(w io.Writer, f Format) (entryWriter, error) {
+	switch f {
+	case "", FormatTarGz:
+		gw := gzip.NewWriter(w)
+		return &tarGzWriter{gw: gw, tw: tar.NewWriter(gw)}, nil
+	case FormatZip:
+		return &zipWriter{zw: zip.NewWriter(w)}, nil
+	default:
+		return nil, fmt.Errorf("invalid archive format: '%s'", f)
+	}
+}
+
+type tarGzWriter struct {
+	gw *gzip.Writer
+	tw *tar.Writer
+}
+
+func (w *tarGzWriter) writeEntry(name string, size int64, r io.Reader) error {
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    size,
+		ModTime: time.Now(),
+	}
+	if err := w.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := io.Copy(w.tw, r)
+	return err
+}
+
+func (w *tarGzWriter) Close() error {
+	if err := w.tw.Close(); err != nil {
+		return err
+	}
+	return w.gw.Close()
+}
+
+type zipWriter struct {
+	zw *zip.Writer
+}
+
+func (w *zipWriter) writeEntry(name string, size int64, r io.Reader) error {
+	hdr := &zip.FileHeader{Name: name, Method: zip.Deflate}
+	hdr.SetModTime(time.Now())
+	f, err := w.zw.CreateHeader(hdr)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	return err
+}
+
+func (w *zipWriter) Close() error {
+	return w.zw.Close()
+}
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zipMagic  = []byte("PK\x03\x04")
+)
+
+// eachEntry calls fn with every file entry of an archive in the order they
+// were written. The format is detected from the start of the archive
+func eachEntry(rd io.Reader, fn func(name string, r io.Reader) error) error {
+	br := bufio.NewReader(rd)
+	magic, _ := br.Peek(len(zipMagic))
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gr, err := gzip.NewReader(br)
+		if err != nil {
+			return fmt.Errorf("error reading archive: %s", err.Error())
+		}
+		defer gr.Close()
+
+		tr := tar.NewReader(gr)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				return nil
+			} else if err != nil {
+				return fmt.Errorf("error reading archive: %s", err.Error())
+			}
+			if hdr.Typeflag != tar.TypeReg && hdr.Typeflag != tar.TypeRegA {
+				continue
+			}
+			if err := fn(hdr.Name, tr); err != nil {
+				return err
+			}
+		}
+	case bytes.HasPrefix(magic, zipMagic):
+		// zip files list their entries at the end, reading them needs random
+		// access so the archive is buffered to a temp file
+		tmp, err := ioutil.TempFile("", "qri_archive_")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+
+		size, err := io.Copy(tmp, br)
+		if err != nil {
+			return err
+		}
+		zr, err := zip.NewReader(tmp, size)
+		if err != nil {
+			return fmt.Errorf("error reading archive: %s", err.Error())
+		}
+		for _, f := range zr.File {
+			if f.FileInfo().IsDir() {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return fmt.Errorf("error reading archive: %s", err.Error())
+			}
+			err = fn(f.Name, rc)
+			rc.Close()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("error reading archive: unrecognized format, expected tar.gz or zip")
+	}
+}
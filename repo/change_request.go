@@ -0,0 +1,154 @@
+package repo
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/qri-io/qri/repo/profile"
+)
+
+// ErrChangeRequestsNotSupported is the expected error for when the
+// ChangeRequestStore interface is *not* implemented
+var ErrChangeRequestsNotSupported = fmt.Errorf("repo: change requests not supported")
+
+// ChangeRequestStatus is the state of a change request
+type ChangeRequestStatus string
+
+const (
+	// CRStatusOpen is a change request that hasn't been acted on
+	CRStatusOpen = ChangeRequestStatus("open")
+	// CRStatusAccepted is a change request that has been merged into the target
+	// dataset as a new version
+	CRStatusAccepted = ChangeRequestStatus("accepted")
+	// CRStatusRejected is a change request the target dataset's owner declined
+	CRStatusRejected = ChangeRequestStatus("rejected")
+)
+
+// ChangeRequest is a proposal from one peer for a new version of a dataset
+// owned by another peer
+type ChangeRequest struct {
+	// ID uniquely identifies a change request. It's the path of the proposed
+	// dataset version
+	ID string `json:"id"`
+	// Target is the dataset the change is proposed for. Target.Path is the
+	// version the proposal is based on
+	Target DatasetRef `json:"target"`
+	// Path of the proposed dataset version
+	Path string `json:"path"`
+	// Proposer & ProposerID are the peername & profile ID of the peer that
+	// created the request
+	Proposer   string     `json:"proposer"`
+	ProposerID profile.ID `json:"proposerID"`
+	// Title & Message describe the proposed change
+	Title   string `json:"title,omitempty"`
+	Message string `json:"message,omitempty"`
+	// Status of the request
+	Status  ChangeRequestStatus `json:"status"`
+	Created time.Time           `json:"created"`
+	Updated time.Time           `json:"updated"`
+	// Result is the path of the version created by accepting the request
+	Result string `json:"result,omitempty"`
+}
+
+// ChangeRequestStore is an opt-in interface for repos that keep track of
+// change requests, both those received from other peers and those sent to
+// other peers
+type ChangeRequestStore interface {
+	// PutChangeRequest adds or updates a change request, keyed by ID
+	PutChangeRequest(cr *ChangeRequest) error
+	// GetChangeRequest fetches a change request by ID
+	GetChangeRequest(id string) (*ChangeRequest, error)
+	// DeleteChangeRequest removes a change request
+	DeleteChangeRequest(id string) error
+	// ListChangeRequests lists change requests, most recently created first
+	ListChangeRequests(limit, offset int) ([]*ChangeRequest, error)
+	// ChangeRequestsForTarget lists change requests for a dataset, most
+	// recently created first. Requests match targets by name and
+	// peername or profileID
+	ChangeRequestsForTarget(target DatasetRef, limit, offset int) ([]*ChangeRequest, error)
+}
+
+// MatchesTarget returns true if a change request proposes a change to a
+// dataset
+func (cr *ChangeRequest) MatchesTarget(ref DatasetRef) bool {
+	if ref.Name == "" || cr.Target.Name != ref.Name {
+		return false
+	}
+	return (ref.Peername != "" && cr.Target.Peername == ref.Peername) ||
+		(ref.ProfileID != "" && cr.Target.ProfileID == ref.ProfileID)
+}
+
+// MemChangeRequestStore is an in-memory implementation of the
+// ChangeRequestStore interface
+type MemChangeRequestStore []*ChangeRequest
+
+// PutChangeRequest adds or updates a change request
+func (s *MemChangeRequestStore) PutChangeRequest(cr *ChangeRequest) error {
+	if cr.ID == "" {
+		return ErrPathRequired
+	}
+	crs := *s
+	for i, c := range crs {
+		if c.ID == cr.ID {
+			crs[i] = cr
+			return nil
+		}
+	}
+	crs = append(crs, cr)
+	sort.SliceStable(crs, func(i, j int) bool { return crs[i].Created.After(crs[j].Created) })
+	*s = crs
+	return nil
+}
+
+// GetChangeRequest fetches a change request by ID
+func (s MemChangeRequestStore) GetChangeRequest(id string) (*ChangeRequest, error) {
+	for _, cr := range s {
+		if cr.ID == id {
+			return cr, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// DeleteChangeRequest removes a change request
+func (s *MemChangeRequestStore) DeleteChangeRequest(id string) error {
+	crs := *s
+	for i, cr := range crs {
+		if cr.ID == id {
+			*s = append(crs[:i], crs[i+1:]...)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// ListChangeRequests lists change requests, most recently created first
+func (s MemChangeRequestStore) ListChangeRequests(limit, offset int) ([]*ChangeRequest, error) {
+	return PageChangeRequests(s, limit, offset), nil
+}
+
+// ChangeRequestsForTarget lists change requests for a dataset, most recently
+// created first
+func (s MemChangeRequestStore) ChangeRequestsForTarget(target DatasetRef, limit, offset int) ([]*ChangeRequest, error) {
+	crs := []*ChangeRequest{}
+	for _, cr := range s {
+		if cr.MatchesTarget(target) {
+			crs = append(crs, cr)
+		}
+	}
+	return PageChangeRequests(crs, limit, offset), nil
+}
+
+// PageChangeRequests applies limit & offset to a slice of change requests.
+// A negative limit returns all change requests after offset
+func PageChangeRequests(crs []*ChangeRequest, limit, offset int) []*ChangeRequest {
+	if offset > len(crs) {
+		offset = len(crs)
+	}
+	stop := limit + offset
+	if limit < 0 || stop > len(crs) {
+		stop = len(crs)
+	}
+	return crs[offset:stop]
+}
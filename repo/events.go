@@ -4,14 +4,23 @@ import (
 	"sort"
 	"time"
 
+	"github.com/qri-io/qri/repo/profile"
 	peer "gx/ipfs/QmdVrMn1LhB4ybb8hMVaMLXnA8XRSewMnK6YqXKXoTcRvN/go-libp2p-peer"
 )
 
 // EventLog keeps logs
 type EventLog interface {
 	LogEvent(t EventType, ref DatasetRef) error
+	// LogEventDetails records an event with the time it occurred, the peer that
+	// performed it & structured details about the action
+	LogEventDetails(t EventType, when time.Time, peerID peer.ID, ref DatasetRef, params *EventParams) error
 	Events(limit, offset int) ([]*Event, error)
 	EventsSince(time.Time) ([]*Event, error)
+	// EventsForRef lists events that reference a dataset, newest first.
+	// Events match a reference by path, or by name and peername or profileID
+	EventsForRef(ref DatasetRef, limit, offset int) ([]*Event, error)
+	// EventsByType lists events of a given type, newest first
+	EventsByType(t EventType, limit, offset int) ([]*Event, error)
 }
 
 // Event is a list of details for logging a query
@@ -20,7 +29,52 @@ type Event struct {
 	Type   EventType
 	Ref    DatasetRef
 	PeerID peer.ID
-	Params interface{}
+	Params *EventParams
+}
+
+// EventParams are structured details recorded with an event. Which fields are
+// set depends on the type of event
+type EventParams struct {
+	// ProfileID & Peername of the user that performed the action
+	ProfileID profile.ID `json:"profileID,omitempty"`
+	Peername  string     `json:"peername,omitempty"`
+	// PrevPath is the path of the dataset before the action, if it changed
+	PrevPath string `json:"prevPath,omitempty"`
+	// PrevName is the name of the dataset before a rename
+	PrevName string `json:"prevName,omitempty"`
+	// Details holds action-specific parameters, like the registry a dataset
+	// was published to
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// Actor returns the peername of the user that performed an event's action,
+// falling back to the profileID & peerID
+func (e *Event) Actor() string {
+	if e.Params != nil {
+		if e.Params.Peername != "" {
+			return e.Params.Peername
+		}
+		if e.Params.ProfileID != "" {
+			return e.Params.ProfileID.String()
+		}
+	}
+	if e.PeerID != "" {
+		return e.PeerID.Pretty()
+	}
+	return ""
+}
+
+// MatchesRef checks if an event references a dataset. Events match by path,
+// or by name and either peername or profileID
+func (e *Event) MatchesRef(ref DatasetRef) bool {
+	if e.Ref.Path != "" && e.Ref.Path == ref.Path {
+		return true
+	}
+	if ref.Name == "" || e.Ref.Name != ref.Name {
+		return false
+	}
+	return (ref.Peername != "" && e.Ref.Peername == ref.Peername) ||
+		(ref.ProfileID != "" && e.Ref.ProfileID == ref.ProfileID)
 }
 
 // EventType classifies types of events that can be logged
@@ -33,12 +87,19 @@ const (
 	ETDsDeleted = EventType("ds_deleted")
 	// ETDsRenamed represents changing a dataset's name. Peers should update their refstore
 	ETDsRenamed = EventType("ds_renamed")
+	// ETDsReset represents moving a dataset back to an earlier version, removing newer versions.
+	// Peers should update their refstore
+	ETDsReset = EventType("ds_reset")
 	// ETDsPinned represents a peer pinning a dataset to their local storage
 	ETDsPinned = EventType("ds_pinned")
 	// ETDsUnpinned represents a peer unpinnning a dataset from local storage
 	ETDsUnpinned = EventType("ds_unpinned")
 	// ETDsAdded represents adding a reference to another peer's dataset to their node
 	ETDsAdded = EventType("ds_added")
+	// ETDsPublished represents publishing a dataset to a registry
+	ETDsPublished = EventType("ds_published")
+	// ETDsUnpublished represents removing a dataset from a registry
+	ETDsUnpublished = EventType("ds_unpublished")
 	// ETTransformExecuted represents running a transformation
 	ETTransformExecuted = EventType("tf_executed")
 )
@@ -49,44 +110,39 @@ type MemEventLog []*Event
 
 // LogEvent adds a query entry to the store
 func (log *MemEventLog) LogEvent(t EventType, ref DatasetRef) error {
-	e := &Event{
+	log.insert(&Event{
 		Time: time.Now(),
 		Type: t,
 		Ref:  ref,
-	}
-	logs := append([]*Event{e}, *log...)
-	sort.Slice(logs, func(i, j int) bool { return logs[i].Time.After(logs[j].Time) })
-	*log = logs
+	})
 	return nil
 }
 
 // LogEventDetails adds an entry to the log
-// TODO: Update LogEvent to work like this, update callers.
-func (log *MemEventLog) LogEventDetails(t EventType, when int64, peerID peer.ID, ref DatasetRef, params interface{}) error {
-	e := &Event{
-		Time:   time.Unix(when, 0),
+func (log *MemEventLog) LogEventDetails(t EventType, when time.Time, peerID peer.ID, ref DatasetRef, params *EventParams) error {
+	log.insert(&Event{
+		Time:   when,
 		Type:   t,
 		Ref:    ref,
 		PeerID: peerID,
 		Params: params,
-	}
-	logs := append([]*Event{e}, *log...)
-	sort.Slice(logs, func(i, j int) bool { return logs[i].Time.After(logs[j].Time) })
-	*log = logs
+	})
 	return nil
 }
 
+// insert adds an event to the log, keeping events sorted newest first
+func (log *MemEventLog) insert(e *Event) {
+	logs := *log
+	i := sort.Search(len(logs), func(i int) bool { return !logs[i].Time.After(e.Time) })
+	logs = append(logs, nil)
+	copy(logs[i+1:], logs[i:])
+	logs[i] = e
+	*log = logs
+}
+
 // Events grabs a set of Events from the store
 func (log MemEventLog) Events(limit, offset int) ([]*Event, error) {
-	if offset > len(log) {
-		offset = len(log)
-	}
-	stop := limit + offset
-	if stop > len(log) {
-		stop = len(log)
-	}
-
-	return log[offset:stop], nil
+	return PageEvents(log, limit, offset), nil
 }
 
 // EventsSince produces a slice of all events since a given time
@@ -100,3 +156,38 @@ func (log MemEventLog) EventsSince(t time.Time) ([]*Event, error) {
 
 	return events, nil
 }
+
+// EventsForRef lists events that reference a dataset, newest first
+func (log MemEventLog) EventsForRef(ref DatasetRef, limit, offset int) ([]*Event, error) {
+	events := []*Event{}
+	for _, e := range log {
+		if e.MatchesRef(ref) {
+			events = append(events, e)
+		}
+	}
+	return PageEvents(events, limit, offset), nil
+}
+
+// EventsByType lists events of a given type, newest first
+func (log MemEventLog) EventsByType(t EventType, limit, offset int) ([]*Event, error) {
+	events := []*Event{}
+	for _, e := range log {
+		if e.Type == t {
+			events = append(events, e)
+		}
+	}
+	return PageEvents(events, limit, offset), nil
+}
+
+// PageEvents applies limit & offset to a slice of events. A negative limit
+// returns all events after offset
+func PageEvents(events []*Event, limit, offset int) []*Event {
+	if offset > len(events) {
+		offset = len(events)
+	}
+	stop := limit + offset
+	if limit < 0 || stop > len(events) {
+		stop = len(events)
+	}
+	return events[offset:stop]
+}
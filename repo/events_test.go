@@ -213,7 +213,7 @@ func TestLogEventDetails(t *testing.T) {
 		when       int64
 		peerID     peer.ID
 		datasetRef DatasetRef
-		params     interface{}
+		params     *EventParams
 	}
 
 	tests := []struct {
@@ -236,7 +236,7 @@ func TestLogEventDetails(t *testing.T) {
 		memEventLog := MemEventLog{}
 
 		for _, log := range test.logs {
-			err := memEventLog.LogEventDetails(log.event, log.when, log.peerID, log.datasetRef, log.params)
+			err := memEventLog.LogEventDetails(log.event, time.Unix(log.when, 0), log.peerID, log.datasetRef, log.params)
 			if err != nil {
 				t.Errorf("Case %d had a LogEventDetails error: %s", i, err.Error())
 			}
@@ -286,3 +286,37 @@ func TestLogEvent(t *testing.T) {
 	}
 
 }
+
+func TestEventsForRefAndType(t *testing.T) {
+	a := DatasetRef{Peername: "peer", Name: "a", Path: "/map/a"}
+	b := DatasetRef{Peername: "peer", Name: "b", Path: "/map/b"}
+
+	log := &MemEventLog{}
+	log.LogEventDetails(ETDsCreated, time.Unix(10, 0), "", a, nil)
+	log.LogEventDetails(ETDsCreated, time.Unix(30, 0), "", b, nil)
+	log.LogEventDetails(ETDsPinned, time.Unix(20, 0), "", a, nil)
+
+	events, err := log.EventsForRef(DatasetRef{Peername: "peer", Name: "a"}, 10, 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := compareEventSlices(events, MemEventLog{(*log)[1], (*log)[2]}); err != nil {
+		t.Errorf("EventsForRef mismatch: %s", err.Error())
+	}
+
+	events, err = log.EventsForRef(DatasetRef{Path: "/map/b"}, 10, 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(events) != 1 || !events[0].Ref.Equal(b) {
+		t.Errorf("expected EventsForRef by path to return one event for b, got: %d", len(events))
+	}
+
+	events, err = log.EventsByType(ETDsCreated, 1, 1)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(events) != 1 || !events[0].Ref.Equal(a) {
+		t.Errorf("expected second created event to be for a")
+	}
+}
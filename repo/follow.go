@@ -0,0 +1,102 @@
+package repo
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/qri-io/qri/repo/profile"
+)
+
+// ErrFollowsNotSupported is the expected error for when the FollowStore
+// interface is *not* implemented
+var ErrFollowsNotSupported = fmt.Errorf("repo: follows not supported")
+
+// Follow is a peer or a single dataset this repo replicates. A follow with no
+// Name follows every dataset a peer announces
+type Follow struct {
+	Peername  string     `json:"peername"`
+	ProfileID profile.ID `json:"profileID,omitempty"`
+	// Name of the followed dataset, empty when following a peer
+	Name    string    `json:"name,omitempty"`
+	Created time.Time `json:"created"`
+}
+
+// String gives the follow as peername or peername/name
+func (f Follow) String() string {
+	if f.Name == "" {
+		return f.Peername
+	}
+	return fmt.Sprintf("%s/%s", f.Peername, f.Name)
+}
+
+// Matches checks if a dataset is followed. Datasets match by profileID where
+// both have one, by peername otherwise
+func (f Follow) Matches(ref DatasetRef) bool {
+	if f.Name != "" && f.Name != ref.Name {
+		return false
+	}
+	if f.ProfileID != "" && ref.ProfileID != "" {
+		return f.ProfileID == ref.ProfileID
+	}
+	return f.Peername != "" && f.Peername == ref.Peername
+}
+
+// FollowStore is an opt-in interface for repos that keep a list of followed
+// peers & datasets
+type FollowStore interface {
+	// PutFollow adds or replaces a follow, keyed by peername & name
+	PutFollow(f *Follow) error
+	// DeleteFollow removes a follow
+	DeleteFollow(peername, name string) error
+	// ListFollows lists all follows, ordered by peername then name
+	ListFollows() ([]*Follow, error)
+}
+
+// sortFollows orders follows by peername then name
+func sortFollows(fs []*Follow) {
+	sort.Slice(fs, func(i, j int) bool {
+		if fs[i].Peername == fs[j].Peername {
+			return fs[i].Name < fs[j].Name
+		}
+		return fs[i].Peername < fs[j].Peername
+	})
+}
+
+// MemFollowStore is an in-memory implementation of the FollowStore interface
+type MemFollowStore []*Follow
+
+// PutFollow adds or replaces a follow
+func (s *MemFollowStore) PutFollow(f *Follow) error {
+	if f.Peername == "" {
+		return ErrPeernameRequired
+	}
+	fs := *s
+	for i, fl := range fs {
+		if fl.Peername == f.Peername && fl.Name == f.Name {
+			fs[i] = f
+			return nil
+		}
+	}
+	fs = append(fs, f)
+	sortFollows(fs)
+	*s = fs
+	return nil
+}
+
+// DeleteFollow removes a follow
+func (s *MemFollowStore) DeleteFollow(peername, name string) error {
+	fs := *s
+	for i, f := range fs {
+		if f.Peername == peername && f.Name == name {
+			*s = append(fs[:i], fs[i+1:]...)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// ListFollows lists all follows, ordered by peername then name
+func (s MemFollowStore) ListFollows() ([]*Follow, error) {
+	return append([]*Follow{}, s...), nil
+}
@@ -0,0 +1,29 @@
+package repo
+
+import (
+	"testing"
+
+	"github.com/qri-io/qri/repo/profile"
+)
+
+func TestFollowMatches(t *testing.T) {
+	cases := []struct {
+		f      Follow
+		ref    DatasetRef
+		expect bool
+	}{
+		{Follow{Peername: "alice"}, DatasetRef{Peername: "alice", Name: "movies"}, true},
+		{Follow{Peername: "alice"}, DatasetRef{Peername: "steve", Name: "movies"}, false},
+		{Follow{Peername: "alice", Name: "movies"}, DatasetRef{Peername: "alice", Name: "movies"}, true},
+		{Follow{Peername: "alice", Name: "movies"}, DatasetRef{Peername: "alice", Name: "cities"}, false},
+		{Follow{Peername: "alice", ProfileID: profile.ID("a")}, DatasetRef{Peername: "renamed", ProfileID: profile.ID("a"), Name: "movies"}, true},
+		{Follow{Peername: "alice", ProfileID: profile.ID("a")}, DatasetRef{Peername: "alice", ProfileID: profile.ID("b"), Name: "movies"}, false},
+		{Follow{}, DatasetRef{Name: "movies"}, false},
+	}
+
+	for i, c := range cases {
+		if got := c.f.Matches(c.ref); got != c.expect {
+			t.Errorf("case %d: expected %s matching %s to be %t", i, c.f, c.ref.AliasString(), c.expect)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package fsrepo
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/qri-io/qri/repo"
+)
+
+// ChangeRequestStore is a file-based implementation of the
+// repo.ChangeRequestStore interface. It stores change requests in a json file,
+// holding the repo lock while writing
+type ChangeRequestStore struct {
+	basepath
+}
+
+// NewChangeRequestStore allocates a ChangeRequestStore
+func NewChangeRequestStore(bp basepath) *ChangeRequestStore {
+	return &ChangeRequestStore{basepath: bp}
+}
+
+// PutChangeRequest adds or updates a change request
+func (s *ChangeRequestStore) PutChangeRequest(cr *repo.ChangeRequest) error {
+	if cr.ID == "" {
+		return repo.ErrPathRequired
+	}
+
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	crs, err := s.changeRequests()
+	if err != nil {
+		return err
+	}
+
+	replaced := false
+	for i, c := range crs {
+		if c.ID == cr.ID {
+			crs[i] = cr
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		crs = append(crs, cr)
+		sort.SliceStable(crs, func(i, j int) bool { return crs[i].Created.After(crs[j].Created) })
+	}
+	return s.saveFile(crs, FileChangeRequests)
+}
+
+// GetChangeRequest fetches a change request by ID
+func (s *ChangeRequestStore) GetChangeRequest(id string) (*repo.ChangeRequest, error) {
+	crs, err := s.changeRequests()
+	if err != nil {
+		return nil, err
+	}
+	for _, cr := range crs {
+		if cr.ID == id {
+			return cr, nil
+		}
+	}
+	return nil, repo.ErrNotFound
+}
+
+// DeleteChangeRequest removes a change request
+func (s *ChangeRequestStore) DeleteChangeRequest(id string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	crs, err := s.changeRequests()
+	if err != nil {
+		return err
+	}
+	for i, cr := range crs {
+		if cr.ID == id {
+			return s.saveFile(append(crs[:i], crs[i+1:]...), FileChangeRequests)
+		}
+	}
+	return repo.ErrNotFound
+}
+
+// ListChangeRequests lists change requests, most recently created first
+func (s *ChangeRequestStore) ListChangeRequests(limit, offset int) ([]*repo.ChangeRequest, error) {
+	crs, err := s.changeRequests()
+	if err != nil {
+		return nil, err
+	}
+	return repo.PageChangeRequests(crs, limit, offset), nil
+}
+
+// ChangeRequestsForTarget lists change requests for a dataset, most recently
+// created first
+func (s *ChangeRequestStore) ChangeRequestsForTarget(target repo.DatasetRef, limit, offset int) ([]*repo.ChangeRequest, error) {
+	crs, err := s.changeRequests()
+	if err != nil {
+		return nil, err
+	}
+
+	matched := []*repo.ChangeRequest{}
+	for _, cr := range crs {
+		if cr.MatchesTarget(target) {
+			matched = append(matched, cr)
+		}
+	}
+	return repo.PageChangeRequests(matched, limit, offset), nil
+}
+
+// changeRequests reads all change requests from disk
+func (s *ChangeRequestStore) changeRequests() ([]*repo.ChangeRequest, error) {
+	crs := []*repo.ChangeRequest{}
+	data, err := s.readBytes(FileChangeRequests)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return crs, nil
+		}
+		log.Debug(err.Error())
+		return nil, fmt.Errorf("error loading change requests: %s", err.Error())
+	}
+
+	if err := json.Unmarshal(data, &crs); err != nil {
+		log.Debug(err.Error())
+		return nil, fmt.Errorf("error decoding change requests: %s", err.Error())
+	}
+	return crs, nil
+}
@@ -1,95 +1,574 @@
 package fsrepo
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/qri-io/cafs"
 	"github.com/qri-io/qri/repo"
+
+	peer "gx/ipfs/QmdVrMn1LhB4ybb8hMVaMLXnA8XRSewMnK6YqXKXoTcRvN/go-libp2p-peer"
 )
 
-// EventLog is a file-based implementation of the repo.EventLog interface
+// DefaultEventSegmentSize is the number of events written to a segment file
+// before starting a new one
+const DefaultEventSegmentSize = 1000
+
+// EventLog is a file-based implementation of the repo.EventLog interface.
+// Events are appended to a series of segment files in the order they're
+// logged, and never rewritten. Each segment has an index of event offsets,
+// times, types & dataset references, which is written alongside the segment
+// once it fills up. Queries use indexes to read only the events they return
 type EventLog struct {
 	basepath
+	// file is a legacy single-file event log, migrated to segments on load
 	file  File
 	store cafs.Filestore
+	// SegmentSize is the max number of events in a segment
+	SegmentSize int
+
+	lock     sync.Mutex
+	loaded   bool
+	segments []*eventSegment
 }
 
-// NewEventLog allocates a new file-based EventLog instance
-func NewEventLog(base string, file File, store cafs.Filestore) EventLog {
-	return EventLog{basepath: basepath(base), file: file, store: store}
+// eventSegment indexes a single segment file
+type eventSegment struct {
+	ID      int   `json:"id"`
+	MinTime int64 `json:"minTime"`
+	MaxTime int64 `json:"maxTime"`
+	// Offsets is the byte offset of each event in the segment file, in order
+	// of writing
+	Offsets []int64 `json:"offsets"`
+	// Times is the unix nanosecond timestamp of each event
+	Times []int64 `json:"times"`
+	// Types lists event positions by event type
+	Types map[repo.EventType][]int `json:"types"`
+	// Refs lists event positions by dataset reference key
+	Refs map[string][]int `json:"refs"`
+	// Size is the length of the segment file in bytes
+	Size int64 `json:"size"`
 }
 
-// LogEvent adds a Event to the store
-func (ql EventLog) LogEvent(t repo.EventType, ref repo.DatasetRef) error {
-	log, err := ql.logs()
-	if err != nil {
-		return err
+func newEventSegment(id int) *eventSegment {
+	return &eventSegment{
+		ID:    id,
+		Types: map[repo.EventType][]int{},
+		Refs:  map[string][]int{},
+	}
+}
+
+// add indexes an event written at a given offset, with a given length
+func (s *eventSegment) add(e *repo.Event, offset, length int64) {
+	pos := len(s.Offsets)
+	t := e.Time.UnixNano()
+	if pos == 0 || t < s.MinTime {
+		s.MinTime = t
+	}
+	if pos == 0 || t > s.MaxTime {
+		s.MaxTime = t
+	}
+	s.Offsets = append(s.Offsets, offset)
+	s.Times = append(s.Times, t)
+	s.Types[e.Type] = append(s.Types[e.Type], pos)
+	for _, key := range eventRefKeys(e.Ref) {
+		s.Refs[key] = append(s.Refs[key], pos)
+	}
+	s.Size = offset + length
+}
+
+func (s *eventSegment) count() int {
+	return len(s.Offsets)
+}
+
+// eventRefKeys lists the index keys of a dataset reference. Events match a
+// reference if they share any key, mirroring repo.Event.MatchesRef
+func eventRefKeys(ref repo.DatasetRef) (keys []string) {
+	if ref.Path != "" {
+		keys = append(keys, "path:"+ref.Path)
+	}
+	if ref.Name != "" {
+		if ref.Peername != "" {
+			keys = append(keys, "name:"+ref.Peername+"/"+ref.Name)
+		}
+		if ref.ProfileID != "" {
+			keys = append(keys, "id:"+ref.ProfileID.String()+"/"+ref.Name)
+		}
+	}
+	return
+}
+
+// NewEventLog allocates a new file-based EventLog instance
+func NewEventLog(base string, file File, store cafs.Filestore) *EventLog {
+	return &EventLog{
+		basepath:    basepath(base),
+		file:        file,
+		store:       store,
+		SegmentSize: DefaultEventSegmentSize,
 	}
+}
 
-	e := &repo.Event{
+// LogEvent adds a Event to the store
+func (ql *EventLog) LogEvent(t repo.EventType, ref repo.DatasetRef) error {
+	return ql.append(&repo.Event{
 		Time: time.Now(),
 		Type: t,
 		Ref:  ref,
-	}
-	log = append([]*repo.Event{e}, log...)
-	sort.Slice(log, func(i, j int) bool { return log[i].Time.After(log[j].Time) })
-	return ql.saveFile(log, ql.file)
+	})
+}
+
+// LogEventDetails adds an Event with a given time, peer & params to the store
+func (ql *EventLog) LogEventDetails(t repo.EventType, when time.Time, peerID peer.ID, ref repo.DatasetRef, params *repo.EventParams) error {
+	return ql.append(&repo.Event{
+		Time:   when,
+		Type:   t,
+		Ref:    ref,
+		PeerID: peerID,
+		Params: params,
+	})
 }
 
 // Events fetches a set of Events from the store
-func (ql EventLog) Events(limit, offset int) ([]*repo.Event, error) {
-	logs, err := ql.logs()
+func (ql *EventLog) Events(limit, offset int) ([]*repo.Event, error) {
+	return ql.query(limit, offset, func(s *eventSegment) []int {
+		return allPositions(s.count())
+	})
+}
+
+// EventsSince fetches a set of Events from the store that occur after a given timestamp,
+// oldest first
+func (ql *EventLog) EventsSince(t time.Time) ([]*repo.Event, error) {
+	since := t.UnixNano()
+	events, err := ql.query(-1, 0, func(s *eventSegment) []int {
+		if s.MaxTime <= since {
+			return nil
+		}
+		var pos []int
+		for i, et := range s.Times {
+			if et > since {
+				pos = append(pos, i)
+			}
+		}
+		return pos
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	if offset > len(logs) {
-		offset = len(logs)
+	for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+		events[i], events[j] = events[j], events[i]
 	}
-	stop := limit + offset
-	if stop > len(logs) {
-		stop = len(logs)
+	return events, nil
+}
+
+// EventsForRef lists events that reference a dataset, newest first
+func (ql *EventLog) EventsForRef(ref repo.DatasetRef, limit, offset int) ([]*repo.Event, error) {
+	keys := eventRefKeys(ref)
+	return ql.query(limit, offset, func(s *eventSegment) []int {
+		var pos []int
+		for _, key := range keys {
+			pos = append(pos, s.Refs[key]...)
+		}
+		return uniquePositions(pos)
+	})
+}
+
+// EventsByType lists events of a given type, newest first
+func (ql *EventLog) EventsByType(t repo.EventType, limit, offset int) ([]*repo.Event, error) {
+	return ql.query(limit, offset, func(s *eventSegment) []int {
+		return s.Types[t]
+	})
+}
+
+// eventPos locates an event within a segment
+type eventPos struct {
+	seg  *eventSegment
+	pos  int
+	time int64
+}
+
+// query reads events at positions selected from each segment, newest first.
+// A negative limit returns all selected events. Segments are visited from
+// newest to oldest, stopping once no remaining segment can hold an event
+// that's newer than the ones already found
+func (ql *EventLog) query(limit, offset int, selector func(s *eventSegment) []int) ([]*repo.Event, error) {
+	ql.lock.Lock()
+	defer ql.lock.Unlock()
+
+	if !ql.loaded || ql.stale() {
+		if err := ql.reload(); err != nil {
+			return nil, err
+		}
+	}
+
+	need := limit + offset
+	if limit == 0 {
+		return []*repo.Event{}, nil
+	}
+
+	segs := make([]*eventSegment, len(ql.segments))
+	copy(segs, ql.segments)
+	sort.Slice(segs, func(i, j int) bool { return segs[i].MaxTime > segs[j].MaxTime })
+
+	var found []eventPos
+	for _, s := range segs {
+		if limit > 0 && len(found) >= need && s.MaxTime < found[need-1].time {
+			break
+		}
+		// walk positions backwards so events logged at the same time are
+		// returned most recently written first
+		sel := selector(s)
+		for k := len(sel) - 1; k >= 0; k-- {
+			i := sel[k]
+			found = append(found, eventPos{seg: s, pos: i, time: s.Times[i]})
+		}
+		sort.SliceStable(found, func(i, j int) bool { return found[i].time > found[j].time })
+		if limit > 0 && len(found) > need {
+			found = found[:need]
+		}
 	}
 
-	return logs[offset:stop], nil
+	if offset > len(found) {
+		offset = len(found)
+	}
+	found = found[offset:]
+
+	events := make([]*repo.Event, len(found))
+	files := map[int]*os.File{}
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+
+	for i, ep := range found {
+		f, ok := files[ep.seg.ID]
+		if !ok {
+			var err error
+			if f, err = os.Open(ql.segmentPath(ep.seg.ID)); err != nil {
+				return nil, fmt.Errorf("error opening event log segment: %s", err.Error())
+			}
+			files[ep.seg.ID] = f
+		}
+
+		e, err := readEventAt(f, ep.seg, ep.pos)
+		if err != nil {
+			return nil, err
+		}
+		events[i] = e
+	}
+
+	return events, nil
 }
 
-// EventsSince fetches a set of Events from the store that occur after a given timestamp
-func (ql EventLog) EventsSince(t time.Time) ([]*repo.Event, error) {
-	logs, err := ql.logs()
+// readEventAt reads the event at a position in a segment file
+func readEventAt(f *os.File, s *eventSegment, pos int) (*repo.Event, error) {
+	start := s.Offsets[pos]
+	end := s.Size
+	if pos+1 < len(s.Offsets) {
+		end = s.Offsets[pos+1]
+	}
+
+	data := make([]byte, end-start)
+	if _, err := f.ReadAt(data, start); err != nil {
+		return nil, fmt.Errorf("error reading event log segment: %s", err.Error())
+	}
+	e := &repo.Event{}
+	if err := json.Unmarshal(data, e); err != nil {
+		return nil, fmt.Errorf("error unmarshaling event: %s", err.Error())
+	}
+	return e, nil
+}
+
+// append writes an event to the end of the active segment, starting a new
+// segment if the active one is full
+func (ql *EventLog) append(e *repo.Event) error {
+	ql.lock.Lock()
+	defer ql.lock.Unlock()
+
+	unlock, err := ql.basepath.lock()
 	if err != nil {
-		return nil, err
+		return err
+	}
+	defer unlock()
+
+	if ql.stale() {
+		ql.loaded = false
+	}
+	if err := ql.load(); err != nil {
+		return err
+	}
+	return ql.write(e)
+}
+
+// reload reads segment indexes holding the repo lock, so segments aren't
+// scanned while another process is writing to them
+func (ql *EventLog) reload() error {
+	unlock, err := ql.basepath.lock()
+	if err != nil {
+		return err
 	}
+	defer unlock()
+
+	ql.loaded = false
+	return ql.load()
+}
 
-	events := make([]*repo.Event, 0, len(logs))
-	for _, e := range logs {
-		if e.Time.After(t) {
-			events = append([]*repo.Event{e}, events...)
+// stale checks if another process has written to the log since segments were
+// loaded, in which case loaded indexes need to be re-read
+func (ql *EventLog) stale() bool {
+	if !ql.loaded {
+		return false
+	}
+	if _, err := os.Stat(ql.segmentPath(len(ql.segments) + 1)); err == nil {
+		return true
+	}
+	if len(ql.segments) == 0 {
+		return false
+	}
+	last := ql.segments[len(ql.segments)-1]
+	fi, err := os.Stat(ql.segmentPath(last.ID))
+	return err != nil || fi.Size() != last.Size
+}
+
+// write appends an event without acquiring the lock
+func (ql *EventLog) write(e *repo.Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		log.Debug(err.Error())
+		return err
+	}
+	data = append(data, '\n')
+
+	s := ql.active()
+	f, err := os.OpenFile(ql.segmentPath(s.ID), os.O_CREATE|os.O_WRONLY|os.O_APPEND, os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("error opening event log segment: %s", err.Error())
+	}
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("error writing event: %s", err.Error())
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+
+	s.add(e, s.Size, int64(len(data)))
+	if s.count() >= ql.segmentSize() {
+		return ql.seal(s)
+	}
+	return nil
+}
+
+// active returns the segment events are currently written to, creating a
+// new segment if the last one is full
+func (ql *EventLog) active() *eventSegment {
+	if len(ql.segments) > 0 {
+		if last := ql.segments[len(ql.segments)-1]; last.count() < ql.segmentSize() {
+			return last
 		}
 	}
+	s := newEventSegment(len(ql.segments) + 1)
+	ql.segments = append(ql.segments, s)
+	return s
+}
 
-	return events, nil
+func (ql *EventLog) segmentSize() int {
+	if ql.SegmentSize <= 0 {
+		return DefaultEventSegmentSize
+	}
+	return ql.SegmentSize
+}
+
+// seal writes the index of a full segment to disk
+func (ql *EventLog) seal(s *eventSegment) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(ql.indexPath(s.ID), data)
+}
+
+func (ql *EventLog) dir() string {
+	return ql.filepath(FileEventSegments)
+}
+
+func (ql *EventLog) segmentPath(id int) string {
+	return filepath.Join(ql.dir(), fmt.Sprintf("%06d.log", id))
+}
+
+func (ql *EventLog) indexPath(id int) string {
+	return filepath.Join(ql.dir(), fmt.Sprintf("%06d.idx", id))
+}
+
+// load reads segment indexes, rebuilding any missing indexes from segment
+// files. load migrates events from a legacy event file if no segments exist
+func (ql *EventLog) load() error {
+	if ql.loaded {
+		return nil
+	}
+	if err := os.MkdirAll(ql.dir(), os.ModePerm); err != nil {
+		return fmt.Errorf("error creating event log directory: %s", err.Error())
+	}
+
+	infos, err := ioutil.ReadDir(ql.dir())
+	if err != nil {
+		return fmt.Errorf("error reading event log directory: %s", err.Error())
+	}
+
+	var ids []int
+	for _, fi := range infos {
+		name := fi.Name()
+		if filepath.Ext(name) != ".log" {
+			continue
+		}
+		id, err := strconv.Atoi(strings.TrimSuffix(name, ".log"))
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	ql.segments = nil
+	for i, id := range ids {
+		if id != i+1 {
+			return fmt.Errorf("event log segment %d is missing", i+1)
+		}
+		s, err := ql.loadSegment(id, i == len(ids)-1)
+		if err != nil {
+			return err
+		}
+		ql.segments = append(ql.segments, s)
+	}
+	ql.loaded = true
+
+	if len(ql.segments) == 0 {
+		return ql.migrate()
+	}
+	return nil
 }
 
-func (ql *EventLog) logs() ([]*repo.Event, error) {
-	ds := []*repo.Event{}
-	data, err := ioutil.ReadFile(ql.filepath(ql.file))
+// loadSegment reads the index of a segment. Sealed segments have an index
+// file, the last segment is indexed by scanning it's contents
+func (ql *EventLog) loadSegment(id int, last bool) (*eventSegment, error) {
+	if data, err := ioutil.ReadFile(ql.indexPath(id)); err == nil {
+		s := newEventSegment(id)
+		if err := json.Unmarshal(data, s); err == nil {
+			return s, nil
+		}
+		log.Debugf("rebuilding corrupt index for event log segment %d", id)
+	}
+
+	s, err := ql.scanSegment(id)
+	if err != nil {
+		return nil, err
+	}
+	if !last || s.count() >= ql.segmentSize() {
+		if err := ql.seal(s); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// scanSegment builds an index by reading every event in a segment file. An
+// incomplete event at the end of the file from an interrupted write is
+// truncated
+func (ql *EventLog) scanSegment(id int) (*eventSegment, error) {
+	path := ql.segmentPath(id)
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening event log segment: %s", err.Error())
+	}
+	defer f.Close()
+
+	s := newEventSegment(id)
+	r := bufio.NewReader(f)
+	var offset int64
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			if len(bytes.TrimSpace(line)) > 0 {
+				log.Debugf("truncating incomplete event in log segment %d", id)
+				if err := os.Truncate(path, offset); err != nil {
+					return nil, err
+				}
+			}
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("error reading event log segment: %s", err.Error())
+		}
+
+		e := &repo.Event{}
+		if err := json.Unmarshal(line, e); err != nil {
+			return nil, fmt.Errorf("error unmarshaling event in log segment %d at offset %d: %s", id, offset, err.Error())
+		}
+		s.add(e, offset, int64(len(line)))
+		offset += int64(len(line))
+	}
+	return s, nil
+}
+
+// migrate moves events from a legacy single-file event log into segments,
+// renaming the legacy file once complete
+func (ql *EventLog) migrate() error {
+	if ql.file == FileUnknown {
+		return nil
+	}
+	path := ql.filepath(ql.file)
+	data, err := ioutil.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return ds, nil
+			return nil
 		}
-		log.Debug(err.Error())
-		return ds, fmt.Errorf("error loading logs: %s", err.Error())
+		return fmt.Errorf("error loading logs: %s", err.Error())
 	}
 
-	if err := json.Unmarshal(data, &ds); err != nil {
+	events := []*repo.Event{}
+	if err := json.Unmarshal(data, &events); err != nil {
 		log.Debug(err.Error())
-		return ds, fmt.Errorf("error unmarshaling logs: %s", err.Error())
+		return fmt.Errorf("error unmarshaling logs: %s", err.Error())
+	}
+
+	// legacy logs are stored newest first, write in chronological order
+	for i := len(events) - 1; i >= 0; i-- {
+		if err := ql.write(events[i]); err != nil {
+			return err
+		}
+	}
+	return os.Rename(path, path+".bak")
+}
+
+func allPositions(n int) []int {
+	pos := make([]int, n)
+	for i := range pos {
+		pos[i] = i
+	}
+	return pos
+}
+
+func uniquePositions(pos []int) []int {
+	if len(pos) < 2 {
+		return pos
+	}
+	sort.Ints(pos)
+	uniq := pos[:1]
+	for _, p := range pos[1:] {
+		if p != uniq[len(uniq)-1] {
+			uniq = append(uniq, p)
+		}
 	}
-	return ds, nil
+	return uniq
 }
@@ -0,0 +1,112 @@
+package fsrepo
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/qri-io/qri/repo"
+)
+
+func TestEventLogSegments(t *testing.T) {
+	path, err := ioutil.TempDir("", "qri_test_event_log")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(path)
+
+	el := NewEventLog(path, FileEventLogs, nil)
+	el.SegmentSize = 3
+
+	ref := repo.DatasetRef{Peername: "peer", Name: "a"}
+	for i := 1; i <= 10; i++ {
+		if err := el.LogEventDetails(repo.ETDsCreated, time.Unix(int64(i), 0), "", ref, nil); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	// 3 sealed segments with indexes, plus an active segment with one event
+	for _, name := range []string{"000001.idx", "000002.idx", "000003.idx", "000004.log"} {
+		if _, err := os.Stat(filepath.Join(path, "events", name)); err != nil {
+			t.Errorf("expected %s to exist: %s", name, err.Error())
+		}
+	}
+	if _, err := os.Stat(filepath.Join(path, "events", "000004.idx")); !os.IsNotExist(err) {
+		t.Error("expected active segment to have no index file")
+	}
+
+	// simulate an interrupted write to the active segment
+	f, err := os.OpenFile(filepath.Join(path, "events", "000004.log"), os.O_WRONLY|os.O_APPEND, os.ModePerm)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	f.Write([]byte(`{"Time":"2001-01-01T`))
+	f.Close()
+
+	// reopen from disk
+	el = NewEventLog(path, FileEventLogs, nil)
+	el.SegmentSize = 3
+
+	events, err := el.Events(4, 2)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	expect := []int64{8, 7, 6, 5}
+	if len(events) != len(expect) {
+		t.Fatalf("expected %d events, got: %d", len(expect), len(events))
+	}
+	for i, e := range events {
+		if e.Time.Unix() != expect[i] {
+			t.Errorf("event %d time mismatch. expected: %d, got: %d", i, expect[i], e.Time.Unix())
+		}
+	}
+
+	// appending after recovery should produce a readable log
+	if err := el.LogEventDetails(repo.ETDsDeleted, time.Unix(11, 0), "", ref, nil); err != nil {
+		t.Fatal(err.Error())
+	}
+	events, err = el.EventsSince(time.Unix(9, 0))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(events) != 2 || events[1].Type != repo.ETDsDeleted {
+		t.Errorf("expected two events ending with a delete, got: %v", events)
+	}
+}
+
+func TestEventLogMigrate(t *testing.T) {
+	path, err := ioutil.TempDir("", "qri_test_event_log_migrate")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(path)
+
+	legacy := []*repo.Event{
+		{Time: time.Unix(30, 0), Type: repo.ETDsDeleted, Ref: repo.DatasetRef{Peername: "peer", Name: "b"}},
+		{Time: time.Unix(20, 0), Type: repo.ETDsCreated, Ref: repo.DatasetRef{Peername: "peer", Name: "b"}},
+		{Time: time.Unix(10, 0), Type: repo.ETDsCreated, Ref: repo.DatasetRef{Peername: "peer", Name: "a"}},
+	}
+	data, err := json.Marshal(legacy)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := ioutil.WriteFile(filepath.Join(path, "events.json"), data, os.ModePerm); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	el := NewEventLog(path, FileEventLogs, nil)
+	events, err := el.EventsForRef(repo.DatasetRef{Peername: "peer", Name: "b"}, 10, 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(events) != 2 || events[0].Type != repo.ETDsDeleted {
+		t.Errorf("expected migrated events for peer/b, got: %v", events)
+	}
+
+	if _, err := os.Stat(filepath.Join(path, "events.json")); !os.IsNotExist(err) {
+		t.Error("expected legacy event file to be moved after migration")
+	}
+}
@@ -1,11 +1,13 @@
 package fsrepo
 
 import (
+	"bytes"
 	"encoding/json"
-	"github.com/ipfs/go-datastore"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+
+	"github.com/ipfs/go-datastore"
 )
 
 type basepath string
@@ -18,13 +20,152 @@ func (bp basepath) readBytes(f File) ([]byte, error) {
 	return ioutil.ReadFile(bp.filepath(f))
 }
 
+// saveFile writes d to a repo file as json. Writes are atomic, readers see
+// either the previous or the new contents of the file. Callers that read a
+// file, modify it & save must hold the repo lock for the whole update
 func (bp basepath) saveFile(d interface{}, f File) error {
 	data, err := json.Marshal(d)
 	if err != nil {
 		log.Debug(err.Error())
 		return err
 	}
-	return ioutil.WriteFile(bp.filepath(f), data, os.ModePerm)
+	return writeFileAtomic(bp.filepath(f), data)
+}
+
+// repairedFiles are the json files repairFiles checks
+var repairedFiles = []File{
+	FileRefstore,
+	FilePeers,
+	FileSelectedRefs,
+	FileChangeRequests,
+	FileTemplates,
+	FileFollows,
+}
+
+// repairFiles checks the repo's json files, fixing any left truncated or
+// corrupt by a crash or a write from a version of qri that didn't write
+// atomically. Temp files from interrupted writes are removed
+func (bp basepath) repairFiles() error {
+	unlock, err := bp.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	tmps, err := filepath.Glob(filepath.Join(string(bp), ".*.tmp*"))
+	if err != nil {
+		return err
+	}
+	for _, tmp := range tmps {
+		log.Debugf("removing temp file from interrupted write: %s", tmp)
+		if err := os.Remove(tmp); err != nil {
+			return err
+		}
+	}
+
+	for _, f := range repairedFiles {
+		if err := bp.repairFile(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// repairFile replaces an invalid json file with the complete entries that
+// can be salvaged from it. The invalid file is kept with a ".corrupt"
+// extension for inspection
+func (bp basepath) repairFile(f File) error {
+	path := bp.filepath(f)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if json.Valid(data) {
+		return nil
+	}
+
+	log.Errorf("repairing corrupt repo file %s, original saved to %s.corrupt", path, path)
+	if err := ioutil.WriteFile(path+".corrupt", data, os.ModePerm); err != nil {
+		return err
+	}
+	salvaged, ok := salvageJSON(data)
+	if !ok {
+		// nothing to salvage, a missing file is read as empty
+		return os.Remove(path)
+	}
+	return writeFileAtomic(path, salvaged)
+}
+
+// salvageJSON recovers the complete leading entries of a truncated json
+// array or object. ok is false if data doesn't start with an array or object
+func salvageJSON(data []byte) (salvaged []byte, ok bool) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, false
+	}
+
+	switch tok {
+	case json.Delim('['):
+		items := []json.RawMessage{}
+		for dec.More() {
+			var item json.RawMessage
+			if err := dec.Decode(&item); err != nil {
+				break
+			}
+			items = append(items, item)
+		}
+		salvaged, err = json.Marshal(items)
+	case json.Delim('{'):
+		entries := map[string]json.RawMessage{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				break
+			}
+			k, isStr := key.(string)
+			if !isStr {
+				break
+			}
+			var val json.RawMessage
+			if err := dec.Decode(&val); err != nil {
+				break
+			}
+			entries[k] = val
+		}
+		salvaged, err = json.Marshal(entries)
+	default:
+		return nil, false
+	}
+	return salvaged, err == nil
+}
+
+// writeFileAtomic writes data to a temp file in the same directory as path
+// & renames it into place once it's been synced to disk
+func writeFileAtomic(path string, data []byte) error {
+	dir, name := filepath.Split(path)
+	f, err := ioutil.TempFile(dir, "."+name+".tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+
+	if _, err = f.Write(data); err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
 }
 
 // File represents a type file in a qri repository
@@ -57,6 +198,12 @@ const (
 	FileSelectedRefs
 	// FileChangeRequests is a file of change requests
 	FileChangeRequests
+	// FileEventSegments is a directory of event log segments & indexes
+	FileEventSegments
+	// FileTemplates is a file of named render templates
+	FileTemplates
+	// FileFollows is a file of followed peers & datasets
+	FileFollows
 )
 
 var paths = map[File]string{
@@ -72,6 +219,9 @@ var paths = map[File]string{
 	FileSearchIndex:    "/index.bleve",
 	FileSelectedRefs:   "/selected_refs.json",
 	FileChangeRequests: "/change_requests.json",
+	FileEventSegments:  "/events",
+	FileTemplates:      "/templates.json",
+	FileFollows:        "/follows.json",
 }
 
 // Filepath gives the relative filepath to a repofile
@@ -0,0 +1,128 @@
+package fsrepo
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+)
+
+func TestSalvageJSON(t *testing.T) {
+	cases := []struct {
+		in, expect string
+		ok         bool
+	}{
+		{`["a","b","c"`, `["a","b","c"]`, true},
+		{`["a","b","c`, `["a","b"]`, true},
+		{`[{"a":1},{"b":`, `[{"a":1}]`, true},
+		{`{"a":1,"b":{"c":`, `{"a":1}`, true},
+		{`{"a":1,"b"`, `{"a":1}`, true},
+		{`[`, `[]`, true},
+		{``, ``, false},
+		{`"string`, ``, false},
+	}
+
+	for i, c := range cases {
+		got, ok := salvageJSON([]byte(c.in))
+		if ok != c.ok {
+			t.Errorf("case %d ok mismatch. expected: %t, got: %t", i, c.ok, ok)
+			continue
+		}
+		if string(got) != c.expect {
+			t.Errorf("case %d result mismatch. expected: %s, got: %s", i, c.expect, string(got))
+		}
+	}
+}
+
+func TestRepairFiles(t *testing.T) {
+	path, err := ioutil.TempDir("", "qri_test_repair_files")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(path)
+	bp := basepath(path)
+
+	truncated := `["peer/a@QmZePf5LeXow3RW5U1AgEiNbW46YnRGhZ7HPvm1UmPFPwt/map/a","peer/b@QmZePf5LeXow3RW5U1AgEiN`
+	if err := ioutil.WriteFile(bp.filepath(FileRefstore), []byte(truncated), os.ModePerm); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := ioutil.WriteFile(bp.filepath(FileSelectedRefs), []byte(`garbage`), os.ModePerm); err != nil {
+		t.Fatal(err.Error())
+	}
+	tmp := filepath.Join(path, ".ds_refs.json.tmp12345")
+	if err := ioutil.WriteFile(tmp, []byte(`[]`), os.ModePerm); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if err := bp.repairFiles(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	rs := Refstore{basepath: bp, file: FileRefstore}
+	refs, err := rs.names()
+	if err != nil {
+		t.Fatalf("error reading repaired refs: %s", err.Error())
+	}
+	if len(refs) != 1 || refs[0].Name != "a" {
+		t.Errorf("expected one salvaged ref, got: %v", refs)
+	}
+
+	if data, err := ioutil.ReadFile(bp.filepath(FileRefstore) + ".corrupt"); err != nil || string(data) != truncated {
+		t.Errorf("expected corrupt file to be kept, got: %s, err: %v", string(data), err)
+	}
+	if _, err := os.Stat(bp.filepath(FileSelectedRefs)); !os.IsNotExist(err) {
+		t.Errorf("expected unsalvageable file to be removed, got: %v", err)
+	}
+	if _, err := os.Stat(tmp); !os.IsNotExist(err) {
+		t.Errorf("expected temp file to be removed, got: %v", err)
+	}
+}
+
+func TestConcurrentRefWrites(t *testing.T) {
+	path, err := ioutil.TempDir("", "qri_test_concurrent_refs")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(path)
+
+	store := cafs.NewMapstore()
+	id := profile.IDB58MustDecode("QmZePf5LeXow3RW5U1AgEiNbW46YnRGhZ7HPvm1UmPFPwt")
+	paths := make([]string, 20)
+	for i := range paths {
+		key, err := store.Put(cafs.NewMemfileBytes("test", []byte(fmt.Sprintf(`{ "title": "dataset %d" }`, i))), true)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		paths[i] = key.String()
+	}
+
+	// separate refstores stand in for separate processes sharing a repo
+	wg := sync.WaitGroup{}
+	errs := make(chan error, len(paths))
+	for i, p := range paths {
+		wg.Add(1)
+		go func(i int, p string) {
+			defer wg.Done()
+			rs := Refstore{basepath: basepath(path), store: store, file: FileRefstore}
+			errs <- rs.PutRef(repo.DatasetRef{ProfileID: id, Peername: "peer", Name: fmt.Sprintf("ds_%d", i), Path: p})
+		}(i, p)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("PutRef error: %s", err.Error())
+		}
+	}
+
+	rs := Refstore{basepath: basepath(path), file: FileRefstore}
+	if count, err := rs.RefCount(); err != nil || count != len(paths) {
+		t.Errorf("expected %d refs, got: %d, err: %v", len(paths), count, err)
+	}
+}
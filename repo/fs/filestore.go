@@ -0,0 +1,307 @@
+package fsrepo
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/multiformats/go-multihash"
+	"github.com/qri-io/cafs"
+)
+
+// FilestorePrefix is the path prefix for content in a Filestore
+const FilestorePrefix = "fs"
+
+// Filestore is a content-addressed file store backed by a plain directory
+// on the local filesystem. Files are keyed by the sha256 multihash of their
+// contents. Directories are keyed by a hash of the names & keys of their
+// children, and stored as directories so paths within them resolve.
+// Filestore needs no network or IPFS repo, and isn't a cafs.Fetcher or a
+// cafs.Pinner: everything it holds is local and retained until deleted
+type Filestore struct {
+	path string
+	lock sync.Mutex
+}
+
+// NewFilestore creates a Filestore at path, creating the directory if it
+// doesn't exist
+func NewFilestore(path string) (*Filestore, error) {
+	if err := os.MkdirAll(path, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("error creating filestore directory: %s", err.Error())
+	}
+	return &Filestore{path: path}, nil
+}
+
+// PathPrefix implements the cafs.Filestore interface
+func (fs *Filestore) PathPrefix() string {
+	return FilestorePrefix
+}
+
+// Has checks if the store holds a key
+func (fs *Filestore) Has(key datastore.Key) (bool, error) {
+	path, err := fs.filepath(key)
+	if err != nil {
+		return false, err
+	}
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Get fetches a file or directory from the store. Keys can address files
+// within a stored directory, eg: /fs/[hash]/dataset.json
+func (fs *Filestore) Get(key datastore.Key) (cafs.File, error) {
+	path, err := fs.filepath(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := openFile(path, key.String(), true)
+	if os.IsNotExist(err) {
+		return nil, datastore.ErrNotFound
+	}
+	return f, err
+}
+
+// Put adds a file or directory to the store, returning it's key. The pin
+// argument is ignored, stored content is kept until it's deleted
+func (fs *Filestore) Put(file cafs.File, pin bool) (key datastore.Key, err error) {
+	tmp, err := ioutil.TempDir(fs.path, ".put-")
+	if err != nil {
+		return
+	}
+	defer os.RemoveAll(tmp)
+
+	tmppath := filepath.Join(tmp, "content")
+	hash, err := writeFile(tmppath, file)
+	if err != nil {
+		return
+	}
+	key = datastore.NewKey(fmt.Sprintf("/%s/%s", FilestorePrefix, hash))
+
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	dest := filepath.Join(fs.path, hash)
+	if _, e := os.Stat(dest); e == nil {
+		// content is already stored
+		return key, nil
+	}
+	err = os.Rename(tmppath, dest)
+	return
+}
+
+// Delete removes content from the store. Only top-level keys can be deleted
+func (fs *Filestore) Delete(key datastore.Key) error {
+	hash, err := fs.hash(key)
+	if err != nil {
+		return err
+	}
+	if strings.Contains(hash, "/") {
+		return fmt.Errorf("cannot delete %s, only top-level keys can be deleted", key.String())
+	}
+
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	path := filepath.Join(fs.path, hash)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return datastore.ErrNotFound
+	}
+	return os.RemoveAll(path)
+}
+
+// Keys lists all top-level keys in the store
+func (fs *Filestore) Keys() ([]datastore.Key, error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	infos, err := ioutil.ReadDir(fs.path)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]datastore.Key, 0, len(infos))
+	for _, fi := range infos {
+		// skip in-progress writes
+		if strings.HasPrefix(fi.Name(), ".") {
+			continue
+		}
+		keys = append(keys, datastore.NewKey(fmt.Sprintf("/%s/%s", FilestorePrefix, fi.Name())))
+	}
+	return keys, nil
+}
+
+// Size gives the total size of the store's contents in bytes
+func (fs *Filestore) Size() (size int64, err error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	err = filepath.Walk(fs.path, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !fi.IsDir() {
+			size += fi.Size()
+		}
+		return nil
+	})
+	return
+}
+
+// NewAdder creates an adder for writing a group of files to the store. Like
+// the in-memory map store, each file is stored under it's own key & wrap is
+// ignored
+func (fs *Filestore) NewAdder(pin, wrap bool) (cafs.Adder, error) {
+	return &adder{store: fs, pin: pin, out: make(chan cafs.AddedFile, 8)}, nil
+}
+
+// filepath gives the location of a key on disk
+func (fs *Filestore) filepath(key datastore.Key) (string, error) {
+	hash, err := fs.hash(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(fs.path, filepath.FromSlash(hash)), nil
+}
+
+// hash strips the store prefix from a key, rejecting keys from other stores
+func (fs *Filestore) hash(key datastore.Key) (string, error) {
+	prefix := fmt.Sprintf("/%s/", FilestorePrefix)
+	str := key.String()
+	if !strings.HasPrefix(str, prefix) || strings.Contains(str, "..") {
+		return "", fmt.Errorf("invalid filestore key: %s", str)
+	}
+	return strings.TrimPrefix(str, prefix), nil
+}
+
+type adder struct {
+	store *Filestore
+	pin   bool
+	out   chan cafs.AddedFile
+}
+
+// AddFile stores a file & reports it on the Added channel
+func (a *adder) AddFile(f cafs.File) error {
+	key, err := a.store.Put(f, a.pin)
+	if err != nil {
+		return err
+	}
+	a.out <- cafs.AddedFile{
+		Path: key,
+		Name: f.FileName(),
+		Hash: key.String(),
+	}
+	return nil
+}
+
+// Added returns a channel of added files
+func (a *adder) Added() chan cafs.AddedFile {
+	return a.out
+}
+
+// Close finishes adding files
+func (a *adder) Close() error {
+	close(a.out)
+	return nil
+}
+
+// writeFile copies a cafs.File to path, returning the hash it's stored under
+func writeFile(path string, f cafs.File) (string, error) {
+	if !f.IsDirectory() {
+		out, err := os.Create(path)
+		if err != nil {
+			return "", err
+		}
+		h := sha256.New()
+		_, err = io.Copy(io.MultiWriter(out, h), f)
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return "", err
+		}
+		return encodeHash(h.Sum(nil))
+	}
+
+	if err := os.Mkdir(path, os.ModePerm); err != nil {
+		return "", err
+	}
+	entries := []string{}
+	for {
+		child, err := f.NextFile()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return "", err
+		}
+		name := filepath.Base(child.FileName())
+		hash, err := writeFile(filepath.Join(path, name), child)
+		child.Close()
+		if err != nil {
+			return "", err
+		}
+		entries = append(entries, fmt.Sprintf("%s %s", name, hash))
+	}
+	sort.Strings(entries)
+	sum := sha256.Sum256([]byte(strings.Join(entries, "\n")))
+	return encodeHash(sum[:])
+}
+
+// encodeHash formats a sha256 digest as a base58 multihash
+func encodeHash(digest []byte) (string, error) {
+	buf, err := multihash.Encode(digest, multihash.SHA2_256)
+	if err != nil {
+		return "", err
+	}
+	return multihash.Multihash(buf).B58String(), nil
+}
+
+// openFile opens a file or directory on disk as a cafs.File. When stream is
+// true file contents are read from disk as the file is read, otherwise
+// they're loaded into memory. Directory children are always loaded into
+// memory to avoid holding a file descriptor for every child
+func openFile(path, name string, stream bool) (cafs.File, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !fi.IsDir() {
+		if !stream {
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			return cafs.NewMemfileBytes(name, data), nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		return cafs.NewMemfileReader(name, f), nil
+	}
+
+	infos, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	children := make([]cafs.File, 0, len(infos))
+	for _, child := range infos {
+		f, err := openFile(filepath.Join(path, child.Name()), child.Name(), false)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, f)
+	}
+	return cafs.NewMemdir(name, children...), nil
+}
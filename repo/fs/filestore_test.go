@@ -0,0 +1,133 @@
+package fsrepo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+	"github.com/qri-io/qri/repo/test"
+)
+
+var _ cafs.Filestore = (*Filestore)(nil)
+
+func TestFilestore(t *testing.T) {
+	path, err := ioutil.TempDir("", "qri_filestore_test")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(path)
+
+	fs, err := NewFilestore(path)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	key, err := fs.Put(cafs.NewMemfileBytes("a.txt", []byte("hello")), true)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	again, err := fs.Put(cafs.NewMemfileBytes("b.txt", []byte("hello")), false)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !key.Equal(again) {
+		t.Errorf("expected identical content to have the same key. %s != %s", key, again)
+	}
+
+	if has, err := fs.Has(key); err != nil || !has {
+		t.Errorf("expected store to have %s. err: %v", key, err)
+	}
+	f, err := fs.Get(key)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	data, err := ioutil.ReadAll(f)
+	f.Close()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if string(data) != "hello" {
+		t.Errorf("data mismatch. expected: hello, got: %s", string(data))
+	}
+
+	dir := cafs.NewMemdir("/dir",
+		cafs.NewMemfileBytes("a.txt", []byte("a")),
+		cafs.NewMemfileBytes("b.txt", []byte("b")),
+	)
+	dirKey, err := fs.Put(dir, false)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	f, err = fs.Get(datastore.NewKey(dirKey.String() + "/b.txt"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	data, _ = ioutil.ReadAll(f)
+	f.Close()
+	if string(data) != "b" {
+		t.Errorf("data mismatch. expected: b, got: %s", string(data))
+	}
+
+	if _, err := fs.Get(datastore.NewKey("/map/nope")); err == nil {
+		t.Error("expected getting a key from another store to error")
+	}
+	if _, err := fs.Get(datastore.NewKey("/fs/nope")); err != datastore.ErrNotFound {
+		t.Errorf("expected missing key to return ErrNotFound, got: %v", err)
+	}
+
+	if size, err := fs.Size(); err != nil || size != 7 {
+		t.Errorf("expected store size to be 7 bytes, got: %d. err: %v", size, err)
+	}
+
+	keys, err := fs.Keys()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(keys) != 2 {
+		t.Errorf("expected 2 keys, got: %d", len(keys))
+	}
+
+	if err := fs.Delete(key); err != nil {
+		t.Fatal(err.Error())
+	}
+	if has, _ := fs.Has(key); has {
+		t.Errorf("expected %s to be deleted", key)
+	}
+	if err := fs.Delete(key); err != datastore.ErrNotFound {
+		t.Errorf("expected deleting a missing key to return ErrNotFound, got: %v", err)
+	}
+}
+
+func TestRepoWithFilestore(t *testing.T) {
+	path := filepath.Join(os.TempDir(), "qri_repo_filestore_test")
+	defer os.RemoveAll(path)
+
+	rmf := func(t *testing.T) repo.Repo {
+		if err := os.RemoveAll(path); err != nil {
+			t.Errorf("error removing files: %s", err.Error())
+		}
+
+		pro, err := profile.NewProfile(config.DefaultProfile())
+		if err != nil {
+			t.Error(err.Error())
+		}
+
+		fs, err := NewFilestore(filepath.Join(path, "store"))
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		r, err := NewRepo(fs, pro, nil, path)
+		if err != nil {
+			t.Errorf("error creating repo: %s", err.Error())
+		}
+		return r
+	}
+
+	test.RunRepoTests(t, rmf)
+}
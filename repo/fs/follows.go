@@ -0,0 +1,103 @@
+package fsrepo
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/qri-io/qri/repo"
+)
+
+// FollowStore is a file-based implementation of the repo.FollowStore
+// interface. It stores follows in a json file, holding the repo lock while
+// writing
+type FollowStore struct {
+	basepath
+}
+
+// NewFollowStore allocates a FollowStore
+func NewFollowStore(bp basepath) *FollowStore {
+	return &FollowStore{basepath: bp}
+}
+
+// PutFollow adds or replaces a follow
+func (s *FollowStore) PutFollow(f *repo.Follow) error {
+	if f.Peername == "" {
+		return repo.ErrPeernameRequired
+	}
+
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	fs, err := s.follows()
+	if err != nil {
+		return err
+	}
+
+	replaced := false
+	for i, fl := range fs {
+		if fl.Peername == f.Peername && fl.Name == f.Name {
+			fs[i] = f
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		fs = append(fs, f)
+		sort.Slice(fs, func(i, j int) bool {
+			if fs[i].Peername == fs[j].Peername {
+				return fs[i].Name < fs[j].Name
+			}
+			return fs[i].Peername < fs[j].Peername
+		})
+	}
+	return s.saveFile(fs, FileFollows)
+}
+
+// DeleteFollow removes a follow
+func (s *FollowStore) DeleteFollow(peername, name string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	fs, err := s.follows()
+	if err != nil {
+		return err
+	}
+	for i, f := range fs {
+		if f.Peername == peername && f.Name == name {
+			return s.saveFile(append(fs[:i], fs[i+1:]...), FileFollows)
+		}
+	}
+	return repo.ErrNotFound
+}
+
+// ListFollows lists all follows, ordered by peername then name
+func (s *FollowStore) ListFollows() ([]*repo.Follow, error) {
+	return s.follows()
+}
+
+// follows reads all follows from disk
+func (s *FollowStore) follows() ([]*repo.Follow, error) {
+	fs := []*repo.Follow{}
+	data, err := s.readBytes(FileFollows)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fs, nil
+		}
+		log.Debug(err.Error())
+		return nil, fmt.Errorf("error loading follows: %s", err.Error())
+	}
+
+	if err := json.Unmarshal(data, &fs); err != nil {
+		log.Debug(err.Error())
+		return nil, fmt.Errorf("error decoding follows: %s", err.Error())
+	}
+	return fs, nil
+}
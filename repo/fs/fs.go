@@ -7,6 +7,7 @@ import (
 
 	golog "github.com/ipfs/go-log"
 	"github.com/libp2p/go-libp2p-crypto"
+	"github.com/qri-io/bleve"
 	"github.com/qri-io/cafs"
 	"github.com/qri-io/dataset/dsgraph"
 	"github.com/qri-io/qri/actions"
@@ -27,7 +28,10 @@ type Repo struct {
 	basepath
 
 	Refstore
-	EventLog
+	*EventLog
+	*ChangeRequestStore
+	*TemplateStore
+	*FollowStore
 
 	profile *profile.Profile
 
@@ -52,6 +56,10 @@ func NewRepo(store cafs.Filestore, pro *profile.Profile, rc *regclient.Client, b
 		return nil, fmt.Errorf("Expected: PrivateKey")
 	}
 
+	if err := bp.repairFiles(); err != nil {
+		return nil, err
+	}
+
 	r := &Repo{
 		profile: pro,
 
@@ -61,6 +69,10 @@ func NewRepo(store cafs.Filestore, pro *profile.Profile, rc *regclient.Client, b
 		Refstore: Refstore{basepath: bp, store: store, file: FileRefstore},
 		EventLog: NewEventLog(base, FileEventLogs, store),
 
+		ChangeRequestStore: NewChangeRequestStore(bp),
+		TemplateStore:      NewTemplateStore(bp),
+		FollowStore:        NewFollowStore(bp),
+
 		profiles: NewProfileStore(bp),
 
 		registry: rc,
@@ -145,8 +157,54 @@ func (r *Repo) UpdateSearchIndex(store cafs.Filestore) error {
 	return search.IndexRepo(r, r.index)
 }
 
+// IndexedPaths lists the dataset paths in the search index
+func (r *Repo) IndexedPaths() ([]string, error) {
+	if r.index == nil {
+		return nil, repo.ErrSearchNotSupported
+	}
+
+	count, err := r.index.DocCount()
+	if err != nil {
+		return nil, err
+	}
+	req := bleve.NewSearchRequestOptions(bleve.NewMatchAllQuery(), int(count), 0, false)
+	res, err := r.index.Search(req)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, len(res.Hits))
+	for i, hit := range res.Hits {
+		paths[i] = hit.ID
+	}
+	return paths, nil
+}
+
+// RebuildSearchIndex drops all entries from the search index & re-indexes
+// every reference in the refstore
+func (r *Repo) RebuildSearchIndex() error {
+	paths, err := r.IndexedPaths()
+	if err != nil {
+		return err
+	}
+
+	batch := r.index.NewBatch()
+	for _, p := range paths {
+		batch.Delete(p)
+	}
+	if err := r.index.Batch(batch); err != nil {
+		return err
+	}
+	return search.IndexRepo(r, r.index)
+}
+
 // SetSelectedRefs sets the current reference selection
 func (r *Repo) SetSelectedRefs(sel []repo.DatasetRef) error {
+	unlock, err := r.basepath.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
 	return r.saveFile(sel, FileSelectedRefs)
 }
 
@@ -0,0 +1,59 @@
+package fsrepo
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/theckman/go-flock"
+)
+
+// repoLock serializes writes to a repo directory. The in-process mutex
+// guards against concurrent goroutines, the advisory file lock on
+// FileLockfile guards against other processes like a running API server, RPC
+// server & CLI commands sharing the same repo
+type repoLock struct {
+	mu    sync.Mutex
+	flock *flock.Flock
+}
+
+var (
+	locksMu sync.Mutex
+	// locks holds one repoLock per repo directory, so every store within a
+	// process that opens the same repo shares a lock
+	locks = map[string]*repoLock{}
+)
+
+// repoLock returns the lock for a repo directory
+func (bp basepath) repoLock() *repoLock {
+	key, err := filepath.Abs(string(bp))
+	if err != nil {
+		key = string(bp)
+	}
+
+	locksMu.Lock()
+	defer locksMu.Unlock()
+	l, ok := locks[key]
+	if !ok {
+		l = &repoLock{flock: flock.NewFlock(bp.filepath(FileLockfile))}
+		locks[key] = l
+	}
+	return l
+}
+
+// lock acquires the repo lock, blocking until it's available. callers must
+// call the returned unlock func once they're done writing
+func (bp basepath) lock() (unlock func(), err error) {
+	l := bp.repoLock()
+	l.mu.Lock()
+	if err := l.flock.Lock(); err != nil {
+		l.mu.Unlock()
+		return nil, fmt.Errorf("error locking repo: %s", err.Error())
+	}
+	return func() {
+		if err := l.flock.Unlock(); err != nil {
+			log.Debugf("error unlocking repo: %s", err.Error())
+		}
+		l.mu.Unlock()
+	}, nil
+}
@@ -5,12 +5,10 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
-	"sync"
 
 	"github.com/ipfs/go-datastore"
 	"github.com/qri-io/qri/config"
 	"github.com/qri-io/qri/repo/profile"
-	"github.com/theckman/go-flock"
 
 	"gx/ipfs/QmdVrMn1LhB4ybb8hMVaMLXnA8XRSewMnK6YqXKXoTcRvN/go-libp2p-peer"
 )
@@ -19,19 +17,14 @@ import (
 var ErrNotFound = fmt.Errorf("Not Found")
 
 // ProfileStore is an on-disk json file implementation of the
-// repo.Peers interface
+// repo.Peers interface. Writes hold the repo lock
 type ProfileStore struct {
-	sync.Mutex
 	basepath
-	flock *flock.Flock
 }
 
 // NewProfileStore allocates a ProfileStore
 func NewProfileStore(bp basepath) ProfileStore {
-	return ProfileStore{
-		basepath: bp,
-		flock:    flock.NewFlock(bp.filepath(FilePeers) + ".lock"),
-	}
+	return ProfileStore{basepath: bp}
 }
 
 // PutProfile adds a peer to the store
@@ -49,8 +42,11 @@ func (r ProfileStore) PutProfile(p *profile.Profile) error {
 	// explicitly remove Online flag
 	enc.Online = false
 
-	r.Lock()
-	defer r.Unlock()
+	unlock, err := r.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
 	ps, err := r.profiles()
 	if err != nil {
@@ -62,9 +58,6 @@ func (r ProfileStore) PutProfile(p *profile.Profile) error {
 
 // PeerIDs gives the peer.IDs list for a given peername
 func (r ProfileStore) PeerIDs(id profile.ID) ([]peer.ID, error) {
-	r.Lock()
-	defer r.Unlock()
-
 	ps, err := r.profiles()
 	if err != nil {
 		return nil, err
@@ -87,9 +80,6 @@ func (r ProfileStore) PeerIDs(id profile.ID) ([]peer.ID, error) {
 
 // List hands back the list of peers
 func (r ProfileStore) List() (map[profile.ID]*profile.Profile, error) {
-	r.Lock()
-	defer r.Unlock()
-
 	ps, err := r.profiles()
 	if err != nil && err.Error() == "EOF" {
 		return map[profile.ID]*profile.Profile{}, nil
@@ -111,9 +101,6 @@ func (r ProfileStore) List() (map[profile.ID]*profile.Profile, error) {
 
 // PeernameID gives the profile.ID for a given peername
 func (r ProfileStore) PeernameID(peername string) (profile.ID, error) {
-	r.Lock()
-	defer r.Unlock()
-
 	ps, err := r.profiles()
 	if err != nil {
 		return "", err
@@ -131,9 +118,6 @@ func (r ProfileStore) PeernameID(peername string) (profile.ID, error) {
 func (r ProfileStore) GetProfile(id profile.ID) (*profile.Profile, error) {
 	log.Debugf("get profile: %s", id.String())
 
-	r.Lock()
-	defer r.Unlock()
-
 	ps, err := r.profiles()
 	if err != nil {
 		return nil, err
@@ -156,9 +140,6 @@ func (r ProfileStore) GetProfile(id profile.ID) (*profile.Profile, error) {
 func (r ProfileStore) PeerProfile(id peer.ID) (*profile.Profile, error) {
 	log.Debugf("peerProfile: %s", id.Pretty())
 
-	r.Lock()
-	defer r.Unlock()
-
 	ps, err := r.profiles()
 	if err != nil {
 		return nil, err
@@ -180,8 +161,11 @@ func (r ProfileStore) PeerProfile(id peer.ID) (*profile.Profile, error) {
 
 // DeleteProfile removes a profile from the store
 func (r ProfileStore) DeleteProfile(id profile.ID) error {
-	r.Lock()
-	defer r.Unlock()
+	unlock, err := r.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
 	ps, err := r.profiles()
 	if err != nil {
@@ -192,35 +176,13 @@ func (r ProfileStore) DeleteProfile(id profile.ID) error {
 }
 
 func (r ProfileStore) saveFile(ps map[string]*config.ProfilePod, f File) error {
-
-	data, err := json.Marshal(ps)
-	if err != nil {
-		log.Debug(err.Error())
-		return err
-	}
-
 	log.Debugf("writing profiles: %s", r.filepath(f))
-	if err := r.flock.Lock(); err != nil {
-		return err
-	}
-	defer func() {
-		r.flock.Unlock()
-		log.Debugf("profiles written")
-	}()
-	return ioutil.WriteFile(r.filepath(f), data, os.ModePerm)
+	return r.basepath.saveFile(ps, f)
 }
 
 func (r *ProfileStore) profiles() (map[string]*config.ProfilePod, error) {
 	log.Debug("reading profiles")
 
-	if err := r.flock.Lock(); err != nil {
-		return nil, err
-	}
-	defer func() {
-		log.Debug("profiles read")
-		r.flock.Unlock()
-	}()
-
 	pp := map[string]*config.ProfilePod{}
 	data, err := ioutil.ReadFile(r.filepath(FilePeers))
 	if err != nil {
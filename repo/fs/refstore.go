@@ -43,6 +43,12 @@ func (n Refstore) PutRef(put repo.DatasetRef) (err error) {
 
 	p := repo.DatasetRef{Peername: put.Peername, ProfileID: put.ProfileID, Name: put.Name, Path: put.Path}
 
+	unlock, err := n.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	names, err := n.names()
 	if err != nil {
 		return err
@@ -97,6 +103,12 @@ func (n Refstore) GetRef(get repo.DatasetRef) (repo.DatasetRef, error) {
 
 // DeleteRef removes a name from the store
 func (n Refstore) DeleteRef(del repo.DatasetRef) error {
+	unlock, err := n.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	names, err := n.names()
 	if err != nil {
 		return err
@@ -118,12 +130,16 @@ func (n Refstore) DeleteRef(del repo.DatasetRef) error {
 	return n.save(names)
 }
 
-// References gives a set of dataset references from the store
+// References gives a set of dataset references from the store. A negative
+// limit returns all references after offset
 func (n Refstore) References(limit, offset int) ([]repo.DatasetRef, error) {
 	names, err := n.names()
 	if err != nil {
 		return nil, err
 	}
+	if limit < 0 {
+		limit = len(names)
+	}
 	res := make([]repo.DatasetRef, limit)
 	for i, ref := range names {
 		if i < offset {
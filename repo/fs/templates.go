@@ -0,0 +1,115 @@
+package fsrepo
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/qri-io/qri/repo"
+)
+
+// TemplateStore is a file-based implementation of the repo.TemplateStore
+// interface. It stores templates in a json file, holding the repo lock while
+// writing
+type TemplateStore struct {
+	basepath
+}
+
+// NewTemplateStore allocates a TemplateStore
+func NewTemplateStore(bp basepath) *TemplateStore {
+	return &TemplateStore{basepath: bp}
+}
+
+// PutTemplate adds or replaces a template
+func (s *TemplateStore) PutTemplate(t *repo.Template) error {
+	if t.Name == "" {
+		return repo.ErrNameRequired
+	}
+	if t.Path == "" {
+		return repo.ErrPathRequired
+	}
+
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	ts, err := s.templates()
+	if err != nil {
+		return err
+	}
+
+	replaced := false
+	for i, tmpl := range ts {
+		if tmpl.Name == t.Name {
+			ts[i] = t
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		ts = append(ts, t)
+		sort.Slice(ts, func(i, j int) bool { return ts[i].Name < ts[j].Name })
+	}
+	return s.saveFile(ts, FileTemplates)
+}
+
+// GetTemplate fetches a template by name
+func (s *TemplateStore) GetTemplate(name string) (*repo.Template, error) {
+	ts, err := s.templates()
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range ts {
+		if t.Name == name {
+			return t, nil
+		}
+	}
+	return nil, repo.ErrNotFound
+}
+
+// DeleteTemplate removes a template
+func (s *TemplateStore) DeleteTemplate(name string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	ts, err := s.templates()
+	if err != nil {
+		return err
+	}
+	for i, t := range ts {
+		if t.Name == name {
+			return s.saveFile(append(ts[:i], ts[i+1:]...), FileTemplates)
+		}
+	}
+	return repo.ErrNotFound
+}
+
+// ListTemplates lists all templates, ordered by name
+func (s *TemplateStore) ListTemplates() ([]*repo.Template, error) {
+	return s.templates()
+}
+
+// templates reads all templates from disk
+func (s *TemplateStore) templates() ([]*repo.Template, error) {
+	ts := []*repo.Template{}
+	data, err := s.readBytes(FileTemplates)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ts, nil
+		}
+		log.Debug(err.Error())
+		return nil, fmt.Errorf("error loading templates: %s", err.Error())
+	}
+
+	if err := json.Unmarshal(data, &ts); err != nil {
+		log.Debug(err.Error())
+		return nil, fmt.Errorf("error decoding templates: %s", err.Error())
+	}
+	return ts, nil
+}
@@ -115,6 +115,8 @@ func (nl NodeList) nodesFromDatasetRef(r Repo, ref *DatasetRef) *dsgraph.Node {
 
 // WalkRepoDatasets visits every dataset in the history of a user's namespace
 // Yes, this potentially a very expensive function to call, use sparingly.
+// Versions that fail to load are visited with a non-nil error & a nil
+// ref.Dataset, and history isn't followed past them
 func WalkRepoDatasets(r Repo, visit func(depth int, ref *DatasetRef, err error) (bool, error)) error {
 	pll := walkParallelism
 	store := r.Store()
@@ -129,8 +131,8 @@ func WalkRepoDatasets(r Repo, visit func(depth int, ref *DatasetRef, err error)
 		pll = count
 	}
 
-	doSection := func(idx, pageSize int, done chan error) error {
-		refs, err := r.References(pageSize, idx*pageSize)
+	doSection := func(offset, limit int, done chan error) error {
+		refs, err := r.References(limit, offset)
 		if err != nil {
 			done <- err
 			return err
@@ -140,8 +142,9 @@ func WalkRepoDatasets(r Repo, visit func(depth int, ref *DatasetRef, err error)
 			ds, err := dsfs.LoadDatasetRefs(store, datastore.NewKey(ref.Path))
 			if err != nil {
 				err = fmt.Errorf("error loading dataset: %s", err.Error())
+			} else {
+				ref.Dataset = ds.Encode()
 			}
-			ref.Dataset = ds.Encode()
 
 			kontinue, err := visit(0, &ref, err)
 			if err != nil {
@@ -159,10 +162,13 @@ func WalkRepoDatasets(r Repo, visit func(depth int, ref *DatasetRef, err error)
 
 				ds, err := dsfs.LoadDatasetRefs(store, datastore.NewKey(ref.Path))
 				if err != nil {
-					done <- err
-					return err
+					// history can't be followed past a version that doesn't load, visit
+					// it with the error & move on to the next reference
+					err = fmt.Errorf("error loading dataset: %s", err.Error())
+					ref.Dataset = nil
+				} else {
+					ref.Dataset = ds.Encode()
 				}
-				ref.Dataset = ds.Encode()
 				kontinue, err = visit(depth, &ref, err)
 				if err != nil {
 					done <- err
@@ -181,7 +187,12 @@ func WalkRepoDatasets(r Repo, visit func(depth int, ref *DatasetRef, err error)
 	pageSize := count / pll
 	done := make(chan error, pll)
 	for i := 0; i < pll; i++ {
-		go doSection(i, pageSize, done)
+		limit := pageSize
+		if i == pll-1 {
+			// last section picks up any remainder
+			limit = count - i*pageSize
+		}
+		go doSection(i*pageSize, limit, done)
 	}
 
 	for i := 0; i < pll; i++ {
@@ -12,6 +12,9 @@ import (
 type MemRepo struct {
 	*MemRefstore
 	*MemEventLog
+	*MemChangeRequestStore
+	*MemTemplateStore
+	*MemFollowStore
 
 	store        cafs.Filestore
 	graph        map[string]*dsgraph.Node
@@ -33,6 +36,10 @@ func NewMemRepo(p *profile.Profile, store cafs.Filestore, ps profile.Store, rc *
 		profile:     p,
 		profiles:    ps,
 		registry:    rc,
+
+		MemChangeRequestStore: &MemChangeRequestStore{},
+		MemTemplateStore:      &MemTemplateStore{},
+		MemFollowStore:        &MemFollowStore{},
 	}, nil
 }
 
@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+)
+
+// AuditRecord is a single write recorded by the audit-log middleware
+type AuditRecord struct {
+	Time time.Time `json:"time"`
+	// Op is the name of the repo or store method that was called
+	Op string `json:"op"`
+	// Ref is the dataset reference or store key written
+	Ref   string `json:"ref,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// NewAuditLog creates a middleware that records every write to a repo &
+// it's store, successful or not. arg is a file to append records to as
+// newline-delimited json. Without a file records are written to the log
+func NewAuditLog(arg string) (Middleware, error) {
+	var w io.Writer
+	if arg != "" {
+		f, err := os.OpenFile(arg, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, err
+		}
+		w = f
+	}
+	return func(r repo.Repo) (repo.Repo, error) {
+		return auditRepo{Repo{r}, &auditor{w: w}}, nil
+	}, nil
+}
+
+// auditor writes audit records
+type auditor struct {
+	lock sync.Mutex
+	w    io.Writer
+}
+
+func (a *auditor) record(op, ref string, err error) {
+	rec := AuditRecord{Time: time.Now(), Op: op, Ref: ref}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	data, e := json.Marshal(rec)
+	if e != nil {
+		log.Error(e.Error())
+		return
+	}
+
+	if a.w == nil {
+		log.Info(string(data))
+		return
+	}
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if _, e := a.w.Write(append(data, '\n')); e != nil {
+		log.Errorf("writing audit log: %s", e.Error())
+	}
+}
+
+type auditRepo struct {
+	Repo
+	a *auditor
+}
+
+func (r auditRepo) Store() cafs.Filestore {
+	inner := r.Repo.Store()
+	return WrapStore(inner, auditStore{Store{inner}, r.a})
+}
+
+func (r auditRepo) PutRef(ref repo.DatasetRef) error {
+	err := r.Repo.PutRef(ref)
+	r.a.record("PutRef", ref.String(), err)
+	return err
+}
+
+func (r auditRepo) DeleteRef(ref repo.DatasetRef) error {
+	err := r.Repo.DeleteRef(ref)
+	r.a.record("DeleteRef", ref.String(), err)
+	return err
+}
+
+func (r auditRepo) SetProfile(p *profile.Profile) error {
+	err := r.Repo.SetProfile(p)
+	r.a.record("SetProfile", p.Peername, err)
+	return err
+}
+
+func (r auditRepo) PutChangeRequest(cr *repo.ChangeRequest) error {
+	err := r.Repo.PutChangeRequest(cr)
+	r.a.record("PutChangeRequest", cr.ID, err)
+	return err
+}
+
+func (r auditRepo) DeleteChangeRequest(id string) error {
+	err := r.Repo.DeleteChangeRequest(id)
+	r.a.record("DeleteChangeRequest", id, err)
+	return err
+}
+
+type auditStore struct {
+	Store
+	a *auditor
+}
+
+func (s auditStore) Put(file cafs.File, pin bool) (datastore.Key, error) {
+	key, err := s.Store.Put(file, pin)
+	s.a.record("Put", key.String(), err)
+	return key, err
+}
+
+func (s auditStore) Delete(key datastore.Key) error {
+	err := s.Store.Delete(key)
+	s.a.record("Delete", key.String(), err)
+	return err
+}
+
+func (s auditStore) NewAdder(pin, wrap bool) (cafs.Adder, error) {
+	adder, err := s.Store.NewAdder(pin, wrap)
+	if err != nil {
+		return nil, err
+	}
+	return auditAdder{adder, s.a}, nil
+}
+
+type auditAdder struct {
+	cafs.Adder
+	a *auditor
+}
+
+func (a auditAdder) AddFile(f cafs.File) error {
+	err := a.Adder.AddFile(f)
+	a.a.record("AddFile", f.FileName(), err)
+	return err
+}
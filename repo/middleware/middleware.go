@@ -0,0 +1,316 @@
+// Package middleware wraps a repo.Repo with a chain of named middlewares
+// enabled by the repo.middleware section of qri's config. Middlewares add
+// policy, like refusing writes or enforcing a storage quota, without changing
+// the underlying repo implementation.
+//
+// Middlewares are configured as strings of the form "name" or "name:arg",
+// where arg is passed to the middleware's constructor:
+//
+//	audit-log[:path]  record every write to a file, or the log if no path is given
+//	read-only         refuse all writes
+//	quota:size        limit store size, eg: quota:10GB
+//	webhook:url       POST every repo event to url
+package middleware
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	golog "github.com/ipfs/go-log"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/qri/repo"
+)
+
+var log = golog.Logger("repo/middleware")
+
+// Middleware wraps a repo, returning a repo with added behaviour
+type Middleware func(r repo.Repo) (repo.Repo, error)
+
+// Constructor creates a Middleware. arg is the part of a config entry after
+// the first ":", or the empty string if there isn't one
+type Constructor func(arg string) (Middleware, error)
+
+var (
+	regLock      sync.Mutex
+	constructors = map[string]Constructor{}
+)
+
+func init() {
+	Register("audit-log", NewAuditLog)
+	Register("read-only", NewReadOnly)
+	Register("quota", NewQuota)
+	Register("webhook", NewWebhook)
+}
+
+// Register makes a middleware available by name. Registering an existing name
+// replaces the previous constructor
+func Register(name string, c Constructor) {
+	regLock.Lock()
+	defer regLock.Unlock()
+	constructors[name] = c
+}
+
+// Names lists registered middlewares in alphabetical order
+func Names() []string {
+	regLock.Lock()
+	defer regLock.Unlock()
+	names := make([]string, 0, len(constructors))
+	for name := range constructors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New creates middlewares from config entries
+func New(entries []string) ([]Middleware, error) {
+	mws := make([]Middleware, len(entries))
+	for i, entry := range entries {
+		name, arg := entry, ""
+		if pos := strings.Index(entry, ":"); pos >= 0 {
+			name, arg = entry[:pos], entry[pos+1:]
+		}
+
+		regLock.Lock()
+		c, ok := constructors[name]
+		regLock.Unlock()
+		if !ok {
+			return nil, fmt.Errorf("unknown repo middleware: '%s', available middleware: %s", name, strings.Join(Names(), ", "))
+		}
+
+		mw, err := c(arg)
+		if err != nil {
+			return nil, fmt.Errorf("repo middleware %s: %s", name, err.Error())
+		}
+		mws[i] = mw
+	}
+	return mws, nil
+}
+
+// Chain wraps a repo in middlewares. The first middleware is outermost, so it
+// sees calls first
+func Chain(r repo.Repo, mws ...Middleware) (repo.Repo, error) {
+	var err error
+	for i := len(mws) - 1; i >= 0; i-- {
+		if r, err = mws[i](r); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Apply wraps a repo in the middlewares listed by config entries
+func Apply(r repo.Repo, entries []string) (repo.Repo, error) {
+	if len(entries) == 0 {
+		return r, nil
+	}
+	mws, err := New(entries)
+	if err != nil {
+		return nil, err
+	}
+	return Chain(r, mws...)
+}
+
+// Repo is a base for middlewares. It passes every method through to the
+// wrapped repo, including the opt-in interfaces defined by package repo.
+// Middlewares embed Repo & override the methods they intercept. Opt-in
+// interfaces the wrapped repo doesn't support return the same errors
+// callers expect when a repo doesn't implement them
+type Repo struct {
+	repo.Repo
+}
+
+// Unwrap returns the wrapped repo
+func (r Repo) Unwrap() repo.Repo {
+	return r.Repo
+}
+
+// SetSelectedRefs implements the repo.RefSelector interface
+func (r Repo) SetSelectedRefs(sel []repo.DatasetRef) error {
+	if rs, ok := r.Repo.(repo.RefSelector); ok {
+		return rs.SetSelectedRefs(sel)
+	}
+	return repo.ErrRefSelectionNotSupported
+}
+
+// SelectedRefs implements the repo.RefSelector interface
+func (r Repo) SelectedRefs() ([]repo.DatasetRef, error) {
+	if rs, ok := r.Repo.(repo.RefSelector); ok {
+		return rs.SelectedRefs()
+	}
+	return nil, repo.ErrRefSelectionNotSupported
+}
+
+// Search implements the repo.Searchable interface
+func (r Repo) Search(p repo.SearchParams) ([]repo.DatasetRef, error) {
+	if s, ok := r.Repo.(repo.Searchable); ok {
+		return s.Search(p)
+	}
+	return nil, repo.ErrSearchNotSupported
+}
+
+// IndexedPaths implements the repo.SearchIndexer interface
+func (r Repo) IndexedPaths() ([]string, error) {
+	if s, ok := r.Repo.(repo.SearchIndexer); ok {
+		return s.IndexedPaths()
+	}
+	return nil, repo.ErrSearchNotSupported
+}
+
+// RebuildSearchIndex implements the repo.SearchIndexer interface
+func (r Repo) RebuildSearchIndex() error {
+	if s, ok := r.Repo.(repo.SearchIndexer); ok {
+		return s.RebuildSearchIndex()
+	}
+	return repo.ErrSearchNotSupported
+}
+
+// PutChangeRequest implements the repo.ChangeRequestStore interface
+func (r Repo) PutChangeRequest(cr *repo.ChangeRequest) error {
+	if s, ok := r.Repo.(repo.ChangeRequestStore); ok {
+		return s.PutChangeRequest(cr)
+	}
+	return repo.ErrChangeRequestsNotSupported
+}
+
+// GetChangeRequest implements the repo.ChangeRequestStore interface
+func (r Repo) GetChangeRequest(id string) (*repo.ChangeRequest, error) {
+	if s, ok := r.Repo.(repo.ChangeRequestStore); ok {
+		return s.GetChangeRequest(id)
+	}
+	return nil, repo.ErrChangeRequestsNotSupported
+}
+
+// DeleteChangeRequest implements the repo.ChangeRequestStore interface
+func (r Repo) DeleteChangeRequest(id string) error {
+	if s, ok := r.Repo.(repo.ChangeRequestStore); ok {
+		return s.DeleteChangeRequest(id)
+	}
+	return repo.ErrChangeRequestsNotSupported
+}
+
+// ListChangeRequests implements the repo.ChangeRequestStore interface
+func (r Repo) ListChangeRequests(limit, offset int) ([]*repo.ChangeRequest, error) {
+	if s, ok := r.Repo.(repo.ChangeRequestStore); ok {
+		return s.ListChangeRequests(limit, offset)
+	}
+	return nil, repo.ErrChangeRequestsNotSupported
+}
+
+// ChangeRequestsForTarget implements the repo.ChangeRequestStore interface
+func (r Repo) ChangeRequestsForTarget(target repo.DatasetRef, limit, offset int) ([]*repo.ChangeRequest, error) {
+	if s, ok := r.Repo.(repo.ChangeRequestStore); ok {
+		return s.ChangeRequestsForTarget(target, limit, offset)
+	}
+	return nil, repo.ErrChangeRequestsNotSupported
+}
+
+// PutTemplate implements the repo.TemplateStore interface
+func (r Repo) PutTemplate(t *repo.Template) error {
+	if s, ok := r.Repo.(repo.TemplateStore); ok {
+		return s.PutTemplate(t)
+	}
+	return repo.ErrTemplatesNotSupported
+}
+
+// GetTemplate implements the repo.TemplateStore interface
+func (r Repo) GetTemplate(name string) (*repo.Template, error) {
+	if s, ok := r.Repo.(repo.TemplateStore); ok {
+		return s.GetTemplate(name)
+	}
+	return nil, repo.ErrTemplatesNotSupported
+}
+
+// DeleteTemplate implements the repo.TemplateStore interface
+func (r Repo) DeleteTemplate(name string) error {
+	if s, ok := r.Repo.(repo.TemplateStore); ok {
+		return s.DeleteTemplate(name)
+	}
+	return repo.ErrTemplatesNotSupported
+}
+
+// ListTemplates implements the repo.TemplateStore interface
+func (r Repo) ListTemplates() ([]*repo.Template, error) {
+	if s, ok := r.Repo.(repo.TemplateStore); ok {
+		return s.ListTemplates()
+	}
+	return nil, repo.ErrTemplatesNotSupported
+}
+
+// PutFollow implements the repo.FollowStore interface
+func (r Repo) PutFollow(f *repo.Follow) error {
+	if s, ok := r.Repo.(repo.FollowStore); ok {
+		return s.PutFollow(f)
+	}
+	return repo.ErrFollowsNotSupported
+}
+
+// DeleteFollow implements the repo.FollowStore interface
+func (r Repo) DeleteFollow(peername, name string) error {
+	if s, ok := r.Repo.(repo.FollowStore); ok {
+		return s.DeleteFollow(peername, name)
+	}
+	return repo.ErrFollowsNotSupported
+}
+
+// ListFollows implements the repo.FollowStore interface
+func (r Repo) ListFollows() ([]*repo.Follow, error) {
+	if s, ok := r.Repo.(repo.FollowStore); ok {
+		return s.ListFollows()
+	}
+	return nil, repo.ErrFollowsNotSupported
+}
+
+// Store is a base for middlewares that intercept store methods. It passes
+// every method through to the wrapped store. Wrap a Store with WrapStore
+// before returning it from a repo so the pinning & fetching abilities of the
+// wrapped store are kept
+type Store struct {
+	cafs.Filestore
+}
+
+// UnwrapStore implements the repo.StoreWrapper interface
+func (s Store) UnwrapStore() cafs.Filestore {
+	return s.Filestore
+}
+
+// WrapStore returns a store that uses mw for all cafs.Filestore methods &
+// passes pinning & fetching through to inner if inner is a cafs.Pinner or
+// cafs.Fetcher, keeping type assertions on the store intact
+func WrapStore(inner cafs.Filestore, mw cafs.Filestore) cafs.Filestore {
+	pinner, isPinner := inner.(cafs.Pinner)
+	fetcher, isFetcher := inner.(cafs.Fetcher)
+	switch {
+	case isPinner && isFetcher:
+		return pinFetchStore{mw, pinner, fetcher}
+	case isPinner:
+		return pinStore{mw, pinner}
+	case isFetcher:
+		return fetchStore{mw, fetcher}
+	}
+	return mw
+}
+
+type pinStore struct {
+	cafs.Filestore
+	cafs.Pinner
+}
+
+func (s pinStore) UnwrapStore() cafs.Filestore { return s.Filestore }
+
+type fetchStore struct {
+	cafs.Filestore
+	cafs.Fetcher
+}
+
+func (s fetchStore) UnwrapStore() cafs.Filestore { return s.Filestore }
+
+type pinFetchStore struct {
+	cafs.Filestore
+	cafs.Pinner
+	cafs.Fetcher
+}
+
+func (s pinFetchStore) UnwrapStore() cafs.Filestore { return s.Filestore }
@@ -0,0 +1,287 @@
+package middleware
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+)
+
+func newMemRepo(t *testing.T) repo.Repo {
+	r, err := repo.NewMemRepo(&profile.Profile{Peername: "peer"}, cafs.NewMapstore(), profile.NewMemStore(), nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return r
+}
+
+func TestNew(t *testing.T) {
+	cases := []struct {
+		entries []string
+		err     string
+	}{
+		{[]string{}, ""},
+		{[]string{"read-only", "audit-log", "quota:10GB", "webhook:https://example.com/hook"}, ""},
+		{[]string{"nope"}, "unknown repo middleware: 'nope', available middleware: audit-log, quota, read-only, webhook"},
+		{[]string{"read-only:yes"}, "repo middleware read-only: read-only doesn't accept an argument"},
+		{[]string{"quota"}, "repo middleware quota: invalid size: ''"},
+		{[]string{"quota:0"}, "repo middleware quota: quota must be greater than zero"},
+		{[]string{"webhook:ftp://example.com"}, "repo middleware webhook: webhook requires an http or https url, eg: webhook:https://example.com/hook"},
+	}
+
+	for i, c := range cases {
+		mws, err := New(c.entries)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%v'", i, c.err, err)
+			continue
+		}
+		if err == nil && len(mws) != len(c.entries) {
+			t.Errorf("case %d expected %d middlewares, got: %d", i, len(c.entries), len(mws))
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	cases := []struct {
+		in  string
+		out int64
+		err bool
+	}{
+		{"100", 100, false},
+		{"100B", 100, false},
+		{"1KB", 1024, false},
+		{"1.5 mb", 1536 * 1024, false},
+		{"10GB", 10 << 30, false},
+		{"2TB", 2 << 40, false},
+		{"lots", 0, true},
+		{"-1", 0, true},
+	}
+	for i, c := range cases {
+		got, err := ParseSize(c.in)
+		if (err != nil) != c.err {
+			t.Errorf("case %d error mismatch. expected error: %t, got: %v", i, c.err, err)
+			continue
+		}
+		if got != c.out {
+			t.Errorf("case %d expected: %d, got: %d", i, c.out, got)
+		}
+	}
+}
+
+func TestChain(t *testing.T) {
+	var calls []string
+	named := func(name string) Middleware {
+		return func(r repo.Repo) (repo.Repo, error) {
+			return orderRepo{Repo{r}, name, &calls}, nil
+		}
+	}
+
+	r, err := Chain(newMemRepo(t), named("a"), named("b"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := r.PutRef(repo.DatasetRef{Peername: "peer", Name: "a", Path: "/map/a"}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(calls) != 2 || calls[0] != "a" || calls[1] != "b" {
+		t.Errorf("expected middleware to be called in order [a b], got: %v", calls)
+	}
+
+	// opt-in interfaces pass through to the wrapped repo
+	if _, ok := r.(repo.ChangeRequestStore); !ok {
+		t.Error("expected wrapped repo to be a ChangeRequestStore")
+	}
+	if err := r.(repo.RefSelector).SetSelectedRefs([]repo.DatasetRef{{Peername: "peer", Name: "a"}}); err != nil {
+		t.Errorf("expected ref selection to pass through, got: %s", err)
+	}
+	if _, err := r.(repo.Searchable).Search(repo.SearchParams{Q: "a"}); err != repo.ErrSearchNotSupported {
+		t.Errorf("expected search on a repo without search to return ErrSearchNotSupported, got: %v", err)
+	}
+}
+
+type orderRepo struct {
+	Repo
+	name  string
+	calls *[]string
+}
+
+func (r orderRepo) PutRef(ref repo.DatasetRef) error {
+	*r.calls = append(*r.calls, r.name)
+	return r.Repo.PutRef(ref)
+}
+
+func TestReadOnly(t *testing.T) {
+	inner := newMemRepo(t)
+	ref := repo.DatasetRef{Peername: "peer", Name: "a", Path: "/map/a"}
+	if err := inner.PutRef(ref); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	r, err := Apply(inner, []string{"read-only"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if _, err := r.GetRef(repo.DatasetRef{Peername: "peer", Name: "a"}); err != nil {
+		t.Errorf("expected reads to succeed, got: %s", err)
+	}
+	if err := r.PutRef(repo.DatasetRef{Peername: "peer", Name: "b", Path: "/map/b"}); err != ErrReadOnly {
+		t.Errorf("expected PutRef to return ErrReadOnly, got: %v", err)
+	}
+	if err := r.DeleteRef(ref); err != ErrReadOnly {
+		t.Errorf("expected DeleteRef to return ErrReadOnly, got: %v", err)
+	}
+	if _, err := r.Store().Put(cafs.NewMemfileBytes("a.txt", []byte("a")), false); err != ErrReadOnly {
+		t.Errorf("expected store Put to return ErrReadOnly, got: %v", err)
+	}
+	if _, err := r.Store().NewAdder(false, true); err != ErrReadOnly {
+		t.Errorf("expected store NewAdder to return ErrReadOnly, got: %v", err)
+	}
+
+	// every write the base middleware forwards must be refused
+	writes := map[string]func() error{
+		"SetSelectedRefs": func() error {
+			return r.(repo.RefSelector).SetSelectedRefs([]repo.DatasetRef{ref})
+		},
+		"PutChangeRequest": func() error {
+			return r.(repo.ChangeRequestStore).PutChangeRequest(&repo.ChangeRequest{ID: "/map/cr"})
+		},
+		"DeleteChangeRequest": func() error {
+			return r.(repo.ChangeRequestStore).DeleteChangeRequest("/map/cr")
+		},
+		"PutTemplate": func() error {
+			return r.(repo.TemplateStore).PutTemplate(&repo.Template{Name: "tmpl", Path: "/map/tmpl"})
+		},
+		"DeleteTemplate": func() error {
+			return r.(repo.TemplateStore).DeleteTemplate("tmpl")
+		},
+		"PutFollow": func() error {
+			return r.(repo.FollowStore).PutFollow(&repo.Follow{Peername: "peer"})
+		},
+		"DeleteFollow": func() error {
+			return r.(repo.FollowStore).DeleteFollow("peer", "")
+		},
+	}
+	for name, write := range writes {
+		if err := write(); err != ErrReadOnly {
+			t.Errorf("expected %s to return ErrReadOnly, got: %v", name, err)
+		}
+	}
+	if repo.BaseStore(r.Store()) != inner.Store() {
+		t.Error("expected BaseStore to unwrap the read-only store")
+	}
+}
+
+func TestQuota(t *testing.T) {
+	r, err := Apply(newMemRepo(t), []string{"quota:10"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if _, err := r.Store().Put(cafs.NewMemfileBytes("a.txt", []byte("12345")), false); err != nil {
+		t.Errorf("expected write within quota to succeed, got: %s", err)
+	}
+	if _, err := r.Store().Put(cafs.NewMemfileBytes("b.txt", []byte("123456")), false); err == nil {
+		t.Error("expected write past quota to fail")
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "qri_audit_log_test")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+
+	r, err := Apply(newMemRepo(t), []string{"audit-log:" + path})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if err := r.PutRef(repo.DatasetRef{Peername: "peer", Name: "a", Path: "/map/a"}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := r.Store().Put(cafs.NewMemfileBytes("a.txt", []byte("a")), false); err != nil {
+		t.Fatal(err.Error())
+	}
+	r.DeleteRef(repo.DatasetRef{Peername: "peer", Name: "nope", Path: "/map/nope"})
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer f.Close()
+
+	recs := []AuditRecord{}
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		rec := AuditRecord{}
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			t.Fatal(err.Error())
+		}
+		recs = append(recs, rec)
+	}
+
+	expect := []string{"PutRef", "Put", "DeleteRef"}
+	if len(recs) != len(expect) {
+		t.Fatalf("expected %d audit records, got: %d", len(expect), len(recs))
+	}
+	for i, op := range expect {
+		if recs[i].Op != op {
+			t.Errorf("record %d op mismatch. expected: %s, got: %s", i, op, recs[i].Op)
+		}
+	}
+	if recs[2].Error == "" {
+		t.Error("expected failed DeleteRef to record an error")
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	received := make(chan *repo.Event, 1)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		e := &repo.Event{}
+		if err := json.NewDecoder(r.Body).Decode(e); err != nil {
+			t.Error(err.Error())
+		}
+		received <- e
+	}))
+	defer s.Close()
+
+	r, err := Apply(newMemRepo(t), []string{"webhook:" + s.URL})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	ref := repo.DatasetRef{Peername: "peer", Name: "a", Path: "/map/a"}
+	if err := r.LogEventDetails(repo.ETDsCreated, time.Unix(100, 0), "", ref, &repo.EventParams{Peername: "peer"}); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	select {
+	case e := <-received:
+		if e.Type != repo.ETDsCreated || e.Ref.Path != ref.Path || e.Params == nil || e.Params.Peername != "peer" {
+			t.Errorf("webhook event mismatch: %v", e)
+		}
+	case <-time.After(time.Second * 5):
+		t.Error("timed out waiting for webhook")
+	}
+
+	// the event is also recorded in the wrapped repo
+	events, err := r.Events(10, 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(events) != 1 {
+		t.Errorf("expected 1 event, got: %d", len(events))
+	}
+}
@@ -0,0 +1,174 @@
+package middleware
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/qri/repo"
+)
+
+// ErrQuotaExceeded is returned for writes that would grow a store past it's
+// quota
+var ErrQuotaExceeded = fmt.Errorf("repo store quota exceeded")
+
+// Sizer is an opt-in interface for stores that can report the total size of
+// their contents in bytes
+type Sizer interface {
+	Size() (int64, error)
+}
+
+// NewQuota creates a middleware that limits the size of a repo's store. arg
+// is the maximum size, either a number of bytes or a number with a KB, MB,
+// GB or TB suffix, eg: quota:10GB. Stores that implement Sizer start from their
+// reported size, all other stores count bytes written since the repo was
+// opened
+func NewQuota(arg string) (Middleware, error) {
+	limit, err := ParseSize(arg)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		return nil, fmt.Errorf("quota must be greater than zero")
+	}
+
+	return func(r repo.Repo) (repo.Repo, error) {
+		q := &quota{limit: limit, store: r.Store()}
+		if s, ok := repo.BaseStore(q.store).(Sizer); ok {
+			size, err := s.Size()
+			if err != nil {
+				return nil, fmt.Errorf("error calculating store size: %s", err.Error())
+			}
+			q.used = size
+		}
+		return quotaRepo{Repo{r}, q}, nil
+	}, nil
+}
+
+// ParseSize parses a size in bytes with an optional KB, MB, GB or TB suffix.
+// Suffixes are powers of 1024
+func ParseSize(s string) (int64, error) {
+	str := strings.ToUpper(strings.TrimSpace(s))
+	mult := int64(1)
+	for i, suffix := range []string{"KB", "MB", "GB", "TB"} {
+		if strings.HasSuffix(str, suffix) {
+			mult = 1 << (10 * uint(i+1))
+			str = strings.TrimSpace(strings.TrimSuffix(str, suffix))
+			break
+		}
+	}
+	str = strings.TrimSuffix(str, "B")
+
+	n, err := strconv.ParseFloat(str, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size: '%s'", s)
+	}
+	return int64(n * float64(mult)), nil
+}
+
+// quota tracks store usage
+type quota struct {
+	lock  sync.Mutex
+	limit int64
+	used  int64
+	store cafs.Filestore
+}
+
+// reserve adds n bytes to usage, erroring if the quota would be exceeded
+func (q *quota) reserve(n int64) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.used+n > q.limit {
+		return ErrQuotaExceeded
+	}
+	q.used += n
+	return nil
+}
+
+// recount refreshes usage from stores that can report their size. Other
+// stores can't tell how much space a delete freed, so usage isn't reduced
+func (q *quota) recount() {
+	if s, ok := repo.BaseStore(q.store).(Sizer); ok {
+		if size, err := s.Size(); err == nil {
+			q.lock.Lock()
+			q.used = size
+			q.lock.Unlock()
+		}
+	}
+}
+
+type quotaRepo struct {
+	Repo
+	q *quota
+}
+
+func (r quotaRepo) Store() cafs.Filestore {
+	inner := r.Repo.Store()
+	return WrapStore(inner, quotaStore{Store{inner}, r.q})
+}
+
+type quotaStore struct {
+	Store
+	q *quota
+}
+
+func (s quotaStore) Put(file cafs.File, pin bool) (datastore.Key, error) {
+	return s.Store.Put(quotaFile{file, s.q}, pin)
+}
+
+func (s quotaStore) Delete(key datastore.Key) error {
+	if err := s.Store.Delete(key); err != nil {
+		return err
+	}
+	s.q.recount()
+	return nil
+}
+
+func (s quotaStore) NewAdder(pin, wrap bool) (cafs.Adder, error) {
+	adder, err := s.Store.NewAdder(pin, wrap)
+	if err != nil {
+		return nil, err
+	}
+	return quotaAdder{adder, s.q}, nil
+}
+
+type quotaAdder struct {
+	cafs.Adder
+	q *quota
+}
+
+func (a quotaAdder) AddFile(f cafs.File) error {
+	return a.Adder.AddFile(quotaFile{f, a.q})
+}
+
+// quotaFile counts bytes as they're read, failing reads that exceed the
+// quota. Stores read files as they're written, so a failed read aborts the
+// write
+type quotaFile struct {
+	cafs.File
+	q *quota
+}
+
+func (f quotaFile) Read(p []byte) (int, error) {
+	n, err := f.File.Read(p)
+	if n > 0 {
+		if qerr := f.q.reserve(int64(n)); qerr != nil {
+			return n, qerr
+		}
+	}
+	return n, err
+}
+
+func (f quotaFile) NextFile() (cafs.File, error) {
+	child, err := f.File.NextFile()
+	if err != nil {
+		return child, err
+	}
+	return quotaFile{child, f.q}, nil
+}
+
+var _ io.Reader = quotaFile{}
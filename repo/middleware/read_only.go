@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"fmt"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/qri/repo"
+)
+
+// ErrReadOnly is returned for writes to a read-only repo
+var ErrReadOnly = fmt.Errorf("repo is read-only")
+
+// NewReadOnly creates a middleware that refuses all changes to refs, the
+// store, selected refs, change requests, templates & follows. Events & peer
+// profiles are still recorded so a read-only repo can connect to the network
+func NewReadOnly(arg string) (Middleware, error) {
+	if arg != "" {
+		return nil, fmt.Errorf("read-only doesn't accept an argument")
+	}
+	return func(r repo.Repo) (repo.Repo, error) {
+		return readOnlyRepo{Repo{r}}, nil
+	}, nil
+}
+
+type readOnlyRepo struct {
+	Repo
+}
+
+func (r readOnlyRepo) Store() cafs.Filestore {
+	inner := r.Repo.Store()
+	return WrapStore(inner, readOnlyStore{Store{inner}})
+}
+
+func (r readOnlyRepo) PutRef(ref repo.DatasetRef) error              { return ErrReadOnly }
+func (r readOnlyRepo) DeleteRef(ref repo.DatasetRef) error           { return ErrReadOnly }
+func (r readOnlyRepo) SetSelectedRefs(sel []repo.DatasetRef) error   { return ErrReadOnly }
+func (r readOnlyRepo) PutChangeRequest(cr *repo.ChangeRequest) error { return ErrReadOnly }
+func (r readOnlyRepo) DeleteChangeRequest(id string) error           { return ErrReadOnly }
+func (r readOnlyRepo) PutTemplate(t *repo.Template) error            { return ErrReadOnly }
+func (r readOnlyRepo) DeleteTemplate(name string) error              { return ErrReadOnly }
+func (r readOnlyRepo) PutFollow(f *repo.Follow) error                { return ErrReadOnly }
+func (r readOnlyRepo) DeleteFollow(peername, name string) error      { return ErrReadOnly }
+
+type readOnlyStore struct {
+	Store
+}
+
+func (s readOnlyStore) Put(file cafs.File, pin bool) (datastore.Key, error) {
+	return datastore.NewKey(""), ErrReadOnly
+}
+func (s readOnlyStore) Delete(key datastore.Key) error { return ErrReadOnly }
+func (s readOnlyStore) NewAdder(pin, wrap bool) (cafs.Adder, error) {
+	return nil, ErrReadOnly
+}
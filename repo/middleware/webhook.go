@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/qri-io/qri/repo"
+
+	peer "gx/ipfs/QmdVrMn1LhB4ybb8hMVaMLXnA8XRSewMnK6YqXKXoTcRvN/go-libp2p-peer"
+)
+
+// WebhookTimeout is the maximum time to wait for a webhook request
+var WebhookTimeout = time.Second * 10
+
+// NewWebhook creates a middleware that POSTs every event logged by a repo to
+// a url as json. Requests are sent in the background, failures are logged &
+// never block the repo
+func NewWebhook(arg string) (Middleware, error) {
+	u, err := url.Parse(arg)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("webhook requires an http or https url, eg: webhook:https://example.com/hook")
+	}
+
+	return func(r repo.Repo) (repo.Repo, error) {
+		return webhookRepo{
+			Repo:   Repo{r},
+			url:    u.String(),
+			client: &http.Client{Timeout: WebhookTimeout},
+		}, nil
+	}, nil
+}
+
+type webhookRepo struct {
+	Repo
+	url    string
+	client *http.Client
+}
+
+func (r webhookRepo) LogEvent(t repo.EventType, ref repo.DatasetRef) error {
+	if err := r.Repo.LogEvent(t, ref); err != nil {
+		return err
+	}
+	go r.send(&repo.Event{Time: time.Now(), Type: t, Ref: ref})
+	return nil
+}
+
+func (r webhookRepo) LogEventDetails(t repo.EventType, when time.Time, peerID peer.ID, ref repo.DatasetRef, params *repo.EventParams) error {
+	if err := r.Repo.LogEventDetails(t, when, peerID, ref, params); err != nil {
+		return err
+	}
+	go r.send(&repo.Event{Time: when, Type: t, Ref: ref, PeerID: peerID, Params: params})
+	return nil
+}
+
+func (r webhookRepo) send(e *repo.Event) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		log.Errorf("encoding webhook event: %s", err.Error())
+		return
+	}
+	res, err := r.client.Post(r.url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		log.Errorf("sending webhook: %s", err.Error())
+		return
+	}
+	res.Body.Close()
+	if res.StatusCode >= 300 {
+		log.Errorf("webhook %s responded with status %d", r.url, res.StatusCode)
+	}
+}
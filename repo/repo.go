@@ -79,7 +79,39 @@ type SearchParams struct {
 	Limit, Offset int
 }
 
+// ErrSearchNotSupported is the expected error for when the Searchable
+// interface is *not* implemented
+var ErrSearchNotSupported = fmt.Errorf("repo: search not supported")
+
 // Searchable is an opt-in interface for supporting repository search
 type Searchable interface {
 	Search(p SearchParams) ([]DatasetRef, error)
 }
+
+// SearchIndexer is an opt-in interface for repos that keep a search index of
+// the datasets in their refstore
+type SearchIndexer interface {
+	// IndexedPaths lists the dataset paths in the search index
+	IndexedPaths() ([]string, error)
+	// RebuildSearchIndex drops all entries from the search index & indexes
+	// every reference in the refstore
+	RebuildSearchIndex() error
+}
+
+// StoreWrapper is implemented by stores that wrap another store to add
+// behaviour, like repo middleware
+type StoreWrapper interface {
+	UnwrapStore() cafs.Filestore
+}
+
+// BaseStore removes all wrapping from a store, returning the underlying
+// store. Use BaseStore when checking the concrete type of a repo's store
+func BaseStore(s cafs.Filestore) cafs.Filestore {
+	for {
+		w, ok := s.(StoreWrapper)
+		if !ok {
+			return s
+		}
+		s = w.UnwrapStore()
+	}
+}
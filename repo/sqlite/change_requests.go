@@ -0,0 +1,93 @@
+package sqliterepo
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/qri-io/qri/repo"
+)
+
+// ChangeRequestStore is a sqlite implementation of the
+// repo.ChangeRequestStore interface
+type ChangeRequestStore struct {
+	db *sql.DB
+}
+
+// PutChangeRequest adds or updates a change request
+func (s *ChangeRequestStore) PutChangeRequest(cr *repo.ChangeRequest) error {
+	if cr.ID == "" {
+		return repo.ErrPathRequired
+	}
+
+	data, err := json.Marshal(cr)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`INSERT OR REPLACE INTO change_requests (id, created, target_profile_id, target_peername, target_name, data) VALUES (?, ?, ?, ?, ?, ?)`,
+		cr.ID, cr.Created.UnixNano(), cr.Target.ProfileID.String(), cr.Target.Peername, cr.Target.Name, string(data))
+	return err
+}
+
+// GetChangeRequest fetches a change request by ID
+func (s *ChangeRequestStore) GetChangeRequest(id string) (*repo.ChangeRequest, error) {
+	crs, err := s.query(`SELECT data FROM change_requests WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(crs) == 0 {
+		return nil, repo.ErrNotFound
+	}
+	return crs[0], nil
+}
+
+// DeleteChangeRequest removes a change request
+func (s *ChangeRequestStore) DeleteChangeRequest(id string) error {
+	res, err := s.db.Exec(`DELETE FROM change_requests WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return repo.ErrNotFound
+	}
+	return nil
+}
+
+// ListChangeRequests lists change requests, most recently created first
+func (s *ChangeRequestStore) ListChangeRequests(limit, offset int) ([]*repo.ChangeRequest, error) {
+	return s.query(`SELECT data FROM change_requests ORDER BY created DESC LIMIT ? OFFSET ?`, limit, offset)
+}
+
+// ChangeRequestsForTarget lists change requests for a dataset, most recently
+// created first
+func (s *ChangeRequestStore) ChangeRequestsForTarget(target repo.DatasetRef, limit, offset int) ([]*repo.ChangeRequest, error) {
+	return s.query(`SELECT data FROM change_requests
+		WHERE target_name != '' AND target_name = ?
+			AND ((? != '' AND target_peername = ?) OR (? != '' AND target_profile_id = ?))
+		ORDER BY created DESC LIMIT ? OFFSET ?`,
+		target.Name, target.Peername, target.Peername, target.ProfileID.String(), target.ProfileID.String(), limit, offset)
+}
+
+// query runs a select of the data column, decoding change requests
+func (s *ChangeRequestStore) query(query string, args ...interface{}) ([]*repo.ChangeRequest, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	crs := []*repo.ChangeRequest{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		cr := &repo.ChangeRequest{}
+		if err := json.Unmarshal([]byte(data), cr); err != nil {
+			return nil, err
+		}
+		crs = append(crs, cr)
+	}
+	return crs, rows.Err()
+}
@@ -0,0 +1,111 @@
+package sqliterepo
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/qri-io/qri/repo"
+	peer "gx/ipfs/QmdVrMn1LhB4ybb8hMVaMLXnA8XRSewMnK6YqXKXoTcRvN/go-libp2p-peer"
+)
+
+// eventColumns are the columns scanEvents reads, in order
+const eventColumns = `time, type, profile_id, peername, name, path, peer_id, params`
+
+// EventLog is a sqlite implementation of the repo.EventLog interface.
+// Events are stored with nanosecond timestamps & indexed by type, path &
+// name
+type EventLog struct {
+	db *sql.DB
+}
+
+// LogEvent adds an event to the log
+func (l *EventLog) LogEvent(t repo.EventType, ref repo.DatasetRef) error {
+	return l.LogEventDetails(t, time.Now(), "", ref, nil)
+}
+
+// LogEventDetails adds an event to the log with the time it occurred, the
+// peer that performed it & structured details
+func (l *EventLog) LogEventDetails(t repo.EventType, when time.Time, peerID peer.ID, ref repo.DatasetRef, params *repo.EventParams) error {
+	var p sql.NullString
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return err
+		}
+		p = sql.NullString{String: string(data), Valid: true}
+	}
+
+	pid := ""
+	if peerID != "" {
+		pid = peerID.Pretty()
+	}
+
+	_, err := l.db.Exec(`INSERT INTO events (time, type, profile_id, peername, name, path, peer_id, params) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		when.UnixNano(), string(t), ref.ProfileID.String(), ref.Peername, ref.Name, ref.Path, pid, p)
+	return err
+}
+
+// Events lists events, newest first
+func (l *EventLog) Events(limit, offset int) ([]*repo.Event, error) {
+	return l.query(`SELECT `+eventColumns+` FROM events ORDER BY time DESC, id DESC LIMIT ? OFFSET ?`, limit, offset)
+}
+
+// EventsSince lists all events after a given time, oldest first
+func (l *EventLog) EventsSince(t time.Time) ([]*repo.Event, error) {
+	return l.query(`SELECT `+eventColumns+` FROM events WHERE time > ? ORDER BY time, id`, t.UnixNano())
+}
+
+// EventsForRef lists events that reference a dataset, newest first
+func (l *EventLog) EventsForRef(ref repo.DatasetRef, limit, offset int) ([]*repo.Event, error) {
+	return l.query(`SELECT `+eventColumns+` FROM events
+		WHERE (path != '' AND path = ?)
+			OR (name != '' AND name = ? AND ((? != '' AND peername = ?) OR (? != '' AND profile_id = ?)))
+		ORDER BY time DESC, id DESC LIMIT ? OFFSET ?`,
+		ref.Path, ref.Name, ref.Peername, ref.Peername, ref.ProfileID.String(), ref.ProfileID.String(), limit, offset)
+}
+
+// EventsByType lists events of a given type, newest first
+func (l *EventLog) EventsByType(t repo.EventType, limit, offset int) ([]*repo.Event, error) {
+	return l.query(`SELECT `+eventColumns+` FROM events WHERE type = ? ORDER BY time DESC, id DESC LIMIT ? OFFSET ?`, string(t), limit, offset)
+}
+
+// query runs a select of eventColumns, scanning rows into events
+func (l *EventLog) query(query string, args ...interface{}) ([]*repo.Event, error) {
+	rows, err := l.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*repo.Event{}
+	for rows.Next() {
+		var (
+			nanos        int64
+			et, pro, pid string
+			params       sql.NullString
+			e            = &repo.Event{}
+		)
+		if err := rows.Scan(&nanos, &et, &pro, &e.Ref.Peername, &e.Ref.Name, &e.Ref.Path, &pid, &params); err != nil {
+			return nil, err
+		}
+		e.Time = time.Unix(0, nanos)
+		e.Type = repo.EventType(et)
+		if e.Ref.ProfileID, err = decodeProfileID(pro); err != nil {
+			return nil, err
+		}
+		if pid != "" {
+			if e.PeerID, err = peer.IDB58Decode(pid); err != nil {
+				return nil, err
+			}
+		}
+		if params.Valid {
+			e.Params = &repo.EventParams{}
+			if err := json.Unmarshal([]byte(params.String), e.Params); err != nil {
+				return nil, err
+			}
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
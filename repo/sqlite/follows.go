@@ -0,0 +1,64 @@
+package sqliterepo
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/qri-io/qri/repo"
+)
+
+// FollowStore is a sqlite implementation of the repo.FollowStore interface
+type FollowStore struct {
+	db *sql.DB
+}
+
+// PutFollow adds or replaces a follow
+func (s *FollowStore) PutFollow(f *repo.Follow) error {
+	if f.Peername == "" {
+		return repo.ErrPeernameRequired
+	}
+	_, err := s.db.Exec(`INSERT OR REPLACE INTO follows (peername, name, profile_id, created) VALUES (?, ?, ?, ?)`,
+		f.Peername, f.Name, f.ProfileID.String(), f.Created.UnixNano())
+	return err
+}
+
+// DeleteFollow removes a follow
+func (s *FollowStore) DeleteFollow(peername, name string) error {
+	res, err := s.db.Exec(`DELETE FROM follows WHERE peername = ? AND name = ?`, peername, name)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return repo.ErrNotFound
+	}
+	return nil
+}
+
+// ListFollows lists all follows, ordered by peername then name
+func (s *FollowStore) ListFollows() ([]*repo.Follow, error) {
+	rows, err := s.db.Query(`SELECT peername, name, profile_id, created FROM follows ORDER BY peername, name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	fs := []*repo.Follow{}
+	for rows.Next() {
+		var (
+			f         = &repo.Follow{}
+			profileID string
+			created   int64
+		)
+		if err := rows.Scan(&f.Peername, &f.Name, &profileID, &created); err != nil {
+			return nil, err
+		}
+		if f.ProfileID, err = decodeProfileID(profileID); err != nil {
+			return nil, err
+		}
+		f.Created = time.Unix(0, created)
+		fs = append(fs, f)
+	}
+	return fs, rows.Err()
+}
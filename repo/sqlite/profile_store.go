@@ -0,0 +1,143 @@
+package sqliterepo
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/repo/profile"
+
+	"gx/ipfs/QmdVrMn1LhB4ybb8hMVaMLXnA8XRSewMnK6YqXKXoTcRvN/go-libp2p-peer"
+)
+
+// ProfileStore is a sqlite implementation of the profile.Store interface.
+// Profiles are stored encoded as json, with a separate table of the peer IDs
+// each profile uses
+type ProfileStore struct {
+	db *sql.DB
+}
+
+// PutProfile adds or updates a profile in the store
+func (s *ProfileStore) PutProfile(p *profile.Profile) error {
+	log.Debugf("put profile: %s", p.ID.String())
+	if p.ID.String() == "" {
+		return fmt.Errorf("profile ID is required")
+	}
+
+	enc, err := p.Encode()
+	if err != nil {
+		return fmt.Errorf("error encoding profile: %s", err.Error())
+	}
+	// explicitly remove Online flag
+	enc.Online = false
+
+	data, err := json.Marshal(enc)
+	if err != nil {
+		return err
+	}
+
+	id := p.ID.String()
+	return transact(s.db, func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`INSERT OR REPLACE INTO profiles (id, peername, pod) VALUES (?, ?, ?)`, id, enc.Peername, string(data)); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM profile_peers WHERE profile_id = ?`, id); err != nil {
+			return err
+		}
+		for _, pid := range enc.PeerIDs {
+			if _, err := tx.Exec(`INSERT OR IGNORE INTO profile_peers (peer_id, profile_id) VALUES (?, ?)`, pid, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// PeerIDs gives the peer.IDs list for a given profile
+func (s *ProfileStore) PeerIDs(id profile.ID) ([]peer.ID, error) {
+	pro, err := s.GetProfile(id)
+	if err != nil {
+		return nil, err
+	}
+	return pro.PeerIDs, nil
+}
+
+// List hands back all profiles in the store
+func (s *ProfileStore) List() (map[profile.ID]*profile.Profile, error) {
+	rows, err := s.db.Query(`SELECT pod FROM profiles`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	profiles := map[profile.ID]*profile.Profile{}
+	for rows.Next() {
+		pro, err := scanProfile(rows)
+		if err != nil {
+			return nil, err
+		}
+		profiles[pro.ID] = pro
+	}
+	return profiles, rows.Err()
+}
+
+// PeernameID gives the profile.ID for a given peername
+func (s *ProfileStore) PeernameID(peername string) (profile.ID, error) {
+	var id string
+	err := s.db.QueryRow(`SELECT id FROM profiles WHERE peername = ? LIMIT 1`, peername).Scan(&id)
+	if err == sql.ErrNoRows {
+		return "", profile.ErrNotFound
+	} else if err != nil {
+		return "", err
+	}
+	return profile.IDB58Decode(id)
+}
+
+// GetProfile fetches a profile from the store
+func (s *ProfileStore) GetProfile(id profile.ID) (*profile.Profile, error) {
+	log.Debugf("get profile: %s", id.String())
+	return scanProfile(s.db.QueryRow(`SELECT pod FROM profiles WHERE id = ?`, id.String()))
+}
+
+// PeerProfile gives the profile that corresponds with a given peer.ID
+func (s *ProfileStore) PeerProfile(id peer.ID) (*profile.Profile, error) {
+	log.Debugf("peerProfile: %s", id.Pretty())
+	return scanProfile(s.db.QueryRow(`SELECT profiles.pod FROM profiles
+		JOIN profile_peers ON profile_peers.profile_id = profiles.id
+		WHERE profile_peers.peer_id = ? LIMIT 1`, fmt.Sprintf("/ipfs/%s", id.Pretty())))
+}
+
+// DeleteProfile removes a profile from the store
+func (s *ProfileStore) DeleteProfile(id profile.ID) error {
+	return transact(s.db, func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM profile_peers WHERE profile_id = ?`, id.String()); err != nil {
+			return err
+		}
+		_, err := tx.Exec(`DELETE FROM profiles WHERE id = ?`, id.String())
+		return err
+	})
+}
+
+// scanner is satisfied by both *sql.Row & *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanProfile decodes a profile from a row with a single pod column
+func scanProfile(row scanner) (*profile.Profile, error) {
+	var data string
+	if err := row.Scan(&data); err == sql.ErrNoRows {
+		return nil, profile.ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	pod := &config.ProfilePod{}
+	if err := json.Unmarshal([]byte(data), pod); err != nil {
+		return nil, fmt.Errorf("error decoding profile: %s", err.Error())
+	}
+	pro := &profile.Profile{}
+	err := pro.Decode(pod)
+	return pro, err
+}
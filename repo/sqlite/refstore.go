@@ -0,0 +1,143 @@
+package sqliterepo
+
+import (
+	"database/sql"
+
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+)
+
+// Refstore is a sqlite implementation of the repo.Refstore interface
+type Refstore struct {
+	db *sql.DB
+}
+
+// PutRef adds a reference to the store
+func (s Refstore) PutRef(put repo.DatasetRef) error {
+	if put.ProfileID == "" {
+		return repo.ErrPeerIDRequired
+	} else if put.Name == "" {
+		return repo.ErrNameRequired
+	} else if put.Path == "" {
+		return repo.ErrPathRequired
+	} else if put.Peername == "" {
+		return repo.ErrPeernameRequired
+	}
+
+	p := repo.DatasetRef{Peername: put.Peername, ProfileID: put.ProfileID, Name: put.Name, Path: put.Path}
+
+	return transact(s.db, func(tx *sql.Tx) error {
+		matches, err := matchingRefs(tx, p)
+		if err != nil {
+			return err
+		}
+		for _, ref := range matches {
+			if ref.Equal(p) {
+				return nil
+			}
+			return repo.ErrNameTaken
+		}
+
+		_, err = tx.Exec(`INSERT INTO refs (profile_id, peername, name, path) VALUES (?, ?, ?, ?)`,
+			p.ProfileID.String(), p.Peername, p.Name, p.Path)
+		return err
+	})
+}
+
+// GetRef completes a partially-known reference
+func (s Refstore) GetRef(get repo.DatasetRef) (repo.DatasetRef, error) {
+	matches, err := matchingRefs(s.db, get)
+	if err != nil {
+		return repo.DatasetRef{}, err
+	}
+	if len(matches) == 0 {
+		return repo.DatasetRef{}, repo.ErrNotFound
+	}
+	return matches[0], nil
+}
+
+// DeleteRef removes a reference from the store
+func (s Refstore) DeleteRef(del repo.DatasetRef) error {
+	return transact(s.db, func(tx *sql.Tx) error {
+		matches, err := matchingRefs(tx, del)
+		if err != nil || len(matches) == 0 {
+			return err
+		}
+		ref := matches[0]
+		_, err = tx.Exec(`DELETE FROM refs WHERE profile_id = ? AND name = ?`, ref.ProfileID.String(), ref.Name)
+		return err
+	})
+}
+
+// References gives a set of dataset references from the store, ordered by
+// peername & name
+func (s Refstore) References(limit, offset int) ([]repo.DatasetRef, error) {
+	rows, err := s.db.Query(`SELECT profile_id, peername, name, path FROM refs ORDER BY peername, name LIMIT ? OFFSET ?`, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return scanRefs(rows)
+}
+
+// RefCount returns the size of the Refstore
+func (s Refstore) RefCount() (count int, err error) {
+	err = s.db.QueryRow(`SELECT count(*) FROM refs`).Scan(&count)
+	return
+}
+
+// querier is satisfied by both *sql.DB & *sql.Tx
+type querier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// matchingRefs finds stored references that Match ref. Candidates are
+// selected by path or name & filtered with DatasetRef.Match so matching
+// follows the same rules as other refstores
+func matchingRefs(q querier, ref repo.DatasetRef) ([]repo.DatasetRef, error) {
+	rows, err := q.Query(`SELECT profile_id, peername, name, path FROM refs WHERE (path != '' AND path = ?) OR name = ? ORDER BY peername, name`, ref.Path, ref.Name)
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := scanRefs(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []repo.DatasetRef{}
+	for _, c := range candidates {
+		if c.Match(ref) {
+			matches = append(matches, c)
+		}
+	}
+	return matches, nil
+}
+
+// scanRefs reads references from rows of (profile_id, peername, name, path),
+// closing rows when done
+func scanRefs(rows *sql.Rows) ([]repo.DatasetRef, error) {
+	defer rows.Close()
+
+	refs := []repo.DatasetRef{}
+	for rows.Next() {
+		var pid string
+		ref := repo.DatasetRef{}
+		if err := rows.Scan(&pid, &ref.Peername, &ref.Name, &ref.Path); err != nil {
+			return nil, err
+		}
+		id, err := decodeProfileID(pid)
+		if err != nil {
+			return nil, err
+		}
+		ref.ProfileID = id
+		refs = append(refs, ref)
+	}
+	return refs, rows.Err()
+}
+
+// decodeProfileID parses a stored profile ID, allowing empty IDs
+func decodeProfileID(s string) (profile.ID, error) {
+	if s == "" {
+		return "", nil
+	}
+	return profile.IDB58Decode(s)
+}
@@ -0,0 +1,295 @@
+// Package sqliterepo implements the repo.Repo interface on top of an
+// embedded sqlite database. References, events & profiles are kept in
+// tables & every write happens within a transaction, so many concurrent
+// requests can share a repo without racing on files
+package sqliterepo
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	golog "github.com/ipfs/go-log"
+	"github.com/libp2p/go-libp2p-crypto"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/dataset/dsgraph"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+	"github.com/qri-io/registry/regclient"
+
+	// register the sqlite3 database/sql driver
+	_ "github.com/mattn/go-sqlite3"
+)
+
+var log = golog.Logger("sqliterepo")
+
+// DBFilename is the name of the database file NewRepo creates within it's
+// base directory
+const DBFilename = "repo.sqlite"
+
+// schema creates all tables the repo uses. Statements are idempotent so the
+// schema is applied every time a repo is opened
+const schema = `
+CREATE TABLE IF NOT EXISTS refs (
+	profile_id TEXT NOT NULL,
+	peername   TEXT NOT NULL,
+	name       TEXT NOT NULL,
+	path       TEXT NOT NULL,
+	PRIMARY KEY (profile_id, name)
+);
+CREATE INDEX IF NOT EXISTS refs_path ON refs (path);
+CREATE INDEX IF NOT EXISTS refs_peername_name ON refs (peername, name);
+
+CREATE TABLE IF NOT EXISTS events (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	time       INTEGER NOT NULL,
+	type       TEXT NOT NULL,
+	profile_id TEXT NOT NULL,
+	peername   TEXT NOT NULL,
+	name       TEXT NOT NULL,
+	path       TEXT NOT NULL,
+	peer_id    TEXT NOT NULL,
+	params     TEXT
+);
+CREATE INDEX IF NOT EXISTS events_time ON events (time);
+CREATE INDEX IF NOT EXISTS events_type ON events (type, time);
+CREATE INDEX IF NOT EXISTS events_path ON events (path);
+CREATE INDEX IF NOT EXISTS events_name ON events (name);
+
+CREATE TABLE IF NOT EXISTS profiles (
+	id       TEXT PRIMARY KEY,
+	peername TEXT NOT NULL,
+	pod      TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS profiles_peername ON profiles (peername);
+
+CREATE TABLE IF NOT EXISTS profile_peers (
+	peer_id    TEXT NOT NULL,
+	profile_id TEXT NOT NULL REFERENCES profiles (id) ON DELETE CASCADE,
+	PRIMARY KEY (peer_id, profile_id)
+);
+
+CREATE TABLE IF NOT EXISTS selected_refs (
+	position INTEGER PRIMARY KEY,
+	ref      TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS change_requests (
+	id                TEXT PRIMARY KEY,
+	created           INTEGER NOT NULL,
+	target_profile_id TEXT NOT NULL,
+	target_peername   TEXT NOT NULL,
+	target_name       TEXT NOT NULL,
+	data              TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS change_requests_target ON change_requests (target_name, created);
+
+CREATE TABLE IF NOT EXISTS templates (
+	name    TEXT PRIMARY KEY,
+	format  TEXT NOT NULL,
+	path    TEXT NOT NULL,
+	updated INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS follows (
+	peername   TEXT NOT NULL,
+	name       TEXT NOT NULL,
+	profile_id TEXT NOT NULL,
+	created    INTEGER NOT NULL,
+	PRIMARY KEY (peername, name)
+);
+`
+
+// Repo is a sqlite-backed implementation of the repo.Repo interface
+type Repo struct {
+	path string
+	db   *sql.DB
+
+	Refstore
+	*EventLog
+	*ChangeRequestStore
+	*TemplateStore
+	*FollowStore
+
+	profile  *profile.Profile
+	profiles *ProfileStore
+
+	store    cafs.Filestore
+	graph    map[string]*dsgraph.Node
+	registry *regclient.Client
+}
+
+// NewRepo opens a sqlite repo in the base directory, creating the directory
+// & database if they don't exist
+func NewRepo(store cafs.Filestore, pro *profile.Profile, rc *regclient.Client, base string) (repo.Repo, error) {
+	if pro.PrivKey == nil {
+		return nil, fmt.Errorf("Expected: PrivateKey")
+	}
+	if err := os.MkdirAll(base, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	db, err := OpenDB(filepath.Join(base, DBFilename))
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Repo{
+		path: base,
+		db:   db,
+
+		Refstore:           Refstore{db: db},
+		EventLog:           &EventLog{db: db},
+		ChangeRequestStore: &ChangeRequestStore{db: db},
+		TemplateStore:      &TemplateStore{db: db},
+		FollowStore:        &FollowStore{db: db},
+
+		profile:  pro,
+		profiles: &ProfileStore{db: db},
+
+		store:    store,
+		registry: rc,
+	}
+
+	// add our own profile to the store if it doesn't already exist.
+	if _, e := r.profiles.GetProfile(pro.ID); e != nil {
+		if err := r.profiles.PutProfile(pro); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// OpenDB opens the sqlite database at path & applies the repo schema.
+// Connections wait on each other instead of failing when the database is
+// locked by another writer
+func OpenDB(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?_busy_timeout=5000&_foreign_keys=1", path))
+	if err != nil {
+		return nil, fmt.Errorf("error opening repo database: %s", err.Error())
+	}
+	// sqlite allows a single writer, serializing connections within a process
+	// avoids busy errors between goroutines
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error creating repo database: %s", err.Error())
+	}
+	return db, nil
+}
+
+// Store returns the underlying cafs.Filestore driving this repo
+func (r *Repo) Store() cafs.Filestore {
+	return r.store
+}
+
+// Graph returns the graph of dataset objects for this repo
+func (r *Repo) Graph() (map[string]*dsgraph.Node, error) {
+	if r.graph == nil {
+		nodes, err := repo.Graph(r)
+		if err != nil {
+			log.Debug(err.Error())
+			return nil, err
+		}
+		r.graph = nodes
+	}
+	return r.graph, nil
+}
+
+// Profile gives this repo's peer profile
+func (r *Repo) Profile() (*profile.Profile, error) {
+	return r.profile, nil
+}
+
+// SetProfile updates this repo's peer profile info
+func (r *Repo) SetProfile(p *profile.Profile) error {
+	r.profile = p
+	return r.profiles.PutProfile(p)
+}
+
+// PrivateKey returns this repo's private key
+func (r *Repo) PrivateKey() crypto.PrivKey {
+	return r.profile.PrivKey
+}
+
+// Profiles returns this repo's Peers implementation
+func (r *Repo) Profiles() profile.Store {
+	return r.profiles
+}
+
+// Registry returns a client for interacting with a federated registry if one exists, otherwise nil
+func (r *Repo) Registry() *regclient.Client {
+	return r.registry
+}
+
+// SetSelectedRefs sets the current reference selection
+func (r *Repo) SetSelectedRefs(sel []repo.DatasetRef) error {
+	return transact(r.db, func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM selected_refs`); err != nil {
+			return err
+		}
+		for i, ref := range sel {
+			data, err := json.Marshal(ref)
+			if err != nil {
+				return err
+			}
+			if _, err := tx.Exec(`INSERT INTO selected_refs (position, ref) VALUES (?, ?)`, i, string(data)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// SelectedRefs gives the current reference selection
+func (r *Repo) SelectedRefs() ([]repo.DatasetRef, error) {
+	rows, err := r.db.Query(`SELECT ref FROM selected_refs ORDER BY position`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []repo.DatasetRef{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		ref := repo.DatasetRef{}
+		if err := json.Unmarshal([]byte(data), &ref); err != nil {
+			return nil, err
+		}
+		res = append(res, ref)
+	}
+	return res, rows.Err()
+}
+
+// Close closes the repo's database
+func (r *Repo) Close() error {
+	return r.db.Close()
+}
+
+// Destroy closes & removes this repository
+func (r *Repo) Destroy() error {
+	r.db.Close()
+	return os.RemoveAll(r.path)
+}
+
+// transact runs fn within a transaction, committing if fn returns nil &
+// rolling back otherwise
+func transact(db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
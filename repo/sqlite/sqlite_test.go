@@ -0,0 +1,207 @@
+package sqliterepo
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+	"github.com/qri-io/qri/repo/test"
+
+	"gx/ipfs/QmdVrMn1LhB4ybb8hMVaMLXnA8XRSewMnK6YqXKXoTcRvN/go-libp2p-peer"
+)
+
+var (
+	_ repo.RefSelector        = (*Repo)(nil)
+	_ repo.ChangeRequestStore = (*Repo)(nil)
+)
+
+func newTestRepo(t *testing.T, path string) repo.Repo {
+	if err := os.RemoveAll(path); err != nil {
+		t.Errorf("error removing files: %s", err.Error())
+	}
+
+	pro, err := profile.NewProfile(config.DefaultProfile())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	r, err := NewRepo(cafs.NewMapstore(), pro, nil, path)
+	if err != nil {
+		t.Fatalf("error creating repo: %s", err.Error())
+	}
+	return r
+}
+
+func TestRepo(t *testing.T) {
+	path, err := ioutil.TempDir("", "qri_sqlite_repo_test")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(path)
+
+	rmf := func(t *testing.T) repo.Repo {
+		return newTestRepo(t, path)
+	}
+
+	test.RunRepoTests(t, rmf)
+}
+
+func TestRefstore(t *testing.T) {
+	path, err := ioutil.TempDir("", "qri_sqlite_refstore_test")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(path)
+	r := newTestRepo(t, path)
+
+	id := profile.IDB58MustDecode("QmZePf5LeXow3RW5U1AgEiNbW46YnRGhZ7HPvm1UmPFPwt")
+	a := repo.DatasetRef{ProfileID: id, Peername: "peer", Name: "a", Path: "/map/a"}
+	b := repo.DatasetRef{ProfileID: id, Peername: "peer", Name: "b", Path: "/map/b"}
+
+	bad := []struct {
+		ref repo.DatasetRef
+		err error
+	}{
+		{repo.DatasetRef{Peername: "peer", Name: "a", Path: "/map/a"}, repo.ErrPeerIDRequired},
+		{repo.DatasetRef{ProfileID: id, Peername: "peer", Path: "/map/a"}, repo.ErrNameRequired},
+		{repo.DatasetRef{ProfileID: id, Peername: "peer", Name: "a"}, repo.ErrPathRequired},
+		{repo.DatasetRef{ProfileID: id, Name: "a", Path: "/map/a"}, repo.ErrPeernameRequired},
+	}
+	for i, c := range bad {
+		if err := r.PutRef(c.ref); err != c.err {
+			t.Errorf("case %d error mismatch. expected: %s, got: %s", i, c.err, err)
+		}
+	}
+
+	for _, ref := range []repo.DatasetRef{b, a, a} {
+		if err := r.PutRef(ref); err != nil {
+			t.Fatalf("error putting ref %s: %s", ref, err.Error())
+		}
+	}
+	if err := r.PutRef(repo.DatasetRef{ProfileID: id, Peername: "peer", Name: "a", Path: "/map/a2"}); err != repo.ErrNameTaken {
+		t.Errorf("expected putting a taken name to return ErrNameTaken, got: %s", err)
+	}
+
+	got, err := r.GetRef(repo.DatasetRef{Peername: "peer", Name: "a"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !got.Equal(a) {
+		t.Errorf("GetRef by name mismatch. expected: %s, got: %s", a, got)
+	}
+	if got, err = r.GetRef(repo.DatasetRef{Path: "/map/b"}); err != nil || !got.Equal(b) {
+		t.Errorf("GetRef by path mismatch. expected: %s, got: %s, err: %v", b, got, err)
+	}
+
+	refs, err := r.References(10, 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(refs) != 2 || !refs[0].Equal(a) || !refs[1].Equal(b) {
+		t.Errorf("expected references to be ordered by name, got: %v", refs)
+	}
+	if refs, err = r.References(1, 1); err != nil || len(refs) != 1 || !refs[0].Equal(b) {
+		t.Errorf("paged references mismatch, got: %v, err: %v", refs, err)
+	}
+
+	if err := r.DeleteRef(repo.DatasetRef{Peername: "peer", Name: "a"}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := r.GetRef(a); err != repo.ErrNotFound {
+		t.Errorf("expected deleted ref to return ErrNotFound, got: %s", err)
+	}
+	if count, err := r.RefCount(); err != nil || count != 1 {
+		t.Errorf("expected a count of 1, got: %d, err: %v", count, err)
+	}
+}
+
+func TestConcurrentPutRef(t *testing.T) {
+	path, err := ioutil.TempDir("", "qri_sqlite_concurrent_test")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(path)
+	r := newTestRepo(t, path)
+
+	id := profile.IDB58MustDecode("QmZePf5LeXow3RW5U1AgEiNbW46YnRGhZ7HPvm1UmPFPwt")
+	wg := sync.WaitGroup{}
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- r.PutRef(repo.DatasetRef{ProfileID: id, Peername: "peer", Name: fmt.Sprintf("ds_%d", i), Path: fmt.Sprintf("/map/%d", i)})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("concurrent PutRef error: %s", err.Error())
+		}
+	}
+
+	if count, err := r.RefCount(); err != nil || count != 20 {
+		t.Errorf("expected 20 refs, got: %d, err: %v", count, err)
+	}
+}
+
+func TestProfileStore(t *testing.T) {
+	path, err := ioutil.TempDir("", "qri_sqlite_profile_test")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(path)
+	r := newTestRepo(t, path)
+	ps := r.Profiles()
+
+	own, err := r.Profile()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := ps.GetProfile(own.ID); err != nil {
+		t.Errorf("expected repo's own profile to be stored: %s", err.Error())
+	}
+
+	pid, err := peer.IDB58Decode("QmZePf5LeXow3RW5U1AgEiNbW46YnRGhZ7HPvm1UmPFPwt")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	pro := &profile.Profile{
+		ID:       profile.ID(pid),
+		Peername: "friend",
+		PeerIDs:  []peer.ID{pid},
+	}
+	if err := ps.PutProfile(pro); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if id, err := ps.PeernameID("friend"); err != nil || id != pro.ID {
+		t.Errorf("PeernameID mismatch. expected: %s, got: %s, err: %v", pro.ID, id, err)
+	}
+	if got, err := ps.PeerProfile(pid); err != nil || got.Peername != "friend" {
+		t.Errorf("PeerProfile mismatch. got: %v, err: %v", got, err)
+	}
+	if ids, err := ps.PeerIDs(pro.ID); err != nil || len(ids) != 1 || ids[0] != pid {
+		t.Errorf("PeerIDs mismatch. got: %v, err: %v", ids, err)
+	}
+	if list, err := ps.List(); err != nil || len(list) != 2 {
+		t.Errorf("expected 2 profiles, got: %d, err: %v", len(list), err)
+	}
+
+	if err := ps.DeleteProfile(pro.ID); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := ps.GetProfile(pro.ID); err != profile.ErrNotFound {
+		t.Errorf("expected deleted profile to return ErrNotFound, got: %s", err)
+	}
+	if _, err := ps.PeerProfile(pid); err != profile.ErrNotFound {
+		t.Errorf("expected deleted peer profile to return ErrNotFound, got: %s", err)
+	}
+}
@@ -0,0 +1,80 @@
+package sqliterepo
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/qri-io/qri/repo"
+)
+
+// TemplateStore is a sqlite implementation of the repo.TemplateStore
+// interface
+type TemplateStore struct {
+	db *sql.DB
+}
+
+// PutTemplate adds or replaces a template
+func (s *TemplateStore) PutTemplate(t *repo.Template) error {
+	if t.Name == "" {
+		return repo.ErrNameRequired
+	}
+	if t.Path == "" {
+		return repo.ErrPathRequired
+	}
+	_, err := s.db.Exec(`INSERT OR REPLACE INTO templates (name, format, path, updated) VALUES (?, ?, ?, ?)`,
+		t.Name, t.Format, t.Path, t.Updated.UnixNano())
+	return err
+}
+
+// GetTemplate fetches a template by name
+func (s *TemplateStore) GetTemplate(name string) (*repo.Template, error) {
+	ts, err := s.query(`SELECT name, format, path, updated FROM templates WHERE name = ?`, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(ts) == 0 {
+		return nil, repo.ErrNotFound
+	}
+	return ts[0], nil
+}
+
+// DeleteTemplate removes a template
+func (s *TemplateStore) DeleteTemplate(name string) error {
+	res, err := s.db.Exec(`DELETE FROM templates WHERE name = ?`, name)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return repo.ErrNotFound
+	}
+	return nil
+}
+
+// ListTemplates lists all templates, ordered by name
+func (s *TemplateStore) ListTemplates() ([]*repo.Template, error) {
+	return s.query(`SELECT name, format, path, updated FROM templates ORDER BY name`)
+}
+
+func (s *TemplateStore) query(query string, args ...interface{}) ([]*repo.Template, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ts := []*repo.Template{}
+	for rows.Next() {
+		var (
+			t       = &repo.Template{}
+			updated int64
+		)
+		if err := rows.Scan(&t.Name, &t.Format, &t.Path, &updated); err != nil {
+			return nil, err
+		}
+		t.Updated = time.Unix(0, updated)
+		ts = append(ts, t)
+	}
+	return ts, rows.Err()
+}
@@ -0,0 +1,102 @@
+package repo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrTemplatesNotSupported is the expected error for when the TemplateStore
+// interface is *not* implemented
+var ErrTemplatesNotSupported = fmt.Errorf("repo: named templates not supported")
+
+// TemplateRefPrefix marks a viz script path that refers to a named template
+// instead of a store path, for example "template:summary"
+const TemplateRefPrefix = "template:"
+
+// TemplateName returns the name of the template a viz script path refers to.
+// ok is false if the path is a store path
+func TemplateName(scriptPath string) (name string, ok bool) {
+	if !strings.HasPrefix(scriptPath, TemplateRefPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(scriptPath, TemplateRefPrefix), true
+}
+
+// Template is a named render template. Template scripts live in the repo's
+// store, named templates can be rendered by name & are available to every
+// render as partials
+type Template struct {
+	Name string `json:"name"`
+	// Format is the output the template produces: html, markdown or text
+	Format string `json:"format"`
+	// Path is the store path of the template script
+	Path    string    `json:"path"`
+	Updated time.Time `json:"updated"`
+}
+
+// TemplateStore is an opt-in interface for repos that keep named render
+// templates
+type TemplateStore interface {
+	// PutTemplate adds or replaces a template, keyed by name
+	PutTemplate(t *Template) error
+	// GetTemplate fetches a template by name
+	GetTemplate(name string) (*Template, error)
+	// DeleteTemplate removes a template
+	DeleteTemplate(name string) error
+	// ListTemplates lists all templates, ordered by name
+	ListTemplates() ([]*Template, error)
+}
+
+// MemTemplateStore is an in-memory implementation of the TemplateStore
+// interface
+type MemTemplateStore []*Template
+
+// PutTemplate adds or replaces a template
+func (s *MemTemplateStore) PutTemplate(t *Template) error {
+	if t.Name == "" {
+		return ErrNameRequired
+	}
+	if t.Path == "" {
+		return ErrPathRequired
+	}
+	ts := *s
+	for i, tmpl := range ts {
+		if tmpl.Name == t.Name {
+			ts[i] = t
+			return nil
+		}
+	}
+	ts = append(ts, t)
+	sort.Slice(ts, func(i, j int) bool { return ts[i].Name < ts[j].Name })
+	*s = ts
+	return nil
+}
+
+// GetTemplate fetches a template by name
+func (s MemTemplateStore) GetTemplate(name string) (*Template, error) {
+	for _, t := range s {
+		if t.Name == name {
+			return t, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// DeleteTemplate removes a template
+func (s *MemTemplateStore) DeleteTemplate(name string) error {
+	ts := *s
+	for i, t := range ts {
+		if t.Name == name {
+			*s = append(ts[:i], ts[i+1:]...)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// ListTemplates lists all templates, ordered by name
+func (s MemTemplateStore) ListTemplates() ([]*Template, error) {
+	return append([]*Template{}, s...), nil
+}
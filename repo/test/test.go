@@ -24,6 +24,10 @@ func RunRepoTests(t *testing.T, rmf RepoMakerFunc) {
 	tests := []repoTestFunc{
 		testProfile,
 		testRefSelector,
+		testEventLog,
+		testChangeRequestStore,
+		testTemplateStore,
+		testFollowStore,
 	}
 
 	for _, test := range tests {
@@ -0,0 +1,81 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/qri-io/qri/repo"
+)
+
+func testChangeRequestStore(t *testing.T, rmf RepoMakerFunc) {
+	r := rmf(t)
+	s, ok := r.(repo.ChangeRequestStore)
+	if !ok {
+		t.Log("repo doesn't implement repo.ChangeRequestStore, skipping change request tests")
+		return
+	}
+
+	target := repo.DatasetRef{Peername: "peer", Name: "cities", Path: "/map/cities1"}
+	crs := []*repo.ChangeRequest{
+		{ID: "/map/a", Target: target, Path: "/map/a", Proposer: "a", Status: repo.CRStatusOpen, Created: time.Unix(10, 0)},
+		{ID: "/map/b", Target: repo.DatasetRef{Peername: "peer", Name: "movies"}, Path: "/map/b", Proposer: "b", Status: repo.CRStatusOpen, Created: time.Unix(30, 0)},
+		{ID: "/map/c", Target: target, Path: "/map/c", Proposer: "c", Status: repo.CRStatusOpen, Created: time.Unix(20, 0)},
+	}
+	for i, cr := range crs {
+		if err := s.PutChangeRequest(cr); err != nil {
+			t.Fatalf("put change request %d error: %s", i, err.Error())
+		}
+	}
+	if err := s.PutChangeRequest(&repo.ChangeRequest{}); err == nil {
+		t.Error("expected putting a change request without an ID to error")
+	}
+
+	checkIDs := func(name string, got []*repo.ChangeRequest, err error, expect ...string) {
+		if err != nil {
+			t.Errorf("%s error: %s", name, err.Error())
+			return
+		}
+		if len(got) != len(expect) {
+			t.Errorf("%s length mismatch. expected: %d, got: %d", name, len(expect), len(got))
+			return
+		}
+		for i, cr := range got {
+			if cr.ID != expect[i] {
+				t.Errorf("%s change request %d mismatch. expected: %s, got: %s", name, i, expect[i], cr.ID)
+			}
+		}
+	}
+
+	got, err := s.ListChangeRequests(10, 0)
+	checkIDs("ListChangeRequests", got, err, "/map/b", "/map/c", "/map/a")
+	got, err = s.ListChangeRequests(1, 1)
+	checkIDs("ListChangeRequests paged", got, err, "/map/c")
+	got, err = s.ChangeRequestsForTarget(repo.DatasetRef{Peername: "peer", Name: "cities"}, 10, 0)
+	checkIDs("ChangeRequestsForTarget", got, err, "/map/c", "/map/a")
+
+	update := *crs[0]
+	update.Status = repo.CRStatusAccepted
+	update.Result = "/map/cities2"
+	if err := s.PutChangeRequest(&update); err != nil {
+		t.Fatalf("update change request error: %s", err.Error())
+	}
+	cr, err := s.GetChangeRequest("/map/a")
+	if err != nil {
+		t.Fatalf("get change request error: %s", err.Error())
+	}
+	if cr.Status != repo.CRStatusAccepted || cr.Result != "/map/cities2" || !cr.Target.Equal(target) {
+		t.Errorf("expected updated change request, got: %v", cr)
+	}
+	got, err = s.ListChangeRequests(10, 0)
+	checkIDs("ListChangeRequests after update", got, err, "/map/b", "/map/c", "/map/a")
+
+	if err := s.DeleteChangeRequest("/map/b"); err != nil {
+		t.Errorf("delete change request error: %s", err.Error())
+	}
+	if _, err := s.GetChangeRequest("/map/b"); err != repo.ErrNotFound {
+		t.Errorf("expected deleted change request to return ErrNotFound, got: %v", err)
+	}
+	if err := s.DeleteChangeRequest("/map/b"); err != repo.ErrNotFound {
+		t.Errorf("expected deleting a missing change request to return ErrNotFound, got: %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/qri-io/qri/repo"
+)
+
+func testEventLog(t *testing.T, rmf RepoMakerFunc) {
+	r := rmf(t)
+
+	a := repo.DatasetRef{Peername: "peer", Name: "a", Path: "/map/a1"}
+	a2 := repo.DatasetRef{Peername: "peer", Name: "a", Path: "/map/a2"}
+	b := repo.DatasetRef{Peername: "peer", Name: "b", Path: "/map/b1"}
+
+	// events are logged out of chronological order on purpose
+	logs := []struct {
+		t    repo.EventType
+		when int64
+		ref  repo.DatasetRef
+		p    *repo.EventParams
+	}{
+		{repo.ETDsCreated, 10, a, nil},
+		{repo.ETDsCreated, 30, b, nil},
+		{repo.ETDsPinned, 20, a, nil},
+		{repo.ETDsCreated, 50, a2, &repo.EventParams{Peername: "peer", PrevPath: "/map/a1", Details: map[string]interface{}{"title": "update"}}},
+		{repo.ETDsDeleted, 40, b, nil},
+	}
+	for i, l := range logs {
+		if err := r.LogEventDetails(l.t, time.Unix(l.when, 0), "", l.ref, l.p); err != nil {
+			t.Fatalf("log %d error: %s", i, err.Error())
+		}
+	}
+
+	checkTimes := func(name string, events []*repo.Event, err error, expect ...int64) {
+		if err != nil {
+			t.Errorf("%s error: %s", name, err.Error())
+			return
+		}
+		if len(events) != len(expect) {
+			t.Errorf("%s length mismatch. expected: %d, got: %d", name, len(expect), len(events))
+			return
+		}
+		for i, e := range events {
+			if e.Time.Unix() != expect[i] {
+				t.Errorf("%s event %d time mismatch. expected: %d, got: %d", name, i, expect[i], e.Time.Unix())
+			}
+		}
+	}
+
+	events, err := r.Events(10, 0)
+	checkTimes("Events", events, err, 50, 40, 30, 20, 10)
+	if len(events) > 0 {
+		p := events[0].Params
+		if p == nil || p.PrevPath != "/map/a1" || p.Details["title"] != "update" || events[0].Actor() != "peer" {
+			t.Errorf("expected event params to be stored, got: %v", p)
+		}
+	}
+	events, err = r.Events(2, 1)
+	checkTimes("Events paged", events, err, 40, 30)
+	events, err = r.Events(0, 0)
+	checkTimes("Events zero limit", events, err)
+
+	events, err = r.EventsSince(time.Unix(20, 0))
+	checkTimes("EventsSince", events, err, 30, 40, 50)
+
+	events, err = r.EventsForRef(repo.DatasetRef{Peername: "peer", Name: "a"}, 10, 0)
+	checkTimes("EventsForRef by name", events, err, 50, 20, 10)
+	events, err = r.EventsForRef(repo.DatasetRef{Path: "/map/b1"}, 10, 0)
+	checkTimes("EventsForRef by path", events, err, 40, 30)
+	events, err = r.EventsForRef(repo.DatasetRef{Peername: "peer", Name: "a"}, 1, 1)
+	checkTimes("EventsForRef paged", events, err, 20)
+	events, err = r.EventsForRef(repo.DatasetRef{Peername: "peer", Name: "c"}, 10, 0)
+	checkTimes("EventsForRef no match", events, err)
+
+	events, err = r.EventsByType(repo.ETDsCreated, 10, 0)
+	checkTimes("EventsByType", events, err, 50, 30, 10)
+	events, err = r.EventsByType(repo.ETDsCreated, 1, 2)
+	checkTimes("EventsByType paged", events, err, 10)
+	events, err = r.EventsByType(repo.ETDsRenamed, 10, 0)
+	checkTimes("EventsByType no match", events, err)
+
+	events, err = r.EventsByType(repo.ETDsDeleted, 1, 0)
+	checkTimes("EventsByType deleted", events, err, 40)
+	if len(events) == 1 && !events[0].Ref.Equal(b) {
+		t.Errorf("expected deleted event ref to equal %s, got: %s", b, events[0].Ref)
+	}
+}
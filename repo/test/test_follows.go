@@ -0,0 +1,66 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/qri-io/qri/repo"
+)
+
+func testFollowStore(t *testing.T, rmf RepoMakerFunc) {
+	r := rmf(t)
+	s, ok := r.(repo.FollowStore)
+	if !ok {
+		t.Log("repo doesn't implement repo.FollowStore, skipping follow tests")
+		return
+	}
+
+	fs := []*repo.Follow{
+		{Peername: "steve", Created: time.Unix(10, 0)},
+		{Peername: "alice", Name: "movies", Created: time.Unix(20, 0)},
+		{Peername: "alice", Created: time.Unix(30, 0)},
+	}
+	for i, f := range fs {
+		if err := s.PutFollow(f); err != nil {
+			t.Errorf("case %d PutFollow error: %s", i, err.Error())
+			return
+		}
+	}
+	if err := s.PutFollow(&repo.Follow{Name: "movies"}); err != repo.ErrPeernameRequired {
+		t.Errorf("expected follow without a peername to error with ErrPeernameRequired, got: %v", err)
+	}
+
+	got, err := s.ListFollows()
+	if err != nil {
+		t.Errorf("ListFollows error: %s", err.Error())
+		return
+	}
+	expect := []string{"alice", "alice/movies", "steve"}
+	if len(got) != len(expect) {
+		t.Errorf("expected %d follows, got: %d", len(expect), len(got))
+		return
+	}
+	for i, f := range got {
+		if f.String() != expect[i] {
+			t.Errorf("follow %d mismatch. expected: %s, got: %s", i, expect[i], f.String())
+		}
+	}
+
+	if err := s.PutFollow(&repo.Follow{Peername: "steve", Created: time.Unix(40, 0)}); err != nil {
+		t.Errorf("error replacing follow: %s", err.Error())
+		return
+	}
+	if got, _ = s.ListFollows(); len(got) != 3 || !got[2].Created.Equal(time.Unix(40, 0)) {
+		t.Errorf("expected follow to be replaced, got: %v", got)
+	}
+
+	if err := s.DeleteFollow("alice", "movies"); err != nil {
+		t.Errorf("DeleteFollow error: %s", err.Error())
+	}
+	if got, _ = s.ListFollows(); len(got) != 2 {
+		t.Errorf("expected 2 follows after delete, got: %d", len(got))
+	}
+	if err := s.DeleteFollow("alice", "movies"); err != repo.ErrNotFound {
+		t.Errorf("expected deleting a missing follow to return ErrNotFound, got: %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/qri-io/qri/repo"
+)
+
+func testTemplateStore(t *testing.T, rmf RepoMakerFunc) {
+	r := rmf(t)
+	s, ok := r.(repo.TemplateStore)
+	if !ok {
+		t.Log("repo doesn't implement repo.TemplateStore, skipping template tests")
+		return
+	}
+
+	ts := []*repo.Template{
+		{Name: "summary", Format: "markdown", Path: "/map/summary", Updated: time.Unix(10, 0)},
+		{Name: "chart", Format: "html", Path: "/map/chart", Updated: time.Unix(20, 0)},
+	}
+	for i, tmpl := range ts {
+		if err := s.PutTemplate(tmpl); err != nil {
+			t.Errorf("case %d PutTemplate error: %s", i, err.Error())
+			return
+		}
+	}
+	if err := s.PutTemplate(&repo.Template{Path: "/map/nameless"}); err != repo.ErrNameRequired {
+		t.Errorf("expected template without a name to error with ErrNameRequired, got: %v", err)
+	}
+
+	got, err := s.ListTemplates()
+	if err != nil {
+		t.Errorf("ListTemplates error: %s", err.Error())
+		return
+	}
+	if len(got) != 2 || got[0].Name != "chart" || got[1].Name != "summary" {
+		t.Errorf("expected templates ordered by name, got: %v", got)
+	}
+
+	if err := s.PutTemplate(&repo.Template{Name: "summary", Format: "text", Path: "/map/summary2", Updated: time.Unix(30, 0)}); err != nil {
+		t.Errorf("error replacing template: %s", err.Error())
+		return
+	}
+	tmpl, err := s.GetTemplate("summary")
+	if err != nil {
+		t.Errorf("GetTemplate error: %s", err.Error())
+		return
+	}
+	if tmpl.Path != "/map/summary2" || tmpl.Format != "text" {
+		t.Errorf("expected template to be replaced, got: %v", tmpl)
+	}
+
+	if err := s.DeleteTemplate("chart"); err != nil {
+		t.Errorf("DeleteTemplate error: %s", err.Error())
+	}
+	if _, err := s.GetTemplate("chart"); err != repo.ErrNotFound {
+		t.Errorf("expected deleted template to be not found, got: %v", err)
+	}
+	if err := s.DeleteTemplate("chart"); err != repo.ErrNotFound {
+		t.Errorf("expected deleting a missing template to return ErrNotFound, got: %v", err)
+	}
+}
@@ -0,0 +1,264 @@
+package sql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Select is a parsed SELECT statement
+type Select struct {
+	Distinct bool
+	Fields   []*Field
+	From     *TableRef
+	Joins    []*Join
+	Where    Expr
+	GroupBy  []Expr
+	Having   Expr
+	OrderBy  []*Order
+	// Limit is the max number of rows to return, -1 means no limit
+	Limit  int
+	Offset int
+}
+
+// Tables lists the names of all tables a statement reads from
+func (s *Select) Tables() []string {
+	names := []string{s.From.Name}
+	for _, j := range s.Joins {
+		names = append(names, j.Table.Name)
+	}
+	return names
+}
+
+// Field is a single selected column. Star fields select all columns, or all
+// columns of Table if set
+type Field struct {
+	Expr  Expr
+	Alias string
+	Star  bool
+	Table string
+}
+
+// TableRef names a table to read from. Table names are usually dataset
+// references like me/dataset_name
+type TableRef struct {
+	Name  string
+	Alias string
+}
+
+// JoinType enumerates the kinds of supported joins
+type JoinType int
+
+const (
+	// InnerJoin keeps only pairs of rows that match the join condition
+	InnerJoin JoinType = iota
+	// LeftJoin keeps every row from the left side, filling unmatched right
+	// side columns with null
+	LeftJoin
+	// CrossJoin pairs every row on the left with every row on the right
+	CrossJoin
+)
+
+// Join combines rows from another table
+type Join struct {
+	Type  JoinType
+	Table *TableRef
+	On    Expr
+}
+
+// Order is a single ORDER BY term
+type Order struct {
+	Expr Expr
+	Desc bool
+}
+
+// Expr is a node in an expression tree
+type Expr interface {
+	String() string
+}
+
+// Literal is a constant value: a number, string, boolean or null
+type Literal struct {
+	Value interface{}
+}
+
+// String implements the Expr interface
+func (l *Literal) String() string {
+	switch v := l.Value.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return "'" + strings.Replace(v, "'", "''", -1) + "'"
+	case bool:
+		if v {
+			return "TRUE"
+		}
+		return "FALSE"
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprintf("%v", l.Value)
+}
+
+// ColumnRef refers to a column by name, optionally qualified by a table name
+// or alias
+type ColumnRef struct {
+	Table string
+	Name  string
+}
+
+// String implements the Expr interface
+func (c *ColumnRef) String() string {
+	if c.Table != "" {
+		return c.Table + "." + c.Name
+	}
+	return c.Name
+}
+
+// Unary is a prefix operator expression: NOT or -
+type Unary struct {
+	Op string
+	X  Expr
+}
+
+// String implements the Expr interface
+func (u *Unary) String() string {
+	if u.Op == "NOT" {
+		return "NOT " + u.X.String()
+	}
+	return u.Op + u.X.String()
+}
+
+// Binary is an infix operator expression
+type Binary struct {
+	Op   string
+	L, R Expr
+}
+
+// String implements the Expr interface
+func (b *Binary) String() string {
+	return fmt.Sprintf("%s %s %s", b.L.String(), b.Op, b.R.String())
+}
+
+// Call is a function call. Star is set for COUNT(*)
+type Call struct {
+	Name     string
+	Args     []Expr
+	Star     bool
+	Distinct bool
+}
+
+// String implements the Expr interface
+func (c *Call) String() string {
+	if c.Star {
+		return strings.ToLower(c.Name) + "(*)"
+	}
+	args := make([]string, len(c.Args))
+	for i, a := range c.Args {
+		args[i] = a.String()
+	}
+	if c.Distinct {
+		return fmt.Sprintf("%s(DISTINCT %s)", strings.ToLower(c.Name), strings.Join(args, ", "))
+	}
+	return fmt.Sprintf("%s(%s)", strings.ToLower(c.Name), strings.Join(args, ", "))
+}
+
+// In tests membership in a list of values
+type In struct {
+	X    Expr
+	List []Expr
+	Not  bool
+}
+
+// String implements the Expr interface
+func (in *In) String() string {
+	list := make([]string, len(in.List))
+	for i, x := range in.List {
+		list[i] = x.String()
+	}
+	op := "IN"
+	if in.Not {
+		op = "NOT IN"
+	}
+	return fmt.Sprintf("%s %s (%s)", in.X.String(), op, strings.Join(list, ", "))
+}
+
+// IsNull tests if a value is null
+type IsNull struct {
+	X   Expr
+	Not bool
+}
+
+// String implements the Expr interface
+func (n *IsNull) String() string {
+	if n.Not {
+		return n.X.String() + " IS NOT NULL"
+	}
+	return n.X.String() + " IS NULL"
+}
+
+// Between tests if a value falls within an inclusive range
+type Between struct {
+	X, Lo, Hi Expr
+	Not       bool
+}
+
+// String implements the Expr interface
+func (b *Between) String() string {
+	op := "BETWEEN"
+	if b.Not {
+		op = "NOT BETWEEN"
+	}
+	return fmt.Sprintf("%s %s %s AND %s", b.X.String(), op, b.Lo.String(), b.Hi.String())
+}
+
+// walk calls fn for x and every expression nested within x, stopping early if
+// fn returns false
+func walk(x Expr, fn func(Expr) bool) bool {
+	if x == nil {
+		return true
+	}
+	if !fn(x) {
+		return false
+	}
+	switch e := x.(type) {
+	case *Unary:
+		return walk(e.X, fn)
+	case *Binary:
+		return walk(e.L, fn) && walk(e.R, fn)
+	case *Call:
+		for _, a := range e.Args {
+			if !walk(a, fn) {
+				return false
+			}
+		}
+	case *In:
+		if !walk(e.X, fn) {
+			return false
+		}
+		for _, a := range e.List {
+			if !walk(a, fn) {
+				return false
+			}
+		}
+	case *IsNull:
+		return walk(e.X, fn)
+	case *Between:
+		return walk(e.X, fn) && walk(e.Lo, fn) && walk(e.Hi, fn)
+	}
+	return true
+}
+
+// hasAggregate checks if an expression contains an aggregate function call
+func hasAggregate(x Expr) bool {
+	found := false
+	walk(x, func(e Expr) bool {
+		if c, ok := e.(*Call); ok && aggregates[strings.ToUpper(c.Name)] {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
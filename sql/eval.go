@@ -0,0 +1,599 @@
+package sql
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// aggregates is the set of supported aggregate functions
+var aggregates = map[string]bool{
+	"COUNT": true,
+	"SUM":   true,
+	"AVG":   true,
+	"MIN":   true,
+	"MAX":   true,
+}
+
+// scalarFunc is a function that computes a value from a row's values
+type scalarFunc func(args []interface{}) (interface{}, error)
+
+// functions is the set of supported scalar functions
+var functions = map[string]scalarFunc{
+	"LOWER": func(args []interface{}) (interface{}, error) {
+		return stringFunc("LOWER", args, strings.ToLower)
+	},
+	"UPPER": func(args []interface{}) (interface{}, error) {
+		return stringFunc("UPPER", args, strings.ToUpper)
+	},
+	"TRIM": func(args []interface{}) (interface{}, error) {
+		return stringFunc("TRIM", args, strings.TrimSpace)
+	},
+	"LENGTH": func(args []interface{}) (interface{}, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("LENGTH takes exactly one argument")
+		}
+		if args[0] == nil {
+			return nil, nil
+		}
+		return int64(len([]rune(toString(args[0])))), nil
+	},
+	"ABS": func(args []interface{}) (interface{}, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("ABS takes exactly one argument")
+		}
+		switch v := args[0].(type) {
+		case nil:
+			return nil, nil
+		case int64:
+			if v < 0 {
+				return -v, nil
+			}
+			return v, nil
+		case float64:
+			return math.Abs(v), nil
+		}
+		return nil, fmt.Errorf("ABS requires a number, got %s", typeName(args[0]))
+	},
+	"ROUND": func(args []interface{}) (interface{}, error) {
+		if len(args) < 1 || len(args) > 2 {
+			return nil, fmt.Errorf("ROUND takes one or two arguments")
+		}
+		if args[0] == nil {
+			return nil, nil
+		}
+		f, ok := toFloat(args[0])
+		if !ok {
+			return nil, fmt.Errorf("ROUND requires a number, got %s", typeName(args[0]))
+		}
+		places := int64(0)
+		if len(args) == 2 {
+			if places, ok = args[1].(int64); !ok {
+				return nil, fmt.Errorf("ROUND places must be an integer")
+			}
+		}
+		if places == 0 {
+			return int64(math.Floor(f + 0.5)), nil
+		}
+		pow := math.Pow(10, float64(places))
+		return math.Floor(f*pow+0.5) / pow, nil
+	},
+	"COALESCE": func(args []interface{}) (interface{}, error) {
+		for _, a := range args {
+			if a != nil {
+				return a, nil
+			}
+		}
+		return nil, nil
+	},
+}
+
+func stringFunc(name string, args []interface{}, fn func(string) string) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("%s takes exactly one argument", name)
+	}
+	if args[0] == nil {
+		return nil, nil
+	}
+	return fn(toString(args[0])), nil
+}
+
+// env is the context an expression is evaluated in: a row of values, and when
+// aggregating, the group of rows the row belongs to
+type env struct {
+	scope *scope
+	row   []interface{}
+	group [][]interface{}
+}
+
+func (e *env) eval(x Expr) (interface{}, error) {
+	switch x := x.(type) {
+	case *Literal:
+		return x.Value, nil
+	case *ColumnRef:
+		i, err := e.scope.resolve(x)
+		if err != nil {
+			return nil, err
+		}
+		return e.row[i], nil
+	case *Unary:
+		return e.evalUnary(x)
+	case *Binary:
+		return e.evalBinary(x)
+	case *Call:
+		if aggregates[x.Name] {
+			return e.evalAggregate(x)
+		}
+		args := make([]interface{}, len(x.Args))
+		for i, a := range x.Args {
+			v, err := e.eval(a)
+			if err != nil {
+				return nil, err
+			}
+			args[i] = v
+		}
+		return functions[x.Name](args)
+	case *In:
+		v, err := e.eval(x.X)
+		if err != nil || v == nil {
+			return nil, err
+		}
+		found := false
+		for _, item := range x.List {
+			iv, err := e.eval(item)
+			if err != nil {
+				return nil, err
+			}
+			if c, ok := compare(v, iv); ok && c == 0 {
+				found = true
+				break
+			}
+		}
+		return found != x.Not, nil
+	case *IsNull:
+		v, err := e.eval(x.X)
+		if err != nil {
+			return nil, err
+		}
+		return (v == nil) != x.Not, nil
+	case *Between:
+		v, err := e.eval(x.X)
+		if err != nil {
+			return nil, err
+		}
+		lo, err := e.eval(x.Lo)
+		if err != nil {
+			return nil, err
+		}
+		hi, err := e.eval(x.Hi)
+		if err != nil {
+			return nil, err
+		}
+		if v == nil || lo == nil || hi == nil {
+			return nil, nil
+		}
+		a, ok := compare(v, lo)
+		if !ok {
+			return nil, fmt.Errorf("cannot compare %s and %s", typeName(v), typeName(lo))
+		}
+		b, ok := compare(v, hi)
+		if !ok {
+			return nil, fmt.Errorf("cannot compare %s and %s", typeName(v), typeName(hi))
+		}
+		return (a >= 0 && b <= 0) != x.Not, nil
+	}
+	return nil, fmt.Errorf("unsupported expression: %s", x)
+}
+
+func (e *env) evalUnary(x *Unary) (interface{}, error) {
+	v, err := e.eval(x.X)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	switch x.Op {
+	case "NOT":
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("NOT requires a boolean, got %s", typeName(v))
+		}
+		return !b, nil
+	case "-":
+		switch n := v.(type) {
+		case int64:
+			return -n, nil
+		case float64:
+			return -n, nil
+		}
+		return nil, fmt.Errorf("cannot negate %s", typeName(v))
+	}
+	return nil, fmt.Errorf("unknown operator %s", x.Op)
+}
+
+func (e *env) evalBinary(x *Binary) (interface{}, error) {
+	l, err := e.eval(x.L)
+	if err != nil {
+		return nil, err
+	}
+
+	// AND & OR use three-valued logic, short circuiting where possible
+	switch x.Op {
+	case "AND":
+		if l == false {
+			return false, nil
+		}
+		r, err := e.eval(x.R)
+		if err != nil {
+			return nil, err
+		}
+		return and(l, r)
+	case "OR":
+		if l == true {
+			return true, nil
+		}
+		r, err := e.eval(x.R)
+		if err != nil {
+			return nil, err
+		}
+		return or(l, r)
+	}
+
+	r, err := e.eval(x.R)
+	if err != nil {
+		return nil, err
+	}
+	if l == nil || r == nil {
+		return nil, nil
+	}
+
+	switch x.Op {
+	case "=", "!=", "<", "<=", ">", ">=":
+		c, ok := compare(l, r)
+		if !ok {
+			return nil, fmt.Errorf("cannot compare %s and %s", typeName(l), typeName(r))
+		}
+		switch x.Op {
+		case "=":
+			return c == 0, nil
+		case "!=":
+			return c != 0, nil
+		case "<":
+			return c < 0, nil
+		case "<=":
+			return c <= 0, nil
+		case ">":
+			return c > 0, nil
+		}
+		return c >= 0, nil
+	case "LIKE":
+		return like(toString(l), toString(r))
+	case "||":
+		return toString(l) + toString(r), nil
+	}
+	return arithmetic(x.Op, l, r)
+}
+
+func (e *env) evalAggregate(x *Call) (interface{}, error) {
+	if e.group == nil {
+		return nil, fmt.Errorf("aggregate function %s is not allowed here", x)
+	}
+	if x.Star {
+		return int64(len(e.group)), nil
+	}
+	if len(x.Args) != 1 {
+		return nil, fmt.Errorf("%s takes exactly one argument", x.Name)
+	}
+
+	var (
+		vals []interface{}
+		seen = map[string]bool{}
+	)
+	for _, row := range e.group {
+		sub := &env{scope: e.scope, row: row}
+		v, err := sub.eval(x.Args[0])
+		if err != nil {
+			return nil, err
+		}
+		if v == nil {
+			continue
+		}
+		if x.Distinct {
+			k := valuesKey([]interface{}{v})
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+		}
+		vals = append(vals, v)
+	}
+
+	switch x.Name {
+	case "COUNT":
+		return int64(len(vals)), nil
+	case "MIN", "MAX":
+		var res interface{}
+		for _, v := range vals {
+			if res == nil {
+				res = v
+				continue
+			}
+			c, ok := compare(v, res)
+			if !ok {
+				return nil, fmt.Errorf("%s: cannot compare %s and %s", x.Name, typeName(v), typeName(res))
+			}
+			if (x.Name == "MIN" && c < 0) || (x.Name == "MAX" && c > 0) {
+				res = v
+			}
+		}
+		return res, nil
+	}
+
+	// SUM & AVG
+	if len(vals) == 0 {
+		return nil, nil
+	}
+	var (
+		sum   interface{} = int64(0)
+		err   error
+		count = float64(len(vals))
+	)
+	for _, v := range vals {
+		if _, ok := toFloat(v); !ok {
+			return nil, fmt.Errorf("%s requires numbers, got %s", x.Name, typeName(v))
+		}
+		if sum, err = arithmetic("+", sum, v); err != nil {
+			return nil, err
+		}
+	}
+	if x.Name == "AVG" {
+		f, _ := toFloat(sum)
+		return f / count, nil
+	}
+	return sum, nil
+}
+
+// and implements three-valued logical conjunction
+func and(l, r interface{}) (interface{}, error) {
+	if err := checkBool(l); err != nil {
+		return nil, err
+	}
+	if err := checkBool(r); err != nil {
+		return nil, err
+	}
+	if l == false || r == false {
+		return false, nil
+	}
+	if l == nil || r == nil {
+		return nil, nil
+	}
+	return true, nil
+}
+
+// or implements three-valued logical disjunction
+func or(l, r interface{}) (interface{}, error) {
+	if err := checkBool(l); err != nil {
+		return nil, err
+	}
+	if err := checkBool(r); err != nil {
+		return nil, err
+	}
+	if l == true || r == true {
+		return true, nil
+	}
+	if l == nil || r == nil {
+		return nil, nil
+	}
+	return false, nil
+}
+
+func checkBool(v interface{}) error {
+	if _, ok := v.(bool); v != nil && !ok {
+		return fmt.Errorf("expected boolean, got %s", typeName(v))
+	}
+	return nil
+}
+
+// truthy converts the result of a condition to a bool. null is false
+func truthy(v interface{}) (bool, error) {
+	switch b := v.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return b, nil
+	}
+	return false, fmt.Errorf("condition must be a boolean, got %s", typeName(v))
+}
+
+func arithmetic(op string, l, r interface{}) (interface{}, error) {
+	li, lint := l.(int64)
+	ri, rint := r.(int64)
+	if lint && rint {
+		switch op {
+		case "+":
+			return li + ri, nil
+		case "-":
+			return li - ri, nil
+		case "*":
+			return li * ri, nil
+		case "/":
+			if ri == 0 {
+				return nil, nil
+			}
+			return li / ri, nil
+		case "%":
+			if ri == 0 {
+				return nil, nil
+			}
+			return li % ri, nil
+		}
+	}
+
+	lf, lok := toFloat(l)
+	rf, rok := toFloat(r)
+	if !lok || !rok {
+		return nil, fmt.Errorf("cannot apply %s to %s and %s", op, typeName(l), typeName(r))
+	}
+	switch op {
+	case "+":
+		return lf + rf, nil
+	case "-":
+		return lf - rf, nil
+	case "*":
+		return lf * rf, nil
+	case "/":
+		if rf == 0 {
+			return nil, nil
+		}
+		return lf / rf, nil
+	case "%":
+		if rf == 0 {
+			return nil, nil
+		}
+		return math.Mod(lf, rf), nil
+	}
+	return nil, fmt.Errorf("unknown operator %s", op)
+}
+
+// compare orders two non-null values, returning false if the values are of
+// types that can't be compared
+func compare(a, b interface{}) (int, bool) {
+	if ai, ok := a.(int64); ok {
+		if bi, ok := b.(int64); ok {
+			switch {
+			case ai < bi:
+				return -1, true
+			case ai > bi:
+				return 1, true
+			}
+			return 0, true
+		}
+	}
+	if af, ok := toFloat(a); ok {
+		if bf, ok := toFloat(b); ok {
+			switch {
+			case af < bf:
+				return -1, true
+			case af > bf:
+				return 1, true
+			}
+			return 0, true
+		}
+		return 0, false
+	}
+
+	switch av := a.(type) {
+	case string:
+		if bv, ok := b.(string); ok {
+			return strings.Compare(av, bv), true
+		}
+	case bool:
+		if bv, ok := b.(bool); ok {
+			switch {
+			case av == bv:
+				return 0, true
+			case !av:
+				return -1, true
+			}
+			return 1, true
+		}
+	}
+	return 0, false
+}
+
+// like matches a string against a SQL LIKE pattern, where % matches any run
+// of characters & _ matches a single character
+func like(s, pattern string) (bool, error) {
+	expr := "^"
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			expr += ".*"
+		case '_':
+			expr += "."
+		default:
+			expr += regexp.QuoteMeta(string(r))
+		}
+	}
+	re, err := regexp.Compile(expr + "$")
+	if err != nil {
+		return false, err
+	}
+	return re.MatchString(s), nil
+}
+
+// normalize converts a value read from a dataset body to one of the value
+// types the engine works with: nil, int64, float64, string, bool. Values of
+// any other type (like nested objects) are kept as is
+func normalize(v interface{}) interface{} {
+	switch n := v.(type) {
+	case int:
+		return int64(n)
+	case int8:
+		return int64(n)
+	case int16:
+		return int64(n)
+	case int32:
+		return int64(n)
+	case uint:
+		return int64(n)
+	case uint8:
+		return int64(n)
+	case uint16:
+		return int64(n)
+	case uint32:
+		return int64(n)
+	case uint64:
+		return int64(n)
+	case float32:
+		return float64(n)
+	}
+	return v
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func toString(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// typeName gives the json schema type name of a value
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case int64:
+		return "integer"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// valuesKey creates a string key that uniquely identifies a list of values,
+// for grouping & de-duplicating
+func valuesKey(vals []interface{}) string {
+	key := ""
+	for _, v := range vals {
+		key += fmt.Sprintf("%s:%v\x00", typeName(v), v)
+	}
+	return key
+}
@@ -0,0 +1,493 @@
+package sql
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Exec runs a parsed SELECT statement against tables from a source
+func Exec(stmt *Select, src Source) (*Table, error) {
+	sc := &scope{}
+	rows, err := loadTable(stmt.From, src, sc)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, j := range stmt.Joins {
+		if rows, err = join(rows, sc, j, src); err != nil {
+			return nil, err
+		}
+	}
+
+	if stmt.Where != nil {
+		if hasAggregate(stmt.Where) {
+			return nil, fmt.Errorf("aggregate functions are not allowed in WHERE")
+		}
+		if rows, err = filter(sc, rows, stmt.Where); err != nil {
+			return nil, err
+		}
+	}
+
+	envs, err := contexts(stmt, sc, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := project(stmt, sc, envs)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// loadTable reads a table from a source, adding it's columns to a scope
+func loadTable(ref *TableRef, src Source, sc *scope) ([][]interface{}, error) {
+	alias := ref.Alias
+	if alias == "" {
+		alias = defaultAlias(ref.Name)
+	}
+	for _, c := range sc.cols {
+		if c.table == alias {
+			return nil, fmt.Errorf("table name \"%s\" specified more than once, use an alias", alias)
+		}
+	}
+
+	t, err := src.Table(ref.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([][]interface{}, len(t.Rows))
+	for i, r := range t.Rows {
+		row := make([]interface{}, len(t.Columns))
+		for j := 0; j < len(row) && j < len(r); j++ {
+			row[j] = normalize(r[j])
+		}
+		rows[i] = row
+	}
+
+	sc.add(alias, t)
+	return rows, nil
+}
+
+// join combines rows with the rows of another table. Joins on equality of a
+// column from each side are performed with a hash table, all others compare
+// every pair of rows
+func join(left [][]interface{}, sc *scope, j *Join, src Source) ([][]interface{}, error) {
+	leftWidth := len(sc.cols)
+	right, err := loadTable(j.Table, src, sc)
+	if err != nil {
+		return nil, err
+	}
+	width := len(sc.cols)
+
+	combine := func(l, r []interface{}) []interface{} {
+		row := make([]interface{}, width)
+		copy(row, l)
+		if r != nil {
+			copy(row[leftWidth:], r)
+		}
+		return row
+	}
+
+	var rows [][]interface{}
+	if li, ri, ok := equiJoinColumns(j.On, sc, leftWidth); ok {
+		index := map[string][]int{}
+		for i, r := range right {
+			if k, ok := joinKey(r[ri-leftWidth]); ok {
+				index[k] = append(index[k], i)
+			}
+		}
+		for _, l := range left {
+			k, ok := joinKey(l[li])
+			matches := index[k]
+			if ok && len(matches) > 0 {
+				for _, i := range matches {
+					rows = append(rows, combine(l, right[i]))
+				}
+			} else if j.Type == LeftJoin {
+				rows = append(rows, combine(l, nil))
+			}
+		}
+		return rows, nil
+	}
+
+	for _, l := range left {
+		matched := false
+		for _, r := range right {
+			row := combine(l, r)
+			if j.On != nil {
+				v, err := (&env{scope: sc, row: row}).eval(j.On)
+				if err != nil {
+					return nil, err
+				}
+				if ok, err := truthy(v); err != nil {
+					return nil, fmt.Errorf("JOIN ON %s", err.Error())
+				} else if !ok {
+					continue
+				}
+			}
+			matched = true
+			rows = append(rows, row)
+		}
+		if !matched && j.Type == LeftJoin {
+			rows = append(rows, combine(l, nil))
+		}
+	}
+	return rows, nil
+}
+
+// equiJoinColumns checks if a join condition is an equality comparison of a
+// column on the left side of a join with a column on the right, returning the
+// scope indexes of both columns
+func equiJoinColumns(on Expr, sc *scope, leftWidth int) (int, int, bool) {
+	b, ok := on.(*Binary)
+	if !ok || b.Op != "=" {
+		return 0, 0, false
+	}
+	lref, lok := b.L.(*ColumnRef)
+	rref, rok := b.R.(*ColumnRef)
+	if !lok || !rok {
+		return 0, 0, false
+	}
+	li, err := sc.resolve(lref)
+	if err != nil {
+		return 0, 0, false
+	}
+	ri, err := sc.resolve(rref)
+	if err != nil {
+		return 0, 0, false
+	}
+	switch {
+	case li < leftWidth && ri >= leftWidth:
+		return li, ri, true
+	case ri < leftWidth && li >= leftWidth:
+		return ri, li, true
+	}
+	return 0, 0, false
+}
+
+// joinKey gives a hash key for a join value. numbers of different types that
+// compare as equal produce the same key. null values never match
+func joinKey(v interface{}) (string, bool) {
+	if v == nil {
+		return "", false
+	}
+	if f, ok := toFloat(v); ok {
+		return "n:" + strconv.FormatFloat(f, 'g', -1, 64), true
+	}
+	return valuesKey([]interface{}{v}), true
+}
+
+// filter drops rows that don't satisfy a condition
+func filter(sc *scope, rows [][]interface{}, cond Expr) ([][]interface{}, error) {
+	kept := rows[:0]
+	for _, row := range rows {
+		v, err := (&env{scope: sc, row: row}).eval(cond)
+		if err != nil {
+			return nil, err
+		}
+		ok, err := truthy(v)
+		if err != nil {
+			return nil, fmt.Errorf("WHERE %s", err.Error())
+		}
+		if ok {
+			kept = append(kept, row)
+		}
+	}
+	return kept, nil
+}
+
+// contexts creates an evaluation environment for each row of output. For
+// aggregate queries each environment is a group of rows
+func contexts(stmt *Select, sc *scope, rows [][]interface{}) ([]*env, error) {
+	grouped := len(stmt.GroupBy) > 0 || stmt.Having != nil
+	for _, f := range stmt.Fields {
+		grouped = grouped || hasAggregate(f.Expr)
+	}
+	for _, o := range stmt.OrderBy {
+		grouped = grouped || hasAggregate(o.Expr)
+	}
+
+	if !grouped {
+		envs := make([]*env, len(rows))
+		for i, row := range rows {
+			envs[i] = &env{scope: sc, row: row}
+		}
+		return envs, nil
+	}
+
+	for _, x := range stmt.GroupBy {
+		if hasAggregate(x) {
+			return nil, fmt.Errorf("aggregate functions are not allowed in GROUP BY")
+		}
+	}
+
+	var envs []*env
+	if len(stmt.GroupBy) == 0 {
+		// aggregating without grouping always produces a single row, even when
+		// there are no input rows
+		first := make([]interface{}, len(sc.cols))
+		if len(rows) > 0 {
+			first = rows[0]
+		} else {
+			rows = [][]interface{}{}
+		}
+		envs = []*env{{scope: sc, row: first, group: rows}}
+	} else {
+		groups := map[string]*env{}
+		for _, row := range rows {
+			vals := make([]interface{}, len(stmt.GroupBy))
+			for i, x := range stmt.GroupBy {
+				v, err := (&env{scope: sc, row: row}).eval(x)
+				if err != nil {
+					return nil, err
+				}
+				vals[i] = v
+			}
+			k := valuesKey(vals)
+			if g, ok := groups[k]; ok {
+				g.group = append(g.group, row)
+				continue
+			}
+			g := &env{scope: sc, row: row, group: [][]interface{}{row}}
+			groups[k] = g
+			envs = append(envs, g)
+		}
+	}
+
+	if stmt.Having != nil {
+		kept := envs[:0]
+		for _, e := range envs {
+			v, err := e.eval(stmt.Having)
+			if err != nil {
+				return nil, err
+			}
+			ok, err := truthy(v)
+			if err != nil {
+				return nil, fmt.Errorf("HAVING %s", err.Error())
+			}
+			if ok {
+				kept = append(kept, e)
+			}
+		}
+		envs = kept
+	}
+	return envs, nil
+}
+
+// output is a projected column
+type output struct {
+	Column
+	expr Expr
+}
+
+// outputs expands the select list of a statement into output columns
+func outputs(stmt *Select, sc *scope) ([]output, error) {
+	var outs []output
+	for _, f := range stmt.Fields {
+		if f.Star {
+			found := false
+			for _, c := range sc.cols {
+				if f.Table != "" && c.table != f.Table {
+					continue
+				}
+				found = true
+				outs = append(outs, output{
+					Column: c.Column,
+					expr:   &ColumnRef{Table: c.table, Name: c.Name},
+				})
+			}
+			if !found {
+				return nil, fmt.Errorf("table \"%s\" does not exist", f.Table)
+			}
+			continue
+		}
+
+		o := output{Column: Column{Name: f.Alias}, expr: f.Expr}
+		if ref, ok := f.Expr.(*ColumnRef); ok {
+			i, err := sc.resolve(ref)
+			if err != nil {
+				return nil, err
+			}
+			o.Type = sc.cols[i].Type
+			if o.Name == "" {
+				o.Name = ref.Name
+			}
+		}
+		if o.Name == "" {
+			o.Name = f.Expr.String()
+		}
+		outs = append(outs, o)
+	}
+	return outs, nil
+}
+
+// project evaluates output columns for each context, then applies DISTINCT,
+// ORDER BY, OFFSET & LIMIT
+func project(stmt *Select, sc *scope, envs []*env) (*Table, error) {
+	outs, err := outputs(stmt, sc)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([][]interface{}, 0, len(envs))
+	rowEnvs := make([]*env, 0, len(envs))
+	seen := map[string]bool{}
+	for _, e := range envs {
+		row := make([]interface{}, len(outs))
+		for i, o := range outs {
+			if row[i], err = e.eval(o.expr); err != nil {
+				return nil, err
+			}
+		}
+		if stmt.Distinct {
+			k := valuesKey(row)
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+		}
+		rows = append(rows, row)
+		rowEnvs = append(rowEnvs, e)
+	}
+
+	if len(stmt.OrderBy) > 0 {
+		if rows, err = order(stmt.OrderBy, outs, rows, rowEnvs); err != nil {
+			return nil, err
+		}
+	}
+
+	if stmt.Offset > 0 {
+		if stmt.Offset > len(rows) {
+			stmt.Offset = len(rows)
+		}
+		rows = rows[stmt.Offset:]
+	}
+	if stmt.Limit >= 0 && stmt.Limit < len(rows) {
+		rows = rows[:stmt.Limit]
+	}
+
+	t := &Table{Columns: make([]Column, len(outs)), Rows: rows}
+	for i, o := range outs {
+		t.Columns[i] = Column{Name: o.Name, Type: columnType(rows, i, o.Type)}
+	}
+	return t, nil
+}
+
+// order sorts output rows. ORDER BY terms can be output column positions
+// (starting at 1), output column names, or expressions
+func order(terms []*Order, outs []output, rows [][]interface{}, envs []*env) ([][]interface{}, error) {
+	keys := make([][]interface{}, len(rows))
+	for i := range rows {
+		keys[i] = make([]interface{}, len(terms))
+	}
+
+	for t, term := range terms {
+		col := -1
+		switch x := term.Expr.(type) {
+		case *Literal:
+			n, ok := x.Value.(int64)
+			if !ok || n < 1 || int(n) > len(outs) {
+				return nil, fmt.Errorf("ORDER BY position %s is not in select list", x)
+			}
+			col = int(n) - 1
+		case *ColumnRef:
+			if x.Table == "" {
+				for i, o := range outs {
+					if o.Name == x.Name {
+						col = i
+						break
+					}
+				}
+			}
+		}
+
+		for i, row := range rows {
+			if col >= 0 {
+				keys[i][t] = row[col]
+				continue
+			}
+			v, err := envs[i].eval(term.Expr)
+			if err != nil {
+				return nil, err
+			}
+			keys[i][t] = v
+		}
+	}
+
+	idx := make([]int, len(rows))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool {
+		for t, term := range terms {
+			c := orderCompare(keys[idx[a]][t], keys[idx[b]][t])
+			if c == 0 {
+				continue
+			}
+			if term.Desc {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+
+	sorted := make([][]interface{}, len(rows))
+	for i, j := range idx {
+		sorted[i] = rows[j]
+	}
+	return sorted, nil
+}
+
+// orderCompare compares values for sorting. nulls sort first, and values of
+// incomparable types are ordered by type name
+func orderCompare(a, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	if c, ok := compare(a, b); ok {
+		return c
+	}
+	ta, tb := typeName(a), typeName(b)
+	switch {
+	case ta < tb:
+		return -1
+	case ta > tb:
+		return 1
+	}
+	return 0
+}
+
+// columnType infers the json schema type of an output column from it's
+// values, falling back to the declared type of the column if all values are
+// null. Columns with mixed types have no type
+func columnType(rows [][]interface{}, col int, declared string) string {
+	typ := ""
+	for _, row := range rows {
+		if row[col] == nil {
+			continue
+		}
+		t := typeName(row[col])
+		switch {
+		case typ == "":
+			typ = t
+		case typ == t:
+		case (typ == "integer" || typ == "number") && (t == "integer" || t == "number"):
+			typ = "number"
+		default:
+			return ""
+		}
+	}
+	if typ == "" {
+		return declared
+	}
+	return typ
+}
@@ -0,0 +1,144 @@
+package sql
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+var testTables = MapSource{
+	"me/cities": &Table{
+		Columns: []Column{
+			{Name: "city", Type: "string"},
+			{Name: "pop", Type: "integer"},
+			{Name: "avg_age", Type: "number"},
+			{Name: "in_usa", Type: "boolean"},
+		},
+		Rows: [][]interface{}{
+			{"toronto", 40000000, 55.5, false},
+			{"new york", 8500000, 44.4, true},
+			{"chicago", 300000, 44.4, true},
+			{"chatham", 35000, 65.25, true},
+			{"raleigh", 250000, 50.65, true},
+		},
+	},
+	"peer/countries": &Table{
+		Columns: []Column{
+			{Name: "in_usa", Type: "boolean"},
+			{Name: "country", Type: "string"},
+		},
+		Rows: [][]interface{}{
+			{true, "usa"},
+			{false, "canada"},
+		},
+	},
+	"peer/mayors": &Table{
+		Columns: []Column{
+			{Name: "city", Type: "string"},
+			{Name: "mayor", Type: "string"},
+		},
+		Rows: [][]interface{}{
+			{"toronto", "john tory"},
+			{"chicago", "rahm emanuel"},
+		},
+	},
+}
+
+func TestQuery(t *testing.T) {
+	cases := []struct {
+		query   string
+		columns []Column
+		rows    string
+	}{
+		{"SELECT city FROM me/cities WHERE pop > 300000",
+			[]Column{{"city", "string"}},
+			`[["toronto"],["new york"]]`},
+		{"SELECT city, pop / 1000 AS kpop FROM me/cities WHERE in_usa AND city LIKE 'ch%' ORDER BY 2",
+			[]Column{{"city", "string"}, {"kpop", "integer"}},
+			`[["chatham",35],["chicago",300]]`},
+		{"SELECT * FROM me/cities ORDER BY avg_age DESC, city LIMIT 2 OFFSET 2",
+			[]Column{{"city", "string"}, {"pop", "integer"}, {"avg_age", "number"}, {"in_usa", "boolean"}},
+			`[["raleigh",250000,50.65,true],["chicago",300000,44.4,true]]`},
+		{"SELECT count(*), sum(pop), min(city), max(avg_age) FROM me/cities",
+			[]Column{{"count(*)", "integer"}, {"sum(pop)", "integer"}, {"min(city)", "string"}, {"max(avg_age)", "number"}},
+			`[[5,49085000,"chatham",65.25]]`},
+		{"SELECT count(*), avg(pop) FROM me/cities WHERE pop < 0",
+			[]Column{{"count(*)", "integer"}, {"avg(pop)", ""}},
+			`[[0,null]]`},
+		{"SELECT in_usa, count(*) AS n FROM me/cities GROUP BY in_usa HAVING count(*) > 1",
+			[]Column{{"in_usa", "boolean"}, {"n", "integer"}},
+			`[[true,4]]`},
+		{"SELECT count(DISTINCT avg_age) FROM me/cities",
+			[]Column{{"count(DISTINCT avg_age)", "integer"}},
+			`[[4]]`},
+		{"SELECT DISTINCT in_usa FROM me/cities ORDER BY in_usa",
+			[]Column{{"in_usa", "boolean"}},
+			`[[false],[true]]`},
+		{"SELECT c.city, n.country FROM me/cities c JOIN peer/countries n ON c.in_usa = n.in_usa WHERE c.pop > 1000000",
+			[]Column{{"city", "string"}, {"country", "string"}},
+			`[["toronto","canada"],["new york","usa"]]`},
+		{"SELECT cities.city, mayor FROM me/cities LEFT JOIN peer/mayors m ON cities.city = m.city AND m.mayor IS NOT NULL ORDER BY city LIMIT 3",
+			[]Column{{"city", "string"}, {"mayor", "string"}},
+			`[["chatham",null],["chicago","rahm emanuel"],["new york",null]]`},
+		{"SELECT country, count(*) FROM me/cities c, peer/countries n WHERE c.in_usa = n.in_usa GROUP BY country ORDER BY count(*) DESC",
+			[]Column{{"country", "string"}, {"count(*)", "integer"}},
+			`[["usa",4],["canada",1]]`},
+		{"SELECT upper(city) || '!', round(avg_age), coalesce(NULL, 1) FROM me/cities WHERE city IN ('toronto', 'nowhere')",
+			[]Column{{"upper(city) || '!'", "string"}, {"round(avg_age)", "integer"}, {"coalesce(NULL, 1)", "integer"}},
+			`[["TORONTO!",56,1]]`},
+	}
+
+	for i, c := range cases {
+		res, err := Query(c.query, testTables)
+		if err != nil {
+			t.Errorf("case %d unexpected error: %s", i, err)
+			continue
+		}
+
+		if len(res.Columns) != len(c.columns) {
+			t.Errorf("case %d column count mismatch. expected: %d, got: %d", i, len(c.columns), len(res.Columns))
+			continue
+		}
+		for j, col := range c.columns {
+			if res.Columns[j] != col {
+				t.Errorf("case %d column %d mismatch. expected: %v, got: %v", i, j, col, res.Columns[j])
+			}
+		}
+
+		data, err := json.Marshal(res.Rows)
+		if err != nil {
+			t.Errorf("case %d error marshaling rows: %s", i, err)
+			continue
+		}
+		if string(data) != c.rows {
+			t.Errorf("case %d rows mismatch. expected:\n%s\ngot:\n%s", i, c.rows, string(data))
+		}
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	cases := []struct {
+		query, err string
+	}{
+		{"SELECT * FROM me/nope", "table not found: me/nope"},
+		{"SELECT nope FROM me/cities", "column \"nope\" does not exist"},
+		{"SELECT city FROM me/cities JOIN peer/mayors ON cities.city = mayors.city", "column reference \"city\" is ambiguous"},
+		{"SELECT * FROM me/cities, me/cities", "table name \"cities\" specified more than once, use an alias"},
+		{"SELECT city FROM me/cities WHERE count(*) > 1", "aggregate functions are not allowed in WHERE"},
+		{"SELECT city FROM me/cities WHERE pop", "WHERE condition must be a boolean, got integer"},
+		{"SELECT city FROM me/cities WHERE city > 1", "cannot compare string and integer"},
+		{"SELECT x.* FROM me/cities", "table \"x\" does not exist"},
+		{"SELECT city FROM me/cities ORDER BY 3", "ORDER BY position 3 is not in select list"},
+		{"SELECT sum(city) FROM me/cities", "SUM requires numbers, got string"},
+	}
+
+	for i, c := range cases {
+		_, err := Query(c.query, testTables)
+		if err == nil {
+			t.Errorf("case %d expected error, got nil", i)
+			continue
+		}
+		if err.Error() != c.err {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%s'", i, c.err, err)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package sql
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenType int
+
+const (
+	tEOF tokenType = iota
+	tIdent
+	tKeyword
+	tNumber
+	tString
+	tOp
+)
+
+// token is a single lexical unit of a query
+type token struct {
+	typ tokenType
+	val string
+	pos int
+}
+
+func (t token) String() string {
+	switch t.typ {
+	case tEOF:
+		return "end of query"
+	case tString:
+		return fmt.Sprintf("'%s'", t.val)
+	}
+	return fmt.Sprintf("\"%s\"", t.val)
+}
+
+// keywords is the set of reserved words. Keywords are case-insensitive, and can
+// be used as identifiers by quoting them
+var keywords = map[string]bool{
+	"SELECT":   true,
+	"DISTINCT": true,
+	"FROM":     true,
+	"AS":       true,
+	"JOIN":     true,
+	"INNER":    true,
+	"LEFT":     true,
+	"OUTER":    true,
+	"CROSS":    true,
+	"ON":       true,
+	"WHERE":    true,
+	"GROUP":    true,
+	"BY":       true,
+	"HAVING":   true,
+	"ORDER":    true,
+	"ASC":      true,
+	"DESC":     true,
+	"LIMIT":    true,
+	"OFFSET":   true,
+	"AND":      true,
+	"OR":       true,
+	"NOT":      true,
+	"IS":       true,
+	"NULL":     true,
+	"TRUE":     true,
+	"FALSE":    true,
+	"LIKE":     true,
+	"IN":       true,
+	"BETWEEN":  true,
+}
+
+// lex splits a query string into tokens
+func lex(q string) ([]token, error) {
+	var (
+		toks []token
+		rs   = []rune(q)
+		i    = 0
+	)
+
+	for i < len(rs) {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '-' && i+1 < len(rs) && rs[i+1] == '-':
+			// line comment
+			for i < len(rs) && rs[i] != '\n' {
+				i++
+			}
+		case isIdentStart(r):
+			start := i
+			for i < len(rs) && isIdentPart(rs[i]) {
+				i++
+			}
+			word := string(rs[start:i])
+			if upper := strings.ToUpper(word); keywords[upper] {
+				toks = append(toks, token{tKeyword, upper, start})
+			} else {
+				toks = append(toks, token{tIdent, word, start})
+			}
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(rs) && unicode.IsDigit(rs[i+1])):
+			start := i
+			for i < len(rs) && (unicode.IsDigit(rs[i]) || rs[i] == '.') {
+				i++
+			}
+			if i < len(rs) && (rs[i] == 'e' || rs[i] == 'E') {
+				i++
+				if i < len(rs) && (rs[i] == '+' || rs[i] == '-') {
+					i++
+				}
+				for i < len(rs) && unicode.IsDigit(rs[i]) {
+					i++
+				}
+			}
+			toks = append(toks, token{tNumber, string(rs[start:i]), start})
+		case r == '\'':
+			start := i
+			str, n, err := lexQuoted(rs[i:], '\'')
+			if err != nil {
+				return nil, fmt.Errorf("unterminated string starting at position %d", start)
+			}
+			i += n
+			toks = append(toks, token{tString, str, start})
+		case r == '"' || r == '`':
+			// quoted identifiers are never keywords
+			start := i
+			str, n, err := lexQuoted(rs[i:], r)
+			if err != nil {
+				return nil, fmt.Errorf("unterminated identifier starting at position %d", start)
+			}
+			i += n
+			toks = append(toks, token{tIdent, str, start})
+		default:
+			op := lexOp(rs[i:])
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character '%c' at position %d", r, i)
+			}
+			toks = append(toks, token{tOp, op, i})
+			i += len(op)
+		}
+	}
+
+	return append(toks, token{tEOF, "", len(rs)}), nil
+}
+
+// lexQuoted reads a string wrapped in quote characters, where a doubled quote
+// character is an escaped quote. lexQuoted returns the unquoted string & the
+// number of runes consumed
+func lexQuoted(rs []rune, quote rune) (string, int, error) {
+	sb := bytes.Buffer{}
+	for i := 1; i < len(rs); i++ {
+		if rs[i] == quote {
+			if i+1 < len(rs) && rs[i+1] == quote {
+				sb.WriteRune(quote)
+				i++
+				continue
+			}
+			return sb.String(), i + 1, nil
+		}
+		sb.WriteRune(rs[i])
+	}
+	return "", 0, fmt.Errorf("unterminated")
+}
+
+// lexOp matches the longest operator at the start of rs
+func lexOp(rs []rune) string {
+	if len(rs) > 1 {
+		switch two := string(rs[:2]); two {
+		case "<=", ">=", "<>", "!=", "||":
+			return two
+		}
+	}
+	switch rs[0] {
+	case '=', '<', '>', '+', '-', '*', '/', '%', '(', ')', ',', '.', ';':
+		return string(rs[0])
+	}
+	return ""
+}
+
+func isIdentStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+func isIdentPart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,599 @@
+package sql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Parse reads a SELECT statement from a query string
+func Parse(q string) (*Select, error) {
+	toks, err := lex(q)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{toks: toks}
+	stmt, err := p.parseSelect()
+	if err != nil {
+		return nil, err
+	}
+	// allow a single trailing semicolon
+	p.acceptOp(";")
+	if p.peek().typ != tEOF {
+		return nil, p.errorf("unexpected %s", p.peek())
+	}
+	return stmt, nil
+}
+
+type parser struct {
+	toks []token
+	i    int
+}
+
+func (p *parser) peek() token {
+	return p.toks[p.i]
+}
+
+func (p *parser) next() token {
+	t := p.toks[p.i]
+	if t.typ != tEOF {
+		p.i++
+	}
+	return t
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("syntax error at position %d: %s", p.peek().pos, fmt.Sprintf(format, args...))
+}
+
+// isKeyword checks if the next token is any of the given keywords
+func (p *parser) isKeyword(kws ...string) bool {
+	t := p.peek()
+	if t.typ != tKeyword {
+		return false
+	}
+	for _, kw := range kws {
+		if t.val == kw {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) acceptKeyword(kw string) bool {
+	if p.isKeyword(kw) {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectKeyword(kw string) error {
+	if !p.acceptKeyword(kw) {
+		return p.errorf("expected %s, found %s", kw, p.peek())
+	}
+	return nil
+}
+
+func (p *parser) isOp(op string) bool {
+	t := p.peek()
+	return t.typ == tOp && t.val == op
+}
+
+func (p *parser) acceptOp(op string) bool {
+	if p.isOp(op) {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectOp(op string) error {
+	if !p.acceptOp(op) {
+		return p.errorf("expected \"%s\", found %s", op, p.peek())
+	}
+	return nil
+}
+
+func (p *parser) expectIdent() (string, error) {
+	t := p.peek()
+	if t.typ != tIdent {
+		return "", p.errorf("expected identifier, found %s", t)
+	}
+	p.next()
+	return t.val, nil
+}
+
+func (p *parser) parseSelect() (*Select, error) {
+	if err := p.expectKeyword("SELECT"); err != nil {
+		return nil, err
+	}
+
+	s := &Select{Limit: -1}
+	s.Distinct = p.acceptKeyword("DISTINCT")
+
+	for {
+		f, err := p.parseField()
+		if err != nil {
+			return nil, err
+		}
+		s.Fields = append(s.Fields, f)
+		if !p.acceptOp(",") {
+			break
+		}
+	}
+
+	if err := p.expectKeyword("FROM"); err != nil {
+		return nil, err
+	}
+	from, err := p.parseTableRef()
+	if err != nil {
+		return nil, err
+	}
+	s.From = from
+
+	for {
+		j, err := p.parseJoin()
+		if err != nil {
+			return nil, err
+		}
+		if j == nil {
+			break
+		}
+		s.Joins = append(s.Joins, j)
+	}
+
+	if p.acceptKeyword("WHERE") {
+		if s.Where, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.acceptKeyword("GROUP") {
+		if err = p.expectKeyword("BY"); err != nil {
+			return nil, err
+		}
+		if s.GroupBy, err = p.parseExprList(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.acceptKeyword("HAVING") {
+		if s.Having, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.acceptKeyword("ORDER") {
+		if err = p.expectKeyword("BY"); err != nil {
+			return nil, err
+		}
+		for {
+			x, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			o := &Order{Expr: x}
+			if p.acceptKeyword("DESC") {
+				o.Desc = true
+			} else {
+				p.acceptKeyword("ASC")
+			}
+			s.OrderBy = append(s.OrderBy, o)
+			if !p.acceptOp(",") {
+				break
+			}
+		}
+	}
+
+	if p.acceptKeyword("LIMIT") {
+		if s.Limit, err = p.parseInt(); err != nil {
+			return nil, err
+		}
+	}
+	if p.acceptKeyword("OFFSET") {
+		if s.Offset, err = p.parseInt(); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+func (p *parser) parseInt() (int, error) {
+	t := p.peek()
+	if t.typ != tNumber {
+		return 0, p.errorf("expected number, found %s", t)
+	}
+	i, err := strconv.Atoi(t.val)
+	if err != nil || i < 0 {
+		return 0, p.errorf("expected positive integer, found %s", t)
+	}
+	p.next()
+	return i, nil
+}
+
+func (p *parser) parseField() (*Field, error) {
+	if p.acceptOp("*") {
+		return &Field{Star: true}, nil
+	}
+
+	// table.*
+	if p.peek().typ == tIdent && p.toks[p.i+1].typ == tOp && p.toks[p.i+1].val == "." &&
+		p.toks[p.i+2].typ == tOp && p.toks[p.i+2].val == "*" {
+		table := p.next().val
+		p.next()
+		p.next()
+		return &Field{Star: true, Table: table}, nil
+	}
+
+	x, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	f := &Field{Expr: x}
+	if p.acceptKeyword("AS") {
+		if f.Alias, err = p.expectIdent(); err != nil {
+			return nil, err
+		}
+	} else if p.peek().typ == tIdent {
+		f.Alias = p.next().val
+	}
+	return f, nil
+}
+
+// parseTableRef reads a table name with an optional alias. Unquoted table
+// names can contain slashes, so dataset references like peer/dataset_name
+// don't need quoting
+func (p *parser) parseTableRef() (*TableRef, error) {
+	name, err := p.expectIdent()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptOp("/") {
+		part, err := p.expectIdent()
+		if err != nil {
+			return nil, err
+		}
+		name = name + "/" + part
+	}
+
+	t := &TableRef{Name: name}
+	if p.acceptKeyword("AS") {
+		if t.Alias, err = p.expectIdent(); err != nil {
+			return nil, err
+		}
+	} else if p.peek().typ == tIdent {
+		t.Alias = p.next().val
+	}
+	return t, nil
+}
+
+// parseJoin reads a join clause, returning nil if there isn't one
+func (p *parser) parseJoin() (*Join, error) {
+	j := &Join{}
+	switch {
+	case p.acceptOp(","):
+		j.Type = CrossJoin
+	case p.acceptKeyword("CROSS"):
+		j.Type = CrossJoin
+		if err := p.expectKeyword("JOIN"); err != nil {
+			return nil, err
+		}
+	case p.acceptKeyword("LEFT"):
+		j.Type = LeftJoin
+		p.acceptKeyword("OUTER")
+		if err := p.expectKeyword("JOIN"); err != nil {
+			return nil, err
+		}
+	case p.acceptKeyword("INNER"):
+		if err := p.expectKeyword("JOIN"); err != nil {
+			return nil, err
+		}
+	case p.acceptKeyword("JOIN"):
+	default:
+		return nil, nil
+	}
+
+	t, err := p.parseTableRef()
+	if err != nil {
+		return nil, err
+	}
+	j.Table = t
+
+	if j.Type != CrossJoin {
+		if err := p.expectKeyword("ON"); err != nil {
+			return nil, err
+		}
+		if j.On, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+	}
+	return j, nil
+}
+
+func (p *parser) parseExprList() ([]Expr, error) {
+	var list []Expr
+	for {
+		x, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, x)
+		if !p.acceptOp(",") {
+			return list, nil
+		}
+	}
+}
+
+func (p *parser) parseExpr() (Expr, error) {
+	return p.parseOr()
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	l, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("OR") {
+		r, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l = &Binary{Op: "OR", L: l, R: r}
+	}
+	return l, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	l, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("AND") {
+		r, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		l = &Binary{Op: "AND", L: l, R: r}
+	}
+	return l, nil
+}
+
+func (p *parser) parseNot() (Expr, error) {
+	if p.acceptKeyword("NOT") {
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &Unary{Op: "NOT", X: x}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (Expr, error) {
+	l, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+
+	t := p.peek()
+	if t.typ == tOp {
+		switch t.val {
+		case "=", "!=", "<>", "<", "<=", ">", ">=":
+			p.next()
+			r, err := p.parseAdditive()
+			if err != nil {
+				return nil, err
+			}
+			op := t.val
+			if op == "<>" {
+				op = "!="
+			}
+			return &Binary{Op: op, L: l, R: r}, nil
+		}
+	}
+
+	if p.acceptKeyword("IS") {
+		not := p.acceptKeyword("NOT")
+		if err := p.expectKeyword("NULL"); err != nil {
+			return nil, err
+		}
+		return &IsNull{X: l, Not: not}, nil
+	}
+
+	not := false
+	if p.isKeyword("NOT") && p.toks[p.i+1].typ == tKeyword {
+		switch p.toks[p.i+1].val {
+		case "IN", "LIKE", "BETWEEN":
+			p.next()
+			not = true
+		}
+	}
+
+	switch {
+	case p.acceptKeyword("IN"):
+		if err := p.expectOp("("); err != nil {
+			return nil, err
+		}
+		list, err := p.parseExprList()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectOp(")"); err != nil {
+			return nil, err
+		}
+		return &In{X: l, List: list, Not: not}, nil
+	case p.acceptKeyword("LIKE"):
+		r, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		var x Expr = &Binary{Op: "LIKE", L: l, R: r}
+		if not {
+			x = &Unary{Op: "NOT", X: x}
+		}
+		return x, nil
+	case p.acceptKeyword("BETWEEN"):
+		lo, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectKeyword("AND"); err != nil {
+			return nil, err
+		}
+		hi, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		return &Between{X: l, Lo: lo, Hi: hi, Not: not}, nil
+	}
+
+	return l, nil
+}
+
+func (p *parser) parseAdditive() (Expr, error) {
+	l, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("+") || p.isOp("-") || p.isOp("||") {
+		op := p.next().val
+		r, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		l = &Binary{Op: op, L: l, R: r}
+	}
+	return l, nil
+}
+
+func (p *parser) parseMultiplicative() (Expr, error) {
+	l, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("*") || p.isOp("/") || p.isOp("%") {
+		op := p.next().val
+		r, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l = &Binary{Op: op, L: l, R: r}
+	}
+	return l, nil
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	if p.acceptOp("-") {
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		// fold negative number literals
+		if lit, ok := x.(*Literal); ok {
+			switch v := lit.Value.(type) {
+			case int64:
+				return &Literal{Value: -v}, nil
+			case float64:
+				return &Literal{Value: -v}, nil
+			}
+		}
+		return &Unary{Op: "-", X: x}, nil
+	}
+	p.acceptOp("+")
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Expr, error) {
+	t := p.peek()
+	switch t.typ {
+	case tNumber:
+		p.next()
+		if i, err := strconv.ParseInt(t.val, 10, 64); err == nil {
+			return &Literal{Value: i}, nil
+		}
+		f, err := strconv.ParseFloat(t.val, 64)
+		if err != nil {
+			return nil, fmt.Errorf("syntax error at position %d: invalid number %s", t.pos, t)
+		}
+		return &Literal{Value: f}, nil
+	case tString:
+		p.next()
+		return &Literal{Value: t.val}, nil
+	case tKeyword:
+		switch t.val {
+		case "NULL":
+			p.next()
+			return &Literal{}, nil
+		case "TRUE":
+			p.next()
+			return &Literal{Value: true}, nil
+		case "FALSE":
+			p.next()
+			return &Literal{Value: false}, nil
+		}
+	case tOp:
+		if t.val == "(" {
+			p.next()
+			x, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectOp(")"); err != nil {
+				return nil, err
+			}
+			return x, nil
+		}
+	case tIdent:
+		p.next()
+		if p.acceptOp("(") {
+			return p.parseCall(t.val)
+		}
+		if p.acceptOp(".") {
+			name, err := p.expectIdent()
+			if err != nil {
+				return nil, err
+			}
+			return &ColumnRef{Table: t.val, Name: name}, nil
+		}
+		return &ColumnRef{Name: t.val}, nil
+	}
+
+	return nil, p.errorf("unexpected %s", t)
+}
+
+// parseCall reads the arguments of a function call, after the opening paren
+func (p *parser) parseCall(name string) (Expr, error) {
+	c := &Call{Name: strings.ToUpper(name)}
+	if _, ok := functions[c.Name]; !ok && !aggregates[c.Name] {
+		return nil, p.errorf("unknown function %s", name)
+	}
+
+	if p.isOp("*") {
+		if c.Name != "COUNT" {
+			return nil, p.errorf("* is only valid as an argument to COUNT")
+		}
+		p.next()
+		c.Star = true
+		return c, p.expectOp(")")
+	}
+	if p.acceptOp(")") {
+		return c, nil
+	}
+
+	if p.acceptKeyword("DISTINCT") {
+		if !aggregates[c.Name] {
+			return nil, p.errorf("DISTINCT is only valid within aggregate functions")
+		}
+		c.Distinct = true
+	}
+
+	args, err := p.parseExprList()
+	if err != nil {
+		return nil, err
+	}
+	c.Args = args
+	return c, p.expectOp(")")
+}
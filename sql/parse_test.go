@@ -0,0 +1,69 @@
+package sql
+
+import (
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	good := []struct {
+		query  string
+		tables []string
+		fields int
+	}{
+		{"SELECT * FROM me/cities", []string{"me/cities"}, 1},
+		{"select city, pop from me/cities where pop > 100 order by pop desc limit 5 offset 1;", []string{"me/cities"}, 2},
+		{"SELECT c.city, s.name AS state FROM me/cities c JOIN peer/states AS s ON c.state = s.code", []string{"me/cities", "peer/states"}, 2},
+		{"SELECT * FROM a LEFT OUTER JOIN b ON a.id = b.id, c", []string{"a", "b", "c"}, 1},
+		{"SELECT in_usa, count(*), avg(pop) avg_pop FROM me/cities GROUP BY in_usa HAVING count(*) > 1", []string{"me/cities"}, 3},
+		{`SELECT "select" FROM "me/cities@/map/QmFoo"`, []string{"me/cities@/map/QmFoo"}, 1},
+		{"SELECT count(DISTINCT city) FROM a WHERE city NOT LIKE 'new%' AND pop NOT BETWEEN 1 AND -5 OR x IS NOT NULL", []string{"a"}, 1},
+		{"SELECT a.* FROM a -- trailing comment", []string{"a"}, 1},
+	}
+
+	for i, c := range good {
+		stmt, err := Parse(c.query)
+		if err != nil {
+			t.Errorf("case %d unexpected error: %s", i, err)
+			continue
+		}
+		tables := stmt.Tables()
+		if len(tables) != len(c.tables) {
+			t.Errorf("case %d table count mismatch. expected: %d, got: %d", i, len(c.tables), len(tables))
+			continue
+		}
+		for j, name := range c.tables {
+			if tables[j] != name {
+				t.Errorf("case %d table %d mismatch. expected: %s, got: %s", i, j, name, tables[j])
+			}
+		}
+		if len(stmt.Fields) != c.fields {
+			t.Errorf("case %d field count mismatch. expected: %d, got: %d", i, c.fields, len(stmt.Fields))
+		}
+	}
+
+	bad := []struct {
+		query, err string
+	}{
+		{"", "syntax error at position 0: expected SELECT, found end of query"},
+		{"SELECT FROM a", "syntax error at position 7: unexpected \"FROM\""},
+		{"SELECT a FROM", "syntax error at position 13: expected identifier, found end of query"},
+		{"SELECT a FROM b WHERE", "syntax error at position 21: unexpected end of query"},
+		{"SELECT nope(a) FROM b", "syntax error at position 12: unknown function nope"},
+		{"SELECT sum(*) FROM b", "syntax error at position 11: * is only valid as an argument to COUNT"},
+		{"SELECT 'a FROM b", "unterminated string starting at position 7"},
+		{"SELECT a FROM b LIMIT -1", "syntax error at position 22: expected number, found \"-\""},
+		{"SELECT a FROM b c d", "syntax error at position 18: unexpected \"d\""},
+		{"SELECT a FROM b JOIN c", "syntax error at position 22: expected ON, found end of query"},
+	}
+
+	for i, c := range bad {
+		_, err := Parse(c.query)
+		if err == nil {
+			t.Errorf("bad case %d expected error, got nil", i)
+			continue
+		}
+		if err.Error() != c.err {
+			t.Errorf("bad case %d error mismatch. expected: '%s', got: '%s'", i, c.err, err)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+// Package sql implements a small SQL query engine over tabular data. It
+// supports SELECT statements with filters, projections, aggregates, grouping,
+// ordering & joins. Tables are held in memory, and supplied by a Source, which
+// qri uses to read dataset bodies by reference
+package sql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Column describes a single column of a table
+type Column struct {
+	Name string `json:"name"`
+	// Type is a json schema type name, empty if unknown or mixed
+	Type string `json:"type,omitempty"`
+}
+
+// Table is an in-memory set of rows
+type Table struct {
+	Name    string          `json:"name,omitempty"`
+	Columns []Column        `json:"columns"`
+	Rows    [][]interface{} `json:"rows"`
+}
+
+// Source resolves a table name to a table
+type Source interface {
+	Table(name string) (*Table, error)
+}
+
+// SourceFunc adapts a function to the Source interface
+type SourceFunc func(name string) (*Table, error)
+
+// Table implements the Source interface
+func (fn SourceFunc) Table(name string) (*Table, error) {
+	return fn(name)
+}
+
+// MapSource is a Source backed by a map of table names to tables
+type MapSource map[string]*Table
+
+// Table implements the Source interface
+func (ms MapSource) Table(name string) (*Table, error) {
+	if t, ok := ms[name]; ok {
+		return t, nil
+	}
+	return nil, fmt.Errorf("table not found: %s", name)
+}
+
+// Query parses & executes a query string
+func Query(q string, src Source) (*Table, error) {
+	stmt, err := Parse(q)
+	if err != nil {
+		return nil, err
+	}
+	return Exec(stmt, src)
+}
+
+// scope lists the columns available to expressions, tagged with the table
+// each column came from
+type scope struct {
+	cols []scopeCol
+}
+
+type scopeCol struct {
+	table string
+	Column
+}
+
+// add appends a table's columns to a scope
+func (s *scope) add(alias string, t *Table) {
+	for _, c := range t.Columns {
+		s.cols = append(s.cols, scopeCol{table: alias, Column: c})
+	}
+}
+
+// resolve finds the index of a referenced column
+func (s *scope) resolve(ref *ColumnRef) (int, error) {
+	found := -1
+	for i, c := range s.cols {
+		if c.Name != ref.Name || (ref.Table != "" && c.table != ref.Table) {
+			continue
+		}
+		if found >= 0 {
+			return -1, fmt.Errorf("column reference \"%s\" is ambiguous", ref)
+		}
+		found = i
+	}
+	if found < 0 {
+		return -1, fmt.Errorf("column \"%s\" does not exist", ref)
+	}
+	return found, nil
+}
+
+// defaultAlias gives the name a table can be referred to by if no alias is
+// given, which is the last slash-separated part of the name
+func defaultAlias(name string) string {
+	// drop any version path from a dataset reference
+	if i := strings.Index(name, "@"); i >= 0 {
+		name = name[:i]
+	}
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}